@@ -72,23 +72,32 @@ func featureEmoji(
 
 func matrixData() *FeatureMatrix {
 	const (
-		OfficialSupport      = "Official Support"
-		ProviderDNSProvider  = "DNS Provider"
-		ProviderRegistrar    = "Registrar"
-		DomainModifierAlias  = "ALIAS"
-		DomainModifierDnssec = "AUTODNSSEC"
-		DomainModifierCaa    = "CAA"
-		DomainModifierPtr    = "PTR"
-		DomainModifierNaptr  = "NAPTR"
-		DomainModifierSoa    = "SOA"
-		DomainModifierSrv    = "SRV"
-		DomainModifierSshfp  = "SSHFP"
-		DomainModifierTlsa   = "TLSA"
-		DomainModifierDs     = "DS"
-		DualHost             = "dual host"
-		CreateDomains        = "create-domains"
-		NoPurge              = "NO_PURGE"
-		GetZones             = "get-zones"
+		OfficialSupport          = "Official Support"
+		ProviderDNSProvider      = "DNS Provider"
+		ProviderRegistrar        = "Registrar"
+		DomainModifierAlias      = "ALIAS"
+		DomainModifierDnssec     = "AUTODNSSEC"
+		DomainModifierCaa        = "CAA"
+		DomainModifierPtr        = "PTR"
+		DomainModifierNaptr      = "NAPTR"
+		DomainModifierSoa        = "SOA"
+		DomainModifierSrv        = "SRV"
+		DomainModifierSshfp      = "SSHFP"
+		DomainModifierSvcb       = "SVCB"
+		DomainModifierTlsa       = "TLSA"
+		DomainModifierDs         = "DS"
+		DomainModifierCert       = "CERT"
+		DomainModifierDname      = "DNAME"
+		DomainModifierHinfo      = "HINFO"
+		DomainModifierLoc        = "LOC"
+		DomainModifierOpenpgpkey = "OPENPGPKEY"
+		DomainModifierRp         = "RP"
+		DomainModifierSmimea     = "SMIMEA"
+		DomainModifierUri        = "URI"
+		DualHost                 = "dual host"
+		CreateDomains            = "create-domains"
+		NoPurge                  = "NO_PURGE"
+		GetZones                 = "get-zones"
 	)
 
 	matrix := &FeatureMatrix{
@@ -105,8 +114,17 @@ func matrixData() *FeatureMatrix {
 			DomainModifierSoa,
 			DomainModifierSrv,
 			DomainModifierSshfp,
+			DomainModifierSvcb,
 			DomainModifierTlsa,
 			DomainModifierDs,
+			DomainModifierCert,
+			DomainModifierDname,
+			DomainModifierHinfo,
+			DomainModifierLoc,
+			DomainModifierOpenpgpkey,
+			DomainModifierRp,
+			DomainModifierSmimea,
+			DomainModifierUri,
 			DualHost,
 			CreateDomains,
 			NoPurge,
@@ -201,10 +219,46 @@ func matrixData() *FeatureMatrix {
 			DomainModifierSshfp,
 			providers.CanUseSSHFP,
 		)
+		setCapability(
+			DomainModifierSvcb,
+			providers.CanUseSVCB,
+		)
 		setCapability(
 			DomainModifierTlsa,
 			providers.CanUseTLSA,
 		)
+		setCapability(
+			DomainModifierCert,
+			providers.CanUseCERT,
+		)
+		setCapability(
+			DomainModifierDname,
+			providers.CanUseDNAME,
+		)
+		setCapability(
+			DomainModifierHinfo,
+			providers.CanUseHINFO,
+		)
+		setCapability(
+			DomainModifierLoc,
+			providers.CanUseLOC,
+		)
+		setCapability(
+			DomainModifierOpenpgpkey,
+			providers.CanUseOPENPGPKEY,
+		)
+		setCapability(
+			DomainModifierRp,
+			providers.CanUseRP,
+		)
+		setCapability(
+			DomainModifierSmimea,
+			providers.CanUseSMIMEA,
+		)
+		setCapability(
+			DomainModifierUri,
+			providers.CanUseURI,
+		)
 		setCapability(
 			GetZones,
 			providers.CanGetZones,
@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"strings"
+	"time"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/audit"
+	"github.com/StackExchange/dnscontrol/v3/pkg/diff2"
+	"github.com/urfave/cli/v2"
+)
+
+// AuditArgs contains the flag that keeps an audit log of the
+// corrections that push runs.
+type AuditArgs struct {
+	AuditLog string
+}
+
+func (args *AuditArgs) flags() []cli.Flag {
+	var flags []cli.Flag
+	flags = append(flags, &cli.StringFlag{
+		Name:        "audit-log",
+		Destination: &args.AuditLog,
+		Usage:       `Append an entry for each correction that is run to this file (JSON Lines). See "dnscontrol history"`,
+	})
+	return flags
+}
+
+// auditTrail records the corrections that push runs in the audit log.
+// All methods do nothing if it is nil, which is the case when there is
+// no audit log.
+type auditTrail struct {
+	log      *audit.Log
+	commit   string
+	user     string
+	rollback bool // Whether the corrections restore the records after one failed.
+	now      func() time.Time
+}
+
+// newAuditTrail opens the audit log of args, if any. configFile is the
+// configuration, whose git commit the entries record.
+func newAuditTrail(args AuditArgs, configFile string) (*auditTrail, error) {
+	if args.AuditLog == "" {
+		return nil, nil
+	}
+	log, err := audit.Open(args.AuditLog)
+	if err != nil {
+		return nil, err
+	}
+	return &auditTrail{
+		log:    log,
+		commit: audit.Commit(configFile),
+		user:   audit.User(),
+		now:    time.Now,
+	}, nil
+}
+
+// record appends the correction c of domain at provider, which ended
+// with err, and made changes.
+func (a *auditTrail) record(domain, provider string, c *models.Correction, changes diff2.ChangeList, err error) error {
+	if a == nil {
+		return nil
+	}
+	e := &audit.Entry{
+		Time:       a.now().UTC(),
+		Commit:     a.commit,
+		User:       a.user,
+		Domain:     domain,
+		Provider:   provider,
+		Correction: c.Msg,
+		Success:    err == nil,
+		Rollback:   a.rollback,
+	}
+	for _, ch := range changes {
+		if ch.Type != diff2.REPORT {
+			e.Changes = append(e.Changes, audit.NewChange(ch))
+		}
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	return a.log.Append(e)
+}
+
+// forRollback returns the trail of the corrections of a rollback.
+func (a *auditTrail) forRollback() *auditTrail {
+	if a == nil {
+		return nil
+	}
+	r := *a
+	r.rollback = true
+	return &r
+}
+
+func (a *auditTrail) close() error {
+	if a == nil {
+		return nil
+	}
+	return a.log.Close()
+}
+
+// correctionChanges returns the changes that the correction whose
+// message is msg makes. Providers make a correction per record, per
+// recordset or for the entire zone, and describe it with a line per
+// record change. A line is matched to the changes of the records it
+// names (see namesKey), whatever its wording: diff2 and the older
+// diff word them differently. If it is also the message of some of
+// those changes, only those are matched.
+func correctionChanges(msg string, changes diff2.ChangeList) diff2.ChangeList {
+	made := map[int]bool{}
+	for _, line := range strings.Split(msg, "\n") {
+		line = strings.TrimSpace(line)
+		var same, exact []int
+		for i, c := range changes {
+			if c.Type == diff2.REPORT || !namesKey(line, c.Key) {
+				continue
+			}
+			same = append(same, i)
+			for _, m := range c.Msgs {
+				if strings.TrimSpace(m) == line {
+					exact = append(exact, i)
+					break
+				}
+			}
+		}
+		if len(exact) == 0 {
+			exact = same
+		}
+		for _, i := range exact {
+			made[i] = true
+		}
+	}
+	var cs diff2.ChangeList
+	for i, c := range changes {
+		if made[i] {
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+// namesKey returns whether line, a line of the message of a correction,
+// is about the records of key: "+ CREATE www.example.com A ..." (diff2)
+// or "+ CREATE A www.example.com ..." (diff).
+func namesKey(line string, key models.RecordKey) bool {
+	f := strings.Fields(line)
+	if len(f) < 4 {
+		return false
+	}
+	a, b := strings.TrimSuffix(f[2], ":"), strings.TrimSuffix(f[3], ":")
+	return (a == key.NameFQDN && b == key.Type) || (a == key.Type && b == key.NameFQDN)
+}
@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/pkg/normalize"
+	"github.com/StackExchange/dnscontrol/v3/pkg/takeover"
+	"github.com/urfave/cli/v2"
+)
+
+var _ = cmd(catMain, func() *cli.Command {
+	var args AuditTakeoverArgs
+	return &cli.Command{
+		Name:  "audit-takeover",
+		Usage: "check that CNAME, ALIAS and delegation targets still exist and cannot be claimed by someone else",
+		Action: func(ctx *cli.Context) error {
+			return AuditTakeover(args)
+		},
+		Flags: args.flags(),
+	}
+}())
+
+// Exit codes of audit-takeover, in addition to 0 (nothing found) and 1
+// (an error, such as an invalid configuration).
+const (
+	takeoverExitFound     = 2 // Some records can be taken over. Takes precedence over takeoverExitUnchecked.
+	takeoverExitUnchecked = 3 // Some records could not be checked.
+)
+
+// AuditTakeoverArgs contains all data/flags needed to run audit-takeover, independently of CLI
+type AuditTakeoverArgs struct {
+	GetDNSConfigArgs
+	Domains      string
+	ResolverFile string
+	Resolver     string
+	Format       string
+	All          bool
+}
+
+func (args *AuditTakeoverArgs) flags() []cli.Flag {
+	flags := args.GetDNSConfigArgs.flags()
+	flags = append(flags, &cli.StringFlag{
+		Name:        "domains",
+		Destination: &args.Domains,
+		Usage:       `Comma separated list of domain names to include`,
+	})
+	flags = append(flags, &cli.StringFlag{
+		Name:        "resolver-file",
+		Destination: &args.ResolverFile,
+		Usage:       `Answer DNS queries from this JSON file instead of the network`,
+	})
+	flags = append(flags, &cli.StringFlag{
+		Name:        "resolver",
+		Destination: &args.Resolver,
+		Usage:       `The recursive resolver to query (host:port); default is the first of /etc/resolv.conf`,
+	})
+	flags = append(flags, &cli.StringFlag{
+		Name:        "format",
+		Destination: &args.Format,
+		Value:       "text",
+		Usage:       `Output format: text or json`,
+	})
+	flags = append(flags, &cli.BoolFlag{
+		Name:        "all",
+		Destination: &args.All,
+		Usage:       `Also list the records that are ok`,
+	})
+	return flags
+}
+
+// AuditTakeover implements the audit-takeover subcommand.
+func AuditTakeover(args AuditTakeoverArgs) error {
+	switch args.Format {
+	case "", "text", "json":
+	default:
+		return exit(fmt.Errorf("unknown format %q (expected text or json)", args.Format))
+	}
+
+	findings, err := auditTakeover(args)
+	if err != nil {
+		return exit(err)
+	}
+
+	if args.Format == "json" {
+		if findings == nil {
+			findings = []*takeover.Finding{}
+		}
+		j, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return exit(err)
+		}
+		fmt.Printf("%s\n", j)
+	} else {
+		writeTakeoverText(os.Stdout, findings, args.All)
+	}
+
+	if takeover.Found(findings) {
+		return cli.Exit("records can be taken over", takeoverExitFound)
+	}
+	for _, f := range findings {
+		if f.Status == takeover.Unchecked {
+			return cli.Exit("some records could not be checked", takeoverExitUnchecked)
+		}
+	}
+	return nil
+}
+
+func auditTakeover(args AuditTakeoverArgs) ([]*takeover.Finding, error) {
+	var resolver takeover.Resolver = &takeover.LiveResolver{Server: args.Resolver}
+	if args.ResolverFile != "" {
+		r, err := takeover.NewFileResolver(args.ResolverFile)
+		if err != nil {
+			return nil, err
+		}
+		resolver = r
+	}
+
+	cfg, err := GetDNSConfig(args.GetDNSConfigArgs)
+	if err != nil {
+		return nil, err
+	}
+	errs := normalize.ValidateAndNormalizeConfig(cfg)
+	if PrintValidationErrors(errs) {
+		return nil, fmt.Errorf("exiting due to validation errors")
+	}
+
+	checker := takeover.NewChecker(cfg, resolver)
+	var findings []*takeover.Finding
+	for _, dc := range cfg.Domains {
+		if args.Domains != "" && !domainInList(dc.UniqueName, strings.Split(args.Domains, ",")) {
+			continue
+		}
+		findings = append(findings, checker.Domain(dc)...)
+	}
+	return findings, nil
+}
+
+func writeTakeoverText(w io.Writer, findings []*takeover.Finding, all bool) {
+	counts := map[takeover.Status]int{}
+	for _, f := range findings {
+		counts[f.Status]++
+		if f.Status == takeover.OK && !all {
+			continue
+		}
+		where := ""
+		if f.Source != "" {
+			where = " (" + f.Source + ")"
+		}
+		fmt.Fprintf(w, "%-10s %s %s %s%s: %s\n", strings.ToUpper(string(f.Status)), f.Name, f.Type, f.Target, where, f.Reason)
+	}
+	var summary []string
+	for _, s := range []takeover.Status{takeover.Vulnerable, takeover.Dangling, takeover.Lame, takeover.Unverified, takeover.Unchecked, takeover.OK} {
+		summary = append(summary, fmt.Sprintf("%d %s", counts[s], s))
+	}
+	fmt.Fprintf(w, "%d records checked: %s\n", len(findings), strings.Join(summary, ", "))
+}
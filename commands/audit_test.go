@@ -0,0 +1,182 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/audit"
+	"github.com/StackExchange/dnscontrol/v3/pkg/diff"
+	"github.com/StackExchange/dnscontrol/v3/pkg/diff2"
+	"github.com/StackExchange/dnscontrol/v3/pkg/notifications"
+	"github.com/StackExchange/dnscontrol/v3/pkg/printer"
+)
+
+func Test_printOrRunCorrections_audit(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit.jsonl")
+	trail, err := newAuditTrail(AuditArgs{AuditLog: file}, "dnsconfig.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	trail.commit, trail.user = "0123456789abcdef", "alice"
+	trail.now = func() time.Time { return time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC) }
+
+	www := &models.RecordConfig{Type: "A", TTL: 300}
+	www.SetLabel("www", "example.com")
+	www.SetTargetIP([]byte{1, 2, 3, 4})
+	changes := diff2.ChangeList{
+		{Type: diff2.CREATE, Key: models.RecordKey{NameFQDN: "www.example.com", Type: "A"}, New: models.Records{www}, Msgs: []string{"+ CREATE www.example.com A 1.2.3.4 ttl=300"}},
+		{Type: diff2.DELETE, Key: models.RecordKey{NameFQDN: "old.example.com", Type: "A"}, Msgs: []string{"- DELETE old.example.com A 5.6.7.8 ttl=300"}},
+	}
+	corrections := []*models.Correction{
+		{Msg: "+ CREATE A www.example.com 1.2.3.4 ttl=300", F: func() error { return nil }},
+		{Msg: "- DELETE A old.example.com 5.6.7.8 ttl=300", F: func() error { return errors.New("denied") }},
+		{Msg: "Just a message"},
+	}
+	notifier, _ := notifications.Init(nil)
+	out := printer.ConsolePrinter{Writer: &bytes.Buffer{}}
+	if !printOrRunCorrections("example.com", "bind", corrections, changes, out, true, false, notifier, trail, false) {
+		t.Error("the failed correction is not reported")
+	}
+	// A preview records nothing.
+	printOrRunCorrections("example.com", "bind", corrections, changes, out, false, false, notifier, trail, false)
+	if err := trail.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := audit.Read(file, audit.Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	e := entries[0]
+	if !e.Success || e.User != "alice" || e.Commit != "0123456789abcdef" || e.Domain != "example.com" || e.Provider != "bind" ||
+		!e.Time.Equal(trail.now()) || e.Correction != corrections[0].Msg {
+		t.Errorf("got %+v", e)
+	}
+	if len(e.Changes) != 1 || e.Changes[0].Verb != "CREATE" || e.Changes[0].Name != "www.example.com" ||
+		len(e.Changes[0].New) != 1 || *e.Changes[0].New[0] != (audit.Record{TTL: 300, Target: "1.2.3.4"}) {
+		t.Errorf("got changes %+v", e.Changes)
+	}
+	if e := entries[1]; e.Success || e.Error != "denied" || len(e.Changes) != 1 || e.Changes[0].Verb != "DELETE" {
+		t.Errorf("got %+v", e)
+	}
+}
+
+func Test_correctionChanges(t *testing.T) {
+	existing := models.Records{makeRec("www", "A", "1.2.3.4"), makeRec("old", "A", "9.9.9.9")}
+	dc := &models.DomainConfig{Name: "example.com", Records: models.Records{
+		makeRec("www", "A", "1.2.3.5"),
+		makeRec("new", "A", "5.5.5.5"),
+		makeRec("new", "MX", "10 mx.example.com."),
+	}}
+	changes, err := diff2.ByRecord(existing, dc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := func(cs diff2.ChangeList) []string {
+		var s []string
+		for _, c := range cs {
+			if c.Type != diff2.REPORT {
+				s = append(s, c.Key.NameFQDN+" "+c.Key.Type)
+			}
+		}
+		sort.Strings(s)
+		return s
+	}
+	all := keys(changes)
+	if len(all) != 4 {
+		t.Fatalf("got changes %v, want 4", all)
+	}
+
+	// A correction per change, with the messages of diff2.
+	for _, c := range changes {
+		if c.Type == diff2.REPORT {
+			continue
+		}
+		got := correctionChanges(c.MsgsJoined, changes)
+		if len(got) != 1 || got[0].MsgsJoined != c.MsgsJoined {
+			t.Errorf("%q: got %v, want only its change", c.MsgsJoined, keys(got))
+		}
+	}
+
+	// A correction per record, with the messages of the older diff.
+	_, create, del, mod, err := diff.New(dc).IncrementalDiff(existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, cs := range []diff.Changeset{create, del, mod} {
+		for _, corr := range cs {
+			msg := corr.String()
+			lines = append(lines, msg)
+			rec := corr.Desired
+			if rec == nil {
+				rec = corr.Existing
+			}
+			want := []string{rec.GetLabelFQDN() + " " + rec.Type}
+			if got := keys(correctionChanges(msg, changes)); strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Errorf("%q: got %v, want %v", msg, got, want)
+			}
+		}
+	}
+
+	// A correction for the entire zone.
+	msg := "Zone update:\n" + strings.Join(lines, "\n")
+	if got := keys(correctionChanges(msg, changes)); strings.Join(got, ",") != strings.Join(all, ",") {
+		t.Errorf("zone: got %v, want %v", got, all)
+	}
+	if got := correctionChanges("Registrar nameservers", changes); len(got) != 0 {
+		t.Errorf("registrar: got %v, want none", keys(got))
+	}
+}
+
+func Test_parseHistoryTime(t *testing.T) {
+	now := time.Date(2023, 4, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		s       string
+		want    time.Time
+		wantErr bool
+	}{
+		{"", time.Time{}, false},
+		{"2023-04-01T08:30:00Z", time.Date(2023, 4, 1, 8, 30, 0, 0, time.UTC), false},
+		{"2023-04-01", time.Date(2023, 4, 1, 0, 0, 0, 0, time.Local), false},
+		{"36h", now.Add(-36 * time.Hour), false},
+		{"7d", time.Date(2023, 4, 3, 12, 0, 0, 0, time.UTC), false},
+		{"-1h", time.Time{}, true},
+		{"yesterday", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseHistoryTime(tt.s, now)
+		if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+			t.Errorf("parseHistoryTime(%q) = %v, %v, want %v (error: %v)", tt.s, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func Test_printHistory(t *testing.T) {
+	entries := []*audit.Entry{
+		{Time: time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC), Commit: "0123456789abcdef0123-dirty", User: "alice", Domain: "example.com", Provider: "bind",
+			Correction: "+ CREATE A www.example.com 1.2.3.4 ttl=300", Success: true},
+		{Time: time.Date(2023, 4, 1, 12, 0, 1, 0, time.UTC), User: "bob", Domain: "example.com", Provider: "bind",
+			Correction: "- DELETE A old.example.com 5.6.7.8 ttl=300", Error: "denied"},
+	}
+	var b bytes.Buffer
+	printHistory(&b, entries)
+	got := b.String()
+	for _, want := range []string{
+		"  example.com  bind  alice  0123456789ab-dirty  OK\n    + CREATE A www.example.com 1.2.3.4 ttl=300\n",
+		"  example.com  bind  bob  -  FAILED: denied\n    - DELETE A old.example.com 5.6.7.8 ttl=300\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("%q not in:\n%s", want, got)
+		}
+	}
+}
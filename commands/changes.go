@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/diff2"
+	"github.com/StackExchange/dnscontrol/v3/pkg/printer"
+	"github.com/StackExchange/dnscontrol/v3/providers"
+)
+
+// zoneChanges returns the records of the zone as they exist at the
+// provider, and the record-level changes needed to turn them into
+// the records in dc.
+//
+// The changes are independent of how the provider groups its
+// corrections (by record, by recordset, or the entire zone). They are
+// used for reporting and checking, never to make the changes.
+func zoneChanges(driver models.DNSProvider, domain *models.DomainConfig) (models.Records, diff2.ChangeList, error) {
+	dc, err := domain.Copy()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := dc.Punycode(); err != nil {
+		return nil, nil, err
+	}
+
+	existing, err := zoneRecords(driver, dc)
+	if err != nil {
+		return nil, nil, err
+	}
+	changes, err := recordChanges(existing, dc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return existing, changes, nil
+}
+
+// zoneRecords returns the records of the zone of dc at driver, which
+// may depend on more than its name (see
+// providers.DomainRecordsGetter).
+func zoneRecords(driver models.DNSProvider, dc *models.DomainConfig) (models.Records, error) {
+	if g, ok := driver.(providers.DomainRecordsGetter); ok {
+		return g.GetDomainRecords(dc)
+	}
+	return driver.GetZoneRecords(dc.Name)
+}
+
+// recordChanges returns the record-level changes needed to turn
+// existing, the records returned by GetZoneRecords, into the records
+// in dc, which must be punycoded. existing is normalized in place.
+func recordChanges(existing models.Records, dc *models.DomainConfig) (diff2.ChangeList, error) {
+	models.PostProcessRecords(existing)
+	return diff2.ByRecord(comparableRecords(existing, dc), dc, nil)
+}
+
+// copyRecords returns a deep copy of recs.
+func copyRecords(recs models.Records) (models.Records, error) {
+	cp := make(models.Records, 0, len(recs))
+	for _, rc := range recs {
+		r, err := rc.Copy()
+		if err != nil {
+			return nil, err
+		}
+		cp = append(cp, r)
+	}
+	return cp, nil
+}
+
+// comparableRecords returns the records that should be compared with
+// dc.Records. Most providers manage the SOA record themselves, so it
+// is only compared if dc has one.
+func comparableRecords(existing models.Records, dc *models.DomainConfig) models.Records {
+	for _, r := range dc.Records {
+		if r.Type == "SOA" {
+			return existing
+		}
+	}
+	var recs models.Records
+	for _, r := range existing {
+		if r.Type != "SOA" {
+			recs = append(recs, r)
+		}
+	}
+	return recs
+}
+
+// changeReports converts a ChangeList to its serializable form.
+func changeReports(changes diff2.ChangeList) []*printer.ChangeReport {
+	var reps []*printer.ChangeReport
+	for _, c := range changes {
+		reps = append(reps, &printer.ChangeReport{
+			Verb: c.Type.String(),
+			Key:  c.Key,
+			Old:  c.Old,
+			New:  c.New,
+			Msgs: c.Msgs,
+		})
+	}
+	return reps
+}
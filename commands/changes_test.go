@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/diff2"
+	"github.com/StackExchange/dnscontrol/v3/providers"
+)
+
+// Test_zoneChangesTag checks that the changes of a tagged (split
+// horizon) domain are those of its own zonefile.
+func Test_zoneChangesTag(t *testing.T) {
+	dir := t.TempDir()
+	for file, content := range map[string]string{
+		"example.com.zone":        "$TTL 300\nwww IN A 1.1.1.1\n",
+		"example.com!inside.zone": "$TTL 300\nwww IN A 10.0.0.1\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	driver, err := providers.CreateDNSProvider("BIND", map[string]string{"directory": dir}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc := &models.DomainConfig{
+		Name:       "example.com",
+		UniqueName: "example.com!inside",
+		Tag:        "inside",
+		Records:    models.Records{makeRec("www", "A", "10.0.0.1")},
+	}
+
+	existing, changes, err := zoneChanges(driver, dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(existing) != 1 || existing[0].GetTargetField() != "10.0.0.1" {
+		t.Errorf("got records %v, want those of the tagged zonefile", existing)
+	}
+	for _, c := range changes {
+		if c.Type != diff2.REPORT {
+			t.Errorf("got change %s, want none", c.MsgsJoined)
+		}
+	}
+}
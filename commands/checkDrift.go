@@ -0,0 +1,244 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/credsfile"
+	"github.com/StackExchange/dnscontrol/v3/pkg/drift"
+	"github.com/StackExchange/dnscontrol/v3/pkg/nameservers"
+	"github.com/StackExchange/dnscontrol/v3/pkg/normalize"
+	"github.com/StackExchange/dnscontrol/v3/providers"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/exp/slices"
+	"golang.org/x/net/idna"
+)
+
+var _ = cmd(catMain, func() *cli.Command {
+	var args CheckDriftArgs
+	return &cli.Command{
+		Name:  "check-drift",
+		Usage: "read live configuration and report the records that differ from dnsconfig.js, without making any change",
+		Action: func(ctx *cli.Context) error {
+			return CheckDrift(args)
+		},
+		Flags: args.flags(),
+	}
+}())
+
+// Exit codes of check-drift, in addition to 0 (no drift) and 1 (an
+// error, such as an invalid configuration).
+const (
+	driftExitFound     = 2 // Some records drifted.
+	driftExitUnchecked = 3 // Some zones could not be checked.
+)
+
+// CheckDriftArgs contains all data/flags needed to run check-drift, independently of CLI
+type CheckDriftArgs struct {
+	GetDNSConfigArgs
+	GetCredentialsArgs
+	FilterArgs
+	Format      string
+	ShowIgnored bool
+}
+
+func (args *CheckDriftArgs) flags() []cli.Flag {
+	flags := args.GetDNSConfigArgs.flags()
+	flags = append(flags, args.GetCredentialsArgs.flags()...)
+	flags = append(flags, args.FilterArgs.flags()...)
+	flags = append(flags, &cli.StringFlag{
+		Name:        "format",
+		Destination: &args.Format,
+		Value:       "text",
+		Usage:       `Output format: text or json`,
+	})
+	flags = append(flags, &cli.BoolFlag{
+		Name:        "show-ignored",
+		Destination: &args.ShowIgnored,
+		Usage:       `Also list the records that are not managed (IGNORE_*, UNMANAGED, NO_PURGE)`,
+	})
+	return flags
+}
+
+// DriftReport is the output of check-drift --format=json.
+type DriftReport struct {
+	Drift bool         `json:"drift"` // True if any zone has drifted.
+	Zones []*DriftZone `json:"zones"`
+}
+
+// DriftZone is the drift of one zone at one provider.
+type DriftZone struct {
+	Domain   string          `json:"domain"`
+	Provider string          `json:"provider"`
+	Error    string          `json:"error,omitempty"` // Set if the zone could not be checked.
+	Counts   map[string]int  `json:"counts,omitempty"`
+	Records  []*drift.Record `json:"records,omitempty"`
+}
+
+// CheckDrift implements the check-drift subcommand. The exit code is
+// driftExitFound if a zone has drifted, driftExitUnchecked if a zone
+// could not be checked (even if others have drifted).
+func CheckDrift(args CheckDriftArgs) error {
+	switch args.Format {
+	case "", "text", "json":
+	default:
+		return exit(fmt.Errorf("unknown format %q (expected text or json)", args.Format))
+	}
+
+	report, err := checkDrift(args)
+	if err != nil {
+		return exit(err)
+	}
+
+	if args.Format == "json" {
+		if err := writeDriftJSON(os.Stdout, report); err != nil {
+			return exit(err)
+		}
+	} else {
+		writeDriftText(os.Stdout, report, args.ShowIgnored)
+	}
+
+	for _, z := range report.Zones {
+		if z.Error != "" {
+			return cli.Exit("some zones could not be checked", driftExitUnchecked)
+		}
+	}
+	if report.Drift {
+		return cli.Exit("drift detected", driftExitFound)
+	}
+	return nil
+}
+
+// checkDrift reads every zone and compares it with the configuration.
+// Only GetZoneRecords (and ListZones, GetNameservers) are called, never
+// GetDomainCorrections, so that no correction is ever built.
+func checkDrift(args CheckDriftArgs) (*DriftReport, error) {
+	cfg, err := GetDNSConfig(args.GetDNSConfigArgs)
+	if err != nil {
+		return nil, err
+	}
+	providerConfigs, err := credsfile.LoadProviderConfigs(args.CredsFile)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := InitializeProviders(cfg, providerConfigs, false); err != nil {
+		return nil, err
+	}
+	errs := normalize.ValidateAndNormalizeConfig(cfg)
+	if PrintValidationErrors(errs) {
+		return nil, fmt.Errorf("exiting due to validation errors")
+	}
+
+	report := &DriftReport{Zones: []*DriftZone{}}
+	for _, domain := range cfg.Domains {
+		if !args.shouldRunDomain(domain) {
+			continue
+		}
+
+		nsList, err := nameservers.DetermineNameserversForProviders(domain, domain.DNSProviderInstances)
+		if err != nil {
+			// The zones can be checked, but not the NS records.
+			for _, provider := range domain.DNSProviderInstances {
+				report.Zones = append(report.Zones, &DriftZone{Domain: domain.UniqueName, Provider: provider.Name, Error: err.Error()})
+			}
+			continue
+		}
+		domain.Nameservers = nsList
+		nameservers.AddNSRecords(domain)
+
+		for _, provider := range domain.DNSProviderInstances {
+			if !args.shouldRunProvider(provider.Name, domain) {
+				continue
+			}
+			z := &DriftZone{Domain: domain.UniqueName, Provider: provider.Name}
+			report.Zones = append(report.Zones, z)
+			records, err := zoneDrift(provider, domain)
+			if err != nil {
+				z.Error = err.Error()
+				continue
+			}
+			z.Records = records
+			z.Counts = map[string]int{}
+			for c, n := range drift.Count(records) {
+				z.Counts[string(c)] = n
+			}
+			report.Drift = report.Drift || drift.HasDrift(records)
+		}
+	}
+	return report, nil
+}
+
+// zoneDrift returns the drift of domain at provider.
+func zoneDrift(provider *models.DNSProviderInstance, domain *models.DomainConfig) ([]*drift.Record, error) {
+	dc, err := domain.Copy()
+	if err != nil {
+		return nil, err
+	}
+	if err := dc.Punycode(); err != nil {
+		return nil, err
+	}
+
+	if lister, ok := provider.Driver.(providers.ZoneLister); ok {
+		zones, err := lister.ListZones()
+		if err != nil {
+			return nil, err
+		}
+		aceZoneName, _ := idna.ToASCII(dc.Name)
+		if !slices.Contains(zones, aceZoneName) {
+			return nil, fmt.Errorf("zone %s does not exist", dc.Name)
+		}
+	}
+
+	existing, err := zoneRecords(provider.Driver, dc)
+	if err != nil {
+		return nil, err
+	}
+	models.PostProcessRecords(existing)
+	return drift.Detect(comparableRecords(existing, dc), dc)
+}
+
+func writeDriftJSON(w io.Writer, report *DriftReport) error {
+	j, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", j)
+	return err
+}
+
+func writeDriftText(w io.Writer, report *DriftReport, showIgnored bool) {
+	for _, z := range report.Zones {
+		if z.Error != "" {
+			fmt.Fprintf(w, "%s at %s: NOT CHECKED: %s\n", z.Domain, z.Provider, z.Error)
+			continue
+		}
+		var counts []string
+		for _, c := range drift.Classes {
+			counts = append(counts, fmt.Sprintf("%d %s", z.Counts[string(c)], c))
+		}
+		fmt.Fprintf(w, "%s at %s: %s\n", z.Domain, z.Provider, strings.Join(counts, ", "))
+		for _, r := range z.Records {
+			if r.Class == drift.Ignored && !showIgnored {
+				continue
+			}
+			fmt.Fprintf(w, "  %-8s %s %s %s\n", strings.ToUpper(string(r.Class)), r.Name, r.Type, driftValues(r))
+		}
+	}
+}
+
+// driftValues describes the values of a drifted record.
+func driftValues(r *drift.Record) string {
+	v := func(v *drift.Value) string { return fmt.Sprintf("%s ttl=%d", v.Target, v.TTL) }
+	switch {
+	case r.Existing == nil:
+		return v(r.Desired)
+	case r.Desired == nil:
+		return v(r.Existing)
+	default:
+		return v(r.Existing) + " -> " + v(r.Desired)
+	}
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/StackExchange/dnscontrol/v3/models"
 	"github.com/StackExchange/dnscontrol/v3/pkg/diff2"
 	"github.com/StackExchange/dnscontrol/v3/pkg/js"
+	"github.com/StackExchange/dnscontrol/v3/pkg/normalize"
 	"github.com/StackExchange/dnscontrol/v3/pkg/printer"
+	"github.com/StackExchange/dnscontrol/v3/pkg/spflib"
 	"github.com/urfave/cli/v2"
 )
 
@@ -79,7 +81,9 @@ func Run(v string) int {
 // Could come from parsing js, or from stored json
 type GetDNSConfigArgs struct {
 	ExecuteDSLArgs
-	JSONFile string
+	JSONFile       string
+	SPFCache       string
+	SPFCachePolicy string
 }
 
 func (args *GetDNSConfigArgs) flags() []cli.Flag {
@@ -95,14 +99,44 @@ func (args *GetDNSConfigArgs) flags() []cli.Flag {
 			Hidden:      true,
 			Usage:       "same as -ir. only here for backwards compatibility, hence hidden",
 		},
+		&cli.StringFlag{
+			Destination: &args.SPFCache,
+			Name:        "spf-cache",
+			Value:       spflib.DefaultCacheFile,
+			Usage:       "File that caches the DNS lookups of SPF flattening",
+		},
+		&cli.StringFlag{
+			Destination: &args.SPFCachePolicy,
+			Name:        "spf-cache-policy",
+			Value:       string(spflib.PolicyWarn),
+			Usage:       "When the SPF cache is out of date: warn, fail, or auto-update it",
+		},
 	)
 }
 
+// configureSPFCache sets the cache of SPF flattening from args. An
+// empty policy is spflib.PolicyWarn, for callers that do not set it.
+func configureSPFCache(args GetDNSConfigArgs) error {
+	policy := spflib.PolicyWarn
+	if args.SPFCachePolicy != "" {
+		var err error
+		if policy, err = spflib.ParsePolicy(args.SPFCachePolicy); err != nil {
+			return err
+		}
+	}
+	normalize.SPFCache = spflib.CacheConfig{File: args.SPFCache, Policy: policy}
+	return nil
+}
+
 // GetDNSConfig reads the json-formatted IR file. Or executes javascript. All depending on flags provided.
 func GetDNSConfig(args GetDNSConfigArgs) (*models.DNSConfig, error) {
 	var err error
 	cfg := &models.DNSConfig{}
 
+	if err := configureSPFCache(args); err != nil {
+		return nil, err
+	}
+
 	if args.JSONFile == "" {
 		// No IR file specified. Generate the IR by running dnsconfig.json
 		// as normal.
@@ -187,7 +221,7 @@ func (args *ExecuteDSLArgs) flags() []cli.Flag {
 			Name:        "config",
 			Value:       "dnsconfig.js",
 			Destination: &args.JSFile,
-			Usage:       "File containing dns config in javascript DSL",
+			Usage:       "File containing dns config in javascript DSL (or YAML), or a directory of such files",
 		},
 		&cli.StringFlag{
 			Name:        "js",
@@ -247,10 +281,11 @@ func (args *GetCredentialsArgs) flags() []cli.Flag {
 	}
 }
 
-// FilterArgs encapsulates the flags/args for sub-commands that can filter by provider or domain.
+// FilterArgs encapsulates the flags/args for sub-commands that can filter by provider, domain or owner.
 type FilterArgs struct {
 	Providers string
 	Domains   string
+	Owners    string
 }
 
 func (args *FilterArgs) flags() []cli.Flag {
@@ -267,6 +302,12 @@ func (args *FilterArgs) flags() []cli.Flag {
 			Usage:       `Comma separated list of domain names to include`,
 			Value:       "",
 		},
+		&cli.StringFlag{
+			Name:        "owner",
+			Destination: &args.Owners,
+			Usage:       `Comma separated list of owners (see OWNER()) whose domains to include`,
+			Value:       "",
+		},
 	}
 }
 
@@ -290,11 +331,28 @@ func (args *FilterArgs) shouldRunProvider(name string, dc *models.DomainConfig)
 	return false
 }
 
-func (args *FilterArgs) shouldRunDomain(d string) bool {
+func (args *FilterArgs) shouldRunDomain(dc *models.DomainConfig) bool {
+	if args.Owners != "" && !ownerInList(dc.Owner(), strings.Split(args.Owners, ",")) {
+		return false
+	}
 	if args.Domains == "" {
 		return true
 	}
-	return domainInList(d, strings.Split(args.Domains, ","))
+	return domainInList(dc.UniqueName, strings.Split(args.Domains, ","))
+}
+
+// ownerInList returns whether owner is in list. Domains without an
+// owner are never in the list.
+func ownerInList(owner string, list []string) bool {
+	if owner == "" {
+		return false
+	}
+	for _, item := range list {
+		if strings.TrimSpace(item) == owner {
+			return true
+		}
+	}
+	return false
 }
 
 func domainInList(domain string, list []string) bool {
@@ -1,6 +1,14 @@
 package commands
 
-import "testing"
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
 
 func Test_domainInList(t *testing.T) {
 	type args struct {
@@ -61,3 +69,33 @@ func Test_domainInList(t *testing.T) {
 		})
 	}
 }
+
+// TestCheck runs the check command as the CLI does.
+func TestCheck(t *testing.T) {
+	defer func(w io.Writer) { cli.ErrWriter = w }(cli.ErrWriter)
+	defer log.SetOutput(os.Stderr)
+
+	var check *cli.Command
+	for _, c := range commands {
+		if c.Name == "check" {
+			check = c
+		}
+	}
+	if check == nil {
+		t.Fatal("no check command")
+	}
+	config := filepath.Join(t.TempDir(), "dnsconfig.js")
+	js := `D("example.com", NewRegistrar("none"), DnsProvider(NewDnsProvider("bind")), A("www", "1.2.3.4"));`
+	if err := os.WriteFile(config, []byte(js), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, argv := range [][]string{
+		{"dnscontrol", "check", "--config", config},
+		{"dnscontrol", "check", "--ir", "test_data/simple.com.zone.json"},
+	} {
+		app := &cli.App{Commands: []*cli.Command{check}}
+		if err := app.Run(argv); err != nil {
+			t.Errorf("%v: %s", argv[1:], err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package commands
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/js"
+	"github.com/StackExchange/dnscontrol/v3/pkg/yamlconfig"
+)
+
+// A configuration directory holds a providers file, which declares
+// the registrars and DNS providers, and one file per zone, in
+// JavaScript or YAML (see documentation/config-dir.md).
+
+// configDirProviders are the names the providers file may have.
+var configDirProviders = []string{"providers.js", "providers.yaml", "providers.yml", "providers.json"}
+
+// isConfigDirFile returns whether the file called name, in a
+// configuration directory, is JavaScript or YAML.
+func isConfigDirFile(name string) bool {
+	return strings.HasSuffix(name, ".js") || yamlconfig.IsConfigFile(name)
+}
+
+// configDirFiles returns the providers file of the configuration in
+// dir, and its zone files: the other files in dir and its
+// subdirectories, sorted. Files and directories whose name starts
+// with "_" or "." are left out, so that they can hold the files that
+// the zones require().
+func configDirFiles(dir string) (providers string, zones []string, err error) {
+	var found []string
+	for _, name := range configDirProviders {
+		if m, _ := filepath.Glob(filepath.Join(dir, name)); len(m) != 0 {
+			found = append(found, m[0])
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", nil, fmt.Errorf("%s: no providers file (%s)", dir, strings.Join(configDirProviders, ", "))
+	case 1:
+		providers = found[0]
+	default:
+		return "", nil, fmt.Errorf("%s: more than one providers file: %s", dir, strings.Join(found, ", "))
+	}
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && (strings.HasPrefix(d.Name(), "_") || strings.HasPrefix(d.Name(), ".")) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && path != providers && isConfigDirFile(d.Name()) {
+			zones = append(zones, path)
+		}
+		return nil
+	})
+	sort.Strings(zones)
+	return providers, zones, err
+}
+
+// loadConfigDir reads the configuration in the directory args.JSFile.
+// The JavaScript files run first, in the same global scope (so that
+// the zones can use the variables of providers.js), then the YAML
+// files are added.
+func loadConfigDir(args ExecuteDSLArgs) (*models.DNSConfig, error) {
+	providers, zones, err := configDirFiles(args.JSFile)
+	if err != nil {
+		return nil, err
+	}
+	var jsFiles, yamlFiles []string
+	for _, file := range append([]string{providers}, zones...) {
+		if yamlconfig.IsConfigFile(file) {
+			yamlFiles = append(yamlFiles, file)
+		} else {
+			jsFiles = append(jsFiles, file)
+		}
+	}
+
+	cfg := &models.DNSConfig{
+		Registrars:   []*models.RegistrarConfig{},
+		DNSProviders: []*models.DNSProviderConfig{},
+		Domains:      []*models.DomainConfig{},
+	}
+	if len(jsFiles) != 0 {
+		cfg, err = js.ExecuteJavascriptFiles(jsFiles, args.DevMode, stringSliceToMap(args.Variable))
+		if err != nil {
+			return nil, fmt.Errorf("executing %s: %w", args.JSFile, err)
+		}
+	}
+	if err := yamlconfig.Merge(cfg, yamlFiles...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+)
+
+func TestConfigDirFiles(t *testing.T) {
+	dir := filepath.Join("test_data", "configdir")
+	providers, zones, err := configDirFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "providers.js"); providers != want {
+		t.Errorf("providers: got %q, want %q", providers, want)
+	}
+	want := []string{
+		filepath.Join(dir, "api.example.yaml"),
+		filepath.Join(dir, "other.example.js"),
+		filepath.Join(dir, "web", "example.com.js"),
+	}
+	if !reflect.DeepEqual(zones, want) {
+		t.Errorf("zones:\ngot  %v\nwant %v", zones, want)
+	}
+
+	if _, _, err := configDirFiles(filepath.Join(dir, "web")); err == nil || !strings.Contains(err.Error(), "no providers file") {
+		t.Errorf("got error %v, want no providers file", err)
+	}
+}
+
+func TestLoadConfigDir(t *testing.T) {
+	cfg, err := ExecuteDSL(ExecuteDSLArgs{JSFile: filepath.Join("test_data", "configdir")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	owners := map[string]string{}
+	for _, dc := range cfg.Domains {
+		owners[dc.Name] = dc.Owner()
+	}
+	want := map[string]string{"example.com": "web", "other.example": "", "api.example": "api"}
+	if !reflect.DeepEqual(owners, want) {
+		t.Errorf("owners: got %v, want %v", owners, want)
+	}
+	if dc := cfg.FindDomain("example.com"); dc == nil || len(dc.Records) != 2 || dc.Metadata[models.DomainContact] != "web@example.com" {
+		t.Errorf("example.com: got %+v", dc)
+	}
+}
+
+func TestShouldRunDomainOwner(t *testing.T) {
+	web := &models.DomainConfig{UniqueName: "example.com", Metadata: map[string]string{models.DomainOwner: "web"}}
+	none := &models.DomainConfig{UniqueName: "other.example", Metadata: map[string]string{}}
+	tests := []struct {
+		args      FilterArgs
+		web, none bool
+	}{
+		{FilterArgs{}, true, true},
+		{FilterArgs{Owners: "web"}, true, false},
+		{FilterArgs{Owners: "api, web"}, true, false},
+		{FilterArgs{Owners: "api"}, false, false},
+		{FilterArgs{Owners: "web", Domains: "other.example"}, false, false},
+	}
+	for _, tt := range tests {
+		if got := tt.args.shouldRunDomain(web); got != tt.web {
+			t.Errorf("%+v: example.com: got %v, want %v", tt.args, got, tt.web)
+		}
+		if got := tt.args.shouldRunDomain(none); got != tt.none {
+			t.Errorf("%+v: other.example: got %v, want %v", tt.args, got, tt.none)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/prettyzone"
+	"github.com/StackExchange/dnscontrol/v3/providers/bind"
+	"github.com/urfave/cli/v2"
+)
+
+var _ = cmd(catUtils, func() *cli.Command {
+	var args ConvertZoneArgs
+	return &cli.Command{
+		Name:  "convert-zone",
+		Usage: "converts BIND zonefiles to dnsconfig.js (stand-alone)",
+		Action: func(ctx *cli.Context) error {
+			if ctx.NArg() < 1 {
+				return cli.Exit("Arguments should be: [zone=]zonefile [...] (Ex: example.com=db.example)", 1)
+			}
+			args.ZoneFiles = ctx.Args().Slice()
+			return exit(ConvertZone(args))
+		},
+		Flags:     args.flags(),
+		UsageText: "dnscontrol convert-zone [command options] [zone=]zonefile [...]",
+		Description: `Convert zonefiles to dnsconfig.js.  This is a stand-alone utility.
+
+The zonefiles may use $ORIGIN, $TTL, $INCLUDE and $GENERATE.
+
+ARGUMENTS:
+   zonefile: A zonefile. The name of the zone is the name of the file,
+             without a "db." prefix or a ".zone" or ".db" suffix,
+             unless it is given before "=".
+
+EXAMPLES:
+   dnscontrol convert-zone example.com.zone
+   dnscontrol convert-zone --format=djs example.com=zones/db.example
+   dnscontrol convert-zone --out-dir=domains --macros=macros.js zones/*.zone`,
+	}
+}())
+
+// ConvertZoneArgs contains all data/flags needed to run convert-zone, independently of CLI.
+type ConvertZoneArgs struct {
+	ZoneFiles    []string // [zone=]file
+	OutputFormat string   // js or djs
+	OutputFile   string   // Filename to send output ("" means stdout)
+	OutputDir    string   // Directory to write one file per zone to
+	DefaultTTL   int      // 0 picks the zone's most common TTL
+	MacrosFile   string   // File to write the macros to ("" means no macros)
+	MinRepeat    int      // Number of zones a record set must be in to become a macro
+}
+
+func (args *ConvertZoneArgs) flags() []cli.Flag {
+	var flags []cli.Flag
+	flags = append(flags, &cli.StringFlag{
+		Name:        "format",
+		Destination: &args.OutputFormat,
+		Value:       "js",
+		Usage:       `Output format: js djs`,
+	})
+	flags = append(flags, &cli.StringFlag{
+		Name:        "out",
+		Destination: &args.OutputFile,
+		Usage:       `Instead of stdout, write to this file`,
+	})
+	flags = append(flags, &cli.StringFlag{
+		Name:        "out-dir",
+		Destination: &args.OutputDir,
+		Usage:       `Write each zone to its own file in this directory, to be loaded with require_glob()`,
+	})
+	flags = append(flags, &cli.IntFlag{
+		Name:        "ttl",
+		Destination: &args.DefaultTTL,
+		Usage:       `Default TTL (0 picks each zone's most common TTL)`,
+	})
+	flags = append(flags, &cli.StringFlag{
+		Name:        "macros",
+		Destination: &args.MacrosFile,
+		Usage:       `Write record sets found in several zones to this file as variables, and use them in the zones`,
+	})
+	flags = append(flags, &cli.IntFlag{
+		Name:        "min-repeat",
+		Destination: &args.MinRepeat,
+		Value:       2,
+		Usage:       `Number of zones a record set must be found in to be written to --macros`,
+	})
+	return flags
+}
+
+// convertedZone is a zonefile being converted.
+type convertedZone struct {
+	name       string
+	recs       models.Records
+	defaultTTL uint32
+	macros     []*zoneMacro // In order of first use.
+}
+
+// zoneMacro is a record set found in several zones.
+type zoneMacro struct {
+	name  string
+	lines []string // As written by formatDsl.
+	zones int
+}
+
+// ConvertZone implements the convert-zone subcommand.
+func ConvertZone(args ConvertZoneArgs) error {
+	if args.OutputFormat != "js" && args.OutputFormat != "djs" {
+		return fmt.Errorf("format %q unknown", args.OutputFormat)
+	}
+	if args.OutputFile != "" && args.OutputDir != "" {
+		return fmt.Errorf("--out and --out-dir are mutually exclusive")
+	}
+
+	var zones []*convertedZone
+	for _, arg := range args.ZoneFiles {
+		name, file := zoneFileArg(arg)
+		recs, err := bind.ParseZoneFile(name, file)
+		if err != nil {
+			return err
+		}
+		z := &convertedZone{name: name, recs: recs, defaultTTL: uint32(args.DefaultTTL)}
+		if z.defaultTTL == 0 {
+			z.defaultTTL = prettyzone.MostCommonTTL(recs)
+		}
+		zones = append(zones, z)
+	}
+
+	var macros []*zoneMacro
+	if args.MacrosFile != "" {
+		macros = extractMacros(zones, args.MinRepeat)
+		if err := writeFile(args.MacrosFile, func(w io.Writer) {
+			writeMacros(w, args.OutputFormat, macros)
+		}); err != nil {
+			return err
+		}
+	}
+
+	if args.OutputDir != "" {
+		if err := os.MkdirAll(args.OutputDir, 0o755); err != nil {
+			return err
+		}
+		macrosFile := ""
+		if args.MacrosFile != "" {
+			var err error
+			if macrosFile, err = relativeRequire(args.OutputDir, args.MacrosFile); err != nil {
+				return err
+			}
+		}
+		if err := writeFile(filepath.Join(args.OutputDir, providersFile), func(w io.Writer) {
+			writeProviders(w, macrosFile)
+		}); err != nil {
+			return err
+		}
+		for _, z := range zones {
+			if err := writeFile(filepath.Join(args.OutputDir, z.name+".js"), func(w io.Writer) {
+				writeConvertedZone(w, args.OutputFormat, z)
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return writeFile(args.OutputFile, func(w io.Writer) {
+		fmt.Fprint(w, providersDecl)
+		for _, z := range zones {
+			writeConvertedZone(w, args.OutputFormat, z)
+		}
+	})
+}
+
+// providersFile is the file of --out-dir that declares the provider
+// and registrar of the zones, and loads the macros.
+const providersFile = "_providers.js"
+
+// providersDecl declares the provider and registrar of the zones.
+const providersDecl = `var DSP_CHANGEME = NewDnsProvider("changeme");
+var REG_CHANGEME = NewRegistrar("none");
+`
+
+// writeProviders writes providersFile. It is required before the
+// zones, then again by require_glob(), therefore it only runs once.
+// macrosFile is the file of the macros, relative to it, if any.
+func writeProviders(w io.Writer, macrosFile string) {
+	fmt.Fprintf(w, "// Written by dnscontrol convert-zone. require() it before the zones of\n")
+	fmt.Fprintf(w, "// this directory; it runs only once.\n")
+	fmt.Fprintf(w, "if (typeof DSP_CHANGEME === 'undefined') {\n")
+	for _, line := range strings.Split(strings.TrimSuffix(providersDecl, "\n"), "\n") {
+		fmt.Fprintf(w, "\t%s\n", line)
+	}
+	if macrosFile != "" {
+		fmt.Fprintf(w, "\trequire(%q);\n", macrosFile)
+	}
+	fmt.Fprintf(w, "}\n")
+}
+
+// relativeRequire returns the argument of a require() of file, in a
+// file of dir.
+func relativeRequire(dir, file string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absDir, absFile)
+	if err != nil {
+		return "", err
+	}
+	rel = filepath.ToSlash(rel)
+	if !strings.HasPrefix(rel, "../") {
+		rel = "./" + rel
+	}
+	return rel, nil
+}
+
+// zoneFileArg splits a "zone=file" argument. If there is no zone, it
+// is the name of the file without the usual prefixes and suffixes.
+func zoneFileArg(arg string) (zone, file string) {
+	if i := strings.IndexByte(arg, '='); i > 0 {
+		return strings.TrimSuffix(arg[:i], "."), arg[i+1:]
+	}
+	zone = filepath.Base(arg)
+	zone = strings.TrimPrefix(zone, "db.")
+	for _, suffix := range []string{".zone", ".db"} {
+		zone = strings.TrimSuffix(zone, suffix)
+	}
+	return zone, arg
+}
+
+// writeFile calls write with the file named filename, or stdout if
+// filename is empty.
+func writeFile(filename string, write func(io.Writer)) error {
+	if filename == "" {
+		write(os.Stdout)
+		return nil
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	write(f)
+	return f.Close()
+}
+
+// rrsetKey identifies a record set of a zone: its label and type.
+type rrsetKey struct{ name, rtype string }
+
+// rrsets groups the records of z that can be in a macro into record
+// sets, in order of appearance.
+func (z *convertedZone) rrsets() ([]rrsetKey, map[rrsetKey][]string) {
+	var order []rrsetKey
+	sets := map[rrsetKey][]string{}
+	for _, rec := range z.recs {
+		if rec.Type == "SOA" || (rec.Type == "NS" && rec.Name == "@") || (rec.Type == "CNAME" && rec.Name == "@") {
+			// These are commented out or annotated by formatDsl.
+			continue
+		}
+		k := rrsetKey{rec.Name, rec.Type}
+		if _, ok := sets[k]; !ok {
+			order = append(order, k)
+		}
+		sets[k] = append(sets[k], formatDsl(z.name, rec, z.defaultTTL))
+	}
+	return order, sets
+}
+
+var macroNameRE = regexp.MustCompile(`[^A-Z0-9_]+`)
+
+// extractMacros finds the record sets that are identical in minRepeat
+// zones or more, and records them in the zones that use them.
+func extractMacros(zones []*convertedZone, minRepeat int) []*zoneMacro {
+	if minRepeat < 2 {
+		minRepeat = 2
+	}
+	type found struct {
+		key   rrsetKey
+		lines []string
+		zones []*convertedZone
+	}
+	var order []string
+	all := map[string]*found{}
+	for _, z := range zones {
+		keys, sets := z.rrsets()
+		for _, k := range keys {
+			sig := strings.Join(sets[k], "\n")
+			f, ok := all[sig]
+			if !ok {
+				f = &found{key: k, lines: sets[k]}
+				all[sig] = f
+				order = append(order, sig)
+			}
+			f.zones = append(f.zones, z)
+		}
+	}
+
+	var macros []*zoneMacro
+	used := map[string]bool{}
+	for _, sig := range order {
+		f := all[sig]
+		if len(f.zones) < minRepeat {
+			continue
+		}
+		label := f.key.name
+		if label == "@" {
+			label = "APEX"
+		}
+		base := macroNameRE.ReplaceAllString(strings.ToUpper(f.key.rtype+"_"+label), "_")
+		name := base
+		for i := 2; used[name]; i++ {
+			name = fmt.Sprintf("%s_%d", base, i)
+		}
+		used[name] = true
+
+		m := &zoneMacro{name: name, lines: f.lines, zones: len(f.zones)}
+		macros = append(macros, m)
+		for _, z := range f.zones {
+			z.macros = append(z.macros, m)
+			z.recs = removeRRset(z.recs, f.key)
+		}
+	}
+	return macros
+}
+
+func removeRRset(recs models.Records, k rrsetKey) models.Records {
+	var kept models.Records
+	for _, rec := range recs {
+		if rec.Name != k.name || rec.Type != k.rtype {
+			kept = append(kept, rec)
+		}
+	}
+	return kept
+}
+
+func writeMacros(w io.Writer, format string, macros []*zoneMacro) {
+	sep := ",\n\t"
+	if format == "djs" {
+		sep = "\n\t, "
+	}
+	fmt.Fprintf(w, "// Record sets found in several zones by dnscontrol convert-zone.\n")
+	for _, m := range macros {
+		fmt.Fprintf(w, "\n// Used by %d zones.\nvar %s = [\n\t%s\n];\n", m.zones, m.name, strings.Join(m.lines, sep))
+	}
+}
+
+func writeConvertedZone(w io.Writer, format string, z *convertedZone) {
+	items := []string{"DnsProvider(DSP_CHANGEME)"}
+	if z.defaultTTL != models.DefaultTTL && z.defaultTTL != 0 {
+		items = append(items, fmt.Sprintf("DefaultTTL(%d)", z.defaultTTL))
+	}
+	for _, m := range z.macros {
+		items = append(items, m.name)
+	}
+	writeDsl(w, format, z.name, "REG_CHANGEME", items, z.recs, z.defaultTTL)
+}
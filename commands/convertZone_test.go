@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/v3/pkg/js"
+	"github.com/andreyvit/diff"
+)
+
+func TestConvertZone(t *testing.T) {
+	dir := t.TempDir()
+	out := func(name string) string { return filepath.Join(dir, name) }
+
+	// One file, with macros.
+	err := ConvertZone(ConvertZoneArgs{
+		ZoneFiles:    []string{"one.example=test_data/convert/db.one.example", "test_data/convert/two.example.zone"},
+		OutputFormat: "djs",
+		OutputFile:   out("zones.djs"),
+		MacrosFile:   out("macros.djs"),
+		MinRepeat:    2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareFiles(t, out("zones.djs"), "test_data/convert/zones.djs")
+	compareFiles(t, out("macros.djs"), "test_data/convert/macros.djs")
+
+	// One file per zone, for require_glob().
+	err = ConvertZone(ConvertZoneArgs{
+		ZoneFiles:    []string{"test_data/convert/db.one.example"},
+		OutputFormat: "js",
+		OutputDir:    out("domains"),
+		MacrosFile:   out("macros.js"),
+		MinRepeat:    2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareFiles(t, out("domains/one.example.js"), "test_data/convert/one.example.js")
+	compareFiles(t, out("domains/_providers.js"), "test_data/convert/_providers.js")
+
+	// The layout runs.
+	dnsconfig := out("dnsconfig.js")
+	if err := os.WriteFile(dnsconfig, []byte(`require("./domains/_providers.js");
+require_glob("./domains/");
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := js.ExecuteJavascript(dnsconfig, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Domains) != 1 || len(cfg.DNSProviders) != 1 || len(cfg.Registrars) != 1 {
+		t.Errorf("got %d domains, %d DNS providers and %d registrars, want 1 of each", len(cfg.Domains), len(cfg.DNSProviders), len(cfg.Registrars))
+	}
+}
+
+func TestZoneFileArg(t *testing.T) {
+	tests := []struct{ arg, zone, file string }{
+		{"example.com=zones/db", "example.com", "zones/db"},
+		{"example.com.=zones/db", "example.com", "zones/db"},
+		{"zones/db.example.com", "example.com", "zones/db.example.com"},
+		{"example.com.zone", "example.com", "example.com.zone"},
+		{"example.com.db", "example.com", "example.com.db"},
+	}
+	for _, tt := range tests {
+		zone, file := zoneFileArg(tt.arg)
+		if zone != tt.zone || file != tt.file {
+			t.Errorf("zoneFileArg(%q) = %q, %q; want %q, %q", tt.arg, zone, file, tt.zone, tt.file)
+		}
+	}
+}
+
+func compareFiles(t *testing.T, gotFilename, wantFilename string) {
+	t.Helper()
+	got, err := os.ReadFile(gotFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile(wantFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w, g := string(want), string(got); w != g {
+		t.Errorf("%s mismatch (-got +want):\n%s", wantFilename, diff.LineDiff(g, w))
+	}
+}
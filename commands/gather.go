@@ -0,0 +1,485 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/diff2"
+	"github.com/StackExchange/dnscontrol/v3/pkg/nameservers"
+	"github.com/StackExchange/dnscontrol/v3/pkg/printer"
+	"github.com/StackExchange/dnscontrol/v3/providers"
+	"golang.org/x/exp/slices"
+	"golang.org/x/net/idna"
+)
+
+// This file implements the part of preview/push that talks to the
+// providers to find out what needs to change. Each domain is a
+// "job". Jobs may run concurrently but their results are always
+// reported (and, for push, executed) in the order of the jobs: that of
+// cfg.Domains, but grouped by owner for a preview (see newDomainJobs).
+
+// domainJob is the result of gathering the corrections for a domain.
+type domainJob struct {
+	domain *models.DomainConfig
+	done   chan struct{}
+	once   sync.Once // Used if the job is not run by startJobs.
+
+	warnings  []string       // Output before any provider is started.
+	providers []*providerJob // The DNS providers, in order.
+	registrar *providerJob   // nil if processing stopped before the registrar.
+	err       error          // A fatal error. The entire run stops.
+
+	// The nameservers and records of the domain before gather added
+	// those of the providers, for createZones.
+	nameservers []*models.Nameserver
+	records     models.Records
+
+	calls []apiCall // The calls to the providers, for the metrics.
+}
+
+// apiCall records a call to a provider.
+type apiCall struct {
+	provider string
+	op       string // The method called, such as "GetZoneRecords".
+	duration time.Duration
+	failed   bool
+}
+
+// providerJob is the result of gathering the corrections of one
+// provider (or registrar) for a domain.
+type providerJob struct {
+	name        string
+	skip        bool
+	create      bool   // push: the zone does not exist yet (see createZones).
+	warning     string // Output instead of the corrections, if set.
+	corrections []*models.Correction
+	err         error
+
+	// Only if PreviewArgs.wantChanges():
+	existing   models.Records
+	desired    *models.DomainConfig // Punycoded, as the provider left it: changes lead from existing to its records.
+	changes    diff2.ChangeList
+	changesErr error
+}
+
+func (j *domainJob) warnf(format string, args ...interface{}) {
+	j.warnings = append(j.warnings, fmt.Sprintf(format, args...))
+}
+
+// call calls fn while holding a slot for provider (see
+// providerLimiter.do), and records how long it took.
+func (j *domainJob) call(lim providerLimiter, provider, op string, fn func() error) error {
+	var err error
+	lim.do(provider, func() {
+		start := time.Now()
+		err = fn()
+		j.calls = append(j.calls, apiCall{provider: provider, op: op, duration: time.Since(start), failed: err != nil})
+	})
+	return err
+}
+
+// gather contacts the providers and collects the corrections for the
+// domain. It does not print anything, the results are recorded in j.
+// Processing stops at the first error, just as it would if the
+// results were printed as they occur.
+func (j *domainJob) gather(args PreviewArgs, push bool, lim providerLimiter) {
+	domain := j.domain
+	j.nameservers, j.records = domain.Nameservers, domain.Records
+	var providersWithExistingZone, providersToGather []*models.DNSProviderInstance
+	missing := map[string]bool{}
+	fetched := map[string]models.Records{} // See zoneExists.
+	for _, provider := range domain.DNSProviderInstances {
+
+		if !args.NoPopulate {
+			exists, recs, err := j.zoneExists(lim, provider, push)
+			if err != nil {
+				j.err = err
+				return
+			}
+			if !exists && !push {
+				j.warnf("Zone '%s' does not exist in the '%s' profile and will be added automatically.\n", domain.Name, provider.Name)
+				continue // continue with next provider, as we can not determine corrections without an existing zone
+			}
+			if !exists {
+				// push creates the zone after the plan and safety checks
+				// (see createZones).
+				missing[provider.Name] = true
+				providersToGather = append(providersToGather, provider)
+				continue
+			}
+			if recs != nil {
+				fetched[provider.Name] = recs
+			}
+		}
+		providersWithExistingZone = append(providersWithExistingZone, provider)
+		providersToGather = append(providersToGather, provider)
+	}
+
+	var names []string
+	for _, provider := range providersWithExistingZone {
+		names = append(names, provider.Name)
+	}
+	sort.Strings(names) // See providerLimiter.doAll.
+	names = slices.Compact(names)
+	var nsList []*models.Nameserver
+	var err error
+	lim.doAll(names, func() {
+		nsList, err = nameservers.DetermineNameserversForProviders(domain, providersWithExistingZone)
+	})
+	if err != nil {
+		j.err = err
+		return
+	}
+	domain.Nameservers = nsList
+	nameservers.AddNSRecords(domain)
+
+	for _, provider := range providersToGather {
+		dc, err := domain.Copy()
+		if err != nil {
+			j.err = err
+			return
+		}
+		pj := &providerJob{name: provider.Name, create: missing[provider.Name]}
+		j.providers = append(j.providers, pj)
+		pj.skip = !args.shouldRunProvider(provider.Name, dc)
+		if pj.skip {
+			continue
+		}
+
+		/// This is where we should audit?
+
+		if pj.create {
+			// The corrections are gathered once the zone exists. Until
+			// then, every record is added to an empty zone.
+			if args.wantChanges() {
+				if pj.changesErr = dc.Punycode(); pj.changesErr == nil {
+					pj.desired = dc
+					pj.changes, pj.changesErr = recordChanges(nil, dc)
+				}
+			}
+			continue
+		}
+		j.gatherProvider(pj, provider, dc, fetched[provider.Name], args, lim)
+		if pj.err != nil {
+			return
+		}
+	}
+
+	j.registrar = &providerJob{name: domain.RegistrarName}
+	j.registrar.skip = !args.shouldRunProvider(domain.RegistrarName, domain)
+	if j.registrar.skip {
+		return
+	}
+	if len(domain.Nameservers) == 0 && domain.Metadata["no_ns"] != "true" {
+		j.registrar.warning = "No nameservers declared; skipping registrar. Add {no_ns:'true'} to force.\n"
+		return
+	}
+	dc, err := domain.Copy()
+	if err != nil {
+		j.err = err
+		return
+	}
+	j.registrar.err = j.call(lim, domain.RegistrarName, "GetRegistrarCorrections", func() (err error) {
+		j.registrar.corrections, err = domain.RegistrarInstance.Driver.GetRegistrarCorrections(dc)
+		return err
+	})
+}
+
+// zoneExists returns whether the zone of the domain exists at
+// provider. The zone is assumed to exist if that can not be known. A
+// push knows it if the provider can create zones: then the zone
+// exists if its records can be read, and they are returned so that
+// they are not read again. The zone is never created here (see
+// createZones).
+func (j *domainJob) zoneExists(lim providerLimiter, provider *models.DNSProviderInstance, push bool) (bool, models.Records, error) {
+	aceZoneName, _ := idna.ToASCII(j.domain.Name)
+	lister, canList := provider.Driver.(providers.ZoneLister)
+	_, canCreate := provider.Driver.(providers.ZoneCreator)
+	switch {
+	case canList && (canCreate || !push):
+		var zones []string
+		err := j.call(lim, provider.Name, "ListZones", func() (err error) {
+			zones, err = lister.ListZones()
+			return err
+		})
+		if err != nil {
+			return false, nil, err
+		}
+		if !slices.Contains(zones, aceZoneName) {
+			if !push {
+				j.warnf("DEBUG: zones: %v\n", zones)
+				j.warnf("DEBUG: Name: %v\n", j.domain.Name)
+			}
+			return false, nil, nil
+		}
+	case canCreate && push:
+		var recs models.Records
+		err := j.call(lim, provider.Name, "GetZoneRecords", func() (err error) {
+			recs, err = zoneRecords(provider.Driver, j.domain)
+			return err
+		})
+		if err == nil && recs == nil {
+			recs = models.Records{} // The zone is empty, not unknown.
+		}
+		return err == nil, recs, nil
+	}
+	return true, nil, nil
+}
+
+// gatherProvider collects the corrections of provider for dc and, if
+// args.wantChanges(), the changes. existing are the records of the
+// zone if they have already been fetched, or nil (not empty). The records are
+// fetched only once if the provider is a
+// providers.ZoneRecordsCorrector (or they were already fetched), so
+// that the corrections and the changes are computed from the same
+// records.
+func (j *domainJob) gatherProvider(pj *providerJob, provider *models.DNSProviderInstance, dc *models.DomainConfig, existing models.Records, args PreviewArgs, lim providerLimiter) {
+	fetched := existing != nil
+	corrector, ok := provider.Driver.(providers.ZoneRecordsCorrector)
+	if ok && (fetched || args.wantChanges()) {
+		if !fetched {
+			pj.err = j.call(lim, provider.Name, "GetZoneRecords", func() (err error) {
+				existing, err = zoneRecords(provider.Driver, dc)
+				return err
+			})
+			if pj.err != nil {
+				return
+			}
+			fetched = true
+		}
+		var recs models.Records
+		if recs, pj.err = copyRecords(existing); pj.err != nil {
+			return
+		}
+		pj.err = j.call(lim, provider.Name, "GetZoneRecordsCorrections", func() (err error) {
+			pj.corrections, err = corrector.GetZoneRecordsCorrections(dc, recs)
+			return err
+		})
+	} else {
+		pj.err = j.call(lim, provider.Name, "GetDomainCorrections", func() (err error) {
+			pj.corrections, err = provider.Driver.GetDomainCorrections(dc)
+			return err
+		})
+	}
+	if pj.err != nil || !args.wantChanges() {
+		return
+	}
+	// dc is as the provider left it: with the records it would write,
+	// such as the SOA of BIND.
+	if pj.desired, pj.changesErr = dc.Copy(); pj.changesErr != nil {
+		return
+	}
+	if pj.changesErr = pj.desired.Punycode(); pj.changesErr != nil {
+		return
+	}
+	if !fetched {
+		pj.changesErr = j.call(lim, provider.Name, "GetZoneRecords", func() (err error) {
+			existing, err = zoneRecords(provider.Driver, pj.desired)
+			return err
+		})
+		if pj.changesErr != nil {
+			return
+		}
+	}
+	pj.existing = existing
+	pj.changes, pj.changesErr = recordChanges(existing, pj.desired)
+}
+
+// creates returns whether gather found zones that a push must create.
+func (j *domainJob) creates() bool {
+	for _, pj := range j.providers {
+		if pj.create && !pj.skip {
+			return true
+		}
+	}
+	return false
+}
+
+// createZones creates the zones that gather found missing, then
+// gathers the corrections again, now that they exist. Push calls it
+// only after the plan and safety checks. The calls are kept, for the
+// metrics.
+func (j *domainJob) createZones(args PreviewArgs, push bool, lim providerLimiter) error {
+	for _, pj := range j.providers {
+		if !pj.create || pj.skip {
+			continue
+		}
+		provider, err := dnsProvider(j.domain, pj.name)
+		if err != nil {
+			return err
+		}
+		creator := provider.Driver.(providers.ZoneCreator) // See zoneExists.
+		err = j.call(lim, pj.name, "EnsureZoneExists", func() error {
+			return creator.EnsureZoneExists(j.domain.Name)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	j.domain.Nameservers, j.domain.Records = j.nameservers, j.records
+	j.warnings, j.providers, j.registrar, j.err = nil, nil, nil, nil
+	j.gather(args, push, lim)
+	return nil
+}
+
+// wait returns when the job is complete. If the jobs are not run by
+// startJobs (n < 2), the first call runs the job.
+func (j *domainJob) wait(n int, args PreviewArgs, push bool, lim providerLimiter) {
+	if n < 2 {
+		j.once.Do(func() { j.gather(args, push, lim) })
+		return
+	}
+	<-j.done
+}
+
+// startJobs runs the jobs on a pool of n workers. Each job's done
+// channel is closed when it is complete.  If n is less than 2 nothing
+// is started; the caller should run each job in turn instead.
+func startJobs(jobs []*domainJob, n int, args PreviewArgs, push bool, lim providerLimiter) {
+	if n < 2 {
+		return
+	}
+	queue := make(chan *domainJob)
+	for i := 0; i < n; i++ {
+		go func() {
+			for j := range queue {
+				j.gather(args, push, lim)
+				close(j.done)
+			}
+		}()
+	}
+	go func() {
+		for _, j := range jobs {
+			queue <- j
+		}
+		close(queue)
+	}()
+}
+
+// providerLimiter caps the number of concurrent calls to a provider.
+// Providers that are not listed are not limited.
+type providerLimiter map[string]chan struct{}
+
+// newProviderLimiter returns the limiter of the providers and
+// registrars of cfg. Each is called one at a time, unless it is
+// providers.ConcurrencySafe: then the limit is the one given in s (see
+// parseProviderConcurrency), if any. Most drivers keep state (such as
+// a cache of zones) that concurrent calls would corrupt.
+func newProviderLimiter(cfg *models.DNSConfig, s string) (providerLimiter, error) {
+	given, err := parseProviderConcurrency(s)
+	if err != nil {
+		return nil, err
+	}
+	safe := map[string]bool{}
+	for _, d := range cfg.Domains {
+		for _, p := range d.DNSProviderInstances {
+			safe[p.Name] = isConcurrencySafe(p.Driver)
+		}
+		if r := d.RegistrarInstance; r != nil {
+			// A registrar may be the same driver as a DNS provider.
+			if s, ok := safe[r.Name]; !ok || s {
+				safe[r.Name] = isConcurrencySafe(r.Driver)
+			}
+		}
+	}
+	lim := providerLimiter{}
+	for name, ok := range safe {
+		switch ch, limited := given[name]; {
+		case !ok:
+			if limited && cap(ch) > 1 {
+				printer.Warnf("--provider-concurrency: %s can not be called concurrently, it is limited to 1\n", name)
+			}
+			lim[name] = make(chan struct{}, 1)
+		case limited:
+			lim[name] = ch
+		}
+	}
+	return lim, nil
+}
+
+func isConcurrencySafe(driver interface{}) bool {
+	cs, ok := driver.(providers.ConcurrencySafe)
+	return ok && cs.ConcurrencySafe()
+}
+
+// parseProviderConcurrency parses a list such as "r53=2,cloudflare=4"
+// into a providerLimiter.
+func parseProviderConcurrency(s string) (providerLimiter, error) {
+	lim := providerLimiter{}
+	if s == "" {
+		return lim, nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		name, val, ok := strings.Cut(item, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid provider concurrency %q (expected name=N)", item)
+		}
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid provider concurrency %q (N must be a positive integer)", item)
+		}
+		lim[name] = make(chan struct{}, n)
+	}
+	return lim, nil
+}
+
+// do calls fn while holding a slot for provider name.
+func (l providerLimiter) do(name string, fn func()) {
+	if ch, ok := l[name]; ok {
+		ch <- struct{}{}
+		defer func() { <-ch }()
+	}
+	fn()
+}
+
+// doAll calls fn while holding a slot for each of the providers in
+// names. names must be sorted so that slots are always acquired in
+// the same order (otherwise two jobs could deadlock).
+func (l providerLimiter) doAll(names []string, fn func()) {
+	if len(names) == 0 {
+		fn()
+		return
+	}
+	l.do(names[0], func() { l.doAll(names[1:], fn) })
+}
+
+// newDomainJobs returns a job for each domain that should be run, in
+// the order of cfg.Domains. For a preview, if any domain has an owner,
+// the jobs are grouped by owner, in order of name, and the domains
+// without one are last. A push always runs in the order of the
+// configuration, which may matter (such as a zone that delegates to
+// another).
+func newDomainJobs(cfg *models.DNSConfig, args PreviewArgs, push bool) []*domainJob {
+	var jobs []*domainJob
+	for _, domain := range cfg.Domains {
+		if !args.shouldRunDomain(domain) {
+			continue
+		}
+		jobs = append(jobs, &domainJob{domain: domain, done: make(chan struct{})})
+	}
+	if !push && hasOwners(jobs) {
+		sort.SliceStable(jobs, func(i, j int) bool {
+			a, b := jobs[i].domain.Owner(), jobs[j].domain.Owner()
+			if a == "" || b == "" {
+				return b == "" && a != ""
+			}
+			return a < b
+		})
+	}
+	return jobs
+}
+
+// hasOwners returns whether the domain of any of jobs has an owner.
+func hasOwners(jobs []*domainJob) bool {
+	for _, job := range jobs {
+		if job.domain.Owner() != "" {
+			return true
+		}
+	}
+	return false
+}
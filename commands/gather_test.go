@@ -0,0 +1,350 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/diff2"
+	"github.com/StackExchange/dnscontrol/v3/pkg/printer"
+)
+
+func Test_parseProviderConcurrency(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   string
+		want    map[string]int
+		wantErr bool
+	}{
+		{"empty", "", map[string]int{}, false},
+		{"one", "r53=2", map[string]int{"r53": 2}, false},
+		{"two", "r53=2,cloudflare=4", map[string]int{"r53": 2, "cloudflare": 4}, false},
+		{"noeq", "r53", nil, true},
+		{"noname", "=2", nil, true},
+		{"zero", "r53=0", nil, true},
+		{"nan", "r53=two", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseProviderConcurrency(tt.given)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseProviderConcurrency() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseProviderConcurrency() got %d entries, want %d", len(got), len(tt.want))
+			}
+			for name, n := range tt.want {
+				if cap(got[name]) != n {
+					t.Errorf("parseProviderConcurrency() %q = %d, want %d", name, cap(got[name]), n)
+				}
+			}
+		})
+	}
+}
+
+func Test_providerLimiter(t *testing.T) {
+	lim, err := parseProviderConcurrency("limited=2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var running, highest int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lim.doAll([]string{"limited", "other"}, func() {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					h := atomic.LoadInt32(&highest)
+					if n <= h || atomic.CompareAndSwapInt32(&highest, h, n) {
+						break
+					}
+				}
+				atomic.AddInt32(&running, -1)
+			})
+		}()
+	}
+	wg.Wait()
+	if highest > 2 {
+		t.Errorf("providerLimiter allowed %d concurrent calls, want at most 2", highest)
+	}
+}
+
+// lazyDriver is a DNS provider that fills a map lazily, as many
+// drivers do, and is therefore not safe for concurrent calls.
+type lazyDriver struct {
+	zones   map[string]bool
+	running int32
+	highest int32
+}
+
+// call records a call about zone. The map is written before the
+// counters (atomics would hide the race from the race detector).
+func (d *lazyDriver) call(zone string) {
+	if d.zones == nil {
+		d.zones = map[string]bool{}
+	}
+	d.zones[zone] = true
+	n := atomic.AddInt32(&d.running, 1)
+	if n > atomic.LoadInt32(&d.highest) {
+		atomic.StoreInt32(&d.highest, n)
+	}
+	time.Sleep(time.Millisecond)
+	atomic.AddInt32(&d.running, -1)
+}
+
+func (d *lazyDriver) GetNameservers(domain string) ([]*models.Nameserver, error) {
+	d.call(domain)
+	return nil, nil
+}
+
+func (d *lazyDriver) GetZoneRecords(domain string) (models.Records, error) {
+	d.call(domain)
+	return nil, nil
+}
+
+func (d *lazyDriver) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correction, error) {
+	d.call(dc.Name)
+	return nil, nil
+}
+
+// Test_gatherSharedProvider runs jobs that share a provider that is not
+// safe for concurrent calls. Run it with -race.
+func Test_gatherSharedProvider(t *testing.T) {
+	d := &lazyDriver{}
+	cfg := &models.DNSConfig{}
+	var jobs []*domainJob
+	for i := 0; i < 8; i++ {
+		domain := &models.DomainConfig{
+			Name:          fmt.Sprintf("example%d.com", i),
+			RegistrarName: "none",
+			DNSProviderInstances: []*models.DNSProviderInstance{
+				{ProviderBase: models.ProviderBase{Name: "lazy", IsDefault: true}, Driver: d},
+			},
+		}
+		cfg.Domains = append(cfg.Domains, domain)
+		jobs = append(jobs, &domainJob{domain: domain, done: make(chan struct{})})
+	}
+	// Asking for concurrent calls is ignored.
+	lim, err := newProviderLimiter(cfg, "lazy=4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := PreviewArgs{Report: "x"} // Also get the zone records.
+	startJobs(jobs, 4, args, false, lim)
+	for _, j := range jobs {
+		j.wait(4, args, false, lim)
+		if j.err != nil {
+			t.Fatal(j.err)
+		}
+	}
+	if d.highest != 1 {
+		t.Errorf("got %d concurrent calls, want 1", d.highest)
+	}
+	if len(d.zones) != len(jobs) {
+		t.Errorf("got %d zones, want %d", len(d.zones), len(jobs))
+	}
+}
+
+func Test_newProviderLimiter(t *testing.T) {
+	cfg := &models.DNSConfig{Domains: []*models.DomainConfig{{
+		Name: "example.com",
+		DNSProviderInstances: []*models.DNSProviderInstance{
+			{ProviderBase: models.ProviderBase{Name: "lazy"}, Driver: &lazyDriver{}},
+			{ProviderBase: models.ProviderBase{Name: "safe"}, Driver: &safeDriver{}},
+			{ProviderBase: models.ProviderBase{Name: "free"}, Driver: &safeDriver{}},
+		},
+	}}}
+	lim, err := newProviderLimiter(cfg, "safe=3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cap(lim["lazy"]) != 1 || cap(lim["safe"]) != 3 {
+		t.Errorf("got lazy=%d safe=%d, want 1 and 3", cap(lim["lazy"]), cap(lim["safe"]))
+	}
+	if _, ok := lim["free"]; ok {
+		t.Error("a safe provider without a limit is limited")
+	}
+}
+
+type safeDriver struct{ lazyDriver }
+
+func (*safeDriver) ConcurrencySafe() bool { return true }
+
+// soaZone is a fakeZone that writes its own SOA record, as BIND does.
+type soaZone struct{ fakeZone }
+
+func makeSOA(serial uint32) *models.RecordConfig {
+	rc := &models.RecordConfig{Type: "SOA", TTL: 300}
+	rc.SetLabel("@", "example.com")
+	rc.SetTargetSOA("ns1.example.com.", "hostmaster.example.com.", serial, 3600, 600, 604800, 300)
+	return rc
+}
+
+func (z *soaZone) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correction, error) {
+	dc.Records = append(dc.Records, makeSOA(2))
+	return z.fakeZone.GetDomainCorrections(dc)
+}
+
+// Test_gatherProviderChanges checks that the changes are those of the
+// records that the provider would write.
+func Test_gatherProviderChanges(t *testing.T) {
+	zone := &soaZone{fakeZone{records: models.Records{makeSOA(1), makeRec("www", "A", "1.2.3.4")}}}
+	domain := &models.DomainConfig{
+		Name:          "example.com",
+		RegistrarName: "none",
+		Records:       models.Records{makeRec("www", "A", "1.2.3.4")},
+		DNSProviderInstances: []*models.DNSProviderInstance{
+			{ProviderBase: models.ProviderBase{Name: "soa", IsDefault: true}, Driver: zone},
+		},
+	}
+	j := &domainJob{domain: domain}
+	j.gather(PreviewArgs{Report: "x"}, false, providerLimiter{})
+	if j.err != nil {
+		t.Fatal(j.err)
+	}
+	pj := j.providers[0]
+	if pj.changesErr != nil {
+		t.Fatal(pj.changesErr)
+	}
+	var got []string
+	for _, c := range pj.changes {
+		if c.Type != diff2.REPORT {
+			got = append(got, c.Type.String()+" "+c.Key.Type)
+		}
+	}
+	if len(got) != 1 || got[0] != "CHANGE SOA" {
+		t.Errorf("got changes %v, want [CHANGE SOA]", got)
+	}
+}
+
+func Test_newDomainJobs(t *testing.T) {
+	cfg := &models.DNSConfig{}
+	for _, d := range []struct{ name, owner string }{{"a.com", "web"}, {"b.com", ""}, {"c.com", "api"}} {
+		cfg.Domains = append(cfg.Domains, &models.DomainConfig{Name: d.name, Metadata: map[string]string{"owner": d.owner}})
+	}
+	names := func(jobs []*domainJob) []string {
+		var s []string
+		for _, j := range jobs {
+			s = append(s, j.domain.Name)
+		}
+		return s
+	}
+	// A preview is grouped by owner, a push runs in the order of the
+	// configuration.
+	if got, want := names(newDomainJobs(cfg, PreviewArgs{}, false)), []string{"c.com", "a.com", "b.com"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("preview: got %v, want %v", got, want)
+	}
+	if got, want := names(newDomainJobs(cfg, PreviewArgs{}, true)), []string{"a.com", "b.com", "c.com"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("push: got %v, want %v", got, want)
+	}
+}
+
+// listedZone is a fakeZone that can be listed and created, and that
+// computes its corrections from the records already fetched.
+type listedZone struct {
+	fakeZone
+	exists  bool
+	fetched int // The calls of GetZoneRecords.
+}
+
+func (z *listedZone) ListZones() ([]string, error) {
+	if !z.exists {
+		return nil, nil
+	}
+	return []string{"example.com"}, nil
+}
+
+func (z *listedZone) EnsureZoneExists(string) error {
+	z.exists = true
+	return nil
+}
+
+func (z *listedZone) GetZoneRecords(domain string) (models.Records, error) {
+	z.fetched++
+	return z.fakeZone.GetZoneRecords(domain)
+}
+
+func (z *listedZone) GetZoneRecordsCorrections(dc *models.DomainConfig, existing models.Records) ([]*models.Correction, error) {
+	return z.fakeZone.GetDomainCorrections(dc)
+}
+
+func listedDomain(zone *listedZone) *models.DomainConfig {
+	return &models.DomainConfig{
+		Name:          "example.com",
+		UniqueName:    "example.com",
+		RegistrarName: "none",
+		Records:       models.Records{makeRec("www", "A", "1.2.3.4")},
+		DNSProviderInstances: []*models.DNSProviderInstance{
+			{ProviderBase: models.ProviderBase{Name: "listed", IsDefault: true}, Driver: zone},
+		},
+	}
+}
+
+// Test_gatherFetchesOnce checks that the records are fetched only once
+// for both the corrections and the changes.
+func Test_gatherFetchesOnce(t *testing.T) {
+	zone := &listedZone{exists: true}
+	j := &domainJob{domain: listedDomain(zone)}
+	j.gather(PreviewArgs{Report: "x"}, false, providerLimiter{})
+	if j.err != nil {
+		t.Fatal(j.err)
+	}
+	pj := j.providers[0]
+	if pj.err != nil || pj.changesErr != nil {
+		t.Fatal(pj.err, pj.changesErr)
+	}
+	if zone.fetched != 1 {
+		t.Errorf("got %d calls of GetZoneRecords, want 1", zone.fetched)
+	}
+	if len(pj.corrections) != 1 || len(pj.changes) != 1 {
+		t.Errorf("got %d corrections and %d changes, want 1 and 1", len(pj.corrections), len(pj.changes))
+	}
+}
+
+// Test_createZones checks that push creates a zone only once it is
+// allowed to.
+func Test_createZones(t *testing.T) {
+	zone := &listedZone{}
+	j := &domainJob{domain: listedDomain(zone)}
+	args := PreviewArgs{Report: "x"}
+	j.gather(args, true, providerLimiter{})
+	if j.err != nil {
+		t.Fatal(j.err)
+	}
+	if zone.exists {
+		t.Fatal("gather created the zone")
+	}
+	if !j.creates() || len(j.providers[0].changes) != 1 {
+		t.Fatalf("got %+v, want a zone to create with 1 change", j.providers[0])
+	}
+
+	var b bytes.Buffer
+	out := printer.ConsolePrinter{Writer: &b}
+	if createZones(out, j, &Plan{}, args, providerLimiter{}) || zone.exists {
+		t.Errorf("created a zone that is not in the plan: %s", b.String())
+	}
+	if !strings.Contains(b.String(), "example.com at listed is not in the plan") {
+		t.Errorf("got %q, want the zone to be refused", b.String())
+	}
+
+	if !createZones(out, j, nil, args, providerLimiter{}) || !zone.exists {
+		t.Fatalf("the zone was not created: %s", b.String())
+	}
+	if j.creates() || len(j.providers[0].corrections) != 1 {
+		t.Errorf("got %+v, want the corrections of the new zone", j.providers[0])
+	}
+	if ns := len(j.domain.Records); ns != 1 {
+		t.Errorf("got %d records, want 1: the domain was not reset", ns)
+	}
+}
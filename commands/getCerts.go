@@ -209,7 +209,9 @@ func GetCerts(args GetCertsArgs) error {
 			}
 		}
 	}
-	notifier.Done()
+	if err := notifier.Done(); err != nil {
+		printer.Warnf("Notifications failed: %s\n", err)
+	}
 	return manyerr
 }
 
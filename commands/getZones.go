@@ -3,12 +3,13 @@ package commands
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/StackExchange/dnscontrol/v3/models"
 	"github.com/StackExchange/dnscontrol/v3/pkg/credsfile"
-	"github.com/StackExchange/dnscontrol/v3/pkg/prettyzone"
 	"github.com/StackExchange/dnscontrol/v3/providers"
 	"github.com/urfave/cli/v2"
 )
@@ -49,11 +50,7 @@ ARGUMENTS:
    zone:     One or more zones (domains) to download; or "all".
 
 FORMATS:
-   --format=js        dnsconfig.js format (not perfect, just a decent first draft)
-   --format=djs       js with disco commas (leading commas)
-   --format=zone      BIND zonefile format
-   --format=tsv       TAB separated value (useful for AWK)
-   --format=nameonly  Just print the zone names
+` + zoneWritersHelp() + `
 
 The columns in --format=tsv are:
    FQDN (the label with the domain)
@@ -63,6 +60,9 @@ The columns in --format=tsv are:
    Target and arguments (quoted like in a zonefile)
    Either empty or a comma-separated list of properties like "cloudflare_proxy=true"
 
+The columns in --format=csv are the zone, the FQDN, then the fields
+of the IR (see --format=json). Empty fields are left empty.
+
 The --ttl flag only applies to zone/js/djs formats.
 
 EXAMPLES:
@@ -70,6 +70,7 @@ EXAMPLES:
    dnscontrol get-zones gmain GANDI_V5 example.com other.com
    dnscontrol get-zones cfmain CLOUDFLAREAPI all
    dnscontrol get-zones --format=tsv bind BIND example.com
+   dnscontrol get-zones --format=json --out=ir.json bind - example.com
    dnscontrol get-zones --format=djs --out=draft.js glcoud GCLOUD example.com`,
 	}
 }())
@@ -137,7 +138,7 @@ func (args *GetZoneArgs) flags() []cli.Flag {
 		Name:        "format",
 		Destination: &args.OutputFormat,
 		Value:       "zone",
-		Usage:       `Output format: js djs zone tsv nameonly`,
+		Usage:       `Output format: ` + zoneWriterNames(),
 	})
 	flags = append(flags, &cli.StringFlag{
 		Name:        "out",
@@ -157,6 +158,11 @@ func GetZone(args GetZoneArgs) error {
 	var providerConfigs map[string]map[string]string
 	var err error
 
+	zw := lookupZoneWriter(args.OutputFormat)
+	if zw == nil {
+		return fmt.Errorf("format %q unknown", args.OutputFormat)
+	}
+
 	// Read it in:
 	providerConfigs, err = credsfile.LoadProviderConfigs(args.CredsFile)
 	if err != nil {
@@ -190,111 +196,64 @@ func GetZone(args GetZoneArgs) error {
 	}
 	defer w.Close()
 
-	if args.OutputFormat == "nameonly" {
-		for _, zone := range zones {
-			fmt.Fprintln(w, zone)
-		}
-		return nil
+	d := &zoneDump{
+		args:         args,
+		providerType: args.ProviderName,
+		zones:        zones,
+	}
+	if d.providerType == "" || d.providerType == "-" {
+		d.providerType = providerConfigs[args.CredName]["TYPE"]
 	}
 
 	// fetch all of the records
-	zoneRecs := make([]models.Records, len(zones))
-	for i, zone := range zones {
-		recs, err := provider.GetZoneRecords(zone)
-		if err != nil {
-			return fmt.Errorf("failed GetZone gzr: %w", err)
+	if !zw.namesOnly {
+		d.records = make([]models.Records, len(zones))
+		for i, zone := range zones {
+			recs, err := provider.GetZoneRecords(zone)
+			if err != nil {
+				return fmt.Errorf("failed GetZone gzr: %w", err)
+			}
+			d.records[i] = recs
 		}
-		zoneRecs[i] = recs
 	}
 
-	// Write the heading:
-
-	dspVariableName := "DSP_" + strings.ToUpper(args.CredName)
-
-	if args.OutputFormat == "js" || args.OutputFormat == "djs" {
+	return zw.write(w, d)
+}
 
-		if args.ProviderName == "-" {
-			fmt.Fprintf(w, `var %s = NewDnsProvider("%s");`+"\n",
-				dspVariableName, args.CredName)
-		} else {
-			fmt.Fprintf(w, `var %s = NewDnsProvider("%s", "%s");`+"\n",
-				dspVariableName, args.CredName, args.ProviderName)
-		}
-		fmt.Fprintf(w, `var REG_CHANGEME = NewRegistrar("none");`+"\n")
+// writeDsl writes the D() of a zone in the js or djs format: items,
+// such as DnsProvider(), then the records.
+func writeDsl(w io.Writer, format, zoneName, registrar string, items []string, recs models.Records, defaultTTL uint32) {
+	sep := ",\n\t" // Commas at EOL
+	if format == "djs" {
+		sep = "\n\t, " // Funky comma mode
 	}
-
-	// print each zone
-	for i, recs := range zoneRecs {
-		zoneName := zones[i]
-
-		z := prettyzone.PrettySort(recs, zoneName, 0, nil)
-		switch args.OutputFormat {
-
-		case "zone":
-			fmt.Fprintf(w, "$ORIGIN %s.\n", zoneName)
-			prettyzone.WriteZoneFileRC(w, z.Records, zoneName, uint32(args.DefaultTTL), nil)
-			fmt.Fprintln(w)
-
-		case "js", "djs":
-			sep := ",\n\t" // Commas at EOL
-			if args.OutputFormat == "djs" {
-				sep = "\n\t, " // Funky comma mode
-			}
-			fmt.Fprintf(w, `D("%s", REG_CHANGEME%s`, zoneName, sep)
-			var o []string
-			o = append(o, fmt.Sprintf("DnsProvider(%s)", dspVariableName))
-			defaultTTL := uint32(args.DefaultTTL)
-			if defaultTTL == 0 {
-				defaultTTL = prettyzone.MostCommonTTL(recs)
-			}
-			if defaultTTL != models.DefaultTTL && defaultTTL != 0 {
-				o = append(o, fmt.Sprintf("DefaultTTL(%d)", defaultTTL))
-			}
-			for _, rec := range recs {
-				if (rec.Type == "CNAME") && (rec.Name == "@") {
-					o = append(o, "// NOTE: CNAME at apex may require manual editing.")
-				}
-				o = append(o, formatDsl(zoneName, rec, defaultTTL))
-			}
-			out := strings.Join(o, sep)
-
-			// Joining with a comma between each item works great but
-			// makes comments look terrible.  Here we clean them up
-			// after the fact.
-			if args.OutputFormat == "djs" {
-				out = strings.ReplaceAll(out, "\n\t, //", "\n\t//, ") // Fix comments
-				out = strings.ReplaceAll(out,
-					"//,  NOTE: CNAME at apex may require manual editing.",
-					"// NOTE: CNAME at apex may require manual editing.",
-				)
-			} else {
-				out = strings.ReplaceAll(out,
-					"// NOTE: CNAME at apex may require manual editing.,",
-					"// NOTE: CNAME at apex may require manual editing.",
-				)
-			}
-			fmt.Fprint(w, out)
-			fmt.Fprint(w, "\n)\n")
-
-		case "tsv":
-			for _, rec := range recs {
-
-				cfproxy := ""
-				if cp, ok := rec.Metadata["cloudflare_proxy"]; ok {
-					if cp == "true" {
-						cfproxy = "\tcloudflare_proxy=true"
-					}
-				}
-
-				fmt.Fprintf(w, "%s\t%s\t%d\tIN\t%s\t%s%s\n",
-					rec.NameFQDN, rec.Name, rec.TTL, rec.Type, rec.GetTargetCombined(), cfproxy)
-			}
-
-		default:
-			return fmt.Errorf("format %q unknown", args.OutputFormat)
+	fmt.Fprintf(w, `D("%s", %s%s`, zoneName, registrar, sep)
+	o := append([]string{}, items...)
+	for _, rec := range recs {
+		if (rec.Type == "CNAME") && (rec.Name == "@") {
+			o = append(o, "// NOTE: CNAME at apex may require manual editing.")
 		}
+		o = append(o, formatDsl(zoneName, rec, defaultTTL))
+	}
+	out := strings.Join(o, sep)
+
+	// Joining with a comma between each item works great but
+	// makes comments look terrible.  Here we clean them up
+	// after the fact.
+	if format == "djs" {
+		out = strings.ReplaceAll(out, "\n\t, //", "\n\t//, ") // Fix comments
+		out = strings.ReplaceAll(out,
+			"//,  NOTE: CNAME at apex may require manual editing.",
+			"// NOTE: CNAME at apex may require manual editing.",
+		)
+	} else {
+		out = strings.ReplaceAll(out,
+			"// NOTE: CNAME at apex may require manual editing.,",
+			"// NOTE: CNAME at apex may require manual editing.",
+		)
 	}
-	return nil
+	fmt.Fprint(w, out)
+	fmt.Fprint(w, "\n)\n")
 }
 
 // jsonQuoted returns a properly escaped JSON string (without quotes).
@@ -328,6 +287,8 @@ func formatDsl(zonename string, rec *models.RecordConfig, defaultTTL uint32) str
 	switch rec.Type { // #rtype_variations
 	case "CAA":
 		return makeCaa(rec, ttlop)
+	case "HTTPS", "SVCB":
+		target = fmt.Sprintf("%d, '%s', '%s'", rec.SvcPriority, rec.GetTargetField(), rec.SvcParams)
 	case "MX":
 		target = fmt.Sprintf("%d, '%s'", rec.MxPreference, rec.GetTargetField())
 	case "NAPTR":
@@ -366,12 +327,37 @@ func formatDsl(zonename string, rec *models.RecordConfig, defaultTTL uint32) str
 	case "R53_ALIAS":
 		return makeR53alias(rec, ttl)
 	default:
+		if rt, ok := models.GetRdataType(rec.Type); ok {
+			target = makeRdataArgs(rt, rec.GetTargetField())
+			break
+		}
 		target = "'" + target + "'"
 	}
 
 	return fmt.Sprintf("%s('%s', %s%s%s)", rec.Type, rec.Name, target, cfproxy, ttlop)
 }
 
+// makeRdataArgs returns the arguments of the builder of a record type
+// that is stored as zonefile rdata: one per field of rt.
+func makeRdataArgs(rt models.RdataType, rdata string) string {
+	fields, err := models.ParseQuotedFields(rdata)
+	if err != nil || len(fields) < len(rt.Fields) {
+		return jsonQuoted(rdata)
+	}
+	// The last field receives the remainder (for example, all of LOC).
+	n := len(rt.Fields) - 1
+	fields = append(fields[:n], strings.Join(fields[n:], " "))
+	args := make([]string, len(fields))
+	for i, f := range fields {
+		if _, err := strconv.ParseUint(f, 10, 32); err == nil {
+			args[i] = f
+		} else {
+			args[i] = jsonQuoted(f)
+		}
+	}
+	return strings.Join(args, ", ")
+}
+
 func makeCaa(rec *models.RecordConfig, ttlop string) string {
 	var target string
 	if rec.CaaFlag == 128 {
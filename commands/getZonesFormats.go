@@ -0,0 +1,308 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/prettyzone"
+	"gopkg.in/yaml.v3"
+)
+
+// zoneWriter is an output format of get-zones.
+type zoneWriter struct {
+	name  string
+	usage string // One line, for the help of get-zones.
+
+	// namesOnly is true if the format only needs the names of the zones.
+	// Their records are not downloaded.
+	namesOnly bool
+
+	write func(w io.Writer, d *zoneDump) error
+}
+
+// zoneDump is what get-zones downloaded.
+type zoneDump struct {
+	args         GetZoneArgs
+	providerType string // The type of the provider, such as ROUTE53.
+	zones        []string
+	records      []models.Records // The records of each of zones.
+}
+
+// dspVariableName is the name of the variable of the provider in
+// dnsconfig.js.
+func (d *zoneDump) dspVariableName() string {
+	return "DSP_" + strings.ToUpper(d.args.CredName)
+}
+
+// defaultTTL is the TTL to leave out of the output, for the formats
+// that have one.
+func (d *zoneDump) defaultTTL(recs models.Records) uint32 {
+	if d.args.DefaultTTL != 0 {
+		return uint32(d.args.DefaultTTL)
+	}
+	return prettyzone.MostCommonTTL(recs)
+}
+
+// zoneWriters are the output formats of get-zones, in the order of
+// the help.
+var zoneWriters = []*zoneWriter{
+	{name: "js", usage: "dnsconfig.js format (not perfect, just a decent first draft)", write: writeZonesDsl},
+	{name: "djs", usage: "js with disco commas (leading commas)", write: writeZonesDsl},
+	{name: "zone", usage: "BIND zonefile format", write: writeZonesBind},
+	{name: "tsv", usage: "TAB separated value (useful for AWK)", write: writeZonesTSV},
+	{name: "json", usage: "dnscontrol IR (JSON), which can be read with --ir", write: writeZonesIR},
+	{name: "yaml", usage: "YAML list of zones and their records", write: writeZonesYAML},
+	{name: "csv", usage: "Comma separated value, one column per field", write: writeZonesCSV},
+	{name: "terraform-route53", usage: "Terraform HCL for the AWS provider", write: writeZonesTerraformRoute53},
+	{name: "terraform-cloudflare", usage: "Terraform HCL for the Cloudflare provider", write: writeZonesTerraformCloudflare},
+	{name: "terraform-gcloud", usage: "Terraform HCL for the Google Cloud provider", write: writeZonesTerraformGcloud},
+	{name: "nameonly", usage: "Just print the zone names", namesOnly: true, write: writeZoneNames},
+}
+
+// lookupZoneWriter returns the output format called name, or nil.
+func lookupZoneWriter(name string) *zoneWriter {
+	for _, zw := range zoneWriters {
+		if zw.name == name {
+			return zw
+		}
+	}
+	return nil
+}
+
+// zoneWritersHelp lists the output formats for the help of get-zones.
+func zoneWritersHelp() string {
+	width := 0
+	for _, zw := range zoneWriters {
+		if len(zw.name) > width {
+			width = len(zw.name)
+		}
+	}
+	var lines []string
+	for _, zw := range zoneWriters {
+		lines = append(lines, fmt.Sprintf("   --format=%-*s  %s", width, zw.name, zw.usage))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func zoneWriterNames() string {
+	var names []string
+	for _, zw := range zoneWriters {
+		names = append(names, zw.name)
+	}
+	return strings.Join(names, " ")
+}
+
+func writeZoneNames(w io.Writer, d *zoneDump) error {
+	for _, zone := range d.zones {
+		fmt.Fprintln(w, zone)
+	}
+	return nil
+}
+
+func writeZonesDsl(w io.Writer, d *zoneDump) error {
+	dspVariableName := d.dspVariableName()
+	if d.args.ProviderName == "-" {
+		fmt.Fprintf(w, `var %s = NewDnsProvider("%s");`+"\n",
+			dspVariableName, d.args.CredName)
+	} else {
+		fmt.Fprintf(w, `var %s = NewDnsProvider("%s", "%s");`+"\n",
+			dspVariableName, d.args.CredName, d.args.ProviderName)
+	}
+	fmt.Fprintf(w, `var REG_CHANGEME = NewRegistrar("none");`+"\n")
+
+	for i, recs := range d.records {
+		defaultTTL := d.defaultTTL(recs)
+		items := []string{fmt.Sprintf("DnsProvider(%s)", dspVariableName)}
+		if defaultTTL != models.DefaultTTL && defaultTTL != 0 {
+			items = append(items, fmt.Sprintf("DefaultTTL(%d)", defaultTTL))
+		}
+		writeDsl(w, d.args.OutputFormat, d.zones[i], "REG_CHANGEME", items, recs, defaultTTL)
+	}
+	return nil
+}
+
+func writeZonesBind(w io.Writer, d *zoneDump) error {
+	for i, recs := range d.records {
+		zoneName := d.zones[i]
+		z := prettyzone.PrettySort(recs, zoneName, 0, nil)
+		fmt.Fprintf(w, "$ORIGIN %s.\n", zoneName)
+		prettyzone.WriteZoneFileRC(w, z.Records, zoneName, uint32(d.args.DefaultTTL), nil)
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+func writeZonesTSV(w io.Writer, d *zoneDump) error {
+	for _, recs := range d.records {
+		for _, rec := range recs {
+
+			cfproxy := ""
+			if cp, ok := rec.Metadata["cloudflare_proxy"]; ok {
+				if cp == "true" {
+					cfproxy = "\tcloudflare_proxy=true"
+				}
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%d\tIN\t%s\t%s%s\n",
+				rec.NameFQDN, rec.Name, rec.TTL, rec.Type, rec.GetTargetCombined(), cfproxy)
+		}
+	}
+	return nil
+}
+
+// writeZonesIR writes the zones as a configuration that uses the
+// provider they were downloaded from and no registrar. As with the js
+// format, the SOA and the NS records of the apex are left out: the
+// provider manages them.
+func writeZonesIR(w io.Writer, d *zoneDump) error {
+	cfg := &models.DNSConfig{
+		Registrars:   []*models.RegistrarConfig{{Name: "none", Type: "NONE"}},
+		DNSProviders: []*models.DNSProviderConfig{{Name: d.args.CredName, Type: d.providerType}},
+		Domains:      []*models.DomainConfig{},
+	}
+	for i, all := range d.records {
+		recs := models.Records{}
+		for _, rec := range all {
+			if rec.Type == "SOA" || (rec.Type == "NS" && rec.GetLabel() == "@") {
+				continue
+			}
+			recs = append(recs, rec)
+		}
+		cfg.Domains = append(cfg.Domains, &models.DomainConfig{
+			Name:             d.zones[i],
+			RegistrarName:    "none",
+			DNSProviderNames: map[string]int{d.args.CredName: -1},
+			Records:          recs,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cfg)
+}
+
+// recordColumns are the fields of a record in the YAML and CSV
+// formats: the FQDN, then the fields of the IR.
+var recordColumns = func() []string {
+	cols := []string{"fqdn", "name", "type", "ttl", "target"}
+	t := reflect.TypeOf(models.RecordConfig{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" || name == "name" || name == "type" || name == "ttl" {
+			continue
+		}
+		cols = append(cols, name)
+	}
+	return cols
+}()
+
+// recordFields returns the fields of rec, as they are in the IR, and
+// its FQDN. Empty fields are left out.
+func recordFields(rec *models.RecordConfig) (map[string]json.RawMessage, error) {
+	j, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(j, &fields); err != nil {
+		return nil, err
+	}
+	fields["fqdn"], _ = json.Marshal(rec.NameFQDN)
+	return fields, nil
+}
+
+func writeZonesYAML(w io.Writer, d *zoneDump) error {
+	var zones []*yaml.Node
+	for i, recs := range d.records {
+		var records []*yaml.Node
+		for _, rec := range recs {
+			fields, err := recordFields(rec)
+			if err != nil {
+				return err
+			}
+			var kv []*yaml.Node
+			for _, col := range recordColumns {
+				raw, ok := fields[col]
+				if !ok {
+					continue
+				}
+				var v interface{}
+				dec := json.NewDecoder(bytes.NewReader(raw))
+				dec.UseNumber()
+				if err := dec.Decode(&v); err != nil {
+					return err
+				}
+				if n, ok := v.(json.Number); ok {
+					v, _ = n.Int64()
+				}
+				value := &yaml.Node{}
+				if err := value.Encode(v); err != nil {
+					return err
+				}
+				kv = append(kv, yamlString(col), value)
+			}
+			records = append(records, &yaml.Node{Kind: yaml.MappingNode, Content: kv})
+		}
+		zones = append(zones, &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+			yamlString("name"), yamlString(d.zones[i]),
+			yamlString("records"), {Kind: yaml.SequenceNode, Content: records},
+		}})
+	}
+	doc := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		yamlString("zones"), {Kind: yaml.SequenceNode, Content: zones},
+	}}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func yamlString(s string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}
+}
+
+// writeZonesCSV writes a line per record, with a column for the zone
+// and each of recordColumns. Strings and numbers are written as is;
+// lists and maps, as JSON.
+func writeZonesCSV(w io.Writer, d *zoneDump) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{"zone"}, recordColumns...)); err != nil {
+		return err
+	}
+	for i, recs := range d.records {
+		for _, rec := range recs {
+			fields, err := recordFields(rec)
+			if err != nil {
+				return err
+			}
+			line := []string{d.zones[i]}
+			for _, col := range recordColumns {
+				raw := fields[col]
+				var s string
+				switch {
+				case len(raw) == 0:
+				case raw[0] == '"':
+					if err := json.Unmarshal(raw, &s); err != nil {
+						return err
+					}
+				default:
+					s = string(raw)
+				}
+				line = append(line, s)
+			}
+			if err := cw.Write(line); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,300 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/miekg/dns"
+)
+
+// hclBlock is a block of Terraform HCL, such as a resource.
+type hclBlock struct {
+	header string    // Such as `resource "aws_route53_zone" "example_com"`.
+	attrs  []hclAttr // In order.
+	blocks []*hclBlock
+}
+
+// hclAttr is an attribute of a block. value is an HCL expression.
+type hclAttr struct{ key, value string }
+
+func (b *hclBlock) attr(key, value string) {
+	b.attrs = append(b.attrs, hclAttr{key, value})
+}
+
+// write writes b, with its "=" aligned like "terraform fmt" does.
+func (b *hclBlock) write(w io.Writer, indent string) {
+	fmt.Fprintf(w, "%s%s {\n", indent, b.header)
+	width := 0
+	for _, a := range b.attrs {
+		if len(a.key) > width {
+			width = len(a.key)
+		}
+	}
+	for _, a := range b.attrs {
+		fmt.Fprintf(w, "%s  %-*s = %s\n", indent, width, a.key, a.value)
+	}
+	for _, nested := range b.blocks {
+		fmt.Fprintln(w)
+		nested.write(w, indent+"  ")
+	}
+	fmt.Fprintf(w, "%s}\n", indent)
+}
+
+var hclEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "${", "$${", "%{", "%%{")
+
+// hclString returns s as an HCL string literal.
+func hclString(s string) string {
+	return `"` + hclEscaper.Replace(s) + `"`
+}
+
+func hclList(values []string) string {
+	var q []string
+	for _, v := range values {
+		q = append(q, hclString(v))
+	}
+	return "[" + strings.Join(q, ", ") + "]"
+}
+
+func hclNumber(n interface{}) string {
+	return fmt.Sprint(n)
+}
+
+var hclNameRE = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// hclNames makes unique names for resources.
+type hclNames map[string]bool
+
+// name returns a name for a resource made of parts, which is not one
+// it returned before.
+func (names hclNames) name(parts ...string) string {
+	for i, p := range parts {
+		p = strings.ToLower(p)
+		p = strings.ReplaceAll(p, "*", "wildcard")
+		p = strings.ReplaceAll(p, "@", "apex")
+		parts[i] = strings.Trim(hclNameRE.ReplaceAllString(p, "_"), "_")
+	}
+	base := strings.Join(parts, "_")
+	if base == "" || (base[0] >= '0' && base[0] <= '9') || base[0] == '-' {
+		base = "_" + base
+	}
+	name := base
+	for i := 2; names[name]; i++ {
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	names[name] = true
+	return name
+}
+
+// hclComment writes a comment about a record that is not converted.
+func hclComment(w io.Writer, rec *models.RecordConfig, why string) {
+	fmt.Fprintf(w, "# %s %s %s: %s\n\n", rec.NameFQDN, rec.Type, rec.GetTargetCombined(), why)
+}
+
+// rrsets groups recs by name and type, in order of appearance.
+func rrsets(recs models.Records) []models.Records {
+	var order []models.RecordKey
+	groups := map[models.RecordKey]models.Records{}
+	for _, rec := range recs {
+		k := rec.Key()
+		if groups[k] == nil {
+			order = append(order, k)
+		}
+		groups[k] = append(groups[k], rec)
+	}
+	var sets []models.Records
+	for _, k := range order {
+		sets = append(sets, groups[k])
+	}
+	return sets
+}
+
+// isRFCType returns whether rtype is a type of the DNS, not a pseudo
+// type such as ALIAS.
+func isRFCType(rtype string) bool {
+	_, ok := dns.StringToType[rtype]
+	return ok
+}
+
+// skipTerraform returns why rec is not converted to Terraform, or "".
+func skipTerraform(rec *models.RecordConfig) string {
+	switch {
+	case rec.Type == "SOA":
+		return "managed by the provider"
+	case rec.Type == "NS" && rec.GetLabel() == "@":
+		return "managed by the provider; the zone resource exports the nameservers"
+	}
+	return ""
+}
+
+func writeZonesTerraformRoute53(w io.Writer, d *zoneDump) error {
+	names := hclNames{}
+	for i, recs := range d.records {
+		name := names.name(d.zones[i])
+		zone := &hclBlock{header: fmt.Sprintf(`resource "aws_route53_zone" %q`, name)}
+		zone.attr("name", hclString(d.zones[i]))
+		zone.write(w, "")
+		fmt.Fprintln(w)
+		zoneID := "aws_route53_zone." + name + ".zone_id"
+
+		for _, set := range rrsets(recs) {
+			rec := set[0]
+			if why := skipTerraform(rec); why != "" {
+				hclComment(w, rec, why)
+				continue
+			}
+			rtype := rec.Type
+			if rtype == "R53_ALIAS" {
+				rtype = rec.R53Alias["type"]
+			}
+			b := &hclBlock{header: fmt.Sprintf(`resource "aws_route53_record" %q`, names.name(d.zones[i], rec.GetLabel(), rtype))}
+			b.attr("zone_id", zoneID)
+			b.attr("name", hclString(rec.NameFQDN))
+			b.attr("type", hclString(rtype))
+
+			switch {
+			case rec.Type == "R53_ALIAS":
+				alias := &hclBlock{header: "alias"}
+				alias.attr("name", hclString(rec.GetTargetField()))
+				if id := rec.R53Alias["zone_id"]; id != "" {
+					alias.attr("zone_id", hclString(id))
+				} else {
+					alias.attr("zone_id", zoneID)
+				}
+				alias.attr("evaluate_target_health", "false")
+				b.blocks = append(b.blocks, alias)
+			case isRFCType(rec.Type):
+				var values []string
+				for _, r := range set {
+					if r.Type == "TXT" {
+						// Strings longer than 255 octets are split with "".
+						values = append(values, strings.Join(r.TxtStrings, `""`))
+					} else {
+						values = append(values, r.GetTargetCombined())
+					}
+				}
+				b.attr("ttl", hclNumber(rec.TTL))
+				b.attr("records", hclList(values))
+			default:
+				hclComment(w, rec, "not supported by Route 53")
+				continue
+			}
+			b.write(w, "")
+			fmt.Fprintln(w)
+		}
+	}
+	return nil
+}
+
+// cloudflareValueTypes are the types a cloudflare_record has a value for.
+// The others have a data block, or are not converted.
+var cloudflareValueTypes = map[string]bool{"A": true, "AAAA": true, "CNAME": true, "MX": true, "NS": true, "PTR": true, "TXT": true}
+
+func writeZonesTerraformCloudflare(w io.Writer, d *zoneDump) error {
+	account := &hclBlock{header: `variable "cloudflare_account_id"`}
+	account.attr("type", "string")
+	account.write(w, "")
+	fmt.Fprintln(w)
+
+	names := hclNames{}
+	for i, recs := range d.records {
+		name := names.name(d.zones[i])
+		zone := &hclBlock{header: fmt.Sprintf(`resource "cloudflare_zone" %q`, name)}
+		zone.attr("account_id", "var.cloudflare_account_id")
+		zone.attr("zone", hclString(d.zones[i]))
+		zone.write(w, "")
+		fmt.Fprintln(w)
+		zoneID := "cloudflare_zone." + name + ".id"
+
+		for _, rec := range recs {
+			if why := skipTerraform(rec); why != "" {
+				hclComment(w, rec, why)
+				continue
+			}
+			b := &hclBlock{header: fmt.Sprintf(`resource "cloudflare_record" %q`, names.name(d.zones[i], rec.GetLabel(), rec.Type))}
+			b.attr("zone_id", zoneID)
+			b.attr("name", hclString(rec.GetLabel()))
+			b.attr("type", hclString(rec.Type))
+
+			switch {
+			case cloudflareValueTypes[rec.Type]:
+				value := strings.TrimSuffix(rec.GetTargetField(), ".")
+				if rec.Type == "TXT" {
+					value = rec.GetTargetTXTJoined()
+				}
+				b.attr("value", hclString(value))
+				if rec.Type == "MX" {
+					b.attr("priority", hclNumber(rec.MxPreference))
+				}
+			case rec.Type == "SRV":
+				service, proto, _ := strings.Cut(rec.GetLabel(), ".")
+				proto, _, _ = strings.Cut(proto, ".")
+				host := strings.TrimPrefix(rec.NameFQDN, service+"."+proto+".")
+				data := &hclBlock{header: "data"}
+				data.attr("service", hclString(service))
+				data.attr("proto", hclString(proto))
+				data.attr("name", hclString(host))
+				data.attr("priority", hclNumber(rec.SrvPriority))
+				data.attr("weight", hclNumber(rec.SrvWeight))
+				data.attr("port", hclNumber(rec.SrvPort))
+				data.attr("target", hclString(strings.TrimSuffix(rec.GetTargetField(), ".")))
+				b.blocks = append(b.blocks, data)
+			case rec.Type == "CAA":
+				data := &hclBlock{header: "data"}
+				data.attr("flags", hclNumber(rec.CaaFlag))
+				data.attr("tag", hclString(rec.CaaTag))
+				data.attr("value", hclString(rec.GetTargetField()))
+				b.blocks = append(b.blocks, data)
+			default:
+				hclComment(w, rec, "not supported by this format")
+				continue
+			}
+			b.attr("ttl", hclNumber(rec.TTL))
+			if rec.Metadata["cloudflare_proxy"] == "true" {
+				b.attr("proxied", "true")
+			}
+			b.write(w, "")
+			fmt.Fprintln(w)
+		}
+	}
+	return nil
+}
+
+func writeZonesTerraformGcloud(w io.Writer, d *zoneDump) error {
+	names := hclNames{}
+	for i, recs := range d.records {
+		name := names.name(d.zones[i])
+		zone := &hclBlock{header: fmt.Sprintf(`resource "google_dns_managed_zone" %q`, name)}
+		zone.attr("name", hclString(strings.ReplaceAll(name, "_", "-")))
+		zone.attr("dns_name", hclString(d.zones[i]+"."))
+		zone.write(w, "")
+		fmt.Fprintln(w)
+
+		for _, set := range rrsets(recs) {
+			rec := set[0]
+			if why := skipTerraform(rec); why != "" {
+				hclComment(w, rec, why)
+				continue
+			}
+			if !isRFCType(rec.Type) {
+				hclComment(w, rec, "not supported by Google Cloud DNS")
+				continue
+			}
+			var values []string
+			for _, r := range set {
+				values = append(values, r.GetTargetCombined())
+			}
+			b := &hclBlock{header: fmt.Sprintf(`resource "google_dns_record_set" %q`, names.name(d.zones[i], rec.GetLabel(), rec.Type))}
+			b.attr("managed_zone", "google_dns_managed_zone."+name+".name")
+			b.attr("name", hclString(rec.NameFQDN+"."))
+			b.attr("type", hclString(rec.Type))
+			b.attr("ttl", hclNumber(rec.TTL))
+			b.attr("rrdatas", hclList(values))
+			b.write(w, "")
+			fmt.Fprintln(w)
+		}
+	}
+	return nil
+}
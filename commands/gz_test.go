@@ -16,6 +16,7 @@ func TestFormatTypes(t *testing.T) {
 	  test_data/$DOMAIN.zone   js              test_data/$DOMAIN.zone.js
 	  test_data/$DOMAIN.zone   tsv             test_data/$DOMAIN.zone.tsv
 	  test_data/$DOMAIN.zone   zone            test_data/$DOMAIN.zone.zone
+	  test_data/$DOMAIN.zone   json, yaml...   test_data/$DOMAIN.zone.json, ...
 	*/
 
 	for _, domain := range []string{"simple.com", "example.org", "apex.com"} {
@@ -23,6 +24,10 @@ func TestFormatTypes(t *testing.T) {
 		t.Run(domain+"/djs", func(t *testing.T) { testFormat(t, domain, "djs") })
 		t.Run(domain+"/tsv", func(t *testing.T) { testFormat(t, domain, "tsv") })
 		t.Run(domain+"/zone", func(t *testing.T) { testFormat(t, domain, "zone") })
+		for _, format := range []string{"json", "yaml", "csv", "terraform-route53", "terraform-cloudflare", "terraform-gcloud"} {
+			format := format
+			t.Run(domain+"/"+format, func(t *testing.T) { testFormat(t, domain, format) })
+		}
 	}
 }
 
@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/StackExchange/dnscontrol/v3/pkg/audit"
+	"github.com/urfave/cli/v2"
+)
+
+var _ = cmd(catUtils, func() *cli.Command {
+	var args HistoryArgs
+	return &cli.Command{
+		Name:  "history",
+		Usage: "show the corrections recorded in the audit log (push --audit-log)",
+		Action: func(ctx *cli.Context) error {
+			return exit(History(args))
+		},
+		Flags: args.flags(),
+	}
+}())
+
+// HistoryArgs contains all data/flags needed to run history, independently of CLI
+type HistoryArgs struct {
+	AuditArgs
+	Domain string
+	Since  string
+	Until  string
+	Format string
+}
+
+func (args *HistoryArgs) flags() []cli.Flag {
+	flags := args.AuditArgs.flags()
+	flags = append(flags, &cli.StringFlag{
+		Name:        "domain",
+		Destination: &args.Domain,
+		Usage:       `Only the corrections of this domain`,
+	})
+	flags = append(flags, &cli.StringFlag{
+		Name:        "since",
+		Destination: &args.Since,
+		Usage:       `Only the corrections at or after this time: RFC 3339 ("2023-04-01T12:00:00Z"), a date ("2023-04-01") or an age ("36h", "7d")`,
+	})
+	flags = append(flags, &cli.StringFlag{
+		Name:        "until",
+		Destination: &args.Until,
+		Usage:       `Only the corrections before this time, in the format of --since`,
+	})
+	flags = append(flags, &cli.StringFlag{
+		Name:        "format",
+		Destination: &args.Format,
+		Value:       "text",
+		Usage:       `Output format: text or json (JSON Lines, as in the audit log)`,
+	})
+	return flags
+}
+
+// History implements the history subcommand.
+func History(args HistoryArgs) error {
+	if args.AuditLog == "" {
+		return fmt.Errorf("the audit log must be given with --audit-log")
+	}
+	if args.Format != "text" && args.Format != "json" {
+		return fmt.Errorf("unknown format %q (expected text or json)", args.Format)
+	}
+	q := audit.Query{Domain: args.Domain}
+	now := time.Now()
+	var err error
+	if q.Since, err = parseHistoryTime(args.Since, now); err != nil {
+		return fmt.Errorf("--since: %w", err)
+	}
+	if q.Until, err = parseHistoryTime(args.Until, now); err != nil {
+		return fmt.Errorf("--until: %w", err)
+	}
+	entries, err := audit.Read(args.AuditLog, q)
+	if err != nil {
+		return err
+	}
+	if args.Format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range entries {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	printHistory(os.Stdout, entries)
+	return nil
+}
+
+// parseHistoryTime parses the time s, which may also be a date (at
+// midnight, local time) or an age: a duration, or a number of days
+// ("7d"), before now. The zero time is returned for "".
+func parseHistoryTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	if days := strings.TrimSuffix(s, "d"); days != s {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q", s)
+}
+
+// printHistory prints entries, each as a line that says who ran what
+// and when, followed by the correction, indented.
+func printHistory(w io.Writer, entries []*audit.Entry) {
+	for _, e := range entries {
+		result := "OK"
+		if !e.Success {
+			result = "FAILED: " + e.Error
+		}
+		if e.Rollback {
+			result = "ROLLBACK " + result
+		}
+		fmt.Fprintf(w, "%s  %s  %s  %s  %s  %s\n",
+			e.Time.Local().Format("2006-01-02 15:04:05 MST"), e.Domain, e.Provider, e.User, shortCommit(e.Commit), result)
+		for _, l := range strings.Split(strings.TrimRight(e.Correction, "\n"), "\n") {
+			fmt.Fprintf(w, "    %s\n", l)
+		}
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No corrections found.")
+	}
+}
+
+// shortCommit abbreviates a commit as git does, keeping "-dirty".
+func shortCommit(commit string) string {
+	if commit == "" {
+		return "-"
+	}
+	c := strings.TrimSuffix(commit, "-dirty")
+	dirty := c != commit
+	if len(c) > 12 {
+		c = c[:12]
+	}
+	if dirty {
+		c += "-dirty"
+	}
+	return c
+}
@@ -0,0 +1,156 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/pkg/lint"
+	"github.com/StackExchange/dnscontrol/v3/pkg/normalize"
+	"github.com/urfave/cli/v2"
+)
+
+var _ = cmd(catMain, func() *cli.Command {
+	var args LintArgs
+	return &cli.Command{
+		Name:  "lint",
+		Usage: "Check dnsconfig.js for records that are valid but probably a mistake. Do not access providers.",
+		Action: func(ctx *cli.Context) error {
+			return Lint(args)
+		},
+		Flags: args.flags(),
+	}
+}())
+
+// lintExitFound is the exit code of lint when there are findings. 1
+// is an error, such as an invalid configuration.
+const lintExitFound = 2
+
+// LintArgs contains all data/flags needed to run lint, independently of CLI
+type LintArgs struct {
+	GetDNSConfigArgs
+	Format    string
+	Enable    string
+	Disable   string
+	Strict    bool
+	ListRules bool
+}
+
+func (args *LintArgs) flags() []cli.Flag {
+	flags := args.GetDNSConfigArgs.flags()
+	flags = append(flags, &cli.StringFlag{
+		Name:        "format",
+		Destination: &args.Format,
+		Value:       "text",
+		Usage:       `Output format: text, json or sarif`,
+	})
+	flags = append(flags, &cli.StringFlag{
+		Name:        "enable",
+		Destination: &args.Enable,
+		Usage:       `Comma separated list of rules to run, in addition to those run by default`,
+	})
+	flags = append(flags, &cli.StringFlag{
+		Name:        "disable",
+		Destination: &args.Disable,
+		Usage:       `Comma separated list of rules not to run`,
+	})
+	flags = append(flags, &cli.BoolFlag{
+		Name:        "strict",
+		Destination: &args.Strict,
+		Usage:       `Exit with an error for notes, not only for warnings`,
+	})
+	flags = append(flags, &cli.BoolFlag{
+		Name:        "list-rules",
+		Destination: &args.ListRules,
+		Usage:       `List the rules and exit`,
+	})
+	return flags
+}
+
+func (args *LintArgs) options() lint.Options {
+	return lint.Options{Enable: splitList(args.Enable), Disable: splitList(args.Disable)}
+}
+
+// splitList splits a comma separated list, ignoring empty items.
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// Lint implements the lint subcommand.
+func Lint(args LintArgs) error {
+	switch args.Format {
+	case "", "text", "json", "sarif":
+	default:
+		return exit(fmt.Errorf("unknown format %q (expected text, json or sarif)", args.Format))
+	}
+	opts := args.options()
+	rules, err := opts.Selected()
+	if err != nil {
+		return exit(err)
+	}
+	if args.ListRules {
+		return exit(listLintRules(os.Stdout, rules))
+	}
+
+	cfg, err := GetDNSConfig(args.GetDNSConfigArgs)
+	if err != nil {
+		return exit(err)
+	}
+	errs := normalize.ValidateAndNormalizeConfig(cfg)
+	if PrintValidationErrors(errs) {
+		return exit(fmt.Errorf("exiting due to validation errors"))
+	}
+
+	// Like SPF flattening, use the cache of SPF lookups if there is one.
+	opts.SPF, err = normalize.SPFCache.Open()
+	if err != nil {
+		return exit(err)
+	}
+	findings, err := lint.Run(cfg, opts)
+	if err != nil {
+		return exit(err)
+	}
+
+	switch args.Format {
+	case "json":
+		err = lint.WriteJSON(os.Stdout, findings)
+	case "sarif":
+		err = lint.WriteSARIF(os.Stdout, rules, findings, strings.TrimPrefix(version, "dnscontrol "))
+	default:
+		err = lint.WriteText(os.Stdout, findings)
+	}
+	if err != nil {
+		return exit(err)
+	}
+
+	for _, f := range findings {
+		if f.Level == lint.Warning || args.Strict {
+			return cli.Exit(fmt.Sprintf("%d lint findings", len(findings)), lintExitFound)
+		}
+	}
+	return nil
+}
+
+func listLintRules(w io.Writer, selected []*lint.Rule) error {
+	on := map[string]bool{}
+	for _, r := range selected {
+		on[r.Name] = true
+	}
+	for _, r := range lint.Rules() {
+		state := "off"
+		if on[r.Name] {
+			state = "on"
+		}
+		if _, err := fmt.Fprintf(w, "%-16s %-3s %-7s %s\n", r.Name, state, r.Level, r.Description); err != nil {
+			return err
+		}
+	}
+	return nil
+}
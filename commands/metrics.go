@@ -0,0 +1,204 @@
+package commands
+
+import (
+	"os"
+	"time"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/metrics"
+	"github.com/StackExchange/dnscontrol/v3/pkg/printer"
+	"github.com/urfave/cli/v2"
+)
+
+// MetricsArgs contains the flags that export the metrics of a
+// preview/push run.
+type MetricsArgs struct {
+	MetricsFile string
+	MetricsPush string
+	MetricsJob  string
+}
+
+func (args *MetricsArgs) flags() []cli.Flag {
+	var flags []cli.Flag
+	flags = append(flags, &cli.StringFlag{
+		Name:        "metrics-file",
+		Destination: &args.MetricsFile,
+		Usage:       `Write metrics of the run to this file, in the OpenMetrics text format`,
+	})
+	flags = append(flags, &cli.StringFlag{
+		Name:        "metrics-push",
+		Destination: &args.MetricsPush,
+		Usage:       `Push metrics of the run to this Prometheus Pushgateway (e.g. "http://localhost:9091")`,
+	})
+	flags = append(flags, &cli.StringFlag{
+		Name:        "metrics-job",
+		Destination: &args.MetricsJob,
+		Value:       "dnscontrol",
+		Usage:       `The job name used with --metrics-push`,
+	})
+	return flags
+}
+
+func (args *MetricsArgs) enabled() bool {
+	return args.MetricsFile != "" || args.MetricsPush != ""
+}
+
+// runMetrics collects the metrics of a preview/push run. All methods
+// do nothing if it is nil, which is the case when no metrics are
+// exported.
+type runMetrics struct {
+	reg     *metrics.Registry
+	start   time.Time
+	command string
+
+	corrections      *metrics.Family
+	changes          *metrics.Family
+	records          *metrics.Family
+	apiCalls         *metrics.Family
+	apiErrors        *metrics.Family
+	correctionErrors *metrics.Family
+	duration         *metrics.Family
+	timestamp        *metrics.Family
+	success          *metrics.Family
+	total            *metrics.Family
+	totalCorrections int
+}
+
+// newRunMetrics returns the metrics of a run, or nil if they are not
+// exported.
+func newRunMetrics(args MetricsArgs, push bool) *runMetrics {
+	if !args.enabled() {
+		return nil
+	}
+	reg := metrics.NewRegistry()
+	m := &runMetrics{reg: reg, start: time.Now(), command: "preview"}
+	if push {
+		m.command = "push"
+	}
+	m.corrections = reg.Gauge("dnscontrol_corrections", "Corrections for a zone at a DNS provider or registrar.", "domain", "provider")
+	m.changes = reg.Gauge("dnscontrol_changes", "Record-level changes needed for a zone at a DNS provider.", "domain", "provider", "verb")
+	m.records = reg.Gauge("dnscontrol_zone_records", "Records of a zone at a DNS provider, before any change.", "domain", "provider")
+	m.apiCalls = reg.Summary("dnscontrol_provider_call_duration_seconds", "Time spent in calls to a provider.", "provider", "op")
+	m.apiErrors = reg.Gauge("dnscontrol_provider_call_errors", "Calls to a provider that returned an error.", "provider", "op")
+	m.correctionErrors = reg.Gauge("dnscontrol_correction_errors", "Corrections that failed.", "domain", "provider")
+	m.duration = reg.Gauge("dnscontrol_run_duration_seconds", "Duration of the run.", "command")
+	m.timestamp = reg.Gauge("dnscontrol_run_timestamp_seconds", "Time the run ended, in seconds since the epoch.", "command")
+	m.success = reg.Gauge("dnscontrol_run_success", "1 if the run completed without error, 0 otherwise.", "command")
+	m.total = reg.Gauge("dnscontrol_run_corrections", "Corrections for all zones.", "command")
+	return m
+}
+
+// observeCall records a call to a provider.
+func (m *runMetrics) observeCall(c apiCall) {
+	if m == nil {
+		return
+	}
+	m.apiCalls.Observe(c.duration.Seconds(), c.provider, c.op)
+	if c.failed {
+		m.apiErrors.Add(1, c.provider, c.op)
+	} else {
+		m.apiErrors.Add(0, c.provider, c.op)
+	}
+}
+
+// observeJob records the results of gathering a domain.
+func (m *runMetrics) observeJob(j *domainJob) {
+	if m == nil {
+		return
+	}
+	for _, c := range j.calls {
+		m.observeCall(c)
+	}
+	domain := j.domain.UniqueName
+	for _, pj := range append(j.providers, j.registrar) {
+		if pj == nil || pj.skip || pj.err != nil {
+			continue
+		}
+		m.corrections.Set(float64(len(pj.corrections)), domain, pj.name)
+		m.totalCorrections += len(pj.corrections)
+		if pj.changesErr != nil || pj == j.registrar {
+			continue
+		}
+		m.records.Set(float64(len(pj.existing)), domain, pj.name)
+		for _, c := range pj.changes {
+			m.changes.Add(1, domain, pj.name, c.Type.String())
+		}
+	}
+}
+
+// wrapCorrections returns corrections that record how long they take
+// to run, and whether they fail.
+func (m *runMetrics) wrapCorrections(domain, provider string, corrections []*models.Correction) []*models.Correction {
+	if m == nil {
+		return corrections
+	}
+	var wrapped []*models.Correction
+	for _, c := range corrections {
+		c := *c
+		if f := c.F; f != nil {
+			c.F = func() error {
+				start := time.Now()
+				err := f()
+				m.observeCall(apiCall{provider: provider, op: "RunCorrection", duration: time.Since(start), failed: err != nil})
+				if err != nil {
+					m.correctionErrors.Add(1, domain, provider)
+				}
+				return err
+			}
+		}
+		wrapped = append(wrapped, &c)
+	}
+	return wrapped
+}
+
+// export writes or pushes the metrics at the end of a run that
+// returned runErr. It returns runErr, or the error exporting the
+// metrics if there is none (otherwise that error is only printed).
+func (m *runMetrics) export(args MetricsArgs, runErr error) error {
+	if m == nil {
+		return runErr
+	}
+	success := 1.0
+	if runErr != nil {
+		success = 0
+	}
+	end := time.Now()
+	m.duration.Set(end.Sub(m.start).Seconds(), m.command)
+	m.timestamp.Set(float64(end.Unix()), m.command)
+	m.success.Set(success, m.command)
+	m.total.Set(float64(m.totalCorrections), m.command)
+
+	if err := m.write(args); err != nil {
+		if runErr == nil {
+			return err
+		}
+		printer.Warnf("Could not export the metrics: %s\n", err)
+	}
+	return runErr
+}
+
+func (m *runMetrics) write(args MetricsArgs) error {
+	if args.MetricsFile != "" {
+		// Write a temporary file and rename it, so that a collector
+		// never reads a partial file.
+		tmp := args.MetricsFile + ".tmp"
+		f, err := os.Create(tmp)
+		if err != nil {
+			return err
+		}
+		if err := m.reg.WriteOpenMetrics(f); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, args.MetricsFile); err != nil {
+			return err
+		}
+	}
+	if args.MetricsPush != "" {
+		return m.reg.Push(args.MetricsPush, args.MetricsJob)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/diff2"
+)
+
+func Test_runMetrics(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "metrics.prom")
+	args := MetricsArgs{MetricsFile: file}
+	m := newRunMetrics(args, true)
+
+	m.observeJob(&domainJob{
+		domain: &models.DomainConfig{Name: "example.com", UniqueName: "example.com"},
+		providers: []*providerJob{{
+			name:        "bind",
+			corrections: []*models.Correction{{Msg: "a"}, {Msg: "b"}},
+			existing:    models.Records{{}, {}, {}},
+			changes:     diff2.ChangeList{{Type: diff2.CREATE}, {Type: diff2.DELETE}, {Type: diff2.CREATE}},
+		}},
+		registrar: &providerJob{name: "none"},
+		calls: []apiCall{
+			{provider: "bind", op: "GetDomainCorrections", duration: time.Second},
+			{provider: "bind", op: "GetZoneRecords", duration: time.Second, failed: true},
+		},
+	})
+	for _, c := range m.wrapCorrections("example.com", "bind", []*models.Correction{
+		{F: func() error { return nil }},
+		{F: func() error { return errors.New("boom") }},
+	}) {
+		c.F()
+	}
+	if err := m.export(args, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(b)
+	for _, want := range []string{
+		`dnscontrol_corrections{domain="example.com",provider="bind"} 2`,
+		`dnscontrol_corrections{domain="example.com",provider="none"} 0`,
+		`dnscontrol_changes{domain="example.com",provider="bind",verb="CREATE"} 2`,
+		`dnscontrol_changes{domain="example.com",provider="bind",verb="DELETE"} 1`,
+		`dnscontrol_zone_records{domain="example.com",provider="bind"} 3`,
+		`dnscontrol_provider_call_duration_seconds_count{provider="bind",op="RunCorrection"} 2`,
+		`dnscontrol_provider_call_duration_seconds_sum{provider="bind",op="GetDomainCorrections"} 1`,
+		`dnscontrol_provider_call_errors{provider="bind",op="GetZoneRecords"} 1`,
+		`dnscontrol_provider_call_errors{provider="bind",op="RunCorrection"} 1`,
+		`dnscontrol_correction_errors{domain="example.com",provider="bind"} 1`,
+		`dnscontrol_run_success{command="push"} 1`,
+		`dnscontrol_run_corrections{command="push"} 2`,
+		"# EOF\n",
+	} {
+		if !strings.Contains(got, want+"\n") && !strings.HasSuffix(got, want) {
+			t.Errorf("missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func Test_runMetrics_disabled(t *testing.T) {
+	m := newRunMetrics(MetricsArgs{MetricsJob: "dnscontrol"}, false)
+	if m != nil {
+		t.Fatal("expected no metrics")
+	}
+	m.observeJob(&domainJob{})
+	cs := []*models.Correction{{Msg: "a"}}
+	if got := m.wrapCorrections("example.com", "bind", cs); &got[0] != &cs[0] {
+		t.Errorf("corrections should not be wrapped")
+	}
+	wantErr := errors.New("failed")
+	if err := m.export(MetricsArgs{}, wantErr); err != wantErr {
+		t.Errorf("got %v, want %v", err, wantErr)
+	}
+}
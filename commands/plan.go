@@ -0,0 +1,150 @@
+package commands
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/diff2"
+	"github.com/StackExchange/dnscontrol/v3/pkg/printer"
+)
+
+// A plan is the result of "preview --out-plan". It records the
+// desired configuration and the state of each zone at the time of the
+// preview. "push --plan" uses the configuration in the plan (not
+// dnsconfig.js) and refuses to change a zone that is no longer in the
+// state it was in when the plan was made.
+
+// planVersion is incremented if the format changes incompatibly.
+const planVersion = 1
+
+// Plan is the file format of a plan.
+type Plan struct {
+	Version int             `json:"version"`
+	Config  json.RawMessage `json:"config"` // The IR, before validation.
+	Zones   []*PlanZone     `json:"zones"`
+}
+
+// PlanZone records the state of one zone at one provider.
+type PlanZone struct {
+	Domain      string                  `json:"domain"` // The UniqueName of the domain.
+	Provider    string                  `json:"provider"`
+	Fingerprint string                  `json:"fingerprint"` // See zoneFingerprint.
+	Changes     []*printer.ChangeReport `json:"changes"`
+}
+
+// newPlan starts a plan for cfg. It must be called before cfg is
+// validated and normalized, as those steps modify cfg.
+func newPlan(cfg *models.DNSConfig) (*Plan, error) {
+	j, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Plan{Version: planVersion, Config: j, Zones: []*PlanZone{}}, nil
+}
+
+// readPlan reads a plan file.
+func readPlan(filename string) (*Plan, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	plan := &Plan{}
+	if err := json.Unmarshal(b, plan); err != nil {
+		return nil, fmt.Errorf("can not parse plan %q: %w", filename, err)
+	}
+	if plan.Version != planVersion {
+		return nil, fmt.Errorf("plan %q has version %d, expected %d", filename, plan.Version, planVersion)
+	}
+	return plan, nil
+}
+
+// write writes the plan to filename.
+func (p *Plan) write(filename string) error {
+	j, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(j, '\n'), 0o644)
+}
+
+// dnsConfig returns the configuration stored in the plan.
+func (p *Plan) dnsConfig() (*models.DNSConfig, error) {
+	cfg := &models.DNSConfig{}
+	if err := json.Unmarshal(p.Config, cfg); err != nil {
+		return nil, err
+	}
+	return preloadProviders(cfg)
+}
+
+// add records the state of a zone.
+func (p *Plan) add(domain, provider string, existing models.Records, changes diff2.ChangeList) {
+	p.Zones = append(p.Zones, &PlanZone{
+		Domain:      domain,
+		Provider:    provider,
+		Fingerprint: zoneFingerprint(existing),
+		Changes:     changeReports(changes),
+	})
+}
+
+// find returns the state of the zone recorded in the plan, or nil.
+func (p *Plan) find(domain, provider string) *PlanZone {
+	for _, z := range p.Zones {
+		if z.Domain == domain && z.Provider == provider {
+			return z
+		}
+	}
+	return nil
+}
+
+// check returns an error if the zone is not in the state recorded in
+// the plan, or the changes needed are not the ones in the plan.
+func (p *Plan) check(domain, provider string, existing models.Records, changes diff2.ChangeList) error {
+	z := p.find(domain, provider)
+	if z == nil {
+		return fmt.Errorf("%s at %s is not in the plan", domain, provider)
+	}
+	if fp := zoneFingerprint(existing); fp != z.Fingerprint {
+		return fmt.Errorf("%s at %s has changed since the plan was made (fingerprint %s, plan has %s)", domain, provider, fp, z.Fingerprint)
+	}
+	if !sameChanges(z.Changes, changeReports(changes)) {
+		return fmt.Errorf("%s at %s: the changes are not the ones in the plan", domain, provider)
+	}
+	return nil
+}
+
+// sameChanges returns true if a and b describe the same changes.
+func sameChanges(a, b []*printer.ChangeReport) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Verb != b[i].Verb || a[i].Key != b[i].Key ||
+			strings.Join(a[i].Msgs, "\n") != strings.Join(b[i].Msgs, "\n") {
+			return false
+		}
+	}
+	return true
+}
+
+// zoneFingerprint returns a hash of the records. The order of the
+// records does not matter.
+func zoneFingerprint(recs models.Records) string {
+	lines := make([]string, 0, len(recs))
+	for _, rc := range recs {
+		lines = append(lines, fmt.Sprintf("%s %s %s %s",
+			rc.GetLabelFQDN(), rc.Type, rc.ToDiffable(), rc.Metadata))
+	}
+	sort.Strings(lines)
+	h := sha256.New()
+	for _, l := range lines {
+		h.Write([]byte(l))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/diff2"
+)
+
+func makeRec(name, typ, target string) *models.RecordConfig {
+	rc := &models.RecordConfig{Type: typ, TTL: 300}
+	rc.SetLabel(name, "example.com")
+	rc.SetTarget(target)
+	return rc
+}
+
+func Test_zoneFingerprint(t *testing.T) {
+	a := makeRec("www", "A", "1.2.3.4")
+	b := makeRec("@", "MX", "mx.example.com.")
+	b.MxPreference = 10
+
+	if zoneFingerprint(models.Records{a, b}) != zoneFingerprint(models.Records{b, a}) {
+		t.Error("zoneFingerprint() depends on the order of the records")
+	}
+
+	c := makeRec("www", "A", "1.2.3.4")
+	c.TTL = 600
+	if zoneFingerprint(models.Records{a, b}) == zoneFingerprint(models.Records{c, b}) {
+		t.Error("zoneFingerprint() ignores the TTL")
+	}
+	if zoneFingerprint(models.Records{a}) == zoneFingerprint(models.Records{a, b}) {
+		t.Error("zoneFingerprint() ignores added records")
+	}
+}
+
+func TestPlan_check(t *testing.T) {
+	existing := models.Records{makeRec("www", "A", "1.2.3.4")}
+	changes := diff2.ChangeList{{
+		Type: diff2.CREATE,
+		Key:  models.RecordKey{NameFQDN: "new.example.com", Type: "A"},
+		Msgs: []string{"+ CREATE new.example.com A 1.2.3.5 ttl=300"},
+	}}
+
+	p := &Plan{Version: planVersion}
+	p.add("example.com", "bind", existing, changes)
+
+	if err := p.check("example.com", "bind", existing, changes); err != nil {
+		t.Errorf("check() unexpected error: %v", err)
+	}
+	if err := p.check("example.org", "bind", existing, changes); err == nil {
+		t.Error("check() accepted a zone that is not in the plan")
+	}
+	drifted := models.Records{makeRec("www", "A", "1.2.3.9")}
+	if err := p.check("example.com", "bind", drifted, changes); err == nil {
+		t.Error("check() accepted a zone that has changed")
+	}
+	if err := p.check("example.com", "bind", existing, nil); err == nil {
+		t.Error("check() accepted different changes")
+	}
+}
@@ -2,21 +2,17 @@ package commands
 
 import (
 	"fmt"
-	"log"
 	"os"
 	"strings"
 
-	"golang.org/x/net/idna"
-
 	"github.com/StackExchange/dnscontrol/v3/models"
 	"github.com/StackExchange/dnscontrol/v3/pkg/credsfile"
-	"github.com/StackExchange/dnscontrol/v3/pkg/nameservers"
+	"github.com/StackExchange/dnscontrol/v3/pkg/diff2"
 	"github.com/StackExchange/dnscontrol/v3/pkg/normalize"
 	"github.com/StackExchange/dnscontrol/v3/pkg/notifications"
 	"github.com/StackExchange/dnscontrol/v3/pkg/printer"
 	"github.com/StackExchange/dnscontrol/v3/providers"
 	"github.com/urfave/cli/v2"
-	"golang.org/x/exp/slices"
 )
 
 var _ = cmd(catMain, func() *cli.Command {
@@ -36,16 +32,32 @@ type PreviewArgs struct {
 	GetDNSConfigArgs
 	GetCredentialsArgs
 	FilterArgs
+	SafetyArgs
+	MetricsArgs
 	Notify      bool
 	WarnChanges bool
 	NoPopulate  bool
 	Full        bool
+
+	Concurrency         int
+	ProviderConcurrency string
+
+	Report string
+	Format string
+
+	OutPlan       string
+	PlanFile      string // Set by push only.
+	SelectChanges bool   // Set by push only.
+	Rollback      bool   // Set by push only.
+	AuditArgs            // Set by push only.
 }
 
 func (args *PreviewArgs) flags() []cli.Flag {
 	flags := args.GetDNSConfigArgs.flags()
 	flags = append(flags, args.GetCredentialsArgs.flags()...)
 	flags = append(flags, args.FilterArgs.flags()...)
+	flags = append(flags, args.SafetyArgs.flags()...)
+	flags = append(flags, args.MetricsArgs.flags()...)
 	flags = append(flags, &cli.BoolFlag{
 		Name:        "notify",
 		Destination: &args.Notify,
@@ -66,6 +78,33 @@ func (args *PreviewArgs) flags() []cli.Flag {
 		Destination: &args.Full,
 		Usage:       `Add headings, providers names, notifications of no changes, etc`,
 	})
+	flags = append(flags, &cli.IntFlag{
+		Name:        "concurrency",
+		Destination: &args.Concurrency,
+		Value:       1,
+		Usage:       `Number of domains to gather corrections for in parallel. Output and execution order are unchanged`,
+	})
+	flags = append(flags, &cli.StringFlag{
+		Name:        "provider-concurrency",
+		Destination: &args.ProviderConcurrency,
+		Usage:       `Limit concurrent calls to the providers that support them (comma separated list of name=N, e.g. "r53=2,cloudflare=4"). Other providers are called one at a time`,
+	})
+	flags = append(flags, &cli.StringFlag{
+		Name:        "report",
+		Destination: &args.Report,
+		Usage:       `Also write a JSON report of the corrections to this file`,
+	})
+	flags = append(flags, &cli.StringFlag{
+		Name:        "format",
+		Destination: &args.Format,
+		Value:       "text",
+		Usage:       `Output format: text or json. With json, the report is written to stdout and the usual output to stderr`,
+	})
+	flags = append(flags, &cli.StringFlag{
+		Name:        "out-plan",
+		Destination: &args.OutPlan,
+		Usage:       `Write a plan to this file. Use "push --plan" to make exactly these changes`,
+	})
 	return flags
 }
 
@@ -94,30 +133,120 @@ func (args *PushArgs) flags() []cli.Flag {
 		Destination: &args.Interactive,
 		Usage:       "Interactive. Confirm or Exclude each correction before they run",
 	})
+	flags = append(flags, &cli.BoolFlag{
+		Name:        "select",
+		Destination: &args.SelectChanges,
+		Usage:       "Interactive. Review each record change (old and new side by side) and make only those accepted",
+	})
+	flags = append(flags, &cli.StringFlag{
+		Name:        "plan",
+		Destination: &args.PlanFile,
+		Usage:       `Push the configuration in this plan (from "preview --out-plan"), only if the zones have not changed since`,
+	})
+	flags = append(flags, &cli.BoolFlag{
+		Name:        "override-safety",
+		Destination: &args.OverrideSafety,
+		Usage:       `Push even if the safety limits (--max-deletes, etc.) are exceeded`,
+	})
+	flags = append(flags, &cli.BoolFlag{
+		Name:        "rollback",
+		Destination: &args.Rollback,
+		Usage:       `If a correction fails, restore the zone (at that provider) to its records before the push`,
+	})
+	flags = append(flags, args.AuditArgs.flags()...)
 	return flags
 }
 
 // Preview implements the preview subcommand.
 func Preview(args PreviewArgs) error {
-	return run(args, false, false, printer.DefaultPrinter)
+	return runWithReport(args, false, false)
 }
 
 // Push implements the push subcommand.
 func Push(args PushArgs) error {
-	return run(args.PreviewArgs, true, args.Interactive, printer.DefaultPrinter)
+	return runWithReport(args.PreviewArgs, true, args.Interactive)
 }
 
-// run is the main routine common to preview/push
-func run(args PreviewArgs, push bool, interactive bool, out printer.CLI) error {
+// wantChanges returns true if the record-level changes of each
+// provider should be determined (in addition to the corrections).
+func (args *PreviewArgs) wantChanges() bool {
+	return args.Report != "" || args.Format == "json" || args.OutPlan != "" || args.PlanFile != "" || args.SelectChanges ||
+		len(args.SafetyArgs.config().Rules()) != 0 || args.MetricsArgs.enabled() || args.AuditLog != "" || args.Rollback
+}
+
+// runWithReport calls run with the printer selected by --format and
+// --report, and writes the JSON report and the metrics (if any) when
+// done.
+func runWithReport(args PreviewArgs, push bool, interactive bool) (err error) {
+	m := newRunMetrics(args.MetricsArgs, push)
+	defer func() { err = m.export(args.MetricsArgs, err) }()
+
+	switch args.Format {
+	case "", "text":
+		if args.Report == "" {
+			return run(args, push, interactive, printer.DefaultPrinter, m)
+		}
+	case "json":
+		// Keep stdout clean for the report.
+		printer.DefaultPrinter.Writer = os.Stderr
+	default:
+		return fmt.Errorf("unknown format %q (expected text or json)", args.Format)
+	}
+
+	out := &printer.JSONPrinter{Inner: printer.DefaultPrinter}
+	out.Report.Push = push
+	runErr := run(args, push, interactive, out, m)
+	if runErr != nil {
+		out.Report.Error = runErr.Error()
+	}
+
+	if args.Report != "" {
+		f, err := os.Create(args.Report)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := out.Write(f); err != nil {
+			return err
+		}
+	}
+	if args.Format == "json" {
+		if err := out.Write(os.Stdout); err != nil {
+			return err
+		}
+	}
+	return runErr
+}
+
+// run is the main routine common to preview/push. m may be nil.
+func run(args PreviewArgs, push bool, interactive bool, out printer.CLI, m *runMetrics) error {
 	// TODO: make truly CLI independent. Perhaps return results on a channel as they occur
 
 	// This is a hack until we have the new printer replacement.
 	printer.SkinnyReport = !args.Full
 
-	cfg, err := GetDNSConfig(args.GetDNSConfigArgs)
+	var cfg *models.DNSConfig
+	var plan, outPlan *Plan
+	var err error
+	if args.PlanFile != "" {
+		if plan, err = readPlan(args.PlanFile); err != nil {
+			return err
+		}
+		if err := configureSPFCache(args.GetDNSConfigArgs); err != nil {
+			return err
+		}
+		cfg, err = plan.dnsConfig()
+	} else {
+		cfg, err = GetDNSConfig(args.GetDNSConfigArgs)
+	}
 	if err != nil {
 		return err
 	}
+	if args.OutPlan != "" {
+		if outPlan, err = newPlan(cfg); err != nil {
+			return err
+		}
+	}
 	providerConfigs, err := credsfile.LoadProviderConfigs(args.CredsFile)
 	if err != nil {
 		return err
@@ -131,100 +260,185 @@ func run(args PreviewArgs, push bool, interactive bool, out printer.CLI) error {
 	if PrintValidationErrors(errs) {
 		return fmt.Errorf("exiting due to validation errors")
 	}
+	lim, err := newProviderLimiter(cfg, args.ProviderConcurrency)
+	if err != nil {
+		return err
+	}
+	var trail *auditTrail
+	if push {
+		if trail, err = newAuditTrail(args.AuditArgs, args.JSFile); err != nil {
+			return fmt.Errorf("audit log: %w", err)
+		}
+		defer trail.close()
+	}
+	jobs := newDomainJobs(cfg, args, push)
+	startJobs(jobs, args.Concurrency, args, push, lim)
+
+	refused := false
+	if rules := args.SafetyArgs.config().Rules(); len(rules) != 0 {
+		// The limits are checked before any correction is run, therefore
+		// every domain must be gathered first.
+		for _, job := range jobs {
+			job.wait(args.Concurrency, args, push, lim)
+		}
+		if vs := checkSafety(rules, jobs); len(vs) != 0 {
+			switch {
+			case !push:
+				for _, v := range vs {
+					out.Warnf("Safety limit exceeded: %s\n", v)
+				}
+				out.Warnf("push would refuse to make these changes (unless --override-safety is used)\n")
+			case args.OverrideSafety:
+				for _, v := range vs {
+					out.Warnf("Safety limit exceeded (overridden): %s\n", v)
+				}
+			default:
+				for _, v := range vs {
+					out.Errorf("Safety limit exceeded: %s\n", v)
+				}
+				out.Errorf("Refusing to push. No changes will be made. Use --override-safety to push anyway.\n")
+				// Show the corrections but do not run them.
+				refused = true
+				push = false
+			}
+		}
+	}
+
 	anyErrors := false
 	totalCorrections := 0
+	owners, _ := out.(printer.OwnerReporter)
+	if !hasOwners(jobs) {
+		owners = nil
+	}
 DomainLoop:
-	for _, domain := range cfg.Domains {
-		if !args.shouldRunDomain(domain.UniqueName) {
-			continue
+	for i, job := range jobs {
+		job.wait(args.Concurrency, args, push, lim)
+		domain := job.domain
+		if owners != nil && (i == 0 || jobs[i-1].domain.Owner() != domain.Owner()) {
+			owners.StartOwner(domain.Owner())
 		}
 		out.StartDomain(domain.UniqueName)
-		var providersWithExistingZone []*models.DNSProviderInstance
-		for _, provider := range domain.DNSProviderInstances {
-
-			if !args.NoPopulate {
-				// preview run: check if zone is already there, if not print a warning
-				if lister, ok := provider.Driver.(providers.ZoneLister); ok && !push {
-					zones, err := lister.ListZones()
-					if err != nil {
-						return err
-					}
-					aceZoneName, _ := idna.ToASCII(domain.Name)
-
-					if !slices.Contains(zones, aceZoneName) {
-						out.Warnf("DEBUG: zones: %v\n", zones)
-						out.Warnf("DEBUG: Name: %v\n", domain.Name)
-
-						out.Warnf("Zone '%s' does not exist in the '%s' profile and will be added automatically.\n", domain.Name, provider.Name)
-						continue // continue with next provider, as we can not determine corrections without an existing zone
-					}
-				} else if creator, ok := provider.Driver.(providers.ZoneCreator); ok && push {
-					// this is the actual push, ensure domain exists at DSP
-					if err := creator.EnsureZoneExists(domain.Name); err != nil {
-						out.Warnf("Error creating domain: %s\n", err)
-						continue // continue with next provider, as we couldn't create this one
-					}
-				}
-			}
-			providersWithExistingZone = append(providersWithExistingZone, provider)
+		created := true
+		if push && job.err == nil && job.creates() {
+			created = createZones(out, job, plan, args, lim)
 		}
-
-		nsList, err := nameservers.DetermineNameserversForProviders(domain, providersWithExistingZone)
-		if err != nil {
-			return err
+		m.observeJob(job)
+		for _, w := range job.warnings {
+			out.Warnf("%s", w)
+		}
+		if job.err != nil {
+			return job.err
+		}
+		if !created {
+			anyErrors = true
+			continue
 		}
-		domain.Nameservers = nsList
-		nameservers.AddNSRecords(domain)
 
-		for _, provider := range providersWithExistingZone {
-			dc, err := domain.Copy()
-			if err != nil {
-				return err
+		for _, pj := range job.providers {
+			out.StartDNSProvider(pj.name, pj.skip)
+			if pj.skip {
+				continue
 			}
-			shouldrun := args.shouldRunProvider(provider.Name, dc)
-			out.StartDNSProvider(provider.Name, !shouldrun)
-			if !shouldrun {
+			if pj.create {
+				// The push was refused.
+				out.Warnf("Zone '%s' does not exist in the '%s' profile and was not created.\n", domain.Name, pj.name)
 				continue
 			}
-
-			/// This is where we should audit?
-
-			corrections, err := provider.Driver.GetDomainCorrections(dc)
-			out.EndProvider(provider.Name, len(corrections), err)
-			if err != nil {
+			out.EndProvider(pj.name, len(pj.corrections), pj.err)
+			if pj.err != nil {
 				anyErrors = true
 				continue DomainLoop
 			}
+			if pj.changesErr != nil {
+				out.Warnf("Could not determine the changes (%s): %s\n", pj.name, pj.changesErr)
+			} else if cr, ok := out.(printer.ChangeReporter); ok && pj.changes != nil {
+				cr.ReportChanges(changeReports(pj.changes))
+			}
+			if outPlan != nil {
+				if pj.changesErr != nil {
+					return fmt.Errorf("can not make a plan: %w", pj.changesErr)
+				}
+				outPlan.add(domain.UniqueName, pj.name, pj.existing, pj.changes)
+			}
+			if plan != nil {
+				err := pj.changesErr
+				if err == nil {
+					err = plan.check(domain.UniqueName, pj.name, pj.existing, pj.changes)
+				}
+				if err != nil {
+					out.Errorf("Refusing to push: %s\n", err)
+					anyErrors = true
+					continue DomainLoop
+				}
+			}
+			if push && args.Rollback && pj.changesErr != nil && len(pj.corrections) != 0 {
+				out.Errorf("Refusing to push without a snapshot of the zone to roll back to (%s): %s\n", pj.name, pj.changesErr)
+				anyErrors = true
+				continue DomainLoop
+			}
+			corrections, changes, ask := pj.corrections, pj.changes, interactive
+			if sel, ok := out.(printer.ChangeSelector); ok && push && args.SelectChanges && len(corrections) != 0 {
+				if pj.changesErr == nil {
+					if corrections, changes, err = selectedCorrections(sel, domain, pj); err != nil {
+						out.Errorf("Could not make the selected changes (%s): %s\n", pj.name, err)
+						anyErrors = true
+						continue DomainLoop
+					}
+					out.Printf("%d correction(s) to make the selected changes (%s)\n", len(corrections), pj.name)
+				} else {
+					// The changes are unknown: confirm each correction instead.
+					ask = true
+				}
+			}
 			totalCorrections += len(corrections)
-			anyErrors = printOrRunCorrections(domain.Name, provider.Name, corrections, out, push, interactive, notifier) || anyErrors
+			corrections = m.wrapCorrections(domain.UniqueName, pj.name, corrections)
+			failed := false
+			if push && args.Rollback {
+				corrections = watchCorrections(corrections, &failed)
+			}
+			anyErrors = printOrRunCorrections(domain.Name, pj.name, corrections, changes, out, push, ask, notifier, trail, args.Rollback) || anyErrors
+			if failed {
+				if err := rollback(out, domain, pj, notifier, trail); err != nil {
+					out.Errorf("Rollback failed (%s): %s\n", pj.name, err)
+				}
+			}
 		}
-		run := args.shouldRunProvider(domain.RegistrarName, domain)
-		out.StartRegistrar(domain.RegistrarName, !run)
-		if !run {
+		rj := job.registrar
+		out.StartRegistrar(rj.name, rj.skip)
+		if rj.skip {
 			continue
 		}
-		if len(domain.Nameservers) == 0 && domain.Metadata["no_ns"] != "true" {
-			out.Warnf("No nameservers declared; skipping registrar. Add {no_ns:'true'} to force.\n")
+		if rj.warning != "" {
+			out.Warnf("%s", rj.warning)
 			continue
 		}
-		dc, err := domain.Copy()
-		if err != nil {
-			log.Fatal(err)
-		}
-		corrections, err := domain.RegistrarInstance.Driver.GetRegistrarCorrections(dc)
-		out.EndProvider(domain.RegistrarName, len(corrections), err)
-		if err != nil {
+		out.EndProvider(rj.name, len(rj.corrections), rj.err)
+		if rj.err != nil {
 			anyErrors = true
 			continue
 		}
-		totalCorrections += len(corrections)
-		anyErrors = printOrRunCorrections(domain.Name, domain.RegistrarName, corrections, out, push, interactive, notifier) || anyErrors
+		totalCorrections += len(rj.corrections)
+		corrections := m.wrapCorrections(domain.UniqueName, rj.name, rj.corrections)
+		// The registrar has no record changes to select: --select
+		// confirms each correction instead.
+		anyErrors = printOrRunCorrections(domain.Name, rj.name, corrections, nil, out, push, interactive || args.SelectChanges, notifier, trail, false) || anyErrors
 	}
 	if os.Getenv("TEAMCITY_VERSION") != "" {
 		fmt.Fprintf(os.Stderr, "##teamcity[buildStatus status='SUCCESS' text='%d corrections']", totalCorrections)
 	}
-	notifier.Done()
+	if err := notifier.Done(); err != nil {
+		out.Warnf("Notifications failed: %s\n", err)
+	}
 	out.Printf("Done. %d corrections.\n", totalCorrections)
+	if outPlan != nil {
+		if err := outPlan.write(args.OutPlan); err != nil {
+			return err
+		}
+		out.Printf("Plan written to %s\n", args.OutPlan)
+	}
+	if refused {
+		return fmt.Errorf("refused to push: safety limits exceeded")
+	}
 	if anyErrors {
 		return fmt.Errorf("completed with errors")
 	}
@@ -234,11 +448,66 @@ DomainLoop:
 	return nil
 }
 
+// createZones creates the zones of job that do not exist yet, if they
+// are in the plan (if any), and gathers its corrections again. The
+// safety limits are checked again, with the records that the
+// providers put in the new zones. It returns false if the domain
+// should not be pushed.
+func createZones(out printer.CLI, job *domainJob, plan *Plan, args PreviewArgs, lim providerLimiter) bool {
+	domain := job.domain
+	if plan != nil {
+		for _, pj := range job.providers {
+			if !pj.create || pj.skip {
+				continue
+			}
+			if err := plan.check(domain.UniqueName, pj.name, pj.existing, pj.changes); err != nil {
+				out.Errorf("Refusing to push: %s\n", err)
+				return false
+			}
+		}
+	}
+	if err := job.createZones(args, true, lim); err != nil {
+		out.Errorf("Error creating domain: %s\n", err)
+		return false
+	}
+	if job.err != nil {
+		return true
+	}
+	for _, pj := range job.providers {
+		if pj.create && !pj.skip {
+			out.Errorf("Zone '%s' was created in the '%s' profile but can not be found\n", domain.Name, pj.name)
+			return false
+		}
+	}
+	rules := args.SafetyArgs.config().Rules()
+	if len(rules) == 0 {
+		return true
+	}
+	vs := checkSafety(rules, []*domainJob{job})
+	if args.OverrideSafety {
+		for _, v := range vs {
+			out.Warnf("Safety limit exceeded (overridden): %s\n", v)
+		}
+		return true
+	}
+	for _, v := range vs {
+		out.Errorf("Safety limit exceeded: %s\n", v)
+	}
+	if len(vs) != 0 {
+		out.Errorf("Refusing to push %s. The new zone was created, no other change will be made.\n", domain.Name)
+	}
+	return len(vs) == 0
+}
+
 // InitializeProviders takes (fully processed) configuration and instantiates all providers and returns them.
 func InitializeProviders(cfg *models.DNSConfig, providerConfigs map[string]map[string]string, notifyFlag bool) (notify notifications.Notifier, err error) {
 	var notificationCfg map[string]string
 	defer func() {
-		notify = notifications.Init(notificationCfg)
+		var nerr error
+		notify, nerr = notifications.Init(notificationCfg)
+		if err == nil {
+			err = nerr
+		}
 	}()
 	if notifyFlag {
 		notificationCfg = providerConfigs["notifications"]
@@ -484,7 +753,12 @@ func refineProviderType(credEntryName string, t string, credFields map[string]st
 
 }
 
-func printOrRunCorrections(domain string, provider string, corrections []*models.Correction, out printer.CLI, push bool, interactive bool, notifier notifications.Notifier) (anyErrors bool) {
+// printOrRunCorrections prints the corrections, and runs them if push is
+// set. changes are the record changes of the provider, if known: the
+// audit trail records each correction that is run, with its changes.
+// If stopOnError is set, the corrections after one that failed are not
+// run.
+func printOrRunCorrections(domain string, provider string, corrections []*models.Correction, changes diff2.ChangeList, out printer.CLI, push bool, interactive bool, notifier notifications.Notifier, trail *auditTrail, stopOnError bool) (anyErrors bool) {
 	anyErrors = false
 	if len(corrections) == 0 {
 		return false
@@ -502,9 +776,24 @@ func printOrRunCorrections(domain string, provider string, corrections []*models
 				if err != nil {
 					anyErrors = true
 				}
+				// A single correction makes all the changes, whatever its message.
+				cs := changes
+				if len(corrections) != 1 {
+					cs = correctionChanges(correction.Msg, changes)
+				}
+				if aerr := trail.record(domain, provider, correction, cs, err); aerr != nil {
+					out.Errorf("Could not write the audit log: %s\n", aerr)
+					anyErrors = true
+				}
 			}
 		}
 		notifier.Notify(domain, provider, correction.Msg, err, !push)
+		if err != nil && stopOnError {
+			if left := len(corrections) - i - 1; left != 0 {
+				out.Warnf("%d correction(s) not run after the failure (%s)\n", left, provider)
+			}
+			break
+		}
 	}
 	return anyErrors
 }
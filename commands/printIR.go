@@ -10,6 +10,7 @@ import (
 	"github.com/StackExchange/dnscontrol/v3/models"
 	"github.com/StackExchange/dnscontrol/v3/pkg/js"
 	"github.com/StackExchange/dnscontrol/v3/pkg/normalize"
+	"github.com/StackExchange/dnscontrol/v3/pkg/yamlconfig"
 	"github.com/urfave/cli/v2"
 )
 
@@ -49,6 +50,8 @@ var _ = cmd(catDebug, func() *cli.Command {
 			pargs.JSONFile = args.JSONFile
 			pargs.DevMode = args.DevMode
 			pargs.Variable = args.Variable
+			pargs.SPFCache = args.SPFCache
+			pargs.SPFCachePolicy = args.SPFCachePolicy
 			// Force these settings:
 			pargs.Pretty = false
 			pargs.Output = os.DevNull
@@ -122,6 +125,14 @@ func ExecuteDSL(args ExecuteDSLArgs) (*models.DNSConfig, error) {
 		return nil, fmt.Errorf("no config specified")
 	}
 
+	if fi, err := os.Stat(args.JSFile); err == nil && fi.IsDir() {
+		return loadConfigDir(args)
+	}
+	if yamlconfig.IsConfigFile(args.JSFile) {
+		// Errors name the file.
+		return yamlconfig.Load(args.JSFile)
+	}
+
 	dnsConfig, err := js.ExecuteJavascript(args.JSFile, args.DevMode, stringSliceToMap(args.Variable))
 	if err != nil {
 		return nil, fmt.Errorf("executing %s: %w", args.JSFile, err)
@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/diff2"
+	"github.com/StackExchange/dnscontrol/v3/pkg/notifications"
+	"github.com/StackExchange/dnscontrol/v3/pkg/printer"
+)
+
+// This file implements "push --rollback": the records of each zone are
+// kept as they were before the push (the records that preview found),
+// and if a correction fails, the zone is restored to them. Like "push
+// --select", the provider is asked for the corrections that turn the
+// zone into desired records, which are those of the snapshot.
+
+// watchCorrections returns corrections that set *failed if they fail.
+func watchCorrections(corrections []*models.Correction, failed *bool) []*models.Correction {
+	var watched []*models.Correction
+	for _, c := range corrections {
+		c := *c
+		if f := c.F; f != nil {
+			c.F = func() error {
+				err := f()
+				if err != nil {
+					*failed = true
+				}
+				return err
+			}
+		}
+		watched = append(watched, &c)
+	}
+	return watched
+}
+
+// snapshotConfig returns a copy of domain, punycoded, whose records
+// are existing: those of the zone before the push.
+func snapshotConfig(domain *models.DomainConfig, existing models.Records) (*models.DomainConfig, error) {
+	dc, err := domain.Copy()
+	if err != nil {
+		return nil, err
+	}
+	if err := dc.Punycode(); err != nil {
+		return nil, err
+	}
+	if dc.Records, err = copyRecords(comparableRecords(existing, dc)); err != nil {
+		return nil, err
+	}
+	return dc, nil
+}
+
+// rollback restores the zone of domain at the provider of pj to the
+// records it had before the push (pj.existing), after a correction
+// failed. It prints the record changes that it undoes, runs the
+// corrections that undo them, and checks that the zone is as it was.
+func rollback(out printer.CLI, domain *models.DomainConfig, pj *providerJob, notifier notifications.Notifier, trail *auditTrail) error {
+	provider, err := dnsProvider(domain, pj.name)
+	if err != nil {
+		return err
+	}
+	dc, err := snapshotConfig(domain, pj.existing)
+	if err != nil {
+		return err
+	}
+
+	// What the push changed, seen from the snapshot.
+	_, restore, err := zoneChanges(provider.Driver, dc)
+	if err != nil {
+		return err
+	}
+	restore = withoutReports(restore)
+	if len(restore) == 0 {
+		out.Printf("Nothing to roll back (%s): the zone is as it was before the push\n", pj.name)
+		return nil
+	}
+	out.Printf("Rolling back %s at %s to its records before the push: %d record change(s) to undo\n", domain.Name, pj.name, len(restore))
+	for _, c := range restore {
+		for _, m := range c.Msgs {
+			out.Printf("  %s\n", m)
+		}
+	}
+
+	corrections, err := provider.Driver.GetDomainCorrections(dc)
+	if err != nil {
+		return err
+	}
+	if printOrRunCorrections(domain.Name, pj.name, corrections, restore, out, true, false, notifier, trail.forRollback(), false) {
+		return fmt.Errorf("a correction of the rollback failed")
+	}
+
+	_, left, err := zoneChanges(provider.Driver, dc)
+	if err != nil {
+		return fmt.Errorf("could not check the rollback: %w", err)
+	}
+	if left = withoutReports(left); len(left) != 0 {
+		var msgs []string
+		for _, c := range left {
+			msgs = append(msgs, c.Msgs...)
+		}
+		return fmt.Errorf("%d record change(s) not undone:\n%s", len(left), strings.Join(msgs, "\n"))
+	}
+	out.Printf("Rolled back %s at %s: %d record change(s) undone\n", domain.Name, pj.name, len(restore))
+	return nil
+}
+
+func withoutReports(changes diff2.ChangeList) diff2.ChangeList {
+	var cs diff2.ChangeList
+	for _, c := range changes {
+		if c.Type != diff2.REPORT {
+			cs = append(cs, c)
+		}
+	}
+	return cs
+}
+
+// dnsProvider returns the DNS provider of domain called name.
+func dnsProvider(domain *models.DomainConfig, name string) (*models.DNSProviderInstance, error) {
+	for _, p := range domain.DNSProviderInstances {
+		if p.Name == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("provider %s not found", name)
+}
@@ -0,0 +1,210 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/diff2"
+	"github.com/StackExchange/dnscontrol/v3/pkg/notifications"
+	"github.com/StackExchange/dnscontrol/v3/pkg/printer"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeZone is a DNS provider of one zone, which makes a correction per
+// record change. The corrections of the names in fail fail.
+type fakeZone struct {
+	records models.Records
+	fail    map[string]bool
+}
+
+func (z *fakeZone) GetNameservers(string) ([]*models.Nameserver, error) {
+	return nil, nil
+}
+
+func (z *fakeZone) GetZoneRecords(string) (models.Records, error) {
+	var recs models.Records
+	for _, r := range z.records {
+		c, _ := r.Copy()
+		recs = append(recs, c)
+	}
+	return recs, nil
+}
+
+func (z *fakeZone) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correction, error) {
+	changes, err := diff2.ByRecord(z.records, dc, nil)
+	if err != nil {
+		return nil, err
+	}
+	var corrections []*models.Correction
+	for _, c := range changes {
+		if c.Type == diff2.REPORT {
+			continue
+		}
+		c := c
+		corrections = append(corrections, &models.Correction{
+			Msg: c.MsgsJoined,
+			F: func() error {
+				if z.fail[c.Key.NameFQDN] {
+					return fmt.Errorf("%s is read-only", c.Key.NameFQDN)
+				}
+				var recs models.Records
+				for _, r := range z.records {
+					if len(c.Old) == 0 || r != c.Old[0] {
+						recs = append(recs, r)
+					}
+				}
+				z.records = append(recs, c.New...)
+				return nil
+			},
+		})
+	}
+	return corrections, nil
+}
+
+func (z *fakeZone) strings() []string {
+	var s []string
+	for _, r := range z.records {
+		s = append(s, r.GetLabel()+" "+r.GetTargetField())
+	}
+	sort.Strings(s)
+	return s
+}
+
+func Test_rollback(t *testing.T) {
+	zone := &fakeZone{
+		records: models.Records{
+			makeRec("@", "A", "1.2.3.4"),
+			makeRec("old", "A", "9.9.9.9"),
+			makeRec("www", "A", "1.2.3.4"),
+		},
+		fail: map[string]bool{"zzz.example.com": true},
+	}
+	before := zone.strings()
+	domain := &models.DomainConfig{
+		Name: "example.com",
+		Records: models.Records{
+			makeRec("@", "A", "1.2.3.5"),
+			makeRec("new", "A", "5.5.5.5"),
+			makeRec("www", "A", "1.2.3.4"),
+			makeRec("zzz", "A", "7.7.7.7"),
+		},
+		DNSProviderInstances: []*models.DNSProviderInstance{{ProviderBase: models.ProviderBase{Name: "fake"}, Driver: zone}},
+	}
+
+	pj := &providerJob{name: "fake"}
+	var err error
+	pj.existing, pj.changes, err = zoneChanges(zone, domain)
+	assert.NoError(t, err)
+	dc, _ := domain.Copy()
+	pj.corrections, err = zone.GetDomainCorrections(dc)
+	assert.NoError(t, err)
+	assert.Len(t, pj.corrections, 4)
+
+	var b bytes.Buffer
+	out := printer.ConsolePrinter{Writer: &b}
+	notifier, _ := notifications.Init(nil)
+	failed := false
+	corrections := watchCorrections(pj.corrections, &failed)
+	assert.True(t, printOrRunCorrections(domain.Name, pj.name, corrections, pj.changes, out, true, false, notifier, nil, true))
+	assert.True(t, failed)
+	assert.NotEqual(t, before, zone.strings(), "nothing was pushed")
+
+	assert.NoError(t, rollback(out, domain, pj, notifier, nil))
+	assert.Equal(t, before, zone.strings())
+	got := b.String()
+	for _, want := range []string{
+		"Rolling back example.com at fake to its records before the push: 3 record change(s) to undo\n",
+		"Rolled back example.com at fake: 3 record change(s) undone\n",
+	} {
+		assert.Contains(t, got, want)
+	}
+
+	// Once restored, there is nothing to roll back.
+	b.Reset()
+	assert.NoError(t, rollback(out, domain, pj, notifier, nil))
+	assert.True(t, strings.HasPrefix(b.String(), "Nothing to roll back (fake)"), b.String())
+
+	// A rollback that fails is reported.
+	pj.existing = append(pj.existing, makeRec("zzz", "A", "8.8.8.8"))
+	err = rollback(out, domain, pj, notifier, nil)
+	assert.EqualError(t, err, "a correction of the rollback failed")
+}
+
+func Test_rollback_stop(t *testing.T) {
+	zone := &fakeZone{
+		records: models.Records{
+			makeRec("@", "A", "1.2.3.4"),
+			makeRec("www", "A", "1.2.3.4"),
+		},
+		fail: map[string]bool{"new.example.com": true},
+	}
+	before := zone.strings()
+	domain := &models.DomainConfig{
+		Name: "example.com",
+		Records: models.Records{
+			makeRec("@", "A", "1.2.3.5"),
+			makeRec("new", "A", "5.5.5.5"),
+			makeRec("www", "A", "1.2.3.4"),
+			makeRec("zzz", "A", "7.7.7.7"),
+		},
+		DNSProviderInstances: []*models.DNSProviderInstance{{ProviderBase: models.ProviderBase{Name: "fake"}, Driver: zone}},
+	}
+
+	pj := &providerJob{name: "fake"}
+	var err error
+	pj.existing, pj.changes, err = zoneChanges(zone, domain)
+	assert.NoError(t, err)
+	dc, _ := domain.Copy()
+	pj.corrections, err = zone.GetDomainCorrections(dc)
+	assert.NoError(t, err)
+	var msgs []string
+	for _, c := range pj.corrections {
+		msgs = append(msgs, c.Msg)
+	}
+	// The failing correction is neither the first nor the last.
+	assert.Len(t, msgs, 3)
+	assert.Contains(t, msgs[1], "new.example.com")
+
+	var b bytes.Buffer
+	out := printer.ConsolePrinter{Writer: &b}
+	notifier, _ := notifications.Init(nil)
+	failed := false
+	corrections := watchCorrections(pj.corrections, &failed)
+	assert.True(t, printOrRunCorrections(domain.Name, pj.name, corrections, pj.changes, out, true, false, notifier, nil, true))
+	assert.True(t, failed)
+	assert.Equal(t, []string{"@ 1.2.3.5", "www 1.2.3.4"}, zone.strings(), "the correction after the failure was run")
+	assert.Contains(t, b.String(), "WARNING: 1 correction(s) not run after the failure (fake)\n")
+	assert.NotContains(t, b.String(), "#3:")
+
+	assert.NoError(t, rollback(out, domain, pj, notifier, nil))
+	assert.Equal(t, before, zone.strings())
+	assert.Contains(t, b.String(), "Rolled back example.com at fake: 1 record change(s) undone\n")
+}
+
+func Test_watchCorrections(t *testing.T) {
+	ran := 0
+	cs := []*models.Correction{
+		{Msg: "ok", F: func() error { ran++; return nil }},
+		{Msg: "no F"},
+	}
+	failed := false
+	for _, c := range watchCorrections(cs, &failed) {
+		if c.F != nil {
+			c.F()
+		}
+	}
+	assert.False(t, failed)
+	assert.Equal(t, 1, ran)
+
+	cs = append(cs, &models.Correction{F: func() error { return fmt.Errorf("boom") }})
+	for _, c := range watchCorrections(cs, &failed) {
+		if c.F != nil {
+			c.F()
+		}
+	}
+	assert.True(t, failed)
+}
@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/pkg/safety"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/net/idna"
+)
+
+// SafetyArgs contains the flags that configure the safety limits of
+// preview/push. See pkg/safety.
+type SafetyArgs struct {
+	MaxDeletes       int
+	MaxDeletePercent int
+	MaxChangedZones  int
+	ProtectApex      string
+	OverrideSafety   bool // Set by push only.
+}
+
+func (args *SafetyArgs) flags() []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{
+			Name:        "max-deletes",
+			Destination: &args.MaxDeletes,
+			Usage:       `Refuse to push if more than this many records would be deleted from a zone`,
+		},
+		&cli.IntFlag{
+			Name:        "max-delete-percent",
+			Destination: &args.MaxDeletePercent,
+			Usage:       `Refuse to push if more than this percentage of a zone's records would be deleted`,
+		},
+		&cli.IntFlag{
+			Name:        "max-changed-zones",
+			Destination: &args.MaxChangedZones,
+			Usage:       `Refuse to push if more than this many zones would change`,
+		},
+		&cli.StringFlag{
+			Name:        "protect-apex",
+			Destination: &args.ProtectApex,
+			Usage:       `Refuse to push if a record of these types would be deleted or changed at the apex of a zone (comma separated list, e.g. "NS,SOA,MX")`,
+		},
+	}
+}
+
+// config returns the configuration of the safety rules.
+func (args *SafetyArgs) config() safety.Config {
+	c := safety.Config{
+		MaxDeletes:       args.MaxDeletes,
+		MaxDeletePercent: args.MaxDeletePercent,
+		MaxChangedZones:  args.MaxChangedZones,
+	}
+	for _, t := range strings.Split(args.ProtectApex, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			c.ProtectApex = append(c.ProtectApex, strings.ToUpper(t))
+		}
+	}
+	return c
+}
+
+// checkSafety evaluates the rules on the changes of every provider of
+// every job. The jobs must be complete. A zone whose changes could not
+// be determined is a violation, as it can not be checked.
+func checkSafety(rules []safety.Rule, jobs []*domainJob) []safety.Violation {
+	var zones []*safety.Zone
+	var vs []safety.Violation
+	for _, job := range jobs {
+		name, err := idna.ToASCII(job.domain.Name)
+		if err != nil {
+			name = job.domain.Name
+		}
+		for _, pj := range job.providers {
+			if pj.skip || pj.err != nil {
+				continue
+			}
+			if pj.changesErr != nil {
+				vs = append(vs, safety.Violation{
+					Rule:     "safety",
+					Domain:   name,
+					Provider: pj.name,
+					Msg:      "could not determine the changes: " + pj.changesErr.Error(),
+				})
+				continue
+			}
+			zones = append(zones, &safety.Zone{
+				Domain:   name,
+				Provider: pj.name,
+				Existing: pj.existing,
+				Changes:  pj.changes,
+			})
+		}
+	}
+	return append(vs, safety.Check(rules, zones)...)
+}
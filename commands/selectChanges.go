@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/diff2"
+	"github.com/StackExchange/dnscontrol/v3/pkg/printer"
+)
+
+// This file implements "push --select": the record-level changes of
+// each provider are shown one at a time, and only those that are
+// accepted are made. Providers may bundle many changes in one
+// correction (or the entire zone, as BIND does), therefore the
+// corrections are not filtered: the rejected changes are undone in
+// the desired records, and the provider is asked for the corrections
+// again.
+
+// selectChanges asks sel which of changes to make. It returns the
+// rejected changes, by their MsgsJoined, which is unique within a
+// ChangeList made by diff2.ByRecord.
+func selectChanges(sel printer.ChangeSelector, changes diff2.ChangeList) map[string]bool {
+	var selectable diff2.ChangeList
+	for _, c := range changes {
+		if c.Type != diff2.REPORT {
+			selectable = append(selectable, c)
+		}
+	}
+	reports := changeReports(selectable)
+
+	rejected := map[string]bool{}
+	var all *printer.Selection // Set by AcceptAll and RejectAll.
+	for i, c := range selectable {
+		answer := printer.Accept
+		if all != nil {
+			answer = *all
+		} else {
+			answer = sel.SelectChange(i, len(selectable), reports[i])
+			if answer == printer.AcceptAll || answer == printer.RejectAll {
+				all = &answer
+			}
+		}
+		if answer == printer.Reject || answer == printer.RejectAll {
+			rejected[c.MsgsJoined] = true
+		}
+	}
+	return rejected
+}
+
+// undoChanges changes the records of dc, which must be punycoded, so
+// that the rejected changes (see selectChanges) are not made: the new
+// records of a rejected change are removed, and its old records are
+// kept. existing are the records of the zone. dc must be a copy of the
+// one the changes were made from (providerJob.desired), so that they
+// are found again; it is an error if one is not.
+func undoChanges(dc *models.DomainConfig, existing models.Records, rejected map[string]bool) error {
+	// The changes are found again, so that their new records are
+	// those of dc.
+	changes, err := diff2.ByRecord(comparableRecords(existing, dc), dc, nil)
+	if err != nil {
+		return err
+	}
+	drop := map[*models.RecordConfig]bool{}
+	var keep models.Records
+	found := 0
+	for _, c := range changes {
+		if !rejected[c.MsgsJoined] {
+			continue
+		}
+		found++
+		for _, rec := range c.New {
+			drop[rec] = true
+		}
+		keep = append(keep, c.Old...)
+	}
+
+	recs := models.Records{}
+	for _, rec := range dc.Records {
+		if !drop[rec] {
+			recs = append(recs, rec)
+		}
+	}
+	dc.Records = append(recs, keep...)
+	if found != len(rejected) {
+		return fmt.Errorf("%d rejected change(s) not found, the zone may have changed", len(rejected)-found)
+	}
+	return nil
+}
+
+// selectedCorrections asks out which changes of pj to make, and
+// returns the corrections that make only those, and the changes they
+// make. If all of them are accepted, they are pj.corrections and
+// pj.changes.
+func selectedCorrections(out printer.ChangeSelector, domain *models.DomainConfig, pj *providerJob) ([]*models.Correction, diff2.ChangeList, error) {
+	rejected := selectChanges(out, pj.changes)
+	if len(rejected) == 0 {
+		return pj.corrections, pj.changes, nil
+	}
+
+	provider, err := dnsProvider(domain, pj.name)
+	if err != nil {
+		return nil, nil, err
+	}
+	dc, err := pj.desired.Copy()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := undoChanges(dc, pj.existing, rejected); err != nil {
+		return nil, nil, err
+	}
+	corrections, err := provider.Driver.GetDomainCorrections(dc)
+	if err != nil {
+		return nil, nil, err
+	}
+	var changes diff2.ChangeList
+	for _, c := range pj.changes {
+		if !rejected[c.MsgsJoined] {
+			changes = append(changes, c)
+		}
+	}
+	return corrections, changes, nil
+}
@@ -0,0 +1,238 @@
+package commands
+
+import (
+	"bytes"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/audit"
+	"github.com/StackExchange/dnscontrol/v3/pkg/diff2"
+	"github.com/StackExchange/dnscontrol/v3/pkg/notifications"
+	"github.com/StackExchange/dnscontrol/v3/pkg/printer"
+	"github.com/stretchr/testify/assert"
+)
+
+// answers is a ChangeSelector that gives its answers in order.
+type answers []printer.Selection
+
+func (a *answers) SelectChange(n, total int, change *printer.ChangeReport) printer.Selection {
+	answer := (*a)[0]
+	*a = (*a)[1:]
+	return answer
+}
+
+func Test_selectChanges(t *testing.T) {
+	changes := diff2.ChangeList{
+		{Type: diff2.REPORT, MsgsJoined: "report"},
+		{Type: diff2.CREATE, MsgsJoined: "one"},
+		{Type: diff2.DELETE, MsgsJoined: "two"},
+		{Type: diff2.CHANGE, MsgsJoined: "three"},
+		{Type: diff2.CHANGE, MsgsJoined: "four"},
+	}
+	tests := []struct {
+		name    string
+		answers answers
+		want    map[string]bool
+	}{
+		{"each", answers{printer.Accept, printer.Reject, printer.Accept, printer.Reject}, map[string]bool{"two": true, "four": true}},
+		{"accept all", answers{printer.Reject, printer.AcceptAll}, map[string]bool{"one": true}},
+		{"reject all", answers{printer.Accept, printer.RejectAll}, map[string]bool{"two": true, "three": true, "four": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := tt.answers
+			assert.Equal(t, tt.want, selectChanges(&a, changes))
+			assert.Empty(t, a, "unused answers")
+		})
+	}
+}
+
+func Test_undoChanges(t *testing.T) {
+	existing := models.Records{
+		makeRec("@", "A", "1.2.3.4"),
+		makeRec("old", "A", "9.9.9.9"),
+		makeRec("www", "A", "1.2.3.4"),
+	}
+	desired := func() *models.DomainConfig {
+		return &models.DomainConfig{Name: "example.com", Records: models.Records{
+			makeRec("@", "A", "1.2.3.5"),
+			makeRec("new", "A", "5.5.5.5"),
+			makeRec("www", "A", "1.2.3.4"),
+		}}
+	}
+
+	// Reject the creation of new and the deletion of old; keep the
+	// change of @.
+	dc := desired()
+	changes, err := diff2.ByRecord(existing, dc, nil)
+	assert.NoError(t, err)
+	rejected := map[string]bool{}
+	for _, c := range changes {
+		if len(c.Old) == 0 || len(c.New) == 0 {
+			rejected[c.MsgsJoined] = true
+		}
+	}
+	assert.Len(t, rejected, 2)
+
+	dc = desired()
+	assert.NoError(t, undoChanges(dc, existing, rejected))
+	var got []string
+	for _, rec := range dc.Records {
+		got = append(got, rec.GetLabel()+" "+rec.GetTargetField())
+	}
+	sort.Strings(got)
+	assert.Equal(t, []string{"@ 1.2.3.5", "old 9.9.9.9", "www 1.2.3.4"}, got)
+
+	// Nothing is left to change but what was accepted.
+	changes, err = diff2.ByRecord(existing, dc, nil)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, diff2.CHANGE, changes[0].Type)
+}
+
+func Test_undoChanges_notFound(t *testing.T) {
+	existing := models.Records{makeRec("www", "A", "1.2.3.4")}
+	dc := &models.DomainConfig{Name: "example.com", Records: models.Records{makeRec("www", "A", "1.2.3.5")}}
+	err := undoChanges(dc, existing, map[string]bool{"± MODIFY www.example.com A (1.2.3.4) -> (1.2.3.6)": true})
+	assert.EqualError(t, err, "1 rejected change(s) not found, the zone may have changed")
+}
+
+// gatherSelect gathers the corrections and changes of the only
+// provider of domain, as push --select does.
+func gatherSelect(domain *models.DomainConfig) *providerJob {
+	pj := &providerJob{name: domain.DNSProviderInstances[0].Name}
+	dc, _ := domain.Copy()
+	j := &domainJob{domain: domain}
+	j.gatherProvider(pj, domain.DNSProviderInstances[0], dc, nil, PreviewArgs{SelectChanges: true}, providerLimiter{})
+	return pj
+}
+
+// minTTLZone is a fakeZone that raises the TTL of the records it
+// writes to 600, as some providers do.
+type minTTLZone struct{ fakeZone }
+
+func (z *minTTLZone) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correction, error) {
+	for _, rec := range dc.Records {
+		if rec.TTL < 600 {
+			rec.TTL = 600
+		}
+	}
+	return z.fakeZone.GetDomainCorrections(dc)
+}
+
+// Test_selectedCorrections_provider rejects a change whose records the
+// provider changed, which must not be made.
+func Test_selectedCorrections_provider(t *testing.T) {
+	www := makeRec("www", "A", "1.2.3.4")
+	www.TTL = 600
+	zone := &minTTLZone{fakeZone{records: models.Records{www}}}
+	domain := &models.DomainConfig{
+		Name:                 "example.com",
+		Records:              models.Records{makeRec("new", "A", "5.5.5.5"), makeRec("www", "A", "1.2.3.5")},
+		DNSProviderInstances: []*models.DNSProviderInstance{{ProviderBase: models.ProviderBase{Name: "ttl"}, Driver: zone}},
+	}
+	pj := gatherSelect(domain)
+	assert.NoError(t, pj.err)
+	assert.NoError(t, pj.changesErr)
+
+	var sel answers
+	for _, c := range pj.changes {
+		switch {
+		case c.Type == diff2.REPORT:
+		case c.Key.NameFQDN == "www.example.com":
+			sel = append(sel, printer.Reject)
+		default:
+			sel = append(sel, printer.Accept)
+		}
+	}
+	assert.Len(t, sel, 2)
+	corrections, _, err := selectedCorrections(&sel, domain, pj)
+	assert.NoError(t, err)
+	for _, c := range corrections {
+		assert.NoError(t, c.F())
+	}
+	assert.Equal(t, []string{"new 5.5.5.5", "www 1.2.3.4"}, zone.strings())
+}
+
+// wholeZone is a fakeZone that makes a single correction for all the
+// changes, as BIND does.
+type wholeZone struct{ fakeZone }
+
+func (z *wholeZone) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correction, error) {
+	corrections, err := z.fakeZone.GetDomainCorrections(dc)
+	if err != nil || len(corrections) < 2 {
+		return corrections, err
+	}
+	var msgs []string
+	for _, c := range corrections {
+		msgs = append(msgs, c.Msg)
+	}
+	return []*models.Correction{{
+		Msg: strings.Join(msgs, "\n"),
+		F: func() error {
+			for _, c := range corrections {
+				if err := c.F(); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}}, nil
+}
+
+func Test_selectedCorrections_audit(t *testing.T) {
+	zone := &wholeZone{fakeZone{records: models.Records{
+		makeRec("@", "A", "1.2.3.4"),
+		makeRec("old", "A", "9.9.9.9"),
+	}}}
+	domain := &models.DomainConfig{
+		Name: "example.com",
+		Records: models.Records{
+			makeRec("@", "A", "1.2.3.5"),
+			makeRec("new", "A", "5.5.5.5"),
+			makeRec("old", "A", "9.9.9.9"),
+		},
+		DNSProviderInstances: []*models.DNSProviderInstance{{ProviderBase: models.ProviderBase{Name: "fake"}, Driver: zone}},
+	}
+	pj := gatherSelect(domain)
+	assert.NoError(t, pj.err)
+	assert.NoError(t, pj.changesErr)
+	assert.Len(t, pj.corrections, 1)
+
+	// Accept the change of @, reject the creation of new.
+	var accepted string
+	sel := answers{}
+	for _, c := range pj.changes {
+		if c.Type == diff2.REPORT {
+			continue
+		}
+		if c.Type == diff2.CHANGE {
+			accepted = c.Key.NameFQDN
+			sel = append(sel, printer.Accept)
+		} else {
+			sel = append(sel, printer.Reject)
+		}
+	}
+	assert.Len(t, sel, 2)
+	corrections, changes, err := selectedCorrections(&sel, domain, pj)
+	assert.NoError(t, err)
+	assert.Len(t, corrections, 1)
+
+	file := filepath.Join(t.TempDir(), "audit.jsonl")
+	trail, err := newAuditTrail(AuditArgs{AuditLog: file}, "dnsconfig.js")
+	assert.NoError(t, err)
+	notifier, _ := notifications.Init(nil)
+	out := printer.ConsolePrinter{Writer: &bytes.Buffer{}}
+	assert.False(t, printOrRunCorrections(domain.Name, pj.name, corrections, changes, out, true, false, notifier, trail, false))
+	assert.NoError(t, trail.close())
+
+	entries, err := audit.Read(file, audit.Query{})
+	assert.NoError(t, err)
+	if assert.Len(t, entries, 1) && assert.Len(t, entries[0].Changes, 1) {
+		assert.Equal(t, accepted, entries[0].Changes[0].Name)
+	}
+	assert.Equal(t, []string{"@ 1.2.3.5", "old 9.9.9.9"}, zone.strings())
+}
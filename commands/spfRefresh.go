@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/StackExchange/dnscontrol/v3/pkg/normalize"
+	"github.com/StackExchange/dnscontrol/v3/pkg/spflib"
+	"github.com/urfave/cli/v2"
+)
+
+var _ = cmd(catMain, func() *cli.Command {
+	var args SPFRefreshArgs
+	return &cli.Command{
+		Name:  "spf-refresh",
+		Usage: "look up again everything that SPF flattening needs, and update the SPF cache. Do not access providers.",
+		Action: func(ctx *cli.Context) error {
+			return exit(SPFRefresh(args))
+		},
+		Flags: args.flags(),
+	}
+}())
+
+// SPFRefreshArgs contains all data/flags needed to run spf-refresh, independently of CLI
+type SPFRefreshArgs struct {
+	GetDNSConfigArgs
+	Check bool
+}
+
+func (args *SPFRefreshArgs) flags() []cli.Flag {
+	flags := args.GetDNSConfigArgs.flags()
+	flags = append(flags, &cli.BoolFlag{
+		Name:        "check",
+		Destination: &args.Check,
+		Usage:       `Do not update the cache: fail if it is out of date`,
+	})
+	return flags
+}
+
+// SPFRefresh implements the spf-refresh subcommand. Every lookup of
+// SPF flattening is made again, whether or not its TTL has expired,
+// and the cache is updated (--spf-cache-policy=auto-update), or, with
+// --check, the command fails if it is out of date
+// (--spf-cache-policy=fail).
+func SPFRefresh(args SPFRefreshArgs) error {
+	cfg, err := GetDNSConfig(args.GetDNSConfigArgs)
+	if err != nil {
+		return err
+	}
+	normalize.SPFCache.Refresh = true
+	normalize.SPFCache.Policy = spflib.PolicyAutoUpdate
+	if args.Check {
+		normalize.SPFCache.Policy = spflib.PolicyFail
+		normalize.SPFCache.Check = true
+	}
+	errs := normalize.ValidateAndNormalizeConfig(cfg)
+	if PrintValidationErrors(errs) {
+		return fmt.Errorf("exiting due to validation errors")
+	}
+	if args.Check {
+		fmt.Printf("%s is up to date\n", normalize.SPFCache.Filename())
+	} else {
+		fmt.Printf("Refreshed %s\n", normalize.SPFCache.Filename())
+	}
+	return nil
+}
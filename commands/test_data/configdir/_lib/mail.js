@@ -0,0 +1 @@
+var MAIL = [MX("@", 10, "mx.example.net.")];
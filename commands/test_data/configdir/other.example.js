@@ -0,0 +1 @@
+D("other.example", REG_NONE, DnsProvider(DSP_BIND), A("@", "9.9.9.9"));
@@ -0,0 +1,3 @@
+require("../_lib/mail.js");
+D("example.com", REG_NONE, DnsProvider(DSP_BIND), OWNER("web", "web@example.com"),
+  A("@", "1.2.3.4"), MAIL);
@@ -0,0 +1,7 @@
+// Written by dnscontrol convert-zone. require() it before the zones of
+// this directory; it runs only once.
+if (typeof DSP_CHANGEME === 'undefined') {
+	var DSP_CHANGEME = NewDnsProvider("changeme");
+	var REG_CHANGEME = NewRegistrar("none");
+	require("../macros.js");
+}
@@ -0,0 +1,12 @@
+D("one.example", REG_CHANGEME,
+	DnsProvider(DSP_CHANGEME),
+	//SOA('@', 'ns1.one.example.', 'hostmaster.one.example.', 1, 3600, 600, 604800, 300),
+	//NAMESERVER('ns1.dns.example.'),
+	MX('@', 10, 'mx1.mail.example.'),
+	MX('@', 20, 'mx2.mail.example.'),
+	TXT('@', 'v=spf1 include:_spf.mail.example -all'),
+	A('www', '192.0.2.1'),
+	A('host1', '192.0.2.1'),
+	A('host2', '192.0.2.2'),
+	A('host3', '192.0.2.3')
+)
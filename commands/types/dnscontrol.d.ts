@@ -371,6 +371,21 @@ declare function AZURE_ALIAS(name: string, type: "A" | "AAAA" | "CNAME", target:
  */
 declare function CAA(name: string, tag: "issue" | "issuewild" | "iodef", value: string, ...modifiers: RecordModifier[]): DomainModifier;
 
+/**
+ * `CERT` adds a `CERT` record ([RFC 4398](https://www.rfc-editor.org/rfc/rfc4398)) to a domain. The name should be the relative label for the record.
+ * 
+ * Type and algorithm may be a mnemonic (for example `PGP`) or an int. The certificate is base64.
+ * 
+ * ```javascript
+ * D("example.com", REGISTRAR, DnsProvider("BIND"),
+ *   CERT("cert", "PGP", 0, 0, "dGVzdA=="),
+ * );
+ * ```
+ * 
+ * @see https://dnscontrol.org/js#CERT
+ */
+declare function CERT(name: string, type: string | number, keytag: number, algorithm: string | number, certificate: string, ...modifiers: RecordModifier[]): DomainModifier;
+
 /**
  * `CF_REDIRECT` uses Cloudflare-specific features ("Forwarding URL" Page Rules) to
  * generate a HTTP 301 permanent redirect.
@@ -477,6 +492,21 @@ declare function CLOUDNS_WR(name: string, target: string, ...modifiers: RecordMo
  */
 declare function CNAME(name: string, target: string, ...modifiers: RecordModifier[]): DomainModifier;
 
+/**
+ * `DNAME` adds a `DNAME` record ([RFC 6672](https://www.rfc-editor.org/rfc/rfc6672)) to a domain. The name should be the relative label for the record. Every name below it is redirected to the same name below the target.
+ * 
+ * A target that is not a FQDN is relative to the domain, as with `CNAME`.
+ * 
+ * ```javascript
+ * D("example.com", REGISTRAR, DnsProvider("BIND"),
+ *   DNAME("old", "new.example.net."),
+ * );
+ * ```
+ * 
+ * @see https://dnscontrol.org/js#DNAME
+ */
+declare function DNAME(name: string, target: string, ...modifiers: RecordModifier[]): DomainModifier;
+
 /**
  * DS adds a DS record to the domain.
  * 
@@ -550,6 +580,43 @@ declare function DnsProvider(name: string, nsCount?: number): DomainModifier;
  */
 declare function FRAME(name: string, target: string, ...modifiers: RecordModifier[]): DomainModifier;
 
+/**
+ * `HINFO` adds a `HINFO` record to a domain. The name should be the relative label for the record.
+ * 
+ * The cpu and os are free text. They are quoted for you.
+ * 
+ * ```javascript
+ * D("example.com", REGISTRAR, DnsProvider("BIND"),
+ *   HINFO("host", "INTEL-386", "Windows NT"),
+ * );
+ * ```
+ * 
+ * @see https://dnscontrol.org/js#HINFO
+ */
+declare function HINFO(name: string, cpu: string, os: string, ...modifiers: RecordModifier[]): DomainModifier;
+
+/**
+ * `HTTPS` adds an `HTTPS` record to a domain. The name should be the relative label for the record.
+ * 
+ * An `HTTPS` record is a `SVCB` record for HTTPS origins ([RFC 9460](https://www.rfc-editor.org/rfc/rfc9460)). The parameters are the same as [`SVCB`](SVCB.md).
+ * 
+ * Priority is an int. Priority 0 is "AliasMode": the record points to another name and params must be empty. Any other priority is "ServiceMode".
+ * 
+ * A target of `"."` means the record's own name.
+ * 
+ * Params are the SvcParams as they would appear in a zonefile, separated by spaces (for example `alpn=h2,h3 port=8443`). Use `""` for none.
+ * 
+ * ```javascript
+ * D("example.com", REGISTRAR, DnsProvider("BIND"),
+ *   HTTPS("@", 1, ".", "alpn=h2,h3 ipv4hint=192.0.2.1"),
+ *   HTTPS("www", 0, "cdn.example.net.", ""),
+ * );
+ * ```
+ * 
+ * @see https://dnscontrol.org/js#HTTPS
+ */
+declare function HTTPS(name: string, priority: number, target: string, params: string, ...modifiers: RecordModifier[]): DomainModifier;
+
 /**
  * WARNING: The `IGNORE_*` family  of functions is risky to use. The code
  * is brittle and has subtle bugs. Use at your own risk. Do not use these
@@ -684,6 +751,21 @@ declare function IGNORE_TARGET(pattern: string, rType: string): DomainModifier;
  */
 declare function INCLUDE(domain: string): DomainModifier;
 
+/**
+ * `LOC` adds a `LOC` record ([RFC 1876](https://www.rfc-editor.org/rfc/rfc1876)) to a domain. The name should be the relative label for the record.
+ * 
+ * The location is written as in a zonefile: latitude, longitude, altitude and, optionally, size and precision.
+ * 
+ * ```javascript
+ * D("example.com", REGISTRAR, DnsProvider("BIND"),
+ *   LOC("@", "52 22 23.000 N 4 53 32.000 E -2.00m 0.00m 10000m 10m"),
+ * );
+ * ```
+ * 
+ * @see https://dnscontrol.org/js#LOC
+ */
+declare function LOC(name: string, location: string, ...modifiers: RecordModifier[]): DomainModifier;
+
 /**
  * MX adds an MX record to the domain.
  * 
@@ -1075,6 +1157,48 @@ declare function NS(name: string, target: string, ...modifiers: RecordModifier[]
  */
 declare function NS1_URLFWD(name: string, target: string, ...modifiers: RecordModifier[]): DomainModifier;
 
+/**
+ * `OPENPGPKEY` adds an `OPENPGPKEY` record ([RFC 7929](https://www.rfc-editor.org/rfc/rfc7929)) to a domain. The name should be the relative label for the record (the hashed local part of the address, then `._openpgpkey`).
+ * 
+ * The public key is base64.
+ * 
+ * ```javascript
+ * D("example.com", REGISTRAR, DnsProvider("BIND"),
+ *   OPENPGPKEY("c93f1e400f26708f98cb19d936620da35eec8f72e57f9eec01c1afd6._openpgpkey", "mQINBF..."),
+ * );
+ * ```
+ * 
+ * @see https://dnscontrol.org/js#OPENPGPKEY
+ */
+declare function OPENPGPKEY(name: string, publickey: string, ...modifiers: RecordModifier[]): DomainModifier;
+
+/**
+ * OWNER records which team is responsible for a domain, and optionally
+ * how to reach them. They are stored in the domain's metadata as `owner`
+ * and `contact`.
+ * 
+ * `preview` and `push` group the domains by owner, and
+ * `--owner=team1,team2` only runs the domains of those teams. Domains
+ * without an owner are never selected by `--owner`.
+ * 
+ * ```javascript
+ * D("example.com", REG_MY_PROVIDER, DnsProvider(DSP_MY_PROVIDER),
+ *     OWNER("web-team", "web-team@example.com"),
+ *     A("@", "10.2.3.4")
+ * );
+ * ```
+ * 
+ * ```shell
+ * dnscontrol preview --owner=web-team
+ * ```
+ * 
+ * OWNER is often used with a [configuration directory](../../config-dir.md),
+ * which has a file per zone.
+ * 
+ * @see https://dnscontrol.org/js#OWNER
+ */
+declare function OWNER(owner: string, contact?: string): DomainModifier;
+
 /**
  * PTR adds a PTR record to the domain.
  * 
@@ -1240,6 +1364,36 @@ declare const PURGE: DomainModifier;
  */
 declare function R53_ALIAS(name: string, target: string, zone_idModifier: DomainModifier & RecordModifier): DomainModifier;
 
+/**
+ * `RP` adds a `RP` (Responsible Person, [RFC 1183](https://www.rfc-editor.org/rfc/rfc1183)) record to a domain. The name should be the relative label for the record.
+ * 
+ * The mbox is a mailbox written as a name (`hostmaster.example.com.` for `hostmaster@example.com`). The txt is the name of a `TXT` record with more information, or `.` for none. Names that are not a FQDN are relative to the domain.
+ * 
+ * ```javascript
+ * D("example.com", REGISTRAR, DnsProvider("BIND"),
+ *   RP("@", "hostmaster.example.com.", "contact.example.com."),
+ * );
+ * ```
+ * 
+ * @see https://dnscontrol.org/js#RP
+ */
+declare function RP(name: string, mbox: string, txt: string, ...modifiers: RecordModifier[]): DomainModifier;
+
+/**
+ * `SMIMEA` adds a `SMIMEA` record ([RFC 8162](https://www.rfc-editor.org/rfc/rfc8162)) to a domain. The name should be the relative label for the record (the hashed local part of the address, then `._smimecert`).
+ * 
+ * Usage, selector, and matchingtype are ints, as in [`TLSA`](TLSA.md). The certificate is a hex string.
+ * 
+ * ```javascript
+ * D("example.com", REGISTRAR, DnsProvider("BIND"),
+ *   SMIMEA("c93f1e400f26708f98cb19d936620da35eec8f72e57f9eec01c1afd6._smimecert", 3, 0, 1, "abcdef0"),
+ * );
+ * ```
+ * 
+ * @see https://dnscontrol.org/js#SMIMEA
+ */
+declare function SMIMEA(name: string, usage: number, selector: number, matchingtype: number, certificate: string, ...modifiers: RecordModifier[]): DomainModifier;
+
 /**
  * `SOA` adds an `SOA` record to a domain. The name should be `@`.  ns and mbox are strings. The other fields are unsigned 32-bit ints.
  * 
@@ -1311,6 +1465,28 @@ declare function SRV(name: string, priority: number, weight: number, port: numbe
  */
 declare function SSHFP(name: string, algorithm: 0 | 1 | 2 | 3 | 4, type: 0 | 1 | 2, value: string, ...modifiers: RecordModifier[]): DomainModifier;
 
+/**
+ * `SVCB` adds a `SVCB` (Service Binding, [RFC 9460](https://www.rfc-editor.org/rfc/rfc9460)) record to a domain. The name should be the relative label for the record.
+ * 
+ * Priority is an int. Priority 0 is "AliasMode": the record points to another name and params must be empty. Any other priority is "ServiceMode".
+ * 
+ * A target of `"."` means the record's own name. Otherwise it is a hostname, which is made into a FQDN like the target of a `CNAME`.
+ * 
+ * Params are the SvcParams as they would appear in a zonefile, separated by spaces (for example `alpn=dot port=853`). Use `""` for none. Params are checked when the configuration is validated.
+ * 
+ * For HTTPS origins, use [`HTTPS`](HTTPS.md) instead.
+ * 
+ * ```javascript
+ * D("example.com", REGISTRAR, DnsProvider("BIND"),
+ *   SVCB("_dns", 1, "dns.example.com.", "alpn=dot port=853"),
+ *   SVCB("_8443._foo.api", 0, "svc4.example.net.", ""),
+ * );
+ * ```
+ * 
+ * @see https://dnscontrol.org/js#SVCB
+ */
+declare function SVCB(name: string, priority: number, target: string, params: string, ...modifiers: RecordModifier[]): DomainModifier;
+
 /**
  * TLSA adds a TLSA record to a domain. The name should be the relative label for the record.
  * 
@@ -1431,6 +1607,21 @@ declare function TLSA(name: string, usage: number, selector: number, type: numbe
  */
 declare function TXT(name: string, contents: string, ...modifiers: RecordModifier[]): DomainModifier;
 
+/**
+ * `URI` adds a `URI` record ([RFC 7553](https://www.rfc-editor.org/rfc/rfc7553)) to a domain. The name should be the relative label for the record.
+ * 
+ * Priority and weight are ints, as in [`SRV`](SRV.md). The target is a URI. It is quoted for you.
+ * 
+ * ```javascript
+ * D("example.com", REGISTRAR, DnsProvider("BIND"),
+ *   URI("_ftp._tcp", 10, 1, "ftp://ftp.example.com/public"),
+ * );
+ * ```
+ * 
+ * @see https://dnscontrol.org/js#URI
+ */
+declare function URI(name: string, priority: number, weight: number, target: string, ...modifiers: RecordModifier[]): DomainModifier;
+
 /**
  * Documentation needed.
  * 
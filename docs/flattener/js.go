@@ -6,6 +6,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"strings"
 
@@ -22,16 +23,24 @@ type gResp struct {
 	}
 }
 
-func (g gResolver) GetSPF(fqdn string) (string, error) {
-	resp, err := http.Get("https://dns.google.com/resolve?type=txt&name=" + fqdn)
+func (g gResolver) query(rtype, fqdn string) (*gResp, error) {
+	resp, err := http.Get("https://dns.google.com/resolve?type=" + rtype + "&name=" + fqdn)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	defer resp.Body.Close()
 	dec := json.NewDecoder(resp.Body)
 	dat := &gResp{}
 	if err = dec.Decode(dat); err != nil {
+		return nil, err
+	}
+	return dat, nil
+}
+
+func (g gResolver) GetSPF(fqdn string) (string, error) {
+	dat, err := g.query("txt", fqdn)
+	if err != nil {
 		return "", err
 	}
 	for _, a := range dat.Answer {
@@ -43,6 +52,45 @@ func (g gResolver) GetSPF(fqdn string) (string, error) {
 	return "", fmt.Errorf("No spf records found")
 }
 
+func (g gResolver) GetA(fqdn string) ([]net.IP, error) {
+	return g.getIPs("a", fqdn)
+}
+
+func (g gResolver) GetAAAA(fqdn string) ([]net.IP, error) {
+	return g.getIPs("aaaa", fqdn)
+}
+
+func (g gResolver) getIPs(rtype, fqdn string) ([]net.IP, error) {
+	dat, err := g.query(rtype, fqdn)
+	if err != nil {
+		return nil, err
+	}
+	var ips []net.IP
+	for _, a := range dat.Answer {
+		// The answer may include CNAMEs.
+		if ip := net.ParseIP(a.Data); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+	return ips, nil
+}
+
+func (g gResolver) GetMX(fqdn string) ([]string, error) {
+	dat, err := g.query("mx", fqdn)
+	if err != nil {
+		return nil, err
+	}
+	var hosts []string
+	for _, a := range dat.Answer {
+		// "10 mx.example.com."
+		f := strings.Fields(a.Data)
+		if len(f) == 2 && f[1] != "." {
+			hosts = append(hosts, strings.TrimSuffix(f[1], "."))
+		}
+	}
+	return hosts, nil
+}
+
 var jq = jquery.NewJQuery
 var parsed *spflib.SPFRecord
 var domain string
@@ -59,7 +107,7 @@ func main() {
 				if err != nil {
 					panic(err)
 				}
-				parsed, err = spflib.Parse(text, resolver)
+				parsed, err = spflib.ParseDomain(text, domain, resolver)
 				if err != nil {
 					panic(err)
 				}
@@ -143,7 +191,7 @@ func genRoot(rec *spflib.SPFRecord) string {
 }
 
 func genPart(rec *spflib.SPFPart) string {
-	if !rec.IsLookup {
+	if rec.IncludeRecord == nil {
 		return fmt.Sprintf(`<li>%s</li>`, rec.Text)
 	}
 	h := fmt.Sprintf(`<li>
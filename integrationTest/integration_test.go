@@ -27,6 +27,7 @@ var endIdx = flag.Int("end", -1, "Test index to stop after")
 var verbose = flag.Bool("verbose", false, "Print corrections as you run them")
 var printElapsed = flag.Bool("elapsed", false, "Print elapsed time for each testgroup")
 var enableCFWorkers = flag.Bool("cfworkers", true, "Set false to disable CF worker tests")
+var runLocal = flag.Bool("local", false, "Run AXFRDDNS against an embedded DNS server, or BIND in a temporary directory, instead of using providers.json")
 
 func init() {
 	testing.Init()
@@ -40,7 +41,15 @@ func getProvider(t *testing.T) (providers.DNSServiceProvider, string, map[int]bo
 		t.Log("No provider specified with -provider")
 		return nil, "", nil, nil
 	}
-	jsons, err := credsfile.LoadProviderConfigs("providers.json")
+	var jsons map[string]map[string]string
+	var err error
+	if *runLocal {
+		var cfg map[string]string
+		cfg, err = localProviderConfig(t, *providerToRun)
+		jsons = map[string]map[string]string{*providerToRun: cfg}
+	} else {
+		jsons, err = credsfile.LoadProviderConfigs("providers.json")
+	}
 	if err != nil {
 		t.Fatalf("Error loading provider configs: %s", err)
 	}
@@ -481,6 +490,28 @@ func sshfp(name string, algorithm uint8, fingerprint uint8, target string) *mode
 	return r
 }
 
+// rdata makes a record of a type that is stored as zonefile rdata.
+// Names in contents must be FQDNs.
+func rdata(name, typ, contents string) *models.RecordConfig {
+	r := makeRec(name, "", typ)
+	if err := r.SetTargetRdata(".", contents); err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func svcb(name string, priority uint16, target string, params string) *models.RecordConfig {
+	r := makeRec(name, target, "SVCB")
+	r.SetTargetSVCBStrings(fmt.Sprint(priority), target, params)
+	return r
+}
+
+func https(name string, priority uint16, target string, params string) *models.RecordConfig {
+	r := makeRec(name, target, "HTTPS")
+	r.SetTargetSVCBStrings(fmt.Sprint(priority), target, params)
+	return r
+}
+
 func txt(name, target string) *models.RecordConfig {
 	r := makeRec(name, "", "TXT")
 	r.SetTargetTXT(target)
@@ -1038,6 +1069,69 @@ func makeTests(t *testing.T) []*TestGroup {
 				sshfp("@", 2, 2, "745a635bc46a397a5c4f21d437483005bcc40d7511ff15fbfafe913a081559bc")),
 		),
 
+		testgroup("SVCB",
+			requires(providers.CanUseSVCB),
+			tc("SVCB record", svcb("_dns", 1, "dns.foo.com.", "alpn=dot port=853")),
+			tc("SVCB change priority", svcb("_dns", 2, "dns.foo.com.", "alpn=dot port=853")),
+			tc("SVCB change params", svcb("_dns", 2, "dns.foo.com.", "alpn=dot,doq port=853")),
+			tc("SVCB alias mode", svcb("_dns", 0, "other.foo.com.", "")),
+		),
+
+		testgroup("HTTPS",
+			requires(providers.CanUseSVCB),
+			tc("HTTPS record", https("@", 1, ".", "alpn=h2,h3")),
+			tc("HTTPS add hints", https("@", 1, ".", "alpn=h2,h3 ipv4hint=192.0.2.1")),
+			tc("HTTPS change target", https("@", 1, "www.foo.com.", "alpn=h2,h3 ipv4hint=192.0.2.1")),
+		),
+
+		testgroup("CERT",
+			requires(providers.CanUseCERT),
+			tc("CERT record", rdata("cert", "CERT", "PGP 0 0 dGVzdA==")),
+			tc("CERT change certificate", rdata("cert", "CERT", "PGP 0 0 Y2hhbmdlZA==")),
+		),
+
+		testgroup("DNAME",
+			requires(providers.CanUseDNAME),
+			tc("DNAME record", rdata("old", "DNAME", "example.net.")),
+			tc("DNAME change target", rdata("old", "DNAME", "example.org.")),
+		),
+
+		testgroup("HINFO",
+			requires(providers.CanUseHINFO),
+			tc("HINFO record", rdata("host", "HINFO", `"INTEL-386" "Windows NT"`)),
+			tc("HINFO change os", rdata("host", "HINFO", `"INTEL-386" "Linux"`)),
+		),
+
+		testgroup("LOC",
+			requires(providers.CanUseLOC),
+			tc("LOC record", rdata("@", "LOC", "52 22 23.000 N 4 53 32.000 E -2.00m 0.00m 10000m 10m")),
+			tc("LOC change altitude", rdata("@", "LOC", "52 22 23.000 N 4 53 32.000 E 10.00m 0.00m 10000m 10m")),
+		),
+
+		testgroup("OPENPGPKEY",
+			requires(providers.CanUseOPENPGPKEY),
+			tc("OPENPGPKEY record", rdata("key._openpgpkey", "OPENPGPKEY", "dGVzdA==")),
+			tc("OPENPGPKEY change key", rdata("key._openpgpkey", "OPENPGPKEY", "Y2hhbmdlZA==")),
+		),
+
+		testgroup("RP",
+			requires(providers.CanUseRP),
+			tc("RP record", rdata("@", "RP", "hostmaster.example.net. contact.example.net.")),
+			tc("RP change mbox", rdata("@", "RP", "admin.example.net. contact.example.net.")),
+		),
+
+		testgroup("SMIMEA",
+			requires(providers.CanUseSMIMEA),
+			tc("SMIMEA record", rdata("smime._smimecert", "SMIMEA", "3 0 1 "+sha256hash)),
+			tc("SMIMEA change usage", rdata("smime._smimecert", "SMIMEA", "2 0 1 "+sha256hash)),
+		),
+
+		testgroup("URI",
+			requires(providers.CanUseURI),
+			tc("URI record", rdata("_ftp._tcp", "URI", `10 1 "ftp://ftp.example.net/public"`)),
+			tc("URI change weight", rdata("_ftp._tcp", "URI", `10 2 "ftp://ftp.example.net/public"`)),
+		),
+
 		testgroup("TLSA",
 			requires(providers.CanUseTLSA),
 			tc("TLSA record", tlsa("_443._tcp", 3, 1, 1, sha256hash)),
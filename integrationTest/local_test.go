@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/v3/pkg/localdns"
+	"github.com/miekg/dns"
+)
+
+// localDomain is the zone used by the -local tests.
+const localDomain = "example.com"
+
+// localProviders are the providers that can run with -local.
+var localProviders = []string{"AXFRDDNS", "BIND", "MOCK"}
+
+// TestLocalProviders runs TestDNSProviders with -local for each of
+// localProviders, so that every build exercises the diff and
+// normalize code. It does nothing if -provider is set.
+func TestLocalProviders(t *testing.T) {
+	if *providerToRun != "" {
+		t.Skip("Skipping. -provider is set")
+	}
+	if testing.Short() {
+		t.Skip("Skipping in short mode")
+	}
+	defer func(p string, l bool) { *providerToRun, *runLocal = p, l }(*providerToRun, *runLocal)
+	*runLocal = true
+	for _, name := range localProviders {
+		*providerToRun = name
+		t.Run(name, TestDNSProviders)
+	}
+}
+
+// localProviderConfig returns the creds.json settings of a provider
+// that is run with -local. Any server is stopped at the end of the test.
+func localProviderConfig(t *testing.T, name string) (map[string]string, error) {
+	switch name {
+
+	case "AXFRDDNS":
+		const (
+			transferSecret = "dHJhbnNmZXItc2VjcmV0"
+			updateSecret   = "dXBkYXRlLXNlY3JldA=="
+		)
+		s := localdns.New()
+		s.TransferKey = &localdns.Key{Name: "transfer.", Algorithm: dns.HmacSHA256, Secret: transferSecret}
+		s.UpdateKey = &localdns.Key{Name: "update.", Algorithm: dns.HmacSHA256, Secret: updateSecret}
+		ns, err := dns.NewRR(fmt.Sprintf("%s. 300 IN NS ns.%s.", localDomain, localDomain))
+		if err != nil {
+			return nil, err
+		}
+		if err := s.AddZone(localDomain, ns); err != nil {
+			return nil, err
+		}
+		if err := s.Start(); err != nil {
+			return nil, err
+		}
+		t.Cleanup(func() { s.Shutdown() })
+		return map[string]string{
+			"domain":       localDomain,
+			"master":       s.Addr(),
+			"nameservers":  "ns." + localDomain,
+			"transfer-key": "hmac-sha256:transfer:" + transferSecret,
+			"update-key":   "hmac-sha256:update:" + updateSecret,
+		}, nil
+
+	case "BIND":
+		return map[string]string{
+			"domain":    localDomain,
+			"directory": t.TempDir(),
+		}, nil
+
+	case "MOCK":
+		return map[string]string{
+			"domain": localDomain,
+		}, nil
+
+	}
+	return nil, fmt.Errorf("provider %s can not be run with -local (only %v)", name, localProviders)
+}
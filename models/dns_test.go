@@ -47,6 +47,43 @@ func TestRR(t *testing.T) {
 	if found != expected {
 		t.Errorf("RR expected (%#v) got (%#v)\n", expected, found)
 	}
+
+	experiment = RecordConfig{
+		Type:        "HTTPS",
+		Name:        "@",
+		NameFQDN:    "example.com",
+		target:      ".",
+		TTL:         300,
+		SvcPriority: 1,
+		SvcParams:   "alpn=h2,h3 port=8443",
+	}
+	expected = "example.com.\t300\tIN\tHTTPS\t1 . alpn=\"h2,h3\" port=\"8443\""
+	found = experiment.ToRR().String()
+	if found != expected {
+		t.Errorf("RR expected (%#v) got (%#v)\n", expected, found)
+	}
+}
+
+func TestSVCBString(t *testing.T) {
+	rc := &RecordConfig{Type: "SVCB"}
+	if err := rc.SetTargetSVCBString("example.com", "1 svc alpn=dot port=853"); err != nil {
+		t.Fatal(err)
+	}
+	if rc.SvcPriority != 1 || rc.GetTargetField() != "svc.example.com." {
+		t.Errorf("got priority=%d target=%q", rc.SvcPriority, rc.GetTargetField())
+	}
+	// Round trip through the RR.
+	rr, err := RRtoRC(rc.ToRR(), "example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr.Type != "SVCB" || rr.SvcParams != rc.SvcParams {
+		t.Errorf("round trip: expected %q got %q", rc.SvcParams, rr.SvcParams)
+	}
+
+	if err := rc.SetTargetSVCBString("example.com", "1 . nosuchkey=1"); err == nil {
+		t.Errorf("expected an error for an unknown key")
+	}
 }
 
 func TestDowncase(t *testing.T) {
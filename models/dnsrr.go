@@ -76,6 +76,8 @@ func RRtoRC(rr dns.RR, origin string) (RecordConfig, error) {
 		err = rc.SetTarget(v.Target)
 	case *dns.DS:
 		err = rc.SetTargetDS(v.KeyTag, v.Algorithm, v.DigestType, v.Digest)
+	case *dns.HTTPS:
+		err = rc.SetTargetSVCB(v.Priority, v.Target, v.Value)
 	case *dns.MX:
 		err = rc.SetTargetMX(v.Preference, v.Mx)
 	case *dns.NS:
@@ -90,12 +92,17 @@ func RRtoRC(rr dns.RR, origin string) (RecordConfig, error) {
 		err = rc.SetTargetSRV(v.Priority, v.Weight, v.Port, v.Target)
 	case *dns.SSHFP:
 		err = rc.SetTargetSSHFP(v.Algorithm, v.Type, v.FingerPrint)
+	case *dns.SVCB:
+		err = rc.SetTargetSVCB(v.Priority, v.Target, v.Value)
 	case *dns.TLSA:
 		err = rc.SetTargetTLSA(v.Usage, v.Selector, v.MatchingType, v.Certificate)
 	case *dns.TXT:
 		err = rc.SetTargetTXTs(v.Txt)
 	default:
-		return *rc, fmt.Errorf("rrToRecord: Unimplemented zone record type=%s (%v)", rc.Type, rr)
+		if !IsRdataType(rc.Type) {
+			return *rc, fmt.Errorf("rrToRecord: Unimplemented zone record type=%s (%v)", rc.Type, rr)
+		}
+		err = rc.setTargetRdataRR(rr)
 	}
 	if err != nil {
 		return *rc, fmt.Errorf("unparsable record received: %w", err)
@@ -2,6 +2,7 @@ package models
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/qdm12/reprint"
 	"golang.org/x/net/idna"
@@ -38,6 +39,18 @@ type DomainConfig struct {
 	DNSProviderInstances []*DNSProviderInstance `json:"-"`
 }
 
+// The keys of DomainConfig.Metadata that say who is responsible for a
+// domain (see OWNER()).
+const (
+	DomainOwner   = "owner"   // The team that owns the domain.
+	DomainContact = "contact" // How to reach them.
+)
+
+// Owner returns the team that owns the domain, or "".
+func (dc *DomainConfig) Owner() string {
+	return dc.Metadata[DomainOwner]
+}
+
 // Copy returns a deep copy of the DomainConfig.
 func (dc *DomainConfig) Copy() (*DomainConfig, error) {
 	newDc := &DomainConfig{}
@@ -82,8 +95,21 @@ func (dc *DomainConfig) Punycode() error {
 		rec.SetLabelFromFQDN(t, dc.Name)
 
 		// Set the target:
+		if rt, ok := GetRdataType(rec.Type); ok {
+			if rt.Hostnames {
+				// Each field is a hostname.
+				fields := strings.Fields(rec.GetTargetField())
+				for i := range fields {
+					if fields[i], err = idna.ToASCII(fields[i]); err != nil {
+						return err
+					}
+				}
+				rec.SetTarget(strings.Join(fields, " "))
+			}
+			continue
+		}
 		switch rec.Type { // #rtype_variations
-		case "ALIAS", "MX", "NS", "CNAME", "PTR", "SRV", "URL", "URL301", "FRAME", "R53_ALIAS", "NS1_URLFWD", "AKAMAICDN", "CLOUDNS_WR":
+		case "ALIAS", "MX", "NS", "CNAME", "PTR", "SRV", "HTTPS", "SVCB", "URL", "URL301", "FRAME", "R53_ALIAS", "NS1_URLFWD", "AKAMAICDN", "CLOUDNS_WR":
 			// These rtypes are hostnames, therefore need to be converted (unlike, for example, an AAAA record)
 			t, err := idna.ToASCII(rec.GetTargetField())
 			if err != nil {
@@ -21,16 +21,26 @@ import (
 //	  AAAA
 //	  ANAME  // Technically not an official rtype yet.
 //	  CAA
+//	  CERT
 //	  CNAME
+//	  DNAME
+//	  HINFO
+//	  HTTPS
+//	  LOC
 //	  MX
 //	  NAPTR
 //	  NS
+//	  OPENPGPKEY
 //	  PTR
+//	  RP
+//	  SMIMEA
 //	  SOA
 //	  SRV
 //	  SSHFP
+//	  SVCB
 //	  TLSA
 //	  TXT
+//	  URI
 //	Pseudo-Types: (alphabetical)
 //	  ALIAS
 //	  CF_REDIRECT
@@ -91,6 +101,7 @@ type RecordConfig struct {
 	TTL       uint32            `json:"ttl,omitempty"`
 	Metadata  map[string]string `json:"meta,omitempty"`
 	Original  interface{}       `json:"-"` // Store pointer to provider-specific record object. Used in diffing.
+	Source    string            `json:"-"` // Where the record is defined in dnsconfig.js ("file:line"), if known.
 
 	// If you add a field to this struct, also add it to the list on MarshalJSON.
 	MxPreference     uint16            `json:"mxpreference,omitempty"`
@@ -116,6 +127,8 @@ type RecordConfig struct {
 	SoaRetry         uint32            `json:"soaretry,omitempty"`
 	SoaExpire        uint32            `json:"soaexpire,omitempty"`
 	SoaMinttl        uint32            `json:"soaminttl,omitempty"`
+	SvcPriority      uint16            `json:"svcpriority,omitempty"`
+	SvcParams        string            `json:"svcparams,omitempty"`
 	TlsaUsage        uint8             `json:"tlsausage,omitempty"`
 	TlsaSelector     uint8             `json:"tlsaselector,omitempty"`
 	TlsaMatchingType uint8             `json:"tlsamatchingtype,omitempty"`
@@ -152,7 +165,8 @@ func (rc *RecordConfig) UnmarshalJSON(b []byte) error {
 		target    string            // If a name, must end with "."
 		TTL       uint32            `json:"ttl,omitempty"`
 		Metadata  map[string]string `json:"meta,omitempty"`
-		Original  interface{}       `json:"-"` // Store pointer to provider-specific record object. Used in diffing.
+		Original  interface{}       `json:"-"`                 // Store pointer to provider-specific record object. Used in diffing.
+		Source    string            `json:"_source,omitempty"` // Set by helpers.js. Not part of the IR.
 
 		MxPreference     uint16            `json:"mxpreference,omitempty"`
 		SrvPriority      uint16            `json:"srvpriority,omitempty"`
@@ -177,6 +191,8 @@ func (rc *RecordConfig) UnmarshalJSON(b []byte) error {
 		SoaRetry         uint32            `json:"soaretry,omitempty"`
 		SoaExpire        uint32            `json:"soaexpire,omitempty"`
 		SoaMinttl        uint32            `json:"soaminttl,omitempty"`
+		SvcPriority      uint16            `json:"svcpriority,omitempty"`
+		SvcParams        string            `json:"svcparams,omitempty"`
 		TlsaUsage        uint8             `json:"tlsausage,omitempty"`
 		TlsaSelector     uint8             `json:"tlsaselector,omitempty"`
 		TlsaMatchingType uint8             `json:"tlsamatchingtype,omitempty"`
@@ -212,6 +228,15 @@ func (rc *RecordConfig) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// WithSource returns err prefixed with the place in dnsconfig.js where
+// the record is defined, if it is known.
+func (rc *RecordConfig) WithSource(err error) error {
+	if err == nil || rc.Source == "" {
+		return err
+	}
+	return fmt.Errorf("%s: %w", rc.Source, err)
+}
+
 // Copy returns a deep copy of a RecordConfig.
 func (rc *RecordConfig) Copy() (*RecordConfig, error) {
 	newR := &RecordConfig{}
@@ -351,6 +376,19 @@ func (rc *RecordConfig) ToRR() dns.RR {
 		log.Fatalf("No such DNS type as (%#v)\n", rc.Type)
 	}
 
+	// These types are stored as zonefile rdata. Let miekg/dns parse it.
+	if IsRdataType(rc.Type) {
+		ttl := rc.TTL
+		if ttl == 0 {
+			ttl = DefaultTTL
+		}
+		rr, err := rc.rdataToRR(ttl)
+		if err != nil {
+			log.Fatalf("ToRR: invalid %s rdata (%q): %v\n", rc.Type, rc.target, err)
+		}
+		return rr
+	}
+
 	// Magically create an RR of the correct type.
 	rr := dns.TypeToRR[rdtype]()
 
@@ -411,6 +449,22 @@ func (rc *RecordConfig) ToRR() dns.RR {
 		rr.(*dns.CAA).Flag = rc.CaaFlag
 		rr.(*dns.CAA).Tag = rc.CaaTag
 		rr.(*dns.CAA).Value = rc.GetTargetField()
+	case dns.TypeHTTPS:
+		rr.(*dns.HTTPS).Priority = rc.SvcPriority
+		rr.(*dns.HTTPS).Target = rc.GetTargetField()
+		value, err := rc.GetSVCBValue()
+		if err != nil {
+			log.Fatalf("ToRR: invalid %s params (%q): %v\n", rc.Type, rc.SvcParams, err)
+		}
+		rr.(*dns.HTTPS).Value = value
+	case dns.TypeSVCB:
+		rr.(*dns.SVCB).Priority = rc.SvcPriority
+		rr.(*dns.SVCB).Target = rc.GetTargetField()
+		value, err := rc.GetSVCBValue()
+		if err != nil {
+			log.Fatalf("ToRR: invalid %s params (%q): %v\n", rc.Type, rc.SvcParams, err)
+		}
+		rr.(*dns.SVCB).Value = value
 	case dns.TypeTLSA:
 		rr.(*dns.TLSA).Usage = rc.TlsaUsage
 		rr.(*dns.TLSA).MatchingType = rc.TlsaMatchingType
@@ -538,8 +592,14 @@ func downcase(recs []*RecordConfig) {
 	for _, r := range recs {
 		r.Name = strings.ToLower(r.Name)
 		r.NameFQDN = strings.ToLower(r.NameFQDN)
+		if t, ok := GetRdataType(r.Type); ok {
+			if t.Hostnames {
+				r.target = strings.ToLower(r.target)
+			}
+			continue
+		}
 		switch r.Type { // #rtype_variations
-		case "ANAME", "CNAME", "DS", "MX", "NS", "PTR", "NAPTR", "SRV", "TLSA", "AKAMAICDN":
+		case "ANAME", "CNAME", "DS", "HTTPS", "MX", "NS", "PTR", "NAPTR", "SRV", "SVCB", "TLSA", "AKAMAICDN":
 			// These record types have a target that is case insensitive, so we downcase it.
 			r.target = strings.ToLower(r.target)
 		case "A", "AAAA", "ALIAS", "CAA", "IMPORT_TRANSFORM", "TXT", "SSHFP", "CF_REDIRECT", "CF_TEMP_REDIRECT", "CF_WORKER_ROUTE":
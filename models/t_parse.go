@@ -55,6 +55,8 @@ func (rc *RecordConfig) PopulateFromString(rtype, contents, origin string) error
 		return rc.SetTargetCAAString(contents)
 	case "DS":
 		return rc.SetTargetDSString(contents)
+	case "HTTPS", "SVCB":
+		return rc.SetTargetSVCBString(origin, contents)
 	case "MX":
 		return rc.SetTargetMXString(contents)
 	case "NAPTR":
@@ -70,6 +72,9 @@ func (rc *RecordConfig) PopulateFromString(rtype, contents, origin string) error
 	case "TLSA":
 		return rc.SetTargetTLSAString(contents)
 	default:
+		if IsRdataType(rtype) {
+			return rc.SetTargetRdata(origin, contents)
+		}
 		return fmt.Errorf("unknown rtype (%s) when parsing (%s) domain=(%s)",
 			rtype, contents, origin)
 	}
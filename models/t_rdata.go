@@ -0,0 +1,83 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// RdataType describes an rtype that has no fields of its own in
+// RecordConfig. The entire rdata is stored in .target, in the format
+// used in zonefiles, and is parsed and generated by miekg/dns.
+//
+// Supporting another rtype that miekg/dns understands only requires an
+// entry in rdataTypes (plus a builder in helpers.js, a capability and
+// the docs).
+type RdataType struct {
+	// Fields are the names of the fields of the rdata, in order. They
+	// are the arguments of the builder in dnsconfig.js. The last field
+	// receives the remainder of the rdata.
+	Fields []string
+	// Hostnames is true if every field is a hostname. They are
+	// downcased and converted to punycode.
+	Hostnames bool
+}
+
+var rdataTypes = map[string]RdataType{
+	"CERT":       {Fields: []string{"type", "keytag", "algorithm", "certificate"}},
+	"DNAME":      {Fields: []string{"target"}, Hostnames: true},
+	"HINFO":      {Fields: []string{"cpu", "os"}},
+	"LOC":        {Fields: []string{"location"}},
+	"OPENPGPKEY": {Fields: []string{"publickey"}},
+	"RP":         {Fields: []string{"mbox", "txt"}, Hostnames: true},
+	"SMIMEA":     {Fields: []string{"usage", "selector", "matchingtype", "certificate"}},
+	"URI":        {Fields: []string{"priority", "weight", "target"}},
+}
+
+// GetRdataType returns the description of rtype, if it is stored as
+// zonefile rdata.
+func GetRdataType(rtype string) (RdataType, bool) {
+	t, ok := rdataTypes[rtype]
+	return t, ok
+}
+
+// IsRdataType returns true if rtype is stored as zonefile rdata.
+func IsRdataType(rtype string) bool {
+	_, ok := rdataTypes[rtype]
+	return ok
+}
+
+// SetTargetRdata sets the target of a record whose type is stored as
+// zonefile rdata (for example `52 22 23.000 N 4 53 32.000 E -2.00m`
+// for a LOC record). The rdata is parsed and stored in its canonical
+// form. The origin is needed to parse names that are not a FQDN.
+func (rc *RecordConfig) SetTargetRdata(origin, contents string) error {
+	if !IsRdataType(rc.Type) {
+		panic("assertion failed: SetTargetRdata called when .Type is " + rc.Type)
+	}
+	rr, err := dns.NewRR(fmt.Sprintf("$ORIGIN %s\n@ %s %s", dns.Fqdn(origin), rc.Type, contents))
+	if err != nil {
+		return fmt.Errorf("%s value (%s) is invalid: %w", rc.Type, contents, err)
+	}
+	if rr == nil {
+		return fmt.Errorf("%s value is empty", rc.Type)
+	}
+	return rc.setTargetRdataRR(rr)
+}
+
+// setTargetRdataRR sets the target to the rdata of rr.
+func (rc *RecordConfig) setTargetRdataRR(rr dns.RR) error {
+	header := rr.Header().String()
+	full := rr.String()
+	if !strings.HasPrefix(full, header) {
+		panic("assertion failed. dns.Hdr.String() behavior has changed in an incompatible way")
+	}
+	return rc.SetTarget(full[len(header):])
+}
+
+// rdataToRR returns the dns.RR of a record whose type is stored as
+// zonefile rdata.
+func (rc *RecordConfig) rdataToRR(ttl uint32) (dns.RR, error) {
+	return dns.NewRR(fmt.Sprintf("%s. %d IN %s %s", rc.NameFQDN, ttl, rc.Type, rc.target))
+}
@@ -0,0 +1,59 @@
+package models
+
+import "testing"
+
+func TestSetTargetRdata(t *testing.T) {
+	tests := []struct {
+		rtype    string
+		contents string
+		want     string
+	}{
+		{"CERT", "PGP 0 0 dGVzdA==", "PGP 0 0 dGVzdA=="},
+		{"DNAME", "new", "new.example.com."},
+		{"HINFO", `"INTEL-386" "Windows NT"`, `"INTEL-386" "Windows NT"`},
+		{"LOC", "52 22 23 N 4 53 32 E -2m", "52 22 23.000 N 04 53 32.000 E -2m 1m 10000m 10m"},
+		{"OPENPGPKEY", "dGVz dA==", "dGVzdA=="},
+		{"RP", "hostmaster contact.example.org.", "hostmaster.example.com. contact.example.org."},
+		{"SMIMEA", "3 0 1 abcdef", "3 0 1 abcdef"},
+		{"URI", `10 1 "ftp://ftp.example.com/"`, `10 1 "ftp://ftp.example.com/"`},
+	}
+	for _, tst := range tests {
+		t.Run(tst.rtype, func(t *testing.T) {
+			rc := &RecordConfig{Type: tst.rtype}
+			rc.SetLabel("foo", "example.com")
+			if err := rc.SetTargetRdata("example.com", tst.contents); err != nil {
+				t.Fatal(err)
+			}
+			if got := rc.GetTargetField(); got != tst.want {
+				t.Errorf("got %q, want %q", got, tst.want)
+			}
+
+			// It survives a round trip through a dns.RR.
+			rr, err := RRtoRC(rc.ToRR(), "example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rr.Type != tst.rtype || rr.GetTargetField() != tst.want {
+				t.Errorf("round trip: got %s %q, want %s %q", rr.Type, rr.GetTargetField(), tst.rtype, tst.want)
+			}
+
+			// The generic parser accepts it too.
+			pc := &RecordConfig{}
+			if err := pc.PopulateFromString(tst.rtype, tst.contents, "example.com"); err != nil {
+				t.Fatal(err)
+			}
+			if pc.GetTargetField() != tst.want {
+				t.Errorf("PopulateFromString: got %q, want %q", pc.GetTargetField(), tst.want)
+			}
+		})
+	}
+}
+
+func TestSetTargetRdata_invalid(t *testing.T) {
+	for _, rtype := range []string{"LOC", "URI", "SMIMEA"} {
+		rc := &RecordConfig{Type: rtype}
+		if err := rc.SetTargetRdata("example.com", "not valid"); err == nil {
+			t.Errorf("%s: expected an error", rtype)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+// SetTargetSVCB sets the SVCB fields. It is used for both SVCB and HTTPS records.
+func (rc *RecordConfig) SetTargetSVCB(priority uint16, target string, params []dns.SVCBKeyValue) error {
+	rc.SvcPriority = priority
+	rc.SetTarget(target)
+	rc.SvcParams = svcbParamsString(params)
+	if rc.Type == "" {
+		rc.Type = "SVCB"
+	}
+	if rc.Type != "SVCB" && rc.Type != "HTTPS" {
+		panic("assertion failed: SetTargetSVCB called when .Type is not SVCB or HTTPS")
+	}
+	return nil
+}
+
+// SetTargetSVCBString is like SetTargetSVCB but accepts one big string
+// (for example `1 . alpn=h2,h3 port=443`). The origin is needed to
+// parse a target that is not a FQDN.
+func (rc *RecordConfig) SetTargetSVCBString(origin, contents string) error {
+	if rc.Type == "" {
+		rc.Type = "SVCB"
+	}
+	rr, err := dns.NewRR(fmt.Sprintf("$ORIGIN %s\n@ %s %s", dns.Fqdn(origin), rc.Type, contents))
+	if err != nil {
+		return fmt.Errorf("%s value (%s) is invalid: %w", rc.Type, contents, err)
+	}
+	switch v := rr.(type) {
+	case *dns.HTTPS:
+		return rc.SetTargetSVCB(v.Priority, v.Target, v.Value)
+	case *dns.SVCB:
+		return rc.SetTargetSVCB(v.Priority, v.Target, v.Value)
+	}
+	return fmt.Errorf("%s value (%s) is not a SVCB or HTTPS record", rc.Type, contents)
+}
+
+// SetTargetSVCBStrings is like SetTargetSVCB but accepts strings.
+func (rc *RecordConfig) SetTargetSVCBStrings(priority, target, params string) error {
+	u16priority, err := strconv.ParseUint(priority, 10, 16)
+	if err != nil {
+		return fmt.Errorf("%s priority '%v' is invalid: %w", rc.Type, priority, err)
+	}
+	rc.SvcPriority = uint16(u16priority)
+	rc.SetTarget(target)
+	rc.SvcParams = params
+	_, err = rc.GetSVCBValue()
+	return err
+}
+
+// GetSVCBValue returns the SvcParams as a list of key/value pairs.
+func (rc *RecordConfig) GetSVCBValue() ([]dns.SVCBKeyValue, error) {
+	if rc.SvcParams == "" {
+		return nil, nil
+	}
+	// Let miekg/dns do the hard work of parsing the SvcParams.
+	rr, err := dns.NewRR(fmt.Sprintf(". SVCB %d . %s", rc.SvcPriority, rc.SvcParams))
+	if err != nil {
+		return nil, fmt.Errorf("SvcParams (%s) are invalid: %w", rc.SvcParams, err)
+	}
+	return rr.(*dns.SVCB).Value, nil
+}
+
+// svcbParamsString returns the SvcParams in the zonefile format.
+func svcbParamsString(params []dns.SVCBKeyValue) string {
+	parts := make([]string, 0, len(params))
+	for _, kv := range params {
+		parts = append(parts, fmt.Sprintf("%s=%s", kv.Key(), kv.String()))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,191 @@
+// Package audit keeps a trail of the corrections that push runs: an
+// append-only log in the JSON Lines format, one entry per correction.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/diff2"
+)
+
+// Entry is a correction that was run.
+type Entry struct {
+	Time       time.Time `json:"time"`
+	Commit     string    `json:"commit,omitempty"` // Of the configuration, if it is in git.
+	User       string    `json:"user"`
+	Domain     string    `json:"domain"`
+	Provider   string    `json:"provider"`
+	Correction string    `json:"correction"`        // The message of the correction.
+	Changes    []*Change `json:"changes,omitempty"` // The record changes that it makes, if known.
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+	Rollback   bool      `json:"rollback,omitempty"` // Whether it restores the records after a correction failed.
+}
+
+// Change is a change of the records of a name and type.
+type Change struct {
+	Verb string    `json:"verb"` // CREATE, CHANGE or DELETE.
+	Name string    `json:"name"`
+	Type string    `json:"type"`
+	Old  []*Record `json:"old,omitempty"`
+	New  []*Record `json:"new,omitempty"`
+}
+
+// Record is the value of a record.
+type Record struct {
+	TTL    uint32 `json:"ttl"`
+	Target string `json:"target"`
+}
+
+// NewChange converts a change of diff2.
+func NewChange(c diff2.Change) *Change {
+	return &Change{
+		Verb: c.Type.String(),
+		Name: c.Key.NameFQDN,
+		Type: c.Key.Type,
+		Old:  records(c.Old),
+		New:  records(c.New),
+	}
+}
+
+func records(recs models.Records) []*Record {
+	var rs []*Record
+	for _, r := range recs {
+		rs = append(rs, &Record{TTL: r.TTL, Target: r.GetTargetCombined()})
+	}
+	return rs
+}
+
+// Log is an audit log open for appending.
+type Log struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// Open opens the log in filename, which it creates if it does not exist.
+func Open(filename string) (*Log, error) {
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return nil, err
+	}
+	return &Log{f: f}, nil
+}
+
+// Append writes e at the end of the log, and syncs it to disk.
+func (l *Log) Append(e *Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// A single write, so that concurrent runs do not mix their lines.
+	if _, err := l.f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return l.f.Sync()
+}
+
+// Close closes the log.
+func (l *Log) Close() error {
+	return l.f.Close()
+}
+
+// Query selects entries of the log. Its zero value selects them all.
+type Query struct {
+	Domain string    // If set, only the entries of this domain.
+	Since  time.Time // If set, only the entries at or after this time.
+	Until  time.Time // If set, only the entries before this time.
+}
+
+// Match returns whether q selects e.
+func (q Query) Match(e *Entry) bool {
+	switch {
+	case q.Domain != "" && !strings.EqualFold(strings.TrimSuffix(q.Domain, "."), e.Domain):
+		return false
+	case !q.Since.IsZero() && e.Time.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && !e.Time.Before(q.Until):
+		return false
+	}
+	return true
+}
+
+// Read returns the entries of the log in filename that q selects, in
+// the order they were appended.
+func Read(filename string, q Query) ([]*Entry, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var entries []*Entry
+	r := bufio.NewReader(f)
+	for n := 1; ; n++ {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// A last line without a newline is a write that did not
+			// complete: it has no entry.
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		e := &Entry{}
+		if err := json.Unmarshal(line, e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", filename, n, err)
+		}
+		if q.Match(e) {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// Commit returns the git commit that the file or directory path is at,
+// with "-dirty" if it has changes that are not committed, or "" if it
+// is not in a git repository.
+func Commit(path string) string {
+	dir, base := path, "."
+	if fi, err := os.Stat(path); err != nil || !fi.IsDir() {
+		dir, base = filepath.Split(path)
+		if dir == "" {
+			dir = "."
+		}
+	}
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	commit := strings.TrimSpace(string(out))
+	out, err = exec.Command("git", "-C", dir, "status", "--porcelain", "--", base).Output()
+	if err == nil && len(strings.TrimSpace(string(out))) != 0 {
+		commit += "-dirty"
+	}
+	return commit
+}
+
+// User returns the name of the user that runs DNSControl.
+func User() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if u := os.Getenv("USER"); u != "" {
+		return u
+	}
+	return os.Getenv("USERNAME")
+}
@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func day(d int) time.Time {
+	return time.Date(2023, 4, d, 12, 0, 0, 0, time.UTC)
+}
+
+func TestLog(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit.jsonl")
+	for i, domain := range []string{"example.com", "example.org", "example.com"} {
+		// Every run appends to the log.
+		l, err := Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Append(&Entry{Time: day(i + 1), Domain: domain, Correction: "run " + string(rune('1'+i)), Success: true}); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		q    Query
+		want string
+	}{
+		{"all", Query{}, "run 1,run 2,run 3"},
+		{"domain", Query{Domain: "Example.COM."}, "run 1,run 3"},
+		{"since", Query{Since: day(2)}, "run 2,run 3"},
+		{"until", Query{Until: day(2)}, "run 1"},
+		{"range", Query{Domain: "example.com", Since: day(1).Add(time.Second), Until: day(4)}, "run 3"},
+		{"none", Query{Domain: "example.net"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := Read(file, tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Correction)
+			}
+			if strings.Join(got, ",") != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadDamaged(t *testing.T) {
+	dir := t.TempDir()
+	good := `{"time":"2023-04-01T12:00:00Z","domain":"example.com","correction":"ok","success":true}` + "\n"
+
+	// A run that crashed while writing its last entry.
+	file := filepath.Join(dir, "truncated.jsonl")
+	os.WriteFile(file, []byte(good+`{"time":"2023-04-01T12:00:01Z","dom`), 0600)
+	entries, err := Read(file, Query{})
+	if err != nil || len(entries) != 1 {
+		t.Errorf("got %d entries, %v; want 1", len(entries), err)
+	}
+
+	file = filepath.Join(dir, "corrupt.jsonl")
+	os.WriteFile(file, []byte(good+"\n"+"not json\n"+good), 0600)
+	if _, err := Read(file, Query{}); err == nil || !strings.Contains(err.Error(), "corrupt.jsonl:3:") {
+		t.Errorf("got error %v, want one at line 3", err)
+	}
+}
+
+func TestCommit(t *testing.T) {
+	if c := Commit(filepath.Join(t.TempDir(), "dnsconfig.js")); c != "" {
+		t.Errorf("got commit %q outside of a repository", c)
+	}
+}
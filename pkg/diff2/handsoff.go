@@ -147,6 +147,17 @@ func handsoff(
 	return desired, msgs, nil
 }
 
+// Unmanaged returns the existing records that dc does not manage: those
+// matched by UNMANAGED/IGNORE_* (ignored) and, if NO_PURGE is set, those
+// that are not in dc (foreign). They are never changed by a By*() call.
+func Unmanaged(existing models.Records, dc *models.DomainConfig) (ignored, foreign models.Records, err error) {
+	if err := compileUnmanagedConfigs(dc.Unmanaged); err != nil {
+		return nil, nil, err
+	}
+	ignored, foreign = processIgnoreAndNoPurge(dc.Name, existing, dc.Records, dc.EnsureAbsent, dc.Unmanaged, dc.KeepUnknown)
+	return ignored, foreign, nil
+}
+
 // processIgnoreAndNoPurge processes the IGNORE_*()/UNMANAGED() and NO_PURGE/ENSURE_ABSENT_REC() features.
 func processIgnoreAndNoPurge(domain string, existing, desired, absences models.Records, unmanagedConfigs []*models.UnmanagedConfig, noPurge bool) (models.Records, models.Records) {
 	var ignorable, foreign models.Records
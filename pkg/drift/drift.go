@@ -0,0 +1,113 @@
+// Package drift finds the differences between the records of a zone at
+// a provider and the records in the configuration, for example records
+// edited in a provider's web console.
+//
+// Unlike a preview, it only reads the zone: no corrections are made,
+// so it is safe to use with read-only credentials.
+package drift
+
+import (
+	"sort"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/diff2"
+)
+
+// Class is the kind of a difference.
+type Class string
+
+// The classes of differences.
+const (
+	Missing  Class = "missing"  // In the configuration, not at the provider.
+	Extra    Class = "extra"    // At the provider, not in the configuration.
+	Modified Class = "modified" // At both, with a different value or TTL.
+	Ignored  Class = "ignored"  // At the provider, not managed (IGNORE_*, UNMANAGED or NO_PURGE).
+)
+
+// Classes lists the classes in the order they are reported.
+var Classes = []Class{Missing, Extra, Modified, Ignored}
+
+// Value is the value of a record.
+type Value struct {
+	TTL    uint32 `json:"ttl"`
+	Target string `json:"target"` // As in a zonefile.
+}
+
+func value(rc *models.RecordConfig) *Value {
+	return &Value{TTL: rc.TTL, Target: rc.GetTargetCombined()}
+}
+
+// Record is a difference in one record.
+type Record struct {
+	Class    Class  `json:"class"`
+	Name     string `json:"name"` // FQDN, without the trailing dot.
+	Type     string `json:"type"`
+	Existing *Value `json:"existing,omitempty"` // Not set if Missing.
+	Desired  *Value `json:"desired,omitempty"`  // Only set if Missing or Modified.
+}
+
+// Detect returns the differences between the existing records of the
+// zone and the records of dc, sorted by name and type. Both must be
+// normalized (see models.PostProcessRecords and dc.Punycode).
+func Detect(existing models.Records, dc *models.DomainConfig) ([]*Record, error) {
+	var drift []*Record
+
+	ignored, foreign, err := diff2.Unmanaged(existing, dc)
+	if err != nil {
+		return nil, err
+	}
+	for _, rc := range append(ignored, foreign...) {
+		drift = append(drift, &Record{Class: Ignored, Name: rc.NameFQDN, Type: rc.Type, Existing: value(rc)})
+	}
+
+	changes, err := diff2.ByRecord(existing, dc, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range changes {
+		r := &Record{Name: c.Key.NameFQDN, Type: c.Key.Type}
+		switch c.Type {
+		case diff2.CREATE:
+			r.Class = Missing
+			r.Desired = value(c.New[0])
+		case diff2.DELETE:
+			r.Class = Extra
+			r.Existing = value(c.Old[0])
+		case diff2.CHANGE:
+			r.Class = Modified
+			r.Existing = value(c.Old[0])
+			r.Desired = value(c.New[0])
+		default:
+			continue
+		}
+		drift = append(drift, r)
+	}
+
+	sort.SliceStable(drift, func(i, j int) bool {
+		if drift[i].Name != drift[j].Name {
+			return drift[i].Name < drift[j].Name
+		}
+		return drift[i].Type < drift[j].Type
+	})
+	return drift, nil
+}
+
+// Count returns the number of differences of each class.
+func Count(drift []*Record) map[Class]int {
+	n := map[Class]int{}
+	for _, r := range drift {
+		n[r.Class]++
+	}
+	return n
+}
+
+// HasDrift returns true if there are differences other than ignored
+// records.
+func HasDrift(drift []*Record) bool {
+	for _, r := range drift {
+		if r.Class != Ignored {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package drift
+
+import (
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+)
+
+func rec(name, rtype, target string, ttl uint32) *models.RecordConfig {
+	rc := &models.RecordConfig{Type: rtype, TTL: ttl}
+	rc.SetLabel(name, "example.com")
+	if err := rc.SetTarget(target); err != nil {
+		panic(err)
+	}
+	return rc
+}
+
+func TestDetect(t *testing.T) {
+	existing := models.Records{
+		rec("@", "A", "1.2.3.4", 300),
+		rec("www", "A", "1.2.3.4", 300),
+		rec("ttl", "A", "1.2.3.4", 300),
+		rec("extra", "A", "1.2.3.4", 300),
+		rec("ignored", "A", "1.2.3.4", 300),
+	}
+	dc := &models.DomainConfig{
+		Name: "example.com",
+		Records: models.Records{
+			rec("@", "A", "1.2.3.4", 300),
+			rec("www", "A", "5.6.7.8", 300),
+			rec("ttl", "A", "1.2.3.4", 600),
+			rec("missing", "A", "1.2.3.4", 300),
+		},
+		Unmanaged: []*models.UnmanagedConfig{{LabelPattern: "ignored"}},
+	}
+
+	drift, err := Detect(existing, dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		class Class
+		name  string
+	}{
+		{Extra, "extra.example.com"},
+		{Ignored, "ignored.example.com"},
+		{Missing, "missing.example.com"},
+		{Modified, "ttl.example.com"},
+		{Modified, "www.example.com"},
+	}
+	if len(drift) != len(want) {
+		t.Fatalf("got %d differences, want %d: %+v", len(drift), len(want), drift)
+	}
+	for i, w := range want {
+		if drift[i].Class != w.class || drift[i].Name != w.name {
+			t.Errorf("#%d: got %s %s, want %s %s", i, drift[i].Class, drift[i].Name, w.class, w.name)
+		}
+	}
+	if m := drift[4]; m.Existing.Target != "1.2.3.4" || m.Desired.Target != "5.6.7.8" {
+		t.Errorf("unexpected values %+v %+v", m.Existing, m.Desired)
+	}
+
+	if n := Count(drift); n[Modified] != 2 || n[Ignored] != 1 {
+		t.Errorf("unexpected counts %v", n)
+	}
+	if !HasDrift(drift) {
+		t.Errorf("expected drift")
+	}
+	if HasDrift(drift[1:2]) {
+		t.Errorf("ignored records are not drift")
+	}
+}
+
+func TestDetect_noPurge(t *testing.T) {
+	existing := models.Records{rec("foreign", "A", "1.2.3.4", 300)}
+	dc := &models.DomainConfig{Name: "example.com", KeepUnknown: true}
+	drift, err := Detect(existing, dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drift) != 1 || drift[0].Class != Ignored {
+		t.Errorf("unexpected drift %+v", drift)
+	}
+}
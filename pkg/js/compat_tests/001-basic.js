@@ -0,0 +1,8 @@
+// ES2015: const, template literals.
+const REG = NewRegistrar("Third-Party", "NONE");
+const CF = NewDnsProvider("Cloudflare", "CLOUDFLAREAPI");
+const zone = "foo";
+
+D(`${zone}.com`, REG, DnsProvider(CF),
+    A("@", `${[1, 2, 3, 4].join(".")}`)
+);
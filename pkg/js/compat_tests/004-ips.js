@@ -0,0 +1,10 @@
+// ES2015: arrow functions, destructuring, spread.
+const REG = NewRegistrar("Third-Party", "NONE");
+const CF = NewDnsProvider("Cloudflare", "CLOUDFLAREAPI");
+
+const BASE = IP("1.2.3.4");
+const hosts = [["@", 0], ["p1", 1], ["p255", 255]];
+
+D("foo.com", REG, DnsProvider(CF, 0),
+    ...hosts.map(([name, offset]) => A(name, BASE + offset))
+);
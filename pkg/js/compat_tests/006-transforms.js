@@ -0,0 +1,15 @@
+// ES2018: object spread.
+const REG = NewRegistrar("Third-Party", "NONE");
+const CF = NewDnsProvider("Cloudflare", "CLOUDFLAREAPI");
+
+const range = { low: "1.1.1.1", high: IP("2.2.2.2") };
+const targets = ["3.3.3.3", "4.4.4.4", IP("5.5.5.5")];
+const TRANSFORM_INT = [
+    { low: "0.0.0.0", high: "1.1.1.1", newBase: "2.2.2.2" },
+    { ...range, newBase: targets },
+    { ...range, newIP: [...targets] },
+];
+
+D("foo.com", REG, DnsProvider(CF),
+    A("@", "1.2.3.4", { transform: TRANSFORM_INT })
+);
@@ -0,0 +1,9 @@
+// ES2017: Object.entries; ES2016: Array.prototype.includes.
+const ttls = { "@": "300s", a: "300", b: "3m", c: "3h", d: "3d", x: "3y" };
+const skip = ["x"];
+
+D("foo.com", "none",
+    ...Object.entries(ttls)
+        .filter(([name]) => !skip.includes(name))
+        .map(([name, ttl], i) => A(name, `1.2.3.${4 + i}`, TTL(ttl)))
+);
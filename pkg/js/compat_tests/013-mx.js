@@ -0,0 +1,14 @@
+// ES2015: classes, default parameters.
+class Mail {
+    constructor(priority = 10) {
+        this.priority = priority;
+    }
+    get host() {
+        return "foo.com.";
+    }
+    record(label = "@") {
+        return MX(label, this.priority, this.host);
+    }
+}
+
+D("foo.com", "none", new Mail(15).record());
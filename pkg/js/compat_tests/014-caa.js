@@ -0,0 +1,12 @@
+// ES2015: spread of an array; ES2019: Array.prototype.flat.
+const report = (critical, ...urls) =>
+    urls.map((url) => critical ? CAA("@", "iodef", url, CAA_CRITICAL) : CAA("@", "iodef", url));
+
+D("foo.com", "none",
+    ...[
+        CAA("@", "issue", "letsencrypt.org"),
+        CAA("@", "issuewild", ";"),
+        [report(true, "mailto:test@example.com"), report(false, "http://example.com")].flat(2),
+        report(true, "https://example.com"),
+    ]
+);
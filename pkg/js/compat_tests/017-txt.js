@@ -0,0 +1,14 @@
+// ES2020: optional chaining, nullish coalescing; ES2015: for...of.
+const txt = {
+    a: { value: "simple" },
+    b: { value: "ws at end " },
+    c: { parts: ["one"] },
+    d: { parts: ["bonie", "clyde"] },
+    e: { parts: ["straw", "wood", "brick"] },
+};
+
+const records = [];
+for (const [label, t] of Object.entries(txt)) {
+    records.push(TXT(label, t.parts?.slice() ?? t.value));
+}
+D("foo.com", "none", records);
@@ -0,0 +1,7 @@
+// ES2015: destructuring in parameters; ES2015: Array.prototype.entries.
+const servers = ["one.foo.com.", "two", "localhost", "three.example.com."];
+
+D("foo.com", "none",
+    [...servers.entries()].map(([i, target]) => SRV("_ntp._udp", i + 1, 100, 123, target)),
+    (({ priority = 0, weight = 0, port = 1 }) => SRV("_ntp._udp", priority, weight, port, "zeros"))({})
+);
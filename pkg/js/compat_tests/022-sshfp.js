@@ -0,0 +1,10 @@
+// ES2019: Array.prototype.flatMap; ES2015: computed property names.
+const SHA1 = 1, SHA256 = 2;
+const fingerprints = {
+    [SHA1]: "66c7d5540b7d75a1fb4c84febfa178ad99bdd67c",
+    [SHA256]: "745a635bc46a397a5c4f21d437483005bcc40d7511ff15fbfafe913a081559bc",
+};
+
+D("foo.com", "none",
+    [1, 2, 3, 4].flatMap((alg) => [SHA1, SHA256].map((type) => SSHFP("@", alg, type, fingerprints[type])))
+);
@@ -0,0 +1,7 @@
+// ES2015: destructuring with computed names.
+const domain = "foo.com";
+const { [domain]: ip } = require("../parse_tests/domain-ip-map.json");
+
+D(domain, "none",
+    A("@", ip)
+);
@@ -0,0 +1,22 @@
+// Timers: D_EXTEND() runs after the script, from the event loop.
+const REG = NewRegistrar("Third-Party", "NONE");
+const CF = NewDnsProvider("Cloudflare", "CLOUDFLAREAPI");
+
+let n = 1;
+const next = () => `10.${n}.${n}.${n++}`;
+
+// Zone and subdomain Zone:
+for (const name of ["foo.com", "bar.foo.com", "foo.edu"]) {
+    D(name, REG, DnsProvider(CF),
+        A("@", next()),
+        A("www", next())
+    );
+}
+
+// Zone that gets extended
+setTimeout(() => {
+    D_EXTEND("foo.edu",
+        A("more1", next()),
+        A("more2", next())
+    );
+}, 1);
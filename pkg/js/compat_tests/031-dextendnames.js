@@ -0,0 +1,23 @@
+// ES2017: Object.entries; ES2015: for...of with destructuring.
+const REG = NewRegistrar("Third-Party", "NONE");
+const DNS = NewDnsProvider("Cloudflare", "CLOUDFLAREAPI");
+
+// Test the name matching algorithm
+
+const zones = {
+  "domain.tld": ["127.0.0.1", "a", "127.0.0.2", "b", "c"],
+  "sub.domain.tld": ["127.0.1.1", "aa", "127.0.1.2", "bb", "cc"],
+};
+for (const [name, [apex, a, ip, cname, target]] of Object.entries(zones)) {
+  D(name, REG, DnsProvider(DNS), A("@", apex), A(a, ip), CNAME(cname, target));
+}
+
+const extensions = [
+  ["domain.tld", "127.0.0.3", "d", "127.0.0.4", "e", "f"],     // Should match domain.tld
+  ["ub.domain.tld", "127.0.0.5", "g", "127.0.0.6", "h", "i"],  // Should match domain.tld
+  ["sub.domain.tld", "127.0.1.3", "dd", "127.0.1.4", "ee", "ff"], // Should match sub.domain.tld
+  ["ssub.domain.tld", "127.0.0.7", "j", "127.0.0.8", "k", "l"], // Should match domain.tld
+];
+for (const [name, apex, a, ip, cname, target] of extensions) {
+  D_EXTEND(name, A("@", apex), A(a, ip), CNAME(cname, target));
+}
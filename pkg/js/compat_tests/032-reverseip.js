@@ -0,0 +1,21 @@
+// ES2015: let, template literals, spread of a generator.
+const REGISTRAR = NewRegistrar('none', 'NONE');    // No registrar.
+const BIND = NewDnsProvider('bind', 'BIND');
+
+D(REV('1.2.3.0/24'), REGISTRAR, DnsProvider(BIND),
+  PTR("1", 'foo.example.com.'),
+  PTR("1.2.3.2", 'bar.example.com.'),
+  PTR(REV("1.2.3.3"), 'baz.example.com.', {skip_fqdn_check:"true"})
+);
+
+function* extensions() {
+  yield [REV("1.2.3.4"), PTR("4", "silly.example.com.")];
+  yield [REV("1.2.3.5"), PTR("1.2.3.5", "willy.example.com.")];
+  yield [REV("1.2.3.6"), PTR(REV("1.2.3.6"), "billy.example.com.")];
+  yield [REV("1.2.3.0/24"), PTR("7", "my.example.com.")];
+  yield [REV("1.2.3.0/24"), PTR("1.2.3.8", "fair.example.com.")];
+  yield [REV("1.2.3.0/24"), PTR(REV(`1.2.3.${9}/32`), "lady.example.com.", {skip_fqdn_check:"true"})];
+}
+for (const [zone, record] of [...extensions()]) {
+  D_EXTEND(zone, record);
+}
@@ -0,0 +1,10 @@
+// ES2015: rest parameters, template literals with raw strings.
+const e2u = (order, service, uri, ...replacement) =>
+    NAPTR("@", order, 10, "U", `E2U+${service}`, String.raw`!^.*$!${uri}!`, ...replacement);
+
+D("foo.com", "none",
+    e2u(100, "sip", "sip:customer-service@example.com", "example"),
+    e2u(102, "email", "mailto:information@example.com", "example"),
+    e2u(103, "email", "mailto:information@example.com", ""),
+    e2u(104, "email", "mailto:information@example.com", ".")
+);
@@ -0,0 +1,15 @@
+// ES2015: Map, template literals.
+const REG = NewRegistrar("Third-Party", "NONE");
+const views = new Map([
+  ["", [NewDnsProvider("otherconfig", "CLOUDFLAREAPI"), "3.3.3.3"]],
+  ["inside", [NewDnsProvider("Cloudflare", "CLOUDFLAREAPI"), "1.1.1.1"]],
+  ["outside", [NewDnsProvider("bind", "BIND"), "8.8.8.8"]],
+]);
+const zone = (tag) => tag ? `example.com!${tag}` : "example.com";
+
+views.forEach(([dns, ip], tag) => {
+  D(zone(tag), REG, DnsProvider(dns), A("main", ip));
+});
+
+D_EXTEND(zone(""), A("www", "33.33.33.33"));
+D_EXTEND(zone("inside"), A("main", "11.11.11.11"));
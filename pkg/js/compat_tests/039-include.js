@@ -0,0 +1,13 @@
+// ES2015: const, template literals.
+const REG = NewRegistrar("Third-Party", "NONE");
+const CF = NewDnsProvider("Cloudflare", "CLOUDFLAREAPI");
+const view = (tag) => `foo.com!${tag}`;
+
+D(view("external"), REG, DnsProvider(CF),
+    A("@", "1.2.3.4")
+);
+
+D(view("internal"), REG, DnsProvider(CF),
+    INCLUDE(view("external")),
+    A("local", "127.0.0.1")
+);
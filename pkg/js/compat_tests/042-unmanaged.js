@@ -0,0 +1,15 @@
+// ES2017: async/await.
+async function rules() {
+  const glob = await Promise.resolve("targetGlob");
+  return [
+    UNMANAGED("", "", `${glob}1`),
+    UNMANAGED("", "CNAME", ""),
+    UNMANAGED("", "A", `${glob}3`),
+    UNMANAGED("lab4"),
+    UNMANAGED("notype", "", `${glob}5`),
+    UNMANAGED("lab6", "A, CNAME"),
+    UNMANAGED("lab7", "TXT", `${glob}7`),
+  ];
+}
+
+rules().then((r) => D("foo.com", "none", ...r));
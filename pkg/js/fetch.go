@@ -0,0 +1,99 @@
+package js
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/dop251/goja"
+)
+
+// defineFetch defines fetch(url, options), a subset of the Fetch API:
+// the options are method, headers and body (a string), and the
+// response has ok, status, statusText, url, headers.get(), text() and
+// json().
+func defineFetch(vm *goja.Runtime, l *eventLoop) {
+	vm.Set("fetch", func(call goja.FunctionCall) goja.Value {
+		url := call.Argument(0).String()
+		method := "GET"
+		var body io.Reader
+		headers := http.Header{}
+		if opts, ok := call.Argument(1).(*goja.Object); ok {
+			if v := opts.Get("method"); v != nil && !goja.IsUndefined(v) {
+				method = strings.ToUpper(v.String())
+			}
+			if v := opts.Get("body"); v != nil && !goja.IsUndefined(v) && !goja.IsNull(v) {
+				body = strings.NewReader(v.String())
+			}
+			if h, ok := opts.Get("headers").(*goja.Object); ok {
+				for _, k := range h.Keys() {
+					headers.Set(k, h.Get(k).String())
+				}
+			}
+		}
+
+		p, resolve, reject := vm.NewPromise()
+		req, err := http.NewRequest(method, url, body)
+		if err != nil {
+			reject(vm.NewGoError(err))
+			return vm.ToValue(p)
+		}
+		req.Header = headers
+
+		l.goAsync(func() func() error {
+			resp, err := http.DefaultClient.Do(req)
+			var data []byte
+			if err == nil {
+				defer resp.Body.Close()
+				data, err = io.ReadAll(resp.Body)
+			}
+			return func() error {
+				if err != nil {
+					reject(vm.NewGoError(err))
+				} else {
+					resolve(fetchResponse(vm, url, resp, data))
+				}
+				return nil
+			}
+		})
+		return vm.ToValue(p)
+	})
+}
+
+// fetchResponse returns the JavaScript Response for resp.
+func fetchResponse(vm *goja.Runtime, url string, resp *http.Response, data []byte) *goja.Object {
+	res := vm.NewObject()
+	res.Set("ok", resp.StatusCode >= 200 && resp.StatusCode < 300)
+	res.Set("status", resp.StatusCode)
+	res.Set("statusText", http.StatusText(resp.StatusCode))
+	res.Set("url", url)
+
+	headers := vm.NewObject()
+	headers.Set("get", func(name string) goja.Value {
+		if vs := resp.Header.Values(name); len(vs) != 0 {
+			return vm.ToValue(strings.Join(vs, ", "))
+		}
+		return goja.Null()
+	})
+	headers.Set("has", func(name string) bool {
+		return len(resp.Header.Values(name)) != 0
+	})
+	res.Set("headers", headers)
+
+	res.Set("text", func() *goja.Promise {
+		p, resolve, _ := vm.NewPromise()
+		resolve(string(data))
+		return p
+	})
+	res.Set("json", func() *goja.Promise {
+		p, resolve, reject := vm.NewPromise()
+		parse, _ := goja.AssertFunction(vm.Get("JSON").ToObject(vm).Get("parse"))
+		if v, err := parse(goja.Undefined(), vm.ToValue(string(data))); err != nil {
+			reject(exceptionValue(vm, err))
+		} else {
+			resolve(v)
+		}
+		return p
+	})
+	return res
+}
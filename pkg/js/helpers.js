@@ -167,6 +167,36 @@ function INCLUDE(name) {
     };
 }
 
+// _yamlFragment(f): Returns a domain modifier that adds f, a YAML
+// fragment read by require(), to the domain.
+function _yamlFragment(f) {
+    return function (d) {
+        if (d.subdomain) {
+            throw 'YAML fragments cannot be used in D_EXTEND() of a subdomain';
+        }
+        var lists = ['records', 'recordsabsent'];
+        for (var i = 0; i < lists.length; i++) {
+            _.each(f[lists[i]] || [], function (r) {
+                if (!r.ttl) {
+                    r.ttl = d.defaultTTL;
+                }
+                d[lists[i]].push(r);
+            });
+        }
+        lists = ['nameservers', 'ignored_names', 'ignored_targets', 'unmanaged'];
+        for (var i = 0; i < lists.length; i++) {
+            d[lists[i]].push.apply(d[lists[i]], f[lists[i]] || []);
+        }
+        _.extend(d.meta, f.meta);
+        if (f.keepunknown) {
+            d.KeepUnknown = true;
+        }
+        if (f.auto_dnssec) {
+            d.auto_dnssec = f.auto_dnssec;
+        }
+    };
+}
+
 // D_EXTEND(name): Update a DNS Domain already added with D(), or subdomain thereof
 function D_EXTEND(name) {
     var domain = _getDomainObject(name);
@@ -234,7 +264,7 @@ function stringToDuration(v) {
     if (matches == null) {
         throw v + ' is not a valid duration string';
     }
-    unit = 's';
+    var unit = 's';
     if (matches[2]) {
         unit = matches[2];
     }
@@ -407,6 +437,22 @@ var DS = recordBuilder('DS', {
     },
 });
 
+// HTTPS(name, priority, target, params, recordModifiers...)
+var HTTPS = recordBuilder('HTTPS', {
+    args: [
+        ['name', _.isString],
+        ['priority', _.isNumber],
+        ['target', _.isString],
+        ['params', _.isString],
+    ],
+    transform: function (record, args, modifiers) {
+        record.name = args.name;
+        record.svcpriority = args.priority;
+        record.target = args.target;
+        record.svcparams = args.params;
+    },
+});
+
 // PTR(name,target, recordModifiers...)
 var PTR = recordBuilder('PTR');
 
@@ -488,6 +534,22 @@ var SSHFP = recordBuilder('SSHFP', {
     },
 });
 
+// SVCB(name, priority, target, params, recordModifiers...)
+var SVCB = recordBuilder('SVCB', {
+    args: [
+        ['name', _.isString],
+        ['priority', _.isNumber],
+        ['target', _.isString],
+        ['params', _.isString],
+    ],
+    transform: function (record, args, modifiers) {
+        record.name = args.name;
+        record.svcpriority = args.priority;
+        record.target = args.target;
+        record.svcparams = args.params;
+    },
+});
+
 // name, usage, selector, matchingtype, certificate
 var TLSA = recordBuilder('TLSA', {
     args: [
@@ -506,6 +568,74 @@ var TLSA = recordBuilder('TLSA', {
     },
 });
 
+// rdataBuilder creates a builder for a record type that is stored as
+// zonefile rdata (see models/t_rdata.go). The arguments are the name,
+// then each field of the rdata as a string or a number. Fields marked
+// 'quoted' are quoted (as in a zonefile); the others are used as-is.
+function rdataBuilder(type, fields) {
+    var args = [['name', _.isString]];
+    for (var i = 0; i < fields.length; i++) {
+        args.push([fields[i][0], isStringOrNumber]);
+    }
+    return recordBuilder(type, {
+        args: args,
+        transform: function (record, args, modifiers) {
+            record.name = args.name;
+            var rdata = [];
+            for (var i = 0; i < fields.length; i++) {
+                var value = String(args[fields[i][0]]);
+                if (fields[i][1] === 'quoted') {
+                    value = '"' + value.replace(/(["\\])/g, '\\$1') + '"';
+                }
+                rdata.push(value);
+            }
+            record.target = rdata.join(' ');
+        },
+    });
+}
+
+function isStringOrNumber(x) {
+    return _.isString(x) || _.isNumber(x);
+}
+
+// CERT(name, type, keytag, algorithm, certificate, recordModifiers...)
+var CERT = rdataBuilder('CERT', [
+    ['type'],
+    ['keytag'],
+    ['algorithm'],
+    ['certificate'],
+]);
+
+// DNAME(name, target, recordModifiers...)
+var DNAME = rdataBuilder('DNAME', [['target']]);
+
+// HINFO(name, cpu, os, recordModifiers...)
+var HINFO = rdataBuilder('HINFO', [
+    ['cpu', 'quoted'],
+    ['os', 'quoted'],
+]);
+
+// LOC(name, location, recordModifiers...)
+// The location is as in a zonefile: "52 22 23.000 N 4 53 32.000 E -2.00m 0.00m 10000m 10m"
+var LOC = rdataBuilder('LOC', [['location']]);
+
+// OPENPGPKEY(name, publickey, recordModifiers...)
+var OPENPGPKEY = rdataBuilder('OPENPGPKEY', [['publickey']]);
+
+// RP(name, mbox, txt, recordModifiers...)
+var RP = rdataBuilder('RP', [['mbox'], ['txt']]);
+
+// SMIMEA(name, usage, selector, matchingtype, certificate, recordModifiers...)
+var SMIMEA = rdataBuilder('SMIMEA', [
+    ['usage'],
+    ['selector'],
+    ['matchingtype'],
+    ['certificate'],
+]);
+
+// URI(name, priority, weight, target, recordModifiers...)
+var URI = rdataBuilder('URI', [['priority'], ['weight'], ['target', 'quoted']]);
+
 function isStringOrArray(x) {
     return _.isString(x) || _.isArray(x);
 }
@@ -659,6 +789,20 @@ function NO_PURGE(d) {
     d.KeepUnknown = true;
 }
 
+// OWNER(owner, contact)
+// Usage: D("example.com", REG, OWNER("web-team", "web@example.com"), ...)
+function OWNER(owner, contact) {
+    if (!_.isString(owner) || owner === '') {
+        throw 'OWNER: the owner must be a non-empty string';
+    }
+    return function (d) {
+        d.meta.owner = owner;
+        if (contact !== undefined) {
+            d.meta.contact = String(contact);
+        }
+    };
+}
+
 // ENSURE_ABSENT_REC()
 // Usage: A("foo", "1.2.3.4", ENSURE_ABSENT_REC())
 function ENSURE_ABSENT_REC() {
@@ -812,11 +956,15 @@ function recordBuilder(type, opts) {
             modifiers.push(arguments[i]);
         }
 
+        // Where the record is defined, for error messages.
+        var source = _callerSource();
+
         return function (d) {
             var record = {
                 type: type,
                 meta: {},
                 ttl: d.defaultTTL,
+                _source: source,
             };
 
             opts.applyModifier(record, modifiers);
@@ -829,7 +977,7 @@ function recordBuilder(type, opts) {
                 record.type != 'CF_TEMP_REDIRECT' &&
                 record.type != 'CF_WORKER_ROUTE'
             ) {
-                fqdn = [d.subdomain, d.name].join('.');
+                var fqdn = [d.subdomain, d.name].join('.');
 
                 record.subdomain = d.subdomain;
                 if (record.name == '@') {
@@ -870,6 +1018,7 @@ function addRecord(d, type, name, target, mods) {
         ttl: d.defaultTTL,
         priority: 0,
         meta: {},
+        _source: _callerSource(),
     };
     // for each modifier, decide based on type:
     // - Function: call is with the record as the argument
@@ -1008,16 +1157,16 @@ function SPF_BUILDER(value) {
         value.raw = '_rawspf';
     }
 
-    r = []; // The list of records to return.
-    p = {}; // The metaparameters to set on the main TXT record.
-    rawspf = value.parts.join(' '); // The unaltered SPF settings.
+    var r = []; // The list of records to return.
+    var p = {}; // The metaparameters to set on the main TXT record.
+    var rawspf = value.parts.join(' '); // The unaltered SPF settings.
 
     // If flattening is requested, generate a TXT record with the raw SPF settings.
     if (value.flatten && value.flatten.length > 0) {
         p.flatten = value.flatten.join(',');
         // Only add the raw spf record if it isn't an empty string
         if (value.raw !== '') {
-            rp = {};
+            var rp = {};
             if (value.ttl) {
                 r.push(TXT(value.raw, rawspf, rp, TTL(value.ttl)));
             } else {
@@ -1074,7 +1223,7 @@ function CAA_BUILDER(value) {
         throw 'CAA_BUILDER requires at least one entry at issue or issuewild';
     }
 
-    r = []; // The list of records to return.
+    var r = []; // The list of records to return.
 
     if (value.iodef) {
         if (value.iodef_critical) {
@@ -1258,7 +1407,7 @@ function DKIM(arr) {
 function require_glob() {
     arguments[2] = 'js'; // force to only include .js files.
     var files = glob.apply(null, arguments);
-    for (i = 0; i < files.length; i++) {
+    for (var i = 0; i < files.length; i++) {
         require(files[i]);
     }
     return files;
@@ -1267,8 +1416,8 @@ function require_glob() {
 // Set default values for CLI variables
 function CLI_DEFAULTS(defaults) {
     for (var key in defaults) {
-        if (typeof this[key] === 'undefined') {
-            this[key] = defaults[key];
+        if (typeof globalThis[key] === 'undefined') {
+            globalThis[key] = defaults[key];
         }
     }
 }
@@ -1288,7 +1437,7 @@ function DOMAIN_ELSEWHERE(domain, registrar, nslist) {
     // NB(tlim): NO_PURGE is added as a precaution since something else
     // is maintaining the DNS records in that zone.  In theory this is
     // not needed since this domain won't have a DSP defined.
-    for (i = 0; i < nslist.length; i++) {
+    for (var i = 0; i < nslist.length; i++) {
         D_EXTEND(domain, NAMESERVER(nslist[i]));
     }
 }
@@ -1301,7 +1450,7 @@ function DOMAIN_ELSEWHERE_AUTO(domain, registrar, dsplist) {
     // NB(tlim): NO_PURGE is required since something else
     // is maintaining the DNS records in that zone, and we have access
     // to updating it (but we don't want to use it.)
-    for (i = 2; i < arguments.length; i++) {
+    for (var i = 2; i < arguments.length; i++) {
         D_EXTEND(domain, DnsProvider(arguments[i]));
     }
 }
@@ -12,18 +12,16 @@ import (
 	"github.com/StackExchange/dnscontrol/v3/models"
 	"github.com/StackExchange/dnscontrol/v3/pkg/printer"
 	"github.com/StackExchange/dnscontrol/v3/pkg/transform"
-	"github.com/robertkrimen/otto"              // load underscore js into vm by default
-	_ "github.com/robertkrimen/otto/underscore" // required by otto
-	"github.com/xddxdd/ottoext/fetch"
-	"github.com/xddxdd/ottoext/loop"
-	"github.com/xddxdd/ottoext/promise"
-	"github.com/xddxdd/ottoext/timers"
+	"github.com/dop251/goja"
 )
 
 //go:embed helpers.js
 var helpersJsStatic string
 var helpersJsFileName = "pkg/js/helpers.js"
 
+//go:embed underscore-min.js
+var underscoreJs string
+
 // currentDirectory is the current directory as used by require().
 // This is used to emulate nodejs-style require() directory handling.
 // If require("a/b/c.js") is called, any require() statement in c.js
@@ -45,66 +43,94 @@ func ExecuteJavascript(file string, devMode bool, variables map[string]string) (
 	// Record the directory path leading up to this file.
 	currentDirectory = filepath.Dir(file)
 
-	return ExecuteJavascriptString(script, devMode, variables)
+	return executeJavascript([]source{{name: file, script: script}}, devMode, variables)
+}
+
+// ExecuteJavascriptFiles runs files, in order, in the same global
+// scope: the variables that a file declares are available to the files
+// that follow, as though they were one file. Each file's require() is
+// relative to its own directory.
+func ExecuteJavascriptFiles(files []string, devMode bool, variables map[string]string) (*models.DNSConfig, error) {
+	var sources []source
+	for _, file := range files {
+		script, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source{name: file, dir: filepath.Dir(file), script: script})
+	}
+	return executeJavascript(sources, devMode, variables)
 }
 
 // ExecuteJavascriptString accepts a string containing javascript and runs it, returning the resulting dnsConfig.
 func ExecuteJavascriptString(script []byte, devMode bool, variables map[string]string) (*models.DNSConfig, error) {
+	return executeJavascript([]source{{name: "dnsconfig.js", script: script}}, devMode, variables)
+}
 
-	vm := otto.New()
-	l := loop.New(vm)
+// source is a script to run.
+type source struct {
+	name   string // The file name used in error messages.
+	dir    string // If set, the currentDirectory while it runs.
+	script []byte
+}
 
-	if err := timers.Define(vm, l); err != nil {
-		return nil, err
-	}
-	if err := promise.Define(vm, l); err != nil {
-		return nil, err
-	}
+// executeJavascript runs the scripts in sources, in order.
+func executeJavascript(sources []source, devMode bool, variables map[string]string) (*models.DNSConfig, error) {
+
+	vm := goja.New()
+	l := newEventLoop(vm)
 
 	// only define fetch() when explicitly enabled
 	if EnableFetch {
-		if err := fetch.Define(vm, l); err != nil {
-			return nil, err
-		}
+		defineFetch(vm, l)
 	}
 
-	vm.Set("require", require)
-	vm.Set("REV", reverse)
-	vm.Set("glob", listFiles) // used for require_glob()
-	vm.Set("PANIC", jsPanic)
+	modules := newLoader(vm)
+	vm.Set("require", modules.require)
+	vm.Set("REV", func(call goja.FunctionCall) goja.Value { return reverse(vm, call) })
+	vm.Set("glob", func(call goja.FunctionCall) goja.Value { return listFiles(vm, call) }) // used for require_glob()
+	vm.Set("PANIC", func(call goja.FunctionCall) goja.Value { return jsPanic(vm, call) })
+	vm.Set("_callerSource", func() goja.Value { return callerSource(vm) }) // used by recordBuilder()
 
-	// add cli variables to otto
+	// add cli variables to the vm
 	for key, value := range variables {
 		vm.Set(key, value)
 	}
 
+	// underscore.js is available to helpers.js and to the user script.
+	if _, err := vm.RunScript("underscore-min.js", underscoreJs); err != nil {
+		return nil, err
+	}
+
 	helperJs := GetHelpers(devMode)
 	// run helper script to prime vm and initialize variables
-	if err := l.Eval(helperJs); err != nil {
+	if _, err := vm.RunScript(helpersJsFileName, helperJs); err != nil {
 		return nil, err
 	}
 
-	// run user script
-	if err := l.Eval(script); err != nil {
-		return nil, err
+	// run user scripts
+	for _, src := range sources {
+		if src.dir != "" {
+			currentDirectory = src.dir
+		}
+		modules.start(src.name)
+		if _, err := vm.RunScript(src.name, string(src.script)); err != nil {
+			return nil, err
+		}
 	}
 
 	// wait for event loop to finish
-	if err := l.Run(); err != nil {
+	if err := l.run(); err != nil {
 		return nil, err
 	}
 
 	// export conf as string and unmarshal
-	value, err := vm.Run(`JSON.stringify(conf)`)
-	if err != nil {
-		return nil, err
-	}
-	str, err := value.ToString()
+	value, err := vm.RunString(`JSON.stringify(conf)`)
 	if err != nil {
 		return nil, err
 	}
 	conf := &models.DNSConfig{}
-	if err = json.Unmarshal([]byte(str), conf); err != nil {
+	if err = json.Unmarshal([]byte(value.String()), conf); err != nil {
 		return nil, err
 	}
 	return conf, nil
@@ -125,65 +151,17 @@ func GetHelpers(devMode bool) string {
 	return helpersJsStatic
 }
 
-func require(call otto.FunctionCall) otto.Value {
-	if len(call.ArgumentList) != 1 {
-		throw(call.Otto, "require takes exactly one argument")
-	}
-	file := call.Argument(0).String() // The filename as given by the user
-
-	// relFile is the file we're actually going to pass to ReadFile().
-	// It defaults to the user-provided name unless it is relative.
-	relFile := file
-	cleanFile := filepath.Clean(filepath.Join(currentDirectory, file))
-	if strings.HasPrefix(file, ".") {
-		relFile = cleanFile
-	}
-
-	// Record the old currentDirectory so that we can return there.
-	currentDirectoryOld := currentDirectory
-	// Record the directory path leading up to the file we're about to require.
-	currentDirectory = filepath.Dir(cleanFile)
-
-	printer.Debugf("requiring: %s (%s)\n", file, relFile)
-	// quick fix, by replacing to linux slashes, to make it work with windows paths too.
-	data, err := os.ReadFile(filepath.ToSlash(relFile))
-
-	if err != nil {
-		throw(call.Otto, err.Error())
-	}
-
-	var value = otto.TrueValue()
-
-	// If its a json file return the json value, else default to true
-	if strings.HasSuffix(filepath.Ext(relFile), "json") {
-		cmd := fmt.Sprintf(`JSON.parse(JSON.stringify(%s))`, string(data))
-		value, err = call.Otto.Run(cmd)
-	} else {
-		_, err = call.Otto.Run(string(data))
-	}
-
-	if err != nil {
-		throw(call.Otto, fmt.Sprintf("File %s: %s", filepath.Base(relFile), err.Error()))
-	}
-
-	// Pop back to the old directory.
-	currentDirectory = currentDirectoryOld
-
-	return value
-}
-
-func listFiles(call otto.FunctionCall) otto.Value {
+func listFiles(vm *goja.Runtime, call goja.FunctionCall) goja.Value {
 	// Check amount of arguments provided
-	if !(len(call.ArgumentList) >= 1 && len(call.ArgumentList) <= 3) {
-		throw(call.Otto, "glob requires at least one argument: folder (string). "+
+	if !(len(call.Arguments) >= 1 && len(call.Arguments) <= 3) {
+		throw(vm, "glob requires at least one argument: folder (string). "+
 			"Optional: recursive (bool) [true], fileExtension (string) [.js]")
 	}
 
 	// Check if provided parameters are valid
 	// First: Let's check dir.
-	if !(call.Argument(0).IsDefined() && call.Argument(0).IsString() &&
-		len(call.Argument(0).String()) > 0) {
-		throw(call.Otto, "glob: first argument needs to be a path, provided as string.")
+	if !(isString(call.Argument(0)) && len(call.Argument(0).String()) > 0) {
+		throw(vm, "glob: first argument needs to be a path, provided as string.")
 	}
 	dir := call.Argument(0).String() // Path where to start listing
 	printer.Debugf("listFiles: cd: %s, user: %s \n", currentDirectory, dir)
@@ -193,30 +171,30 @@ func listFiles(call otto.FunctionCall) otto.Value {
 	dir = filepath.ToSlash(filepath.Join(currentDirectory, dir))
 
 	if _, err := os.Stat(dir); os.IsNotExist(err) {
-		throw(call.Otto, "glob: provided path does not exist.")
+		throw(vm, "glob: provided path does not exist.")
 	}
 
 	// Second: Recursive?
 	var recursive = true
-	if call.Argument(1).IsDefined() && !call.Argument(1).IsNull() {
-		if call.Argument(1).IsBoolean() {
-			recursive, _ = call.Argument(1).ToBoolean() // If it should be recursive
+	if !goja.IsUndefined(call.Argument(1)) && !goja.IsNull(call.Argument(1)) {
+		if b, ok := call.Argument(1).Export().(bool); ok {
+			recursive = b // If it should be recursive
 		} else {
-			throw(call.Otto, "glob: second argument, if recursive, needs to be bool.")
+			throw(vm, "glob: second argument, if recursive, needs to be bool.")
 		}
 	}
 
 	// Third: File extension filter.
 	var fileExtension = ".js"
-	if call.Argument(2).IsDefined() && !call.Argument(2).IsNull() {
-		if call.Argument(2).IsString() {
+	if !goja.IsUndefined(call.Argument(2)) && !goja.IsNull(call.Argument(2)) {
+		if isString(call.Argument(2)) {
 			fileExtension = call.Argument(2).String() // Which file extension to filter for.
 			if !strings.HasPrefix(fileExtension, ".") {
 				// If it doesn't start with a dot, probably user forgot it and we do it instead.
 				fileExtension = "." + fileExtension
 			}
 		} else {
-			throw(call.Otto, "glob: third argument, file extension, needs to be a string. * for no filter.")
+			throw(vm, "glob: third argument, file extension, needs to be a string. * for no filter.")
 		}
 	}
 
@@ -248,21 +226,20 @@ func listFiles(call otto.FunctionCall) otto.Value {
 		return err
 	})
 	if err != nil {
-		throw(call.Otto, fmt.Sprintf("dirwalk failed: %v", err.Error()))
+		throw(vm, fmt.Sprintf("dirwalk failed: %v", err.Error()))
 	}
 
 	// let's pass the data back to the JS engine.
-	value, err := call.Otto.ToValue(files)
-	if err != nil {
-		throw(call.Otto, fmt.Sprintf("converting value failed: %v", err.Error()))
+	var values []interface{}
+	for _, f := range files {
+		values = append(values, f)
 	}
-
-	return value
+	return vm.NewArray(values...)
 }
 
-func jsPanic(call otto.FunctionCall) otto.Value {
-	if len(call.ArgumentList) != 1 {
-		throw(call.Otto, "PANIC takes exactly one argument")
+func jsPanic(vm *goja.Runtime, call goja.FunctionCall) goja.Value {
+	if len(call.Arguments) != 1 {
+		throw(vm, "PANIC takes exactly one argument")
 	}
 
 	message := call.Argument(0).String() // The filename as given by the user
@@ -270,23 +247,54 @@ func jsPanic(call otto.FunctionCall) otto.Value {
 	os.Exit(1)
 
 	// Won't be actually executed
-	v, _ := otto.ToValue(0)
-	return v
+	return goja.Undefined()
+}
+
+// callerSource returns "file:line" of the innermost call on the stack
+// that is in the user's files rather than in helpers.js, or undefined.
+func callerSource(vm *goja.Runtime) goja.Value {
+	for _, f := range vm.CaptureCallStack(0, nil) {
+		switch f.SrcName() {
+		case "", "<native>", helpersJsFileName, "underscore-min.js":
+			continue
+		}
+		p := f.Position()
+		return vm.ToValue(fmt.Sprintf("%s:%d", f.SrcName(), p.Line))
+	}
+	return goja.Undefined()
+}
+
+// throw throws a JavaScript Error with the message str.
+func throw(vm *goja.Runtime, str string) {
+	e, err := vm.New(vm.Get("Error"), vm.ToValue(str))
+	if err != nil {
+		panic(err)
+	}
+	panic(e)
+}
+
+// exceptionValue returns the value thrown, if err is a JavaScript
+// exception, or a GoError.
+func exceptionValue(vm *goja.Runtime, err error) goja.Value {
+	if ex, ok := err.(*goja.Exception); ok {
+		return ex.Value()
+	}
+	return vm.NewGoError(err)
 }
 
-func throw(vm *otto.Otto, str string) {
-	panic(vm.MakeCustomError("Error", str))
+func isString(v goja.Value) bool {
+	_, ok := v.Export().(string)
+	return ok
 }
 
-func reverse(call otto.FunctionCall) otto.Value {
-	if len(call.ArgumentList) != 1 {
-		throw(call.Otto, "REV takes exactly one argument")
+func reverse(vm *goja.Runtime, call goja.FunctionCall) goja.Value {
+	if len(call.Arguments) != 1 {
+		throw(vm, "REV takes exactly one argument")
 	}
 	dom := call.Argument(0).String()
 	rev, err := transform.ReverseDomainName(dom)
 	if err != nil {
-		throw(call.Otto, err.Error())
+		throw(vm, err.Error())
 	}
-	v, _ := otto.ToValue(rev)
-	return v
+	return vm.ToValue(rev)
 }
@@ -4,8 +4,11 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"unicode"
 
@@ -17,8 +20,9 @@ import (
 )
 
 const (
-	testDir  = "pkg/js/parse_tests"
-	errorDir = "pkg/js/error_tests"
+	testDir   = "pkg/js/parse_tests"
+	errorDir  = "pkg/js/error_tests"
+	compatDir = "pkg/js/compat_tests"
 )
 
 func init() {
@@ -151,3 +155,243 @@ func TestErrors(t *testing.T) {
 
 	}
 }
+
+// compatExcluded lists the parse_tests that have no rewrite in
+// compat_tests, and why. TestParsedFiles still runs them.
+var compatExcluded = map[string]string{
+	// Literal records only: newer JavaScript would not change them.
+	"002-ttl.js":                  "literal",
+	"003-meta.js":                 "literal",
+	"005-ignored-records.js":      "literal",
+	"007-importTransformTTL.js":   "literal",
+	"009-reverse.js":              "literal",
+	"010-alias.js":                "literal",
+	"011-cfRedirect.js":           "literal",
+	"015-tlsa.js":                 "literal",
+	"016-backslash.js":            "literal",
+	"018-dkim.js":                 "literal",
+	"019-r53-alias.js":            "literal",
+	"023-ignored-glob-records.js": "literal",
+	"025-autodnssec.js":           "literal",
+	"026-azure-alias.js":          "literal",
+	"027-ds.js":                   "literal",
+	"029-dextendsub.js":           "literal",
+	"030-dextenddoc.js":           "literal",
+	"033-revextend.js":            "literal",
+	"034-nameserver-ttl.js":       "literal",
+	"036-dextendcf.js":            "literal",
+	"038-soa.js":                  "literal",
+	"040-cfWorkerRoute.js":        "literal",
+	"040-r53-zone.js":             "literal",
+	"043-safety.js":               "literal",
+	"044-ensureabsent.js":         "literal",
+	"045-svcb.js":                 "literal",
+	"046-rdata.js":                "literal",
+	"049-owner.js":                "literal",
+	// These test require(), which resolves files next to the script
+	// (see TestRequireModule), not the syntax.
+	"008-import.js":         "require",
+	"020-complexRequire.js": "require",
+	"047-modules.js":        "require",
+	"048-yaml.js":           "require",
+	// TestParsedFiles replaces the "-" provider types before comparing.
+	"041-newstyleproviders.js": "provider types",
+}
+
+// TestCompat runs the files in compat_tests. Each one is a rewrite of
+// the parse_tests file of the same name using newer JavaScript, and
+// must produce the same configuration. Every parse_tests file must be
+// rewritten or in compatExcluded.
+func TestCompat(t *testing.T) {
+	files, err := os.ReadDir(compatDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewritten := map[string]bool{}
+	for _, f := range files {
+		rewritten[f.Name()] = true
+	}
+	parsed, err := os.ReadDir(testDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range parsed {
+		name := f.Name()
+		if filepath.Ext(name) != ".js" || !unicode.IsNumber(rune(name[0])) {
+			continue
+		}
+		if _, ok := compatExcluded[name]; ok == rewritten[name] {
+			t.Errorf("%s: must be either rewritten in %s or in compatExcluded", name, compatDir)
+		}
+	}
+
+	for _, f := range files {
+		name := f.Name()
+		if filepath.Ext(name) != ".js" {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			conf, err := ExecuteJavascript(filepath.Join(compatDir, name), true, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actualJSON, err := json.MarshalIndent(conf, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			expectedFile := filepath.Join(testDir, strings.TrimSuffix(name, ".js")+".json")
+			expectedJSON, err := os.ReadFile(expectedFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testifyrequire.JSONEqf(t, string(expectedJSON), string(actualJSON), "EXPECTING %q = \n```\n%s\n```", expectedFile, actualJSON)
+		})
+	}
+}
+
+func TestAsyncErrors(t *testing.T) {
+	tests := []struct{ desc, text string }{
+		{"rejected promise", `Promise.reject(new Error("boom"))`},
+		{"async throw", `(async () => { throw new Error("boom") })()`},
+		{"timer throw", `setTimeout(() => { throw new Error("boom") }, 0)`},
+	}
+	for _, tst := range tests {
+		t.Run(tst.desc, func(t *testing.T) {
+			if _, err := ExecuteJavascriptString([]byte(tst.text), true, nil); err == nil || !strings.Contains(err.Error(), "boom") {
+				t.Fatalf("expected error boom, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFetch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Method", r.Method)
+		fmt.Fprintf(w, `{"name": "foo.com", "ip": "1.2.3.4", "auth": %q}`, r.Header.Get("X-Auth"))
+	}))
+	defer ts.Close()
+
+	EnableFetch = true
+	defer func() { EnableFetch = false }()
+	script := fmt.Sprintf(`
+		FETCH(%q, { method: "post", headers: { "X-Auth": "secret" } })
+			.then((r) => {
+				if (!r.ok || r.headers.get("X-Method") !== "POST") throw new Error("bad response");
+				return r.json();
+			})
+			.then((j) => D(j.name, "none", A("@", j.ip), TXT("@", j.auth)));
+	`, ts.URL)
+	conf, err := ExecuteJavascriptString([]byte(script), true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conf.Domains) != 1 || len(conf.Domains[0].Records) != 2 || conf.Domains[0].Records[1].TxtStrings[0] != "secret" {
+		t.Errorf("unexpected configuration %+v", conf.Domains)
+	}
+}
+
+func TestRequireCycle(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.js":   `require("./a.cjs")`,
+		"a.cjs":     `require("./lib/b.js"); exports.a = 1`,
+		"lib/b.js":  `require("../a.cjs")`,
+		"self.js":   `require("./self.js")`,
+		"toMain.js": `require("./main2.js")`,
+		"main2.js":  `require("./toMain.js")`,
+	}
+	os.Mkdir(filepath.Join(dir, "lib"), 0o755)
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The files are named relative to the directory of the script.
+	tests := []struct{ main, chain string }{
+		{"main.js", "a.cjs -> " + filepath.FromSlash("lib/b.js") + " -> a.cjs"},
+		{"self.js", "self.js -> self.js"},
+		{"main2.js", "main2.js -> toMain.js -> main2.js"},
+	}
+	for _, tst := range tests {
+		t.Run(tst.main, func(t *testing.T) {
+			_, err := ExecuteJavascript(filepath.Join(dir, tst.main), true, nil)
+			if err == nil || !strings.Contains(err.Error(), "require cycle: "+tst.chain+" at ") {
+				t.Errorf("expected a require cycle %q, got %v", tst.chain, err)
+			}
+		})
+	}
+}
+
+func TestRequireModule(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		// Only a .cjs file is a module, whatever it contains.
+		"dnsconfig.js": `var m = require("./lib.cjs"); require("./plain.js");
+D("example.com", NewRegistrar("none"), TXT("@", m.text), TXT("@", PLAIN));`,
+		"lib.cjs":  `var text = "module"; exports.text = text;`,
+		"plain.js": `var PLAIN = "mentions module.exports and exports.x = 1";`,
+	}
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conf, err := ExecuteJavascript(filepath.Join(dir, "dnsconfig.js"), true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recs := conf.Domains[0].Records
+	if len(recs) != 2 || recs[0].TxtStrings[0] != "module" || !strings.HasPrefix(recs[1].TxtStrings[0], "mentions") {
+		t.Errorf("unexpected records %+v", recs)
+	}
+}
+
+func TestSource(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"dnsconfig.js": `require("./lib.js");
+D("example.com", NewRegistrar("none"),
+    A("@", "1.2.3.4"),
+    CNAME("www.example.com", "@"),
+    MAILRECORDS()
+);`,
+		"lib.js": `function MAILRECORDS() {
+    return [
+        MX("@", 10, "mx"),
+    ];
+}`,
+	}
+	for name, text := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	main := filepath.Join(dir, "dnsconfig.js")
+	lib := filepath.Join(dir, "lib.js")
+
+	conf, err := ExecuteJavascript(main, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{main + ":3", main + ":4", lib + ":3"}
+	for i, rec := range conf.Domains[0].Records {
+		if rec.Source != want[i] {
+			t.Errorf("record %d: got source %q, want %q", i, rec.Source, want[i])
+		}
+	}
+
+	// The source is not part of the IR.
+	ir, err := json.Marshal(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(ir, []byte("_source")) {
+		t.Errorf("the IR includes the source: %s", ir)
+	}
+
+	errs := normalize.ValidateAndNormalizeConfig(conf)
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), main+":4: ") {
+		t.Errorf("expected an error at %s:4, got %v", main, errs)
+	}
+}
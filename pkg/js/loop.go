@@ -0,0 +1,156 @@
+package js
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// eventLoop runs what the script leaves to do after it returns: the
+// callbacks of setTimeout()/setInterval(), and the completion of
+// fetch(). Promise reactions are run by goja itself.
+type eventLoop struct {
+	vm      *goja.Runtime
+	timers  map[int64]*timer
+	nextID  int64
+	pending int               // Calls to fetch() in progress.
+	done    chan func() error // Run on the loop when a fetch() completes.
+
+	// Promises rejected without a handler, in order.
+	rejected []*goja.Promise
+}
+
+type timer struct {
+	id       int64
+	when     time.Time
+	interval time.Duration // 0 for setTimeout().
+	fn       goja.Callable
+	args     []goja.Value
+}
+
+func newEventLoop(vm *goja.Runtime) *eventLoop {
+	l := &eventLoop{
+		vm:     vm,
+		timers: map[int64]*timer{},
+		done:   make(chan func() error),
+	}
+	vm.SetPromiseRejectionTracker(func(p *goja.Promise, op goja.PromiseRejectionOperation) {
+		switch op {
+		case goja.PromiseRejectionReject:
+			l.rejected = append(l.rejected, p)
+		case goja.PromiseRejectionHandle:
+			for i, r := range l.rejected {
+				if r == p {
+					l.rejected = append(l.rejected[:i], l.rejected[i+1:]...)
+					break
+				}
+			}
+		}
+	})
+	vm.Set("setTimeout", func(call goja.FunctionCall) goja.Value { return l.setTimer(call, false) })
+	vm.Set("setInterval", func(call goja.FunctionCall) goja.Value { return l.setTimer(call, true) })
+	vm.Set("clearTimeout", l.clearTimer)
+	vm.Set("clearInterval", l.clearTimer)
+	return l
+}
+
+func (l *eventLoop) setTimer(call goja.FunctionCall, repeat bool) goja.Value {
+	fn, ok := goja.AssertFunction(call.Argument(0))
+	if !ok {
+		throw(l.vm, "setTimeout/setInterval: the first argument must be a function")
+	}
+	delay := time.Duration(call.Argument(1).ToInteger()) * time.Millisecond
+	if delay < 0 {
+		delay = 0
+	}
+	l.nextID++
+	t := &timer{id: l.nextID, when: time.Now().Add(delay), fn: fn}
+	if len(call.Arguments) > 2 {
+		t.args = call.Arguments[2:]
+	}
+	if repeat {
+		t.interval = delay
+		if t.interval == 0 {
+			t.interval = time.Millisecond
+		}
+	}
+	l.timers[t.id] = t
+	return l.vm.ToValue(t.id)
+}
+
+func (l *eventLoop) clearTimer(id int64) {
+	delete(l.timers, id)
+}
+
+// next returns the timer that is due first, or nil.
+func (l *eventLoop) next() *timer {
+	var next *timer
+	for _, t := range l.timers {
+		if next == nil || t.when.Before(next.when) || (t.when.Equal(next.when) && t.id < next.id) {
+			next = t
+		}
+	}
+	return next
+}
+
+// run runs the loop until there is nothing left to do. It returns the
+// first error thrown by a callback, or the reason of a promise that was
+// rejected and never handled.
+func (l *eventLoop) run() error {
+	for {
+		if err := l.unhandled(); err != nil {
+			return err
+		}
+		t := l.next()
+		if t == nil && l.pending == 0 {
+			return nil
+		}
+
+		var wait <-chan time.Time
+		if t != nil {
+			d := time.Until(t.when)
+			if d <= 0 {
+				if err := l.fire(t); err != nil {
+					return err
+				}
+				continue
+			}
+			wait = time.After(d)
+		}
+		select {
+		case f := <-l.done:
+			l.pending--
+			if err := f(); err != nil {
+				return err
+			}
+		case <-wait:
+		}
+	}
+}
+
+func (l *eventLoop) fire(t *timer) error {
+	if t.interval > 0 {
+		t.when = t.when.Add(t.interval)
+	} else {
+		delete(l.timers, t.id)
+	}
+	_, err := t.fn(goja.Undefined(), t.args...)
+	return err
+}
+
+func (l *eventLoop) unhandled() error {
+	if len(l.rejected) == 0 {
+		return nil
+	}
+	return fmt.Errorf("unhandled promise rejection: %s", l.rejected[0].Result())
+}
+
+// goAsync runs f in a goroutine. f returns a function that is then run
+// on the loop, where it may use the runtime.
+func (l *eventLoop) goAsync(f func() func() error) {
+	l.pending++
+	go func() {
+		l.done <- f()
+	}()
+}
@@ -0,0 +1,190 @@
+package js
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/printer"
+	"github.com/StackExchange/dnscontrol/v3/pkg/yamlconfig"
+	"github.com/dop251/goja"
+)
+
+// loader implements require().
+//
+// A JavaScript file is run in the global scope, as though its contents
+// had been included in the file that requires it, each time it is
+// required. However, a .cjs file is a CommonJS module: it runs in its
+// own scope, require() returns its exports, and it only runs the first
+// time it is required.
+type loader struct {
+	vm      *goja.Runtime
+	modules map[string]*goja.Object // The module objects, by absolute path.
+	dir     string                  // The absolute directory of the script being run.
+	loading []loading               // The files being loaded, outermost first.
+}
+
+type loading struct {
+	abs  string // The absolute path, to compare.
+	name string // The name in messages, relative to dir.
+}
+
+// newLoader returns the loader for a run of scripts.
+func newLoader(vm *goja.Runtime) *loader {
+	return &loader{vm: vm, modules: map[string]*goja.Object{}}
+}
+
+// start is called before the script in file is run, with the
+// currentDirectory set to its directory.
+func (l *loader) start(file string) {
+	l.dir, _ = filepath.Abs(currentDirectory)
+	abs, _ := filepath.Abs(file)
+	l.loading = []loading{{abs: abs, name: l.name(abs)}}
+}
+
+// name returns the name of the file at abs in messages: its path
+// relative to the directory of the script, if there is one.
+func (l *loader) name(abs string) string {
+	if rel, err := filepath.Rel(l.dir, abs); err == nil {
+		return rel
+	}
+	return abs
+}
+
+// require implements require(file). A relative file is relative to
+// the currentDirectory.
+func (l *loader) require(call goja.FunctionCall) goja.Value {
+	vm := l.vm
+	if len(call.Arguments) != 1 {
+		throw(vm, "require takes exactly one argument")
+	}
+	file := call.Argument(0).String() // The filename as given by the user
+
+	// relFile is the file we're actually going to pass to ReadFile().
+	// It defaults to the user-provided name unless it is relative.
+	relFile := file
+	cleanFile := filepath.Clean(filepath.Join(currentDirectory, file))
+	if strings.HasPrefix(file, ".") {
+		relFile = cleanFile
+	}
+
+	// Record the old currentDirectory so that we can return there.
+	currentDirectoryOld := currentDirectory
+	defer func() { currentDirectory = currentDirectoryOld }()
+	// Record the directory path leading up to the file we're about to require.
+	currentDirectory = filepath.Dir(cleanFile)
+
+	abs, err := filepath.Abs(relFile)
+	if err != nil {
+		throw(vm, err.Error())
+	}
+	if m, ok := l.modules[abs]; ok {
+		return m.Get("exports")
+	}
+	for i, f := range l.loading {
+		if f.abs == abs {
+			var chain []string
+			for _, f := range l.loading[i:] {
+				chain = append(chain, f.name)
+			}
+			throw(vm, fmt.Sprintf("require cycle: %s -> %s", strings.Join(chain, " -> "), l.name(abs)))
+		}
+	}
+	l.loading = append(l.loading, loading{abs: abs, name: l.name(abs)})
+	defer func() { l.loading = l.loading[:len(l.loading)-1] }()
+
+	printer.Debugf("requiring: %s (%s)\n", file, relFile)
+	// quick fix, by replacing to linux slashes, to make it work with windows paths too.
+	data, err := os.ReadFile(filepath.ToSlash(relFile))
+
+	if err != nil {
+		throw(vm, err.Error())
+	}
+
+	var value = vm.ToValue(true)
+
+	// If its a json file return the json value, if it is a YAML
+	// fragment return a domain modifier, else default to true
+	switch {
+	case strings.HasSuffix(filepath.Ext(relFile), "json"):
+		cmd := fmt.Sprintf(`JSON.parse(JSON.stringify(%s))`, string(data))
+		value, err = vm.RunScript(relFile, cmd)
+	case strings.HasSuffix(relFile, ".yaml") || strings.HasSuffix(relFile, ".yml"):
+		value, err = yamlFragment(vm, relFile)
+	case filepath.Ext(relFile) == ".cjs":
+		value, err = l.runModule(abs, relFile, string(data))
+	default:
+		_, err = vm.RunScript(relFile, string(data))
+	}
+
+	if err != nil {
+		throw(vm, fmt.Sprintf("File %s: %s", filepath.Base(relFile), err.Error()))
+	}
+
+	return value
+}
+
+// runModule runs a CommonJS module and returns its exports.
+func (l *loader) runModule(abs, relFile, src string) (goja.Value, error) {
+	vm := l.vm
+	// The function is on the first line so that line numbers in
+	// messages are those of the file.
+	wrapper, err := vm.RunScript(relFile, "(function (exports, require, module, __filename, __dirname) {"+src+"\n})")
+	if err != nil {
+		return nil, err
+	}
+	fn, _ := goja.AssertFunction(wrapper)
+
+	dir := currentDirectory
+	module := vm.NewObject()
+	exports := vm.NewObject()
+	module.Set("exports", exports)
+	module.Set("id", relFile)
+	// The require() of a module is relative to the module, even if it
+	// is called later.
+	require := func(call goja.FunctionCall) goja.Value {
+		old := currentDirectory
+		defer func() { currentDirectory = old }()
+		currentDirectory = dir
+		return l.require(call)
+	}
+
+	// A module is cached once it has run without error.
+	if _, err := fn(goja.Undefined(), exports, vm.ToValue(require), module, vm.ToValue(relFile), vm.ToValue(dir)); err != nil {
+		return nil, err
+	}
+	l.modules[abs] = module
+	return module.Get("exports"), nil
+}
+
+// yamlFragment reads the YAML fragment in file (see pkg/yamlconfig),
+// and returns a domain modifier that adds it to a domain.
+func yamlFragment(vm *goja.Runtime, file string) (goja.Value, error) {
+	dc, err := yamlconfig.LoadFragment(file)
+	if err != nil {
+		return nil, err
+	}
+	j, err := json.Marshal(dc)
+	if err != nil {
+		return nil, err
+	}
+	// Keep where the records are defined, as recordBuilder() does.
+	var frag map[string]interface{}
+	if err := json.Unmarshal(j, &frag); err != nil {
+		return nil, err
+	}
+	for key, recs := range map[string]models.Records{"records": dc.Records, "recordsabsent": dc.EnsureAbsent} {
+		list, _ := frag[key].([]interface{})
+		for i, rec := range list {
+			rec.(map[string]interface{})["_source"] = recs[i].Source
+		}
+	}
+	j, err = json.Marshal(frag)
+	if err != nil {
+		return nil, err
+	}
+	return vm.RunScript(file, fmt.Sprintf("_yamlFragment(%s)", j))
+}
@@ -0,0 +1,5 @@
+D("foo.com","none",
+    HTTPS("@",1,".","alpn=h2,h3 ipv4hint=192.0.2.1"),
+    HTTPS("www",0,"cdn.example.net.",""),
+    SVCB("_dns",1,"dns",'alpn=dot port=853')
+);
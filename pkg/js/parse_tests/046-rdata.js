@@ -0,0 +1,10 @@
+D("foo.com","none",
+    CERT("cert", "PGP", 0, 0, "dGVzdA=="),
+    DNAME("old", "new.example.com."),
+    HINFO("host", "INTEL-386", "Windows NT"),
+    LOC("@", "52 22 23.000 N 4 53 32.000 E -2.00m 0.00m 10000m 10m"),
+    OPENPGPKEY("key._openpgpkey", "dGVzdA=="),
+    RP("@", "hostmaster.foo.com.", "contact"),
+    SMIMEA("smime._smimecert", 3, 0, 1, "abcdef"),
+    URI("_ftp._tcp", 10, 1, "ftp://ftp.foo.com/public")
+);
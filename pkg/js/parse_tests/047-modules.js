@@ -0,0 +1,9 @@
+var a = require('./modules/teamA.cjs');
+var b = require('./modules/teamB.cjs');
+
+D('foo.com', 'none',
+    a.records(),
+    b.records(),
+    TXT('loads', String(helperLoads)),
+    TXT('leaked', typeof name)
+);
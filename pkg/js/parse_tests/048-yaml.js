@@ -0,0 +1,5 @@
+D('foo.com', 'none',
+    DefaultTTL(600),
+    require('./yaml/mail.yaml'),
+    A('www', '1.2.3.4')
+);
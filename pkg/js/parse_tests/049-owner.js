@@ -0,0 +1,2 @@
+D('foo.com', 'none', OWNER('web', 'web@example.com'), A('@', '1.2.3.4'));
+D('bar.com', 'none', OWNER('api'));
@@ -0,0 +1,6 @@
+// A CommonJS module: it only runs once, however many files require it.
+helperLoads = (typeof helperLoads === 'undefined' ? 0 : helperLoads) + 1;
+
+exports.cname = function (label) {
+    return CNAME(label, 'foo.com.');
+};
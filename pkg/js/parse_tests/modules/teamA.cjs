@@ -0,0 +1,8 @@
+var name = 'a'; // Not visible outside of this module.
+var helpers = require('./lib/helpers.cjs');
+
+module.exports = {
+    records: function () {
+        return [helpers.cname(name)];
+    },
+};
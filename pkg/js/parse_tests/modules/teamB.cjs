@@ -0,0 +1,4 @@
+var name = 'b'; // Not visible outside of this module.
+const { cname } = require('./lib/helpers.cjs');
+
+exports.records = () => [cname(name)];
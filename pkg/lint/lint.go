@@ -0,0 +1,281 @@
+// Package lint finds things in a configuration that are valid, but
+// probably a mistake: an SPF record that needs too many lookups, an MX
+// that points at a CNAME, and so on.
+//
+// Each check is a Rule. Rules are registered with Register, and each
+// can be enabled or disabled when the linter is run.
+package lint
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/spflib"
+)
+
+// Level is how serious a finding is. The values are those of SARIF.
+type Level string
+
+// The levels of findings.
+const (
+	Warning Level = "warning" // Probably a mistake.
+	Note    Level = "note"    // A matter of opinion.
+)
+
+// Finding is something a rule found.
+type Finding struct {
+	Rule    string `json:"rule"`
+	Level   Level  `json:"level"`
+	Domain  string `json:"domain"`
+	Name    string `json:"name,omitempty"`   // FQDN of the record, if the finding is about one.
+	Type    string `json:"type,omitempty"`   // Type of the record, if the finding is about one.
+	Source  string `json:"source,omitempty"` // Where the record is defined ("file:line"), if known.
+	Message string `json:"message"`
+}
+
+// Rule is a check that is run on each domain.
+type Rule struct {
+	Name        string // Used to enable or disable the rule.
+	Description string
+	Level       Level
+	Disabled    bool // If true, the rule only runs if it is enabled explicitly.
+	Run         func(*Pass)
+}
+
+var rules = map[string]*Rule{}
+
+// Register adds a rule. It panics if a rule with the same name exists.
+func Register(r *Rule) {
+	if _, ok := rules[r.Name]; ok {
+		panic(fmt.Sprintf("lint: rule %q registered twice", r.Name))
+	}
+	rules[r.Name] = r
+}
+
+// Rules returns all the rules, sorted by name.
+func Rules() []*Rule {
+	var all []*Rule
+	for _, r := range rules {
+		all = append(all, r)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Name < all[j].Name })
+	return all
+}
+
+// Options selects the rules that are run.
+type Options struct {
+	Enable  []string // Rules to run even if they are disabled by default.
+	Disable []string // Rules not to run.
+
+	// SPF resolves the SPF records of domains that are not in the
+	// configuration. If nil, they are looked up in the DNS.
+	SPF spflib.Resolver
+}
+
+// Selected returns the rules that opts selects.
+func (opts Options) Selected() ([]*Rule, error) {
+	enable := map[string]bool{}
+	for _, name := range opts.Enable {
+		if rules[name] == nil {
+			return nil, fmt.Errorf("unknown lint rule %q", name)
+		}
+		enable[name] = true
+	}
+	for _, name := range opts.Disable {
+		if rules[name] == nil {
+			return nil, fmt.Errorf("unknown lint rule %q", name)
+		}
+		enable[name] = false
+	}
+	var selected []*Rule
+	for _, r := range Rules() {
+		if on, ok := enable[r.Name]; on || (!ok && !r.Disabled) {
+			selected = append(selected, r)
+		}
+	}
+	return selected, nil
+}
+
+// Run runs the rules selected by opts on each domain of cfg, which
+// must be normalized. Findings are sorted by domain, name and rule.
+func Run(cfg *models.DNSConfig, opts Options) ([]Finding, error) {
+	selected, err := opts.Selected()
+	if err != nil {
+		return nil, err
+	}
+	var inner spflib.Resolver = spflib.LiveResolver{}
+	if opts.SPF != nil {
+		inner = opts.SPF
+	}
+	z := newZones(cfg)
+	spf := &configResolver{zones: z, inner: inner}
+
+	var findings []Finding
+	for _, dc := range cfg.Domains {
+		for _, r := range selected {
+			p := &Pass{Config: cfg, Domain: dc, SPF: spf, zones: z, rule: r}
+			r.Run(p)
+			findings = append(findings, p.findings...)
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Domain != b.Domain {
+			return a.Domain < b.Domain
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.Rule < b.Rule
+	})
+	return findings, nil
+}
+
+// Pass is what a rule is given to check a domain.
+type Pass struct {
+	Config *models.DNSConfig
+	Domain *models.DomainConfig
+	SPF    spflib.Resolver // Uses the records of the configuration first.
+
+	zones    *zones
+	rule     *Rule
+	findings []Finding
+}
+
+// Reportf reports a finding about rc, or about the domain if rc is nil.
+func (p *Pass) Reportf(rc *models.RecordConfig, format string, args ...interface{}) {
+	f := Finding{
+		Rule:    p.rule.Name,
+		Level:   p.rule.Level,
+		Domain:  p.Domain.UniqueName,
+		Message: fmt.Sprintf(format, args...),
+	}
+	if f.Domain == "" {
+		f.Domain = p.Domain.Name
+	}
+	if rc != nil {
+		f.Name = rc.NameFQDN
+		f.Type = rc.Type
+		f.Source = rc.Source
+	}
+	p.findings = append(p.findings, f)
+}
+
+// Lookup returns the records named fqdn, in any domain of the
+// configuration, and whether a domain of the configuration contains
+// fqdn. fqdn may end with a dot.
+func (p *Pass) Lookup(fqdn string) (models.Records, bool) {
+	return p.zones.lookup(fqdn)
+}
+
+// zones indexes the records of all domains by name.
+type zones struct {
+	names   map[string]bool // The domains.
+	records map[string]models.Records
+}
+
+func newZones(cfg *models.DNSConfig) *zones {
+	z := &zones{names: map[string]bool{}, records: map[string]models.Records{}}
+	for _, dc := range cfg.Domains {
+		z.names[dc.Name] = true
+		for _, rc := range dc.Records {
+			z.records[rc.NameFQDN] = append(z.records[rc.NameFQDN], rc)
+		}
+	}
+	return z
+}
+
+func (z *zones) lookup(fqdn string) (models.Records, bool) {
+	fqdn = strings.ToLower(strings.TrimSuffix(fqdn, "."))
+	return z.records[fqdn], z.managed(fqdn)
+}
+
+// managed returns whether fqdn is in one of the domains.
+func (z *zones) managed(fqdn string) bool {
+	for name := fqdn; ; {
+		if z.names[name] {
+			return true
+		}
+		i := strings.IndexByte(name, '.')
+		if i < 0 {
+			return false
+		}
+		name = name[i+1:]
+	}
+}
+
+// configResolver returns the SPF, A, AAAA and MX records of the
+// domains of the configuration from the configuration, and the others
+// from inner.
+type configResolver struct {
+	zones *zones
+	inner spflib.Resolver
+}
+
+func (c *configResolver) GetSPF(name string) (string, error) {
+	recs, managed := c.zones.lookup(name)
+	if !managed {
+		return c.inner.GetSPF(name)
+	}
+	for _, rc := range recs {
+		if spf, ok := spfText(rc); ok {
+			return spf, nil
+		}
+	}
+	return "", fmt.Errorf("%w found for %s", spflib.ErrNoSPF, name)
+}
+
+func (c *configResolver) GetA(name string) ([]net.IP, error) {
+	return c.getIPs(name, "A", c.inner.GetA)
+}
+
+func (c *configResolver) GetAAAA(name string) ([]net.IP, error) {
+	return c.getIPs(name, "AAAA", c.inner.GetAAAA)
+}
+
+func (c *configResolver) getIPs(name, rtype string, inner func(string) ([]net.IP, error)) ([]net.IP, error) {
+	recs, managed := c.zones.lookup(name)
+	if !managed {
+		return inner(name)
+	}
+	var ips []net.IP
+	for _, rc := range recs {
+		if rc.Type == rtype {
+			ips = append(ips, rc.GetTargetIP())
+		}
+	}
+	return ips, nil
+}
+
+func (c *configResolver) GetMX(name string) ([]string, error) {
+	recs, managed := c.zones.lookup(name)
+	if !managed {
+		return c.inner.GetMX(name)
+	}
+	var mxs []*models.RecordConfig
+	for _, rc := range recs {
+		if rc.Type == "MX" {
+			mxs = append(mxs, rc)
+		}
+	}
+	sort.SliceStable(mxs, func(i, j int) bool { return mxs[i].MxPreference < mxs[j].MxPreference })
+	var hosts []string
+	for _, rc := range mxs {
+		if host := strings.TrimSuffix(rc.GetTargetField(), "."); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts, nil
+}
+
+// spfText returns the text of rc if it is an SPF record.
+func spfText(rc *models.RecordConfig) (string, bool) {
+	if rc.Type != "TXT" && rc.Type != "SPF" {
+		return "", false
+	}
+	txt := strings.Join(rc.TxtStrings, "")
+	return txt, strings.HasPrefix(txt, "v=spf1 ")
+}
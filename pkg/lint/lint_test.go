@@ -0,0 +1,208 @@
+package lint
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+)
+
+type fakeResolver map[string]string
+
+func (r fakeResolver) GetSPF(name string) (string, error) {
+	if spf, ok := r[name]; ok {
+		return spf, nil
+	}
+	return "", fmt.Errorf("no SPF record for %s", name)
+}
+
+func (r fakeResolver) GetA(name string) ([]net.IP, error)    { return nil, nil }
+func (r fakeResolver) GetAAAA(name string) ([]net.IP, error) { return nil, nil }
+func (r fakeResolver) GetMX(name string) ([]string, error)   { return nil, nil }
+
+// record returns a record of example.com.
+func record(rtype, name, target string) *models.RecordConfig {
+	rc := &models.RecordConfig{Type: rtype, TTL: 300, Metadata: map[string]string{}}
+	rc.SetLabel(name, "example.com")
+	switch rtype {
+	case "TXT":
+		rc.SetTargetTXT(target)
+	case "MX":
+		rc.SetTargetMX(10, target)
+	default:
+		rc.SetTarget(target)
+	}
+	return rc
+}
+
+func findings(t *testing.T, rule string, recs ...*models.RecordConfig) []string {
+	t.Helper()
+	cfg := &models.DNSConfig{Domains: []*models.DomainConfig{{Name: "example.com", Records: recs}}}
+	var disable []string
+	for _, r := range Rules() {
+		if r.Name != rule {
+			disable = append(disable, r.Name)
+		}
+	}
+	fs, err := Run(cfg, Options{Enable: []string{rule}, Disable: disable, SPF: fakeResolver{
+		"_spf.example.net": "v=spf1 a mx a:a.example.net a:b.example.net -all",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range fs {
+		got = append(got, f.Name+" "+f.Message)
+	}
+	return got
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		rule string
+		recs []*models.RecordConfig
+		want []string
+	}{
+		{
+			rule: "caa-missing",
+			recs: []*models.RecordConfig{record("A", "@", "1.2.3.4")},
+			want: []string{" no CAA record at the apex of example.com"},
+		},
+		{
+			rule: "caa-missing",
+			recs: []*models.RecordConfig{record("CAA", "@", "letsencrypt.org")},
+		},
+		{
+			rule: "cname-dangling",
+			recs: []*models.RecordConfig{
+				record("CNAME", "a", "missing.example.com."),
+				record("CNAME", "b", "host.example.com."),
+				record("CNAME", "c", "example.com."),
+				record("CNAME", "d", "x.sub.example.com."),
+				record("CNAME", "e", "elsewhere.example.net."),
+				record("A", "host", "1.2.3.4"),
+				record("NS", "sub", "ns.example.net."),
+			},
+			want: []string{"a.example.com CNAME a.example.com points at missing.example.com, which has no records"},
+		},
+		{
+			rule: "cname-dangling",
+			recs: []*models.RecordConfig{
+				record("CNAME", "a", "missing.example.com."),
+				record("A", "*", "1.2.3.4"),
+			},
+		},
+		{
+			rule: "dmarc-missing",
+			recs: []*models.RecordConfig{
+				record("MX", "@", "mx.example.net."),
+				record("MX", "@", "mx2.example.net."),
+				record("MX", "null", "."),
+			},
+			want: []string{"example.com example.com has MX records but no DMARC policy (a TXT record at _dmarc.example.com)"},
+		},
+		{
+			rule: "dmarc-missing",
+			recs: []*models.RecordConfig{
+				record("MX", "sub", "mx.example.net."),
+				record("TXT", "_dmarc", "v=DMARC1; p=reject"),
+			},
+		},
+		{
+			rule: "mx-cname",
+			recs: []*models.RecordConfig{
+				record("MX", "@", "mail.example.com."),
+				record("MX", "@", "mx.example.com."),
+				record("CNAME", "mail", "host.example.net."),
+				record("A", "mx", "1.2.3.4"),
+			},
+			want: []string{"example.com MX example.com points at mail.example.com, which is a CNAME"},
+		},
+		{
+			rule: "spf-lookups",
+			recs: []*models.RecordConfig{
+				record("TXT", "@", "v=spf1 include:_spf.example.net include:spf.example.com a mx -all"),
+				record("TXT", "spf", "v=spf1 a a a a -all"),
+				record("TXT", "other", "v=spf1 include:unknown.example.net -all"),
+			},
+			want: []string{
+				"example.com the SPF record of example.com needs 12 DNS lookups, more than the 10 permitted",
+				"other.example.com cannot count the lookups of the SPF record of other.example.com: no SPF record for unknown.example.net",
+			},
+		},
+		{
+			rule: "ttl-outlier",
+			recs: func() []*models.RecordConfig {
+				recs := []*models.RecordConfig{record("A", "a", "1.2.3.4"), record("A", "b", "1.2.3.4"), record("A", "c", "1.2.3.4"), record("A", "d", "1.2.3.4")}
+				recs[3].TTL = 86400
+				return recs
+			}(),
+			want: []string{"d.example.com A d.example.com has a TTL of 86400, while the median TTL of example.com is 300"},
+		},
+		{
+			rule: "wildcard-shadow",
+			recs: []*models.RecordConfig{
+				record("A", "*", "1.2.3.4"),
+				record("MX", "*", "mx.example.net."),
+				record("A", "foo", "1.2.3.5"),
+				record("CNAME", "bar", "foo.example.com."),
+				record("A", "baz", "1.2.3.6"),
+				record("MX", "baz", "mx.example.net."),
+			},
+			want: []string{"foo.example.com foo.example.com exists, so *.example.com does not apply to it, but it has no MX record like *.example.com"},
+		},
+	}
+	for i, tst := range tests {
+		t.Run(fmt.Sprintf("%d-%s", i, tst.rule), func(t *testing.T) {
+			got := findings(t, tst.rule, tst.recs...)
+			if strings.Join(got, "\n") != strings.Join(tst.want, "\n") {
+				t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tst.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestSelected(t *testing.T) {
+	rules, err := Options{}.Selected()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rules {
+		if r.Disabled {
+			t.Errorf("rule %s is disabled by default but was selected", r.Name)
+		}
+	}
+	if _, err := (Options{Disable: []string{"no-such-rule"}}).Selected(); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	rules := Rules()
+	fs := []Finding{
+		{Rule: "mx-cname", Level: Warning, Domain: "example.com", Name: "example.com", Type: "MX", Source: "dir/dnsconfig.js:12", Message: "m1"},
+		{Rule: "caa-missing", Level: Note, Domain: "example.com", Message: "m2"},
+	}
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, rules, fs, "v1"); err != nil {
+		t.Fatal(err)
+	}
+	var got sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	results := got.Runs[0].Results
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if r := results[0]; rules[r.RuleIndex].Name != "mx-cname" || r.Locations[0].PhysicalLocation.ArtifactLocation.URI != "dir/dnsconfig.js" || r.Locations[0].PhysicalLocation.Region.StartLine != 12 {
+		t.Errorf("wrong result: %+v", r)
+	}
+	if r := results[1]; r.Locations[0].PhysicalLocation != nil || r.Locations[0].LogicalLocations[0].FullyQualifiedName != "example.com" {
+		t.Errorf("wrong result: %+v", r)
+	}
+}
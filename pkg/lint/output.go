@@ -0,0 +1,152 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// WriteText writes one line per finding, starting with where the
+// record is defined, like a compiler.
+func WriteText(w io.Writer, findings []Finding) error {
+	for _, f := range findings {
+		where := f.Source
+		if where == "" {
+			where = f.Domain
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s: %s [%s]\n", where, f.Level, f.Message, f.Rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the findings as a JSON array.
+func WriteJSON(w io.Writer, findings []Finding) error {
+	if findings == nil {
+		findings = []Finding{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(findings)
+}
+
+// The subset of SARIF 2.1.0 that is written by WriteSARIF.
+type (
+	sarifLog struct {
+		Version string     `json:"version"`
+		Schema  string     `json:"$schema"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		Version        string      `json:"version,omitempty"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID                   string       `json:"id"`
+		ShortDescription     sarifMessage `json:"shortDescription"`
+		DefaultConfiguration struct {
+			Level Level `json:"level"`
+		} `json:"defaultConfiguration"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		RuleIndex int             `json:"ruleIndex"`
+		Level     Level           `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+	sarifLocation struct {
+		PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+		LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+	}
+	sarifPhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region struct {
+			StartLine int `json:"startLine"`
+		} `json:"region"`
+	}
+	sarifLogicalLocation struct {
+		FullyQualifiedName string `json:"fullyQualifiedName"`
+		Kind               string `json:"kind"`
+	}
+)
+
+// WriteSARIF writes the findings in the SARIF format, which code
+// review tools such as GitHub code scanning read. rules are those that
+// were run; version is the version of dnscontrol.
+func WriteSARIF(w io.Writer, rules []*Rule, findings []Finding, version string) error {
+	driver := sarifDriver{
+		Name:           "dnscontrol",
+		Version:        version,
+		InformationURI: "https://docs.dnscontrol.org/commands/lint",
+		Rules:          []sarifRule{},
+	}
+	index := map[string]int{}
+	for i, r := range rules {
+		sr := sarifRule{ID: r.Name, ShortDescription: sarifMessage{r.Description}}
+		sr.DefaultConfiguration.Level = r.Level
+		driver.Rules = append(driver.Rules, sr)
+		index[r.Name] = i
+	}
+
+	results := []sarifResult{}
+	for _, f := range findings {
+		loc := sarifLocation{}
+		if file, line, ok := splitSource(f.Source); ok {
+			loc.PhysicalLocation = &sarifPhysicalLocation{}
+			loc.PhysicalLocation.ArtifactLocation.URI = filepath.ToSlash(file)
+			loc.PhysicalLocation.Region.StartLine = line
+		}
+		if f.Name != "" {
+			loc.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: f.Name + "/" + f.Type, Kind: "record"}}
+		} else {
+			loc.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: f.Domain, Kind: "domain"}}
+		}
+		results = append(results, sarifResult{
+			RuleID:    f.Rule,
+			RuleIndex: index[f.Rule],
+			Level:     f.Level,
+			Message:   sarifMessage{f.Message},
+			Locations: []sarifLocation{loc},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
+
+// splitSource splits "file:line".
+func splitSource(source string) (file string, line int, ok bool) {
+	i := strings.LastIndexByte(source, ':')
+	if i < 0 {
+		return "", 0, false
+	}
+	line, err := strconv.Atoi(source[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return source[:i], line, true
+}
@@ -0,0 +1,233 @@
+package lint
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/spflib"
+	"golang.org/x/exp/slices"
+)
+
+// Keep these in alphabetical order.
+
+func init() {
+	Register(&Rule{
+		Name:        "caa-missing",
+		Description: "The apex has no CAA record, so any certificate authority may issue certificates for the domain.",
+		Level:       Note,
+		Run:         caaMissing,
+	})
+	Register(&Rule{
+		Name:        "cname-dangling",
+		Description: "A CNAME points at a name in a managed domain that has no records.",
+		Level:       Warning,
+		Run:         cnameDangling,
+	})
+	Register(&Rule{
+		Name:        "dmarc-missing",
+		Description: "A name has MX records but there is no DMARC policy for it.",
+		Level:       Warning,
+		Run:         dmarcMissing,
+	})
+	Register(&Rule{
+		Name:        "mx-cname",
+		Description: "An MX record points at a CNAME, which RFC 2181 forbids.",
+		Level:       Warning,
+		Run:         mxCNAME,
+	})
+	Register(&Rule{
+		Name:        "spf-lookups",
+		Description: "An SPF record needs more than the 10 DNS lookups permitted by RFC 7208.",
+		Level:       Warning,
+		Run:         spfLookups,
+	})
+	Register(&Rule{
+		Name:        "ttl-outlier",
+		Description: "A record has a TTL 10 times higher or lower than the median TTL of the domain.",
+		Level:       Note,
+		Disabled:    true,
+		Run:         ttlOutlier,
+	})
+	Register(&Rule{
+		Name:        "wildcard-shadow",
+		Description: "A name exists next to a wildcard, so the wildcard does not apply to it, but it lacks a type of record the wildcard has.",
+		Level:       Note,
+		Run:         wildcardShadow,
+	})
+}
+
+func caaMissing(p *Pass) {
+	for _, rc := range p.Domain.Records {
+		if rc.Type == "CAA" && rc.GetLabel() == "@" {
+			return
+		}
+	}
+	p.Reportf(nil, "no CAA record at the apex of %s", p.Domain.Name)
+}
+
+func cnameDangling(p *Pass) {
+	for _, rc := range p.Domain.Records.GetByType("CNAME") {
+		target := strings.TrimSuffix(rc.GetTargetField(), ".")
+		recs, managed := p.Lookup(target)
+		if !managed || len(recs) != 0 || p.zones.names[target] || p.delegated(target) || p.wildcard(target) != "" {
+			continue
+		}
+		p.Reportf(rc, "CNAME %s points at %s, which has no records", rc.NameFQDN, target)
+	}
+}
+
+func dmarcMissing(p *Pass) {
+	apexPolicy := p.hasDMARC(p.Domain.Name)
+	seen := map[string]bool{}
+	for _, rc := range p.Domain.Records.GetByType("MX") {
+		if rc.GetTargetField() == "." || seen[rc.NameFQDN] {
+			// A null MX (RFC 7505) receives no mail.
+			continue
+		}
+		seen[rc.NameFQDN] = true
+		if apexPolicy || p.hasDMARC(rc.NameFQDN) {
+			continue
+		}
+		p.Reportf(rc, "%s has MX records but no DMARC policy (a TXT record at _dmarc.%s)", rc.NameFQDN, p.Domain.Name)
+	}
+}
+
+func (p *Pass) hasDMARC(fqdn string) bool {
+	recs, _ := p.Lookup("_dmarc." + fqdn)
+	for _, rc := range recs {
+		if rc.Type == "TXT" && strings.HasPrefix(strings.Join(rc.TxtStrings, ""), "v=DMARC1") {
+			return true
+		}
+	}
+	return false
+}
+
+func mxCNAME(p *Pass) {
+	for _, rc := range p.Domain.Records.GetByType("MX") {
+		target := rc.GetTargetField()
+		if target == "." {
+			continue
+		}
+		recs, _ := p.Lookup(target)
+		for _, t := range recs {
+			if t.Type == "CNAME" {
+				p.Reportf(rc, "MX %s points at %s, which is a CNAME", rc.NameFQDN, strings.TrimSuffix(target, "."))
+				break
+			}
+		}
+	}
+}
+
+// maxSPFLookups is the limit of RFC 7208, section 4.6.4.
+const maxSPFLookups = 10
+
+func spfLookups(p *Pass) {
+	for _, rc := range p.Domain.Records {
+		txt, ok := spfText(rc)
+		if !ok {
+			continue
+		}
+		rec, err := spflib.Parse(txt, p.SPF)
+		if err != nil {
+			p.Reportf(rc, "cannot count the lookups of the SPF record of %s: %s", rc.NameFQDN, err)
+			continue
+		}
+		if n := rec.Lookups(); n > maxSPFLookups {
+			p.Reportf(rc, "the SPF record of %s needs %d DNS lookups, more than the %d permitted", rc.NameFQDN, n, maxSPFLookups)
+		}
+	}
+}
+
+func ttlOutlier(p *Pass) {
+	recs := p.Domain.Records
+	if len(recs) < 4 {
+		// Too few records for a median to mean anything.
+		return
+	}
+	ttls := make([]uint64, len(recs))
+	for i, rc := range recs {
+		ttls[i] = uint64(rc.TTL)
+	}
+	sort.Slice(ttls, func(i, j int) bool { return ttls[i] < ttls[j] })
+	median := ttls[len(ttls)/2]
+	for _, rc := range recs {
+		if ttl := uint64(rc.TTL); ttl >= median*10 || ttl*10 <= median {
+			p.Reportf(rc, "%s %s has a TTL of %d, while the median TTL of %s is %d", rc.Type, rc.NameFQDN, rc.TTL, p.Domain.Name, median)
+		}
+	}
+}
+
+func wildcardShadow(p *Pass) {
+	names, byName := p.Domain.Records.GroupedByFQDN()
+	for _, name := range names {
+		wildcard := p.wildcard(name)
+		if wildcard == "" || wildcard == name {
+			continue
+		}
+		recs := byName[name]
+		if hasType(recs, "CNAME") {
+			continue
+		}
+		var missing []string
+		for _, rtype := range types(byName[wildcard]) {
+			if !hasType(recs, rtype) {
+				missing = append(missing, rtype)
+			}
+		}
+		if len(missing) != 0 {
+			p.Reportf(recs[0], "%s exists, so %s does not apply to it, but it has no %s record like %s", name, wildcard, strings.Join(missing, "/"), wildcard)
+		}
+	}
+}
+
+// wildcard returns the name of the wildcard whose records fqdn would
+// match if it did not exist, or "".
+func (p *Pass) wildcard(fqdn string) string {
+	i := strings.IndexByte(fqdn, '.')
+	if i < 0 {
+		return ""
+	}
+	w := "*" + fqdn[i:]
+	if recs, _ := p.Lookup(w); len(recs) != 0 {
+		return w
+	}
+	return ""
+}
+
+// delegated returns whether fqdn is in a subdomain delegated by NS
+// records.
+func (p *Pass) delegated(fqdn string) bool {
+	for name := fqdn; !p.zones.names[name]; {
+		recs, _ := p.Lookup(name)
+		if hasType(recs, "NS") {
+			return true
+		}
+		i := strings.IndexByte(name, '.')
+		if i < 0 {
+			return false
+		}
+		name = name[i+1:]
+	}
+	return false
+}
+
+func hasType(recs models.Records, rtype string) bool {
+	for _, rc := range recs {
+		if rc.Type == rtype {
+			return true
+		}
+	}
+	return false
+}
+
+// types returns the types of recs, in order of appearance.
+func types(recs models.Records) []string {
+	var ts []string
+	for _, rc := range recs {
+		if !slices.Contains(ts, rc.Type) {
+			ts = append(ts, rc.Type)
+		}
+	}
+	return ts
+}
@@ -0,0 +1,428 @@
+// Package localdns implements a small in-process authoritative DNS
+// server. It answers queries, zone transfers (AXFR, RFC 5936) and
+// dynamic updates (RFC 2136), optionally authenticated with TSIG.
+//
+// It is meant for tests: the integration tests run against the
+// AXFRDDNS provider and this server, without any network or
+// credentials.
+package localdns
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Key is a TSIG key.
+type Key struct {
+	Name      string // A FQDN, for example "update.".
+	Algorithm string // For example dns.HmacSHA256.
+	Secret    string // Base64.
+}
+
+// Server is an authoritative DNS server for a set of zones.
+type Server struct {
+	// TransferKey and UpdateKey, if set, are required to transfer and
+	// to update a zone. They must be set before Start.
+	TransferKey *Key
+	UpdateKey   *Key
+
+	mu    sync.Mutex
+	zones map[string][]dns.RR // The SOA is first.
+
+	addr     string
+	udp, tcp *dns.Server
+}
+
+// New returns a server with no zones.
+func New() *Server {
+	return &Server{zones: map[string][]dns.RR{}}
+}
+
+// AddZone adds (or replaces) the zone origin with the records rrs. A
+// SOA is created if rrs has none.
+func (s *Server) AddZone(origin string, rrs ...dns.RR) error {
+	origin = canonical(origin)
+	var soa dns.RR
+	var rest []dns.RR
+	for _, rr := range rrs {
+		if !dns.IsSubDomain(origin, canonical(rr.Header().Name)) {
+			return fmt.Errorf("%s is not in zone %s", rr.Header().Name, origin)
+		}
+		if rr.Header().Rrtype == dns.TypeSOA {
+			soa = rr
+			continue
+		}
+		rest = append(rest, rr)
+	}
+	if soa == nil {
+		var err error
+		soa, err = dns.NewRR(fmt.Sprintf("%s 300 IN SOA ns.%s hostmaster.%s 1 3600 600 604800 300", origin, origin, origin))
+		if err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.zones[origin] = append([]dns.RR{soa}, rest...)
+	return nil
+}
+
+// Records returns a copy of the records of the zone origin, the SOA
+// first, or nil if there is no such zone.
+func (s *Server) Records(origin string) []dns.RR {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var rrs []dns.RR
+	for _, rr := range s.zones[canonical(origin)] {
+		rrs = append(rrs, dns.Copy(rr))
+	}
+	return rrs
+}
+
+// Addr returns the address (host:port) the server listens on, for both
+// UDP and TCP.
+func (s *Server) Addr() string {
+	return s.addr
+}
+
+// Start starts listening on a random port of the loopback interface.
+func (s *Server) Start() error {
+	secrets := map[string]string{}
+	for _, k := range []*Key{s.TransferKey, s.UpdateKey} {
+		if k != nil {
+			secrets[k.Name] = k.Secret
+		}
+	}
+
+	// The UDP port may be in use by another process. Try again with
+	// another one.
+	var l net.Listener
+	var pc net.PacketConn
+	var err error
+	for i := 0; i < 10; i++ {
+		l, err = net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return err
+		}
+		pc, err = net.ListenPacket("udp", l.Addr().String())
+		if err == nil {
+			break
+		}
+		l.Close()
+	}
+	if err != nil {
+		return err
+	}
+	s.addr = l.Addr().String()
+
+	var wg sync.WaitGroup
+	serve := func(srv *dns.Server, start func() error) {
+		wg.Add(1)
+		srv.NotifyStartedFunc = wg.Done
+		go start()
+	}
+	s.tcp = s.newDNSServer(secrets)
+	s.tcp.Listener = l
+	serve(s.tcp, s.tcp.ActivateAndServe)
+	s.udp = s.newDNSServer(secrets)
+	s.udp.PacketConn = pc
+	serve(s.udp, s.udp.ActivateAndServe)
+	wg.Wait()
+	return nil
+}
+
+func (s *Server) newDNSServer(secrets map[string]string) *dns.Server {
+	return &dns.Server{
+		Handler:       s,
+		TsigSecret:    secrets,
+		UDPSize:       dns.MaxMsgSize, // Updates may be large.
+		MsgAcceptFunc: acceptFunc,
+	}
+}
+
+// Shutdown stops the server.
+func (s *Server) Shutdown() error {
+	var errs []string
+	for _, srv := range []*dns.Server{s.tcp, s.udp} {
+		if srv == nil {
+			continue
+		}
+		if err := srv.Shutdown(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) != 0 {
+		return fmt.Errorf("shutting down: %s", strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// acceptFunc accepts updates too, unlike dns.DefaultMsgAcceptFunc.
+func acceptFunc(dh dns.Header) dns.MsgAcceptAction {
+	const qr = 1 << 15
+	if dh.Bits&qr != 0 {
+		return dns.MsgIgnore
+	}
+	switch opcode := int(dh.Bits>>11) & 0xF; opcode {
+	case dns.OpcodeQuery, dns.OpcodeUpdate:
+	default:
+		return dns.MsgRejectNotImplemented
+	}
+	if dh.Qdcount != 1 {
+		return dns.MsgReject
+	}
+	return dns.MsgAccept
+}
+
+// ServeDNS implements dns.Handler.
+func (s *Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	q := r.Question[0]
+	switch {
+	case r.Opcode == dns.OpcodeUpdate:
+		s.reply(w, r, s.update(w, r))
+	case q.Qtype == dns.TypeAXFR:
+		s.transfer(w, r)
+	default:
+		s.query(w, r)
+	}
+}
+
+// authorized returns true if r is signed with the key k, or k is nil.
+func authorized(w dns.ResponseWriter, r *dns.Msg, k *Key) bool {
+	if k == nil {
+		return true
+	}
+	t := r.IsTsig()
+	return t != nil && w.TsigStatus() == nil && strings.EqualFold(t.Hdr.Name, k.Name)
+}
+
+// reply sends an empty reply with rcode, signed if r is.
+func (s *Server) reply(w dns.ResponseWriter, r *dns.Msg, rcode int) {
+	m := new(dns.Msg)
+	m.SetRcode(r, rcode)
+	m.Authoritative = true
+	if t := r.IsTsig(); t != nil && w.TsigStatus() == nil {
+		m.SetTsig(t.Hdr.Name, t.Algorithm, t.Fudge, time.Now().Unix())
+	}
+	w.WriteMsg(m)
+}
+
+func (s *Server) transfer(w dns.ResponseWriter, r *dns.Msg) {
+	zone := canonical(r.Question[0].Name)
+	if !authorized(w, r, s.TransferKey) {
+		s.reply(w, r, dns.RcodeNotAuth)
+		return
+	}
+	rrs := s.Records(zone)
+	if rrs == nil {
+		s.reply(w, r, dns.RcodeNotAuth)
+		return
+	}
+	// The SOA is sent first and last. Large zones are sent in several
+	// messages.
+	rrs = append(rrs, rrs[0])
+	ch := make(chan *dns.Envelope)
+	go func() {
+		defer close(ch)
+		for len(rrs) != 0 {
+			n := len(rrs)
+			if n > 200 {
+				n = 200
+			}
+			ch <- &dns.Envelope{RR: rrs[:n]}
+			rrs = rrs[n:]
+		}
+	}()
+	tr := new(dns.Transfer)
+	if err := tr.Out(w, r, ch); err != nil {
+		for range ch {
+			// Let the sender finish.
+		}
+	}
+}
+
+func (s *Server) query(w dns.ResponseWriter, r *dns.Msg) {
+	q := r.Question[0]
+	name := canonical(q.Name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	origin := s.findZone(name)
+	if origin == "" {
+		s.reply(w, r, dns.RcodeRefused)
+		return
+	}
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+	exists := false
+	for _, rr := range s.zones[origin] {
+		if canonical(rr.Header().Name) != name {
+			continue
+		}
+		exists = true
+		t := rr.Header().Rrtype
+		if t == q.Qtype || q.Qtype == dns.TypeANY || t == dns.TypeCNAME {
+			m.Answer = append(m.Answer, dns.Copy(rr))
+		}
+	}
+	if len(m.Answer) == 0 {
+		m.Ns = append(m.Ns, dns.Copy(s.zones[origin][0]))
+		if !exists {
+			m.Rcode = dns.RcodeNameError
+		}
+	}
+	w.WriteMsg(m)
+}
+
+// findZone returns the zone that name belongs to, or "". It must be
+// called with the lock held.
+func (s *Server) findZone(name string) string {
+	for labels := dns.SplitDomainName(name); len(labels) != 0; labels = labels[1:] {
+		origin := canonical(strings.Join(labels, "."))
+		if _, ok := s.zones[origin]; ok {
+			return origin
+		}
+	}
+	return ""
+}
+
+// update processes a dynamic update and returns the rcode.
+// Prerequisites (RFC 2136, section 2.4) are not supported.
+func (s *Server) update(w dns.ResponseWriter, r *dns.Msg) int {
+	if !authorized(w, r, s.UpdateKey) {
+		return dns.RcodeRefused
+	}
+	zone := canonical(r.Question[0].Name)
+	if len(r.Answer) != 0 {
+		return dns.RcodeNotImplemented
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rrs, ok := s.zones[zone]
+	if !ok {
+		return dns.RcodeNotAuth
+	}
+
+	// Check the entire update before applying any of it.
+	for _, rr := range r.Ns {
+		h := rr.Header()
+		if !dns.IsSubDomain(zone, canonical(h.Name)) {
+			return dns.RcodeNotZone
+		}
+		switch h.Class {
+		case dns.ClassINET, dns.ClassANY, dns.ClassNONE:
+		default:
+			return dns.RcodeFormatError
+		}
+	}
+
+	changed := false
+	for _, rr := range r.Ns {
+		var c bool
+		rrs, c = applyUpdate(zone, rrs, rr)
+		changed = changed || c
+	}
+	if changed {
+		rrs[0].(*dns.SOA).Serial++
+	}
+	s.zones[zone] = rrs
+	return dns.RcodeSuccess
+}
+
+// applyUpdate applies one RR of the update section (RFC 2136, section
+// 3.4.2) to the records of zone. It returns the new records, and
+// whether they changed.
+func applyUpdate(zone string, rrs []dns.RR, u dns.RR) ([]dns.RR, bool) {
+	h := u.Header()
+	name := canonical(h.Name)
+	apex := name == zone
+
+	// keep returns the records except those for which del is true.
+	keep := func(del func(rr dns.RR) bool) ([]dns.RR, bool) {
+		kept := rrs[:1:1] // The SOA is never deleted.
+		for _, rr := range rrs[1:] {
+			if !del(rr) {
+				kept = append(kept, rr)
+			}
+		}
+		return kept, len(kept) != len(rrs)
+	}
+	at := func(rr dns.RR) bool { return canonical(rr.Header().Name) == name }
+
+	switch h.Class {
+
+	case dns.ClassANY:
+		// Delete an RRset, or all RRsets of a name. The NS of the
+		// apex are kept.
+		return keep(func(rr dns.RR) bool {
+			t := rr.Header().Rrtype
+			if !at(rr) || (apex && t == dns.TypeNS) {
+				return false
+			}
+			return h.Rrtype == dns.TypeANY || h.Rrtype == t
+		})
+
+	case dns.ClassNONE:
+		// Delete an RR. The last NS of the apex is kept.
+		target := dns.Copy(u)
+		target.Header().Class = dns.ClassINET
+		if apex && h.Rrtype == dns.TypeNS && count(rrs, name, dns.TypeNS) == 1 {
+			return rrs, false
+		}
+		return keep(func(rr dns.RR) bool { return dns.IsDuplicate(rr, target) })
+
+	}
+
+	// Add an RR.
+	u = dns.Copy(u)
+	switch {
+	case h.Rrtype == dns.TypeSOA:
+		if !apex {
+			return rrs, false
+		}
+		rrs[0] = u
+		return rrs, true
+	case h.Rrtype == dns.TypeCNAME:
+		// A CNAME replaces the CNAME, and can't coexist with other records.
+		if count(rrs, name, dns.TypeANY)-count(rrs, name, dns.TypeCNAME) != 0 {
+			return rrs, false
+		}
+		rrs, _ = keep(func(rr dns.RR) bool { return at(rr) && rr.Header().Rrtype == dns.TypeCNAME })
+	case count(rrs, name, dns.TypeCNAME) != 0:
+		return rrs, false
+	}
+	dup := false
+	for _, rr := range rrs[1:] {
+		if at(rr) && rr.Header().Rrtype == h.Rrtype {
+			// An RRset has a single TTL.
+			rr.Header().Ttl = h.Ttl
+			dup = dup || dns.IsDuplicate(rr, u)
+		}
+	}
+	if !dup {
+		rrs = append(rrs, u)
+	}
+	return rrs, true
+}
+
+// count returns the number of records of type rtype (or any type) at name.
+func count(rrs []dns.RR, name string, rtype uint16) int {
+	n := 0
+	for _, rr := range rrs[1:] {
+		if canonical(rr.Header().Name) == name && (rtype == dns.TypeANY || rr.Header().Rrtype == rtype) {
+			n++
+		}
+	}
+	return n
+}
+
+func canonical(name string) string {
+	return strings.ToLower(dns.Fqdn(name))
+}
@@ -0,0 +1,160 @@
+package localdns
+
+import (
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+var (
+	transferKey = &Key{Name: "transfer.", Algorithm: dns.HmacSHA256, Secret: "c2VjcmV0LXRyYW5zZmVy"}
+	updateKey   = &Key{Name: "update.", Algorithm: dns.HmacSHA256, Secret: "c2VjcmV0LXVwZGF0ZQ=="}
+)
+
+func mustRR(t *testing.T, s string) dns.RR {
+	t.Helper()
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rr
+}
+
+func start(t *testing.T) *Server {
+	t.Helper()
+	s := New()
+	s.TransferKey = transferKey
+	s.UpdateKey = updateKey
+	if err := s.AddZone("example.com", mustRR(t, "example.com. 300 IN NS ns.example.com.")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Shutdown() })
+	return s
+}
+
+func axfr(s *Server, k *Key) ([]dns.RR, error) {
+	m := new(dns.Msg)
+	m.SetAxfr("example.com.")
+	tr := new(dns.Transfer)
+	if k != nil {
+		tr.TsigSecret = map[string]string{k.Name: k.Secret}
+		m.SetTsig(k.Name, k.Algorithm, 300, time.Now().Unix())
+	}
+	env, err := tr.In(m, s.Addr())
+	if err != nil {
+		return nil, err
+	}
+	var rrs []dns.RR
+	for e := range env {
+		if e.Error != nil {
+			return nil, e.Error
+		}
+		rrs = append(rrs, e.RR...)
+	}
+	return rrs, nil
+}
+
+func update(t *testing.T, s *Server, k *Key, insert, remove []dns.RR) int {
+	t.Helper()
+	m := new(dns.Msg)
+	m.SetUpdate("example.com.")
+	m.Remove(remove)
+	m.Insert(insert)
+	c := new(dns.Client)
+	if k != nil {
+		c.TsigSecret = map[string]string{k.Name: k.Secret}
+		m.SetTsig(k.Name, k.Algorithm, 300, time.Now().Unix())
+	}
+	r, _, err := c.Exchange(m, s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r.Rcode
+}
+
+func TestTransfer(t *testing.T) {
+	s := start(t)
+
+	rrs, err := axfr(s, transferKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rrs) != 3 || rrs[0].Header().Rrtype != dns.TypeSOA || rrs[2].Header().Rrtype != dns.TypeSOA {
+		t.Errorf("unexpected transfer %v", rrs)
+	}
+
+	if _, err := axfr(s, nil); err == nil {
+		t.Errorf("expected a transfer without TSIG to fail")
+	}
+	if _, err := axfr(s, updateKey); err == nil {
+		t.Errorf("expected a transfer with the wrong key to fail")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	s := start(t)
+	a1 := mustRR(t, "www.example.com. 300 IN A 1.2.3.4")
+	a2 := mustRR(t, "www.example.com. 600 IN A 5.6.7.8")
+	cname := mustRR(t, "www.example.com. 300 IN CNAME example.com.")
+
+	if rc := update(t, s, nil, []dns.RR{a1}, nil); rc != dns.RcodeRefused {
+		t.Errorf("expected an update without TSIG to be refused, got %s", dns.RcodeToString[rc])
+	}
+	if rc := update(t, s, updateKey, []dns.RR{a1, a2}, nil); rc != dns.RcodeSuccess {
+		t.Fatalf("update failed: %s", dns.RcodeToString[rc])
+	}
+	rrs := s.Records("example.com")
+	if len(rrs) != 4 || rrs[0].(*dns.SOA).Serial != 2 {
+		t.Fatalf("unexpected records %v", rrs)
+	}
+	// The RRset has a single TTL.
+	if rrs[2].Header().Ttl != 600 {
+		t.Errorf("expected TTL 600, got %v", rrs[2])
+	}
+
+	// A CNAME can't be added next to other records.
+	update(t, s, updateKey, []dns.RR{cname}, nil)
+	if rrs := s.Records("example.com"); len(rrs) != 4 {
+		t.Errorf("unexpected records %v", rrs)
+	}
+
+	// Replace the A records by the CNAME.
+	update(t, s, updateKey, []dns.RR{cname}, []dns.RR{a1, a2})
+	rrs = s.Records("example.com")
+	if len(rrs) != 3 || rrs[2].Header().Rrtype != dns.TypeCNAME {
+		t.Errorf("unexpected records %v", rrs)
+	}
+
+	// The last NS of the apex is kept.
+	update(t, s, updateKey, nil, []dns.RR{mustRR(t, "example.com. 300 IN NS ns.example.com.")})
+	if rrs := s.Records("example.com"); len(rrs) != 3 {
+		t.Errorf("unexpected records %v", rrs)
+	}
+
+	if rc := update(t, s, updateKey, []dns.RR{mustRR(t, "www.example.org. 300 IN A 1.2.3.4")}, nil); rc != dns.RcodeNotZone {
+		t.Errorf("expected NOTZONE, got %s", dns.RcodeToString[rc])
+	}
+}
+
+func TestQuery(t *testing.T) {
+	s := start(t)
+	c := new(dns.Client)
+	m := new(dns.Msg)
+	m.SetQuestion("example.com.", dns.TypeNS)
+	r, _, err := c.Exchange(m, s.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Authoritative || len(r.Answer) != 1 {
+		t.Errorf("unexpected answer %v", r)
+	}
+
+	m.SetQuestion("nosuchname.example.com.", dns.TypeA)
+	if r, _, err = c.Exchange(m, s.Addr()); err != nil || r.Rcode != dns.RcodeNameError {
+		t.Errorf("expected NXDOMAIN, got %v %v", r, err)
+	}
+}
@@ -0,0 +1,210 @@
+// Package metrics writes metrics in the OpenMetrics text format, or
+// pushes them to a Prometheus Pushgateway.
+//
+// Only what dnscontrol needs is implemented: gauges and summaries
+// (count and sum, no quantiles). The values describe a single run, so
+// there are no counters.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Content types of the formats.
+const (
+	OpenMetricsType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	TextType        = "text/plain; version=0.0.4; charset=utf-8" // The Prometheus text format.
+)
+
+// Registry is a set of metric families. It is safe for concurrent use.
+type Registry struct {
+	mu       sync.Mutex
+	families []*Family
+}
+
+// Family is a metric family: one metric with different label values.
+type Family struct {
+	reg    *Registry
+	name   string
+	help   string
+	typ    string // "gauge" or "summary".
+	labels []string
+	series map[string]*series
+}
+
+type series struct {
+	values []string // Label values.
+	value  float64  // The gauge, or the sum of the summary.
+	count  uint64   // Summaries only.
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Gauge adds a gauge to the registry.
+func (r *Registry) Gauge(name, help string, labels ...string) *Family {
+	return r.add(name, help, "gauge", labels)
+}
+
+// Summary adds a summary to the registry.
+func (r *Registry) Summary(name, help string, labels ...string) *Family {
+	return r.add(name, help, "summary", labels)
+}
+
+func (r *Registry) add(name, help, typ string, labels []string) *Family {
+	f := &Family{reg: r, name: name, help: help, typ: typ, labels: labels, series: map[string]*series{}}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.families = append(r.families, f)
+	return f
+}
+
+// get returns the series for the label values. The caller must hold
+// f.reg.mu.
+func (f *Family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s has labels %v, got values %v", f.name, f.labels, values))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{values: values}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Set sets the value of a gauge.
+func (f *Family) Set(v float64, values ...string) {
+	f.reg.mu.Lock()
+	defer f.reg.mu.Unlock()
+	f.get(values).value = v
+}
+
+// Add adds to the value of a gauge.
+func (f *Family) Add(v float64, values ...string) {
+	f.reg.mu.Lock()
+	defer f.reg.mu.Unlock()
+	f.get(values).value += v
+}
+
+// Observe adds an observation to a summary.
+func (f *Family) Observe(v float64, values ...string) {
+	f.reg.mu.Lock()
+	defer f.reg.mu.Unlock()
+	s := f.get(values)
+	s.value += v
+	s.count++
+}
+
+// WriteOpenMetrics writes the metrics in the OpenMetrics text format.
+func (r *Registry) WriteOpenMetrics(w io.Writer) error {
+	return r.write(w, true)
+}
+
+// WriteText writes the metrics in the Prometheus text format, which is
+// the same without the "# EOF" marker.
+func (r *Registry) WriteText(w io.Writer) error {
+	return r.write(w, false)
+}
+
+func (r *Registry) write(w io.Writer, openMetrics bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var b bytes.Buffer
+	for _, f := range r.families {
+		fmt.Fprintf(&b, "# HELP %s %s\n", f.name, escape(f.help, false))
+		fmt.Fprintf(&b, "# TYPE %s %s\n", f.name, f.typ)
+		var all []*series
+		for _, s := range f.series {
+			all = append(all, s)
+		}
+		sort.Slice(all, func(i, j int) bool {
+			return strings.Join(all[i].values, "\xff") < strings.Join(all[j].values, "\xff")
+		})
+		for _, s := range all {
+			labels := f.labelString(s.values)
+			if f.typ == "summary" {
+				fmt.Fprintf(&b, "%s_count%s %d\n", f.name, labels, s.count)
+				fmt.Fprintf(&b, "%s_sum%s %s\n", f.name, labels, formatFloat(s.value))
+			} else {
+				fmt.Fprintf(&b, "%s%s %s\n", f.name, labels, formatFloat(s.value))
+			}
+		}
+	}
+	if openMetrics {
+		b.WriteString("# EOF\n")
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func (f *Family) labelString(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	var parts []string
+	for i, l := range f.labels {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, l, escape(values[i], true)))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// escape escapes a label value (quote is true) or a HELP text.
+func escape(s string, quote bool) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	if quote {
+		s = strings.ReplaceAll(s, `"`, `\"`)
+	}
+	return s
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Push replaces the metrics of job at the Pushgateway at gateway (for
+// example "http://localhost:9091").
+func (r *Registry) Push(gateway, job string) error {
+	var b bytes.Buffer
+	if err := r.WriteText(&b); err != nil {
+		return err
+	}
+	u := strings.TrimSuffix(gateway, "/") + "/metrics/job/" + url.PathEscape(job)
+	req, err := http.NewRequest(http.MethodPut, u, &b)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", TextType)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("pushing metrics to %s: %s: %s", u, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
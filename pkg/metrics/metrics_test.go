@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func testRegistry() *Registry {
+	r := NewRegistry()
+	g := r.Gauge("test_records", "Records in\nthe zone.", "domain", "provider")
+	g.Set(3, "example.com", "bind")
+	g.Add(2, "a\"b.com", "bind")
+	g.Add(1, "a\"b.com", "bind")
+	s := r.Summary("test_seconds", "Time spent.", "op")
+	s.Observe(0.5, "get")
+	s.Observe(0.25, "get")
+	r.Gauge("test_up", "Up.").Set(1)
+	return r
+}
+
+const want = `# HELP test_records Records in\nthe zone.
+# TYPE test_records gauge
+test_records{domain="a\"b.com",provider="bind"} 3
+test_records{domain="example.com",provider="bind"} 3
+# HELP test_seconds Time spent.
+# TYPE test_seconds summary
+test_seconds_count{op="get"} 2
+test_seconds_sum{op="get"} 0.75
+# HELP test_up Up.
+# TYPE test_up gauge
+test_up 1
+`
+
+func TestWrite(t *testing.T) {
+	var b bytes.Buffer
+	if err := testRegistry().WriteOpenMetrics(&b); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != want+"# EOF\n" {
+		t.Errorf("got:\n%s\nwant:\n%s# EOF", got, want)
+	}
+
+	b.Reset()
+	if err := testRegistry().WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestPush(t *testing.T) {
+	var method, path, ctype, body string
+	status := http.StatusOK
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		method, path, ctype, body = r.Method, r.URL.EscapedPath(), r.Header.Get("Content-Type"), string(b)
+		w.WriteHeader(status)
+	}))
+	defer ts.Close()
+
+	if err := testRegistry().Push(ts.URL+"/", "dns control"); err != nil {
+		t.Fatal(err)
+	}
+	if method != http.MethodPut || path != "/metrics/job/dns%20control" || ctype != TextType || body != want {
+		t.Errorf("unexpected request %s %s %s:\n%s", method, path, ctype, body)
+	}
+
+	status = http.StatusBadRequest
+	if err := testRegistry().Push(ts.URL, "dnscontrol"); err == nil {
+		t.Errorf("expected an error")
+	}
+}
@@ -22,6 +22,11 @@ func sortedKeys[K constraints.Ordered, V any](m map[K]V) []K {
 	return keys
 }
 
+// SPFCache is where the DNS lookups of SPF flattening are cached, and
+// what is done when the cache is out of date. The commands set it from
+// their flags.
+var SPFCache spflib.CacheConfig
+
 // hasSpfRecords returns true if this record requests SPF unrolling.
 func flattenSPFs(cfg *models.DNSConfig) []error {
 	var cache spflib.CachingResolver
@@ -35,14 +40,14 @@ func flattenSPFs(cfg *models.DNSConfig) []error {
 			txtTarget := strings.Join(txt.TxtStrings, "")
 			if txt.Metadata["flatten"] != "" || txt.Metadata["split"] != "" {
 				if cache == nil {
-					cache, err = spflib.NewCache("spfcache.json")
+					cache, err = SPFCache.Open()
 					if err != nil {
 						return []error{err}
 					}
 				}
-				rec, err = spflib.Parse(txtTarget, cache)
+				rec, err = spflib.ParseDomain(txtTarget, txt.GetLabelFQDN(), cache)
 				if err != nil {
-					errs = append(errs, err)
+					errs = append(errs, txt.WithSource(err))
 					continue
 				}
 			}
@@ -50,7 +55,7 @@ func flattenSPFs(cfg *models.DNSConfig) []error {
 				rec = rec.Flatten(flatten)
 				err = txt.SetTargetTXT(rec.TXT())
 				if err != nil {
-					errs = append(errs, err)
+					errs = append(errs, txt.WithSource(err))
 					continue
 				}
 			}
@@ -67,7 +72,7 @@ func flattenSPFs(cfg *models.DNSConfig) []error {
 				if oh, ok := txt.Metadata["overhead1"]; ok {
 					i, err := strconv.Atoi(oh)
 					if err != nil {
-						errs = append(errs, Warning{fmt.Errorf("split overhead1 %q is not an int", oh)})
+						errs = append(errs, atSource(txt, Warning{fmt.Errorf("split overhead1 %q is not an int", oh)}))
 					}
 					overhead1 = i
 				}
@@ -77,13 +82,13 @@ func flattenSPFs(cfg *models.DNSConfig) []error {
 				if oh, ok := txt.Metadata["txtMaxSize"]; ok {
 					i, err := strconv.Atoi(oh)
 					if err != nil {
-						errs = append(errs, Warning{fmt.Errorf("split txtMaxSize %q is not an int", oh)})
+						errs = append(errs, atSource(txt, Warning{fmt.Errorf("split txtMaxSize %q is not an int", oh)}))
 					}
 					txtMaxSize = i
 				}
 
 				if !strings.Contains(split, "%d") {
-					errs = append(errs, Warning{fmt.Errorf("split format `%s` in `%s` is not proper format (missing %%d)", split, txt.GetLabelFQDN())})
+					errs = append(errs, atSource(txt, Warning{fmt.Errorf("split format `%s` in `%s` is not proper format (missing %%d)", split, txt.GetLabelFQDN())}))
 					continue
 				}
 				recs := rec.TXTSplit(split+"."+domain.Name, overhead1, txtMaxSize)
@@ -107,17 +112,47 @@ func flattenSPFs(cfg *models.DNSConfig) []error {
 	}
 	// check if cache is stale
 	for _, e := range cache.ResolveErrors() {
-		errs = append(errs, Warning{fmt.Errorf("problem resolving SPF record: %s", e)})
+		err := fmt.Errorf("problem resolving SPF record: %s", e)
+		if !SPFCache.Refresh {
+			// The cached answer is used instead.
+			err = Warning{err}
+		}
+		errs = append(errs, err)
 	}
 	if len(cache.ResolveErrors()) == 0 {
-		changed := cache.ChangedRecords()
-		if len(changed) > 0 {
-			if err := cache.Save("spfcache.updated.json"); err != nil {
-				errs = append(errs, err)
-			} else {
-				errs = append(errs, Warning{fmt.Errorf("%d spf record lookups are out of date with cache (%s).\nWrote changes to spfcache.updated.json. Please rename and commit:\n    $ mv spfcache.updated.json spfcache.json\n    $ git commit -m 'Update spfcache.json' spfcache.json", len(changed), strings.Join(changed, ","))})
-			}
+		if err := checkSPFCache(cache); err != nil {
+			errs = append(errs, err)
 		}
 	}
 	return errs
 }
+
+// checkSPFCache applies the policy of SPFCache if cache is out of date.
+// If the answers that were looked up again are unchanged, only their
+// timestamps are updated in the cache file, whatever the policy (but
+// not if SPFCache.Check).
+func checkSPFCache(cache spflib.CachingResolver) error {
+	changed := cache.ChangedRecords()
+	file := SPFCache.Filename()
+	if len(changed) == 0 {
+		if len(cache.RefreshedRecords()) == 0 || SPFCache.Check {
+			return nil
+		}
+		return cache.Save(file)
+	}
+	if SPFCache.Policy == spflib.PolicyAutoUpdate {
+		if err := cache.Save(file); err != nil {
+			return err
+		}
+		return Warning{fmt.Errorf("%d spf record lookups were out of date with cache (%s).\nUpdated %s. Please commit it:\n    $ git commit -m 'Update %s' %s", len(changed), strings.Join(changed, ","), file, file, file)}
+	}
+	updated := SPFCache.UpdatedFilename()
+	if err := cache.Save(updated); err != nil {
+		return err
+	}
+	err := fmt.Errorf("%d spf record lookups are out of date with cache (%s).\nWrote changes to %s. Please rename and commit:\n    $ mv %s %s\n    $ git commit -m 'Update %s' %s", len(changed), strings.Join(changed, ","), updated, updated, file, file, file)
+	if SPFCache.Policy == spflib.PolicyFail {
+		return err
+	}
+	return Warning{err}
+}
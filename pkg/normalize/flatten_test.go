@@ -0,0 +1,76 @@
+package normalize
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/v3/pkg/spflib"
+)
+
+// staleCache is a CachingResolver whose changed records are changed,
+// and whose refreshed records were looked up again but are unchanged.
+type staleCache struct {
+	spflib.CachingResolver
+	changed   []string
+	refreshed []string
+	saved     []string
+}
+
+func (c *staleCache) ChangedRecords() []string   { return c.changed }
+func (c *staleCache) RefreshedRecords() []string { return c.refreshed }
+
+func (c *staleCache) Save(filename string) error {
+	c.saved = append(c.saved, filename)
+	return os.WriteFile(filename, []byte("{}"), 0644)
+}
+
+func TestCheckSPFCache(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "spf.json")
+	updated := filepath.Join(dir, "spf.updated.json")
+	defer func(old spflib.CacheConfig) { SPFCache = old }(SPFCache)
+
+	spf := []string{"_spf.example.com"}
+	tests := []struct {
+		policy    spflib.Policy
+		check     bool
+		changed   []string
+		refreshed []string
+		err       string // "error", "warning" or "".
+		saved     string
+	}{
+		{spflib.PolicyWarn, false, nil, nil, "", ""},
+		{spflib.PolicyWarn, false, spf, nil, "warning", updated},
+		{spflib.PolicyFail, false, spf, nil, "error", updated},
+		{spflib.PolicyAutoUpdate, false, nil, nil, "", ""},
+		{spflib.PolicyAutoUpdate, false, spf, nil, "warning", file},
+		// Expired or refreshed answers that are unchanged get new
+		// timestamps, whatever the policy.
+		{spflib.PolicyWarn, false, nil, spf, "", file},
+		{spflib.PolicyFail, false, nil, spf, "", file},
+		{spflib.PolicyAutoUpdate, false, nil, spf, "", file},
+		{spflib.PolicyFail, true, nil, spf, "", ""},
+	}
+	for _, tt := range tests {
+		SPFCache = spflib.CacheConfig{File: file, Policy: tt.policy, Check: tt.check}
+		cache := &staleCache{changed: tt.changed, refreshed: tt.refreshed}
+		err := checkSPFCache(cache)
+		got := ""
+		if _, ok := err.(Warning); ok {
+			got = "warning"
+		} else if err != nil {
+			got = "error"
+		}
+		if got != tt.err {
+			t.Errorf("%s %v: got %v, want %s", tt.policy, tt.changed, err, tt.err)
+		}
+		saved := ""
+		if len(cache.saved) != 0 {
+			saved = cache.saved[0]
+		}
+		if saved != tt.saved {
+			t.Errorf("%s %v: saved %q, want %q", tt.policy, tt.changed, saved, tt.saved)
+		}
+	}
+}
@@ -60,6 +60,7 @@ func validateRecordTypes(rec *models.RecordConfig, domain string, pTypes []strin
 		"CAA":              true,
 		"CNAME":            true,
 		"DS":               true,
+		"HTTPS":            true,
 		"IMPORT_TRANSFORM": false,
 		"MX":               true,
 		"NAPTR":            true,
@@ -68,11 +69,12 @@ func validateRecordTypes(rec *models.RecordConfig, domain string, pTypes []strin
 		"SOA":              true,
 		"SRV":              true,
 		"SSHFP":            true,
+		"SVCB":             true,
 		"TLSA":             true,
 		"TXT":              true,
 	}
 	_, ok := validTypes[rec.Type]
-	if !ok {
+	if !ok && !models.IsRdataType(rec.Type) {
 		cType := providers.GetCustomRecordType(rec.Type)
 		if cType == nil {
 			return fmt.Errorf("unsupported record type (%v) domain=%v name=%v", rec.Type, domain, rec.GetLabel())
@@ -172,7 +174,7 @@ func checkTargets(rec *models.RecordConfig, domain string) (errs []error) {
 			if _, ok := e.(Warning); ok {
 				err = Warning{err}
 			}
-			errs = append(errs, err)
+			errs = append(errs, atSource(rec, err))
 		}
 	}
 	switch rec.Type { // #rtype_variations
@@ -192,6 +194,12 @@ func checkTargets(rec *models.RecordConfig, domain string) (errs []error) {
 		if labelFQDN == targetFQDN {
 			check(fmt.Errorf("CNAME loop (target points at itself)"))
 		}
+	case "HTTPS", "SVCB":
+		if target != "." {
+			check(checkTarget(target))
+		}
+		_, err := rec.GetSVCBValue()
+		check(err)
 	case "MX":
 		check(checkTarget(target))
 	case "NAPTR":
@@ -219,12 +227,16 @@ func checkTargets(rec *models.RecordConfig, domain string) (errs []error) {
 		check(checkTarget(target))
 	case "TXT", "IMPORT_TRANSFORM", "CAA", "SSHFP", "TLSA", "DS":
 	default:
+		if models.IsRdataType(rec.Type) {
+			// Checked when the target is canonicalized.
+			return
+		}
 		if rec.Metadata["orig_custom_type"] != "" {
 			// it is a valid custom type. We perform no validation on target
 			return
 		}
-		errs = append(errs, fmt.Errorf("checkTargets: Unimplemented record type (%v) domain=%v name=%v",
-			rec.Type, domain, rec.GetLabel()))
+		errs = append(errs, rec.WithSource(fmt.Errorf("checkTargets: Unimplemented record type (%v) domain=%v name=%v",
+			rec.Type, domain, rec.GetLabel())))
 	}
 	return
 }
@@ -274,10 +286,14 @@ func importTransform(srcDomain, dstDomain *models.DomainConfig, transforms []tra
 			r := newRec()
 			r.SetTarget(transformCNAME(r.GetTargetField(), srcDomain.Name, dstDomain.Name))
 			dstDomain.Records = append(dstDomain.Records, r)
-		case "AKAMAICDN", "MX", "NAPTR", "NS", "SOA", "SRV", "TXT", "CAA", "TLSA":
+		case "AKAMAICDN", "HTTPS", "MX", "NAPTR", "NS", "SOA", "SRV", "SVCB", "TXT", "CAA", "TLSA":
 			// Not imported.
 			continue
 		default:
+			if models.IsRdataType(rec.Type) {
+				// Not imported.
+				continue
+			}
 			return fmt.Errorf("import_transform: Unimplemented record type %v (%v)",
 				rec.Type, rec.GetLabel())
 		}
@@ -301,6 +317,15 @@ type Warning struct {
 	error
 }
 
+// atSource prefixes err with the place in dnsconfig.js where rec is
+// defined. A Warning stays a Warning.
+func atSource(rec *models.RecordConfig, err error) error {
+	if w, ok := err.(Warning); ok {
+		return Warning{rec.WithSource(w.error)}
+	}
+	return rec.WithSource(err)
+}
+
 // ValidateAndNormalizeConfig performs and normalization and/or validation of the IR.
 func ValidateAndNormalizeConfig(config *models.DNSConfig) (errs []error) {
 	err := processSplitHorizonDomains(config)
@@ -322,7 +347,7 @@ func ValidateAndNormalizeConfig(config *models.DNSConfig) (errs []error) {
 				continue
 			}
 			// If NO_PURGE is in use, make sure this *isn't* a provider that *doesn't* support NO_PURGE.
-			if domain.KeepUnknown && providers.ProviderHasCapability(pType, providers.CantUseNOPURGE) {
+			if domain.KeepUnknown && providers.InstanceHasCapability(provider, providers.CantUseNOPURGE) {
 				errs = append(errs, fmt.Errorf("%s uses NO_PURGE which is not supported by %s(%s)", domain.Name, provider.Name, pType))
 			}
 		}
@@ -357,7 +382,7 @@ func ValidateAndNormalizeConfig(config *models.DNSConfig) (errs []error) {
 			}
 			// If label ends with dot, add to the list of errors.
 			if strings.HasSuffix(rec.GetLabel(), ".") {
-				errs = append(errs, fmt.Errorf("label %q does not match D(%q)", rec.GetLabel(), domain.Name))
+				errs = append(errs, rec.WithSource(fmt.Errorf("label %q does not match D(%q)", rec.GetLabel(), domain.Name)))
 				return errs // Exit early.
 			}
 
@@ -371,10 +396,10 @@ func ValidateAndNormalizeConfig(config *models.DNSConfig) (errs []error) {
 
 			// Validate the unmodified inputs:
 			if err := validateRecordTypes(rec, domain.Name, pTypes); err != nil {
-				errs = append(errs, err)
+				errs = append(errs, rec.WithSource(err))
 			}
 			if err := checkLabel(rec.GetLabel(), rec.Type, rec.GetTargetField(), domain.Name, rec.Metadata); err != nil {
-				errs = append(errs, err)
+				errs = append(errs, atSource(rec, err))
 			}
 
 			if errs2 := checkTargets(rec, domain.Name); errs2 != nil {
@@ -382,7 +407,7 @@ func ValidateAndNormalizeConfig(config *models.DNSConfig) (errs []error) {
 			}
 
 			// Canonicalize Targets.
-			if rec.Type == "CNAME" || rec.Type == "MX" || rec.Type == "NS" || rec.Type == "SRV" {
+			if rec.Type == "CNAME" || rec.Type == "HTTPS" || rec.Type == "MX" || rec.Type == "NS" || rec.Type == "SRV" || rec.Type == "SVCB" {
 				// #rtype_variations
 				// These record types have a target that is a hostname.
 				// We normalize them to a FQDN so there is less variation to handle.  If a
@@ -392,31 +417,41 @@ func ValidateAndNormalizeConfig(config *models.DNSConfig) (errs []error) {
 					origin = rec.SubDomain + "." + origin
 				}
 				rec.SetTarget(dnsutil.AddOrigin(rec.GetTargetField(), origin))
+			} else if models.IsRdataType(rec.Type) {
+				// The rdata is parsed and stored in its canonical form.
+				// Names in it that are not a FQDN are relative to the domain.
+				origin := domain.Name
+				if rec.SubDomain != "" {
+					origin = rec.SubDomain + "." + origin
+				}
+				if err := rec.SetTargetRdata(origin, rec.GetTargetField()); err != nil {
+					errs = append(errs, rec.WithSource(fmt.Errorf("in %s %s.%s: %w", rec.Type, rec.GetLabel(), domain.Name, err)))
+				}
 			} else if rec.Type == "A" || rec.Type == "AAAA" {
 				rec.SetTarget(net.ParseIP(rec.GetTargetField()).String())
 			} else if rec.Type == "PTR" {
 				var err error
 				var name string
 				if name, err = transform.PtrNameMagic(rec.GetLabel(), domain.Name); err != nil {
-					errs = append(errs, err)
+					errs = append(errs, rec.WithSource(err))
 				}
 				rec.SetLabel(name, domain.Name)
 			} else if rec.Type == "CAA" {
 				if rec.CaaTag != "issue" && rec.CaaTag != "issuewild" && rec.CaaTag != "iodef" {
-					errs = append(errs, fmt.Errorf("CAA tag %s is invalid", rec.CaaTag))
+					errs = append(errs, rec.WithSource(fmt.Errorf("CAA tag %s is invalid", rec.CaaTag)))
 				}
 			} else if rec.Type == "TLSA" {
 				if rec.TlsaUsage > 3 {
-					errs = append(errs, fmt.Errorf("TLSA Usage %d is invalid in record %s (domain %s)",
-						rec.TlsaUsage, rec.GetLabel(), domain.Name))
+					errs = append(errs, rec.WithSource(fmt.Errorf("TLSA Usage %d is invalid in record %s (domain %s)",
+						rec.TlsaUsage, rec.GetLabel(), domain.Name)))
 				}
 				if rec.TlsaSelector > 1 {
-					errs = append(errs, fmt.Errorf("TLSA Selector %d is invalid in record %s (domain %s)",
-						rec.TlsaSelector, rec.GetLabel(), domain.Name))
+					errs = append(errs, rec.WithSource(fmt.Errorf("TLSA Selector %d is invalid in record %s (domain %s)",
+						rec.TlsaSelector, rec.GetLabel(), domain.Name)))
 				}
 				if rec.TlsaMatchingType > 2 {
-					errs = append(errs, fmt.Errorf("TLSA MatchingType %d is invalid in record %s (domain %s)",
-						rec.TlsaMatchingType, rec.GetLabel(), domain.Name))
+					errs = append(errs, rec.WithSource(fmt.Errorf("TLSA MatchingType %d is invalid in record %s (domain %s)",
+						rec.TlsaMatchingType, rec.GetLabel(), domain.Name)))
 				}
 			}
 
@@ -436,17 +471,17 @@ func ValidateAndNormalizeConfig(config *models.DNSConfig) (errs []error) {
 			if rec.Type == "IMPORT_TRANSFORM" {
 				table, err := transform.DecodeTransformTable(rec.Metadata["transform_table"])
 				if err != nil {
-					errs = append(errs, err)
+					errs = append(errs, rec.WithSource(err))
 					continue
 				}
 				c := config.FindDomain(rec.GetTargetField())
 				if c == nil {
 					err = fmt.Errorf("IMPORT_TRANSFORM mentions non-existant domain %q", rec.GetTargetField())
-					errs = append(errs, err)
+					errs = append(errs, rec.WithSource(err))
 				}
 				err = importTransform(c, domain, table, rec.TTL)
 				if err != nil {
-					errs = append(errs, err)
+					errs = append(errs, rec.WithSource(err))
 				}
 			}
 		}
@@ -477,7 +512,7 @@ func ValidateAndNormalizeConfig(config *models.DNSConfig) (errs []error) {
 		// Validate FQDN consistency
 		for _, r := range d.Records {
 			if r.NameFQDN == "" || !strings.HasSuffix(r.NameFQDN, d.Name) {
-				errs = append(errs, fmt.Errorf("record named '%s' does not have correct FQDN for domain '%s'. FQDN: %s", r.Name, d.Name, r.NameFQDN))
+				errs = append(errs, r.WithSource(fmt.Errorf("record named '%s' does not have correct FQDN for domain '%s'. FQDN: %s", r.Name, d.Name, r.NameFQDN)))
 			}
 		}
 		// Verify AutoDNSSEC is valid.
@@ -498,7 +533,7 @@ func ValidateAndNormalizeConfig(config *models.DNSConfig) (errs []error) {
 				// be performed.
 				continue
 			}
-			if es := providers.AuditRecords(provider.ProviderBase.ProviderType, domain.Records); len(es) != 0 {
+			if es := providers.AuditInstanceRecords(provider, domain.Records); len(es) != 0 {
 				for _, e := range es {
 					errs = append(errs, fmt.Errorf("%s rejects domain %s: %w", provider.ProviderBase.ProviderType, domain.Name, e))
 				}
@@ -567,14 +602,14 @@ func checkCNAMEs(dc *models.DomainConfig) (errs []error) {
 	for _, r := range dc.Records {
 		if r.Type == "CNAME" {
 			if cnames[r.GetLabel()] {
-				errs = append(errs, fmt.Errorf("cannot have multiple CNAMEs with same name: %s", r.GetLabelFQDN()))
+				errs = append(errs, r.WithSource(fmt.Errorf("cannot have multiple CNAMEs with same name: %s", r.GetLabelFQDN())))
 			}
 			cnames[r.GetLabel()] = true
 		}
 	}
 	for _, r := range dc.Records {
 		if cnames[r.GetLabel()] && r.Type != "CNAME" {
-			errs = append(errs, fmt.Errorf("cannot have CNAME and %s record with same name: %s", r.Type, r.GetLabelFQDN()))
+			errs = append(errs, r.WithSource(fmt.Errorf("cannot have CNAME and %s record with same name: %s", r.Type, r.GetLabelFQDN())))
 		}
 	}
 	return
@@ -585,7 +620,7 @@ func checkDuplicates(records []*models.RecordConfig) (errs []error) {
 	for _, r := range records {
 		diffable := fmt.Sprintf("%s %s %s", r.GetLabelFQDN(), r.Type, r.ToDiffable())
 		if seen[diffable] != nil {
-			errs = append(errs, fmt.Errorf("exact duplicate record found: %s", diffable))
+			errs = append(errs, r.WithSource(fmt.Errorf("exact duplicate record found: %s", diffable)))
 		}
 		seen[diffable] = r
 	}
@@ -721,13 +756,23 @@ var providerCapabilityChecks = []pairTypeCapability{
 	capabilityCheck("AUTODNSSEC", providers.CanAutoDNSSEC),
 	capabilityCheck("AZURE_ALIAS", providers.CanUseAzureAlias),
 	capabilityCheck("CAA", providers.CanUseCAA),
+	capabilityCheck("CERT", providers.CanUseCERT),
+	capabilityCheck("DNAME", providers.CanUseDNAME),
+	capabilityCheck("HINFO", providers.CanUseHINFO),
+	capabilityCheck("HTTPS", providers.CanUseSVCB),
+	capabilityCheck("LOC", providers.CanUseLOC),
 	capabilityCheck("NAPTR", providers.CanUseNAPTR),
+	capabilityCheck("OPENPGPKEY", providers.CanUseOPENPGPKEY),
 	capabilityCheck("PTR", providers.CanUsePTR),
 	capabilityCheck("R53_ALIAS", providers.CanUseRoute53Alias),
+	capabilityCheck("RP", providers.CanUseRP),
+	capabilityCheck("SMIMEA", providers.CanUseSMIMEA),
 	capabilityCheck("SOA", providers.CanUseSOA),
 	capabilityCheck("SRV", providers.CanUseSRV),
 	capabilityCheck("SSHFP", providers.CanUseSSHFP),
+	capabilityCheck("SVCB", providers.CanUseSVCB),
 	capabilityCheck("TLSA", providers.CanUseTLSA),
+	capabilityCheck("URI", providers.CanUseURI),
 
 	// DS needs special record-level checks
 	{
@@ -745,7 +790,7 @@ type pairTypeCapability struct {
 	caps []providers.Capability
 	// checkFunc provides additional checks of each provider. This function should be
 	// called if records of type rType are found in the zonefile.
-	checkFunc func(pType string, _ models.Records) error
+	checkFunc func(provider *models.DNSProviderInstance, _ models.Records) error
 }
 
 func capabilityCheck(rType string, caps ...providers.Capability) pairTypeCapability {
@@ -755,9 +800,9 @@ func capabilityCheck(rType string, caps ...providers.Capability) pairTypeCapabil
 	}
 }
 
-func providerHasAtLeastOneCapability(pType string, caps ...providers.Capability) bool {
+func providerHasAtLeastOneCapability(provider *models.DNSProviderInstance, caps ...providers.Capability) bool {
 	for _, cap := range caps {
-		if providers.ProviderHasCapability(pType, cap) {
+		if providers.InstanceHasCapability(provider, cap) {
 			return true
 		}
 	}
@@ -765,12 +810,13 @@ func providerHasAtLeastOneCapability(pType string, caps ...providers.Capability)
 	return false
 }
 
-func checkProviderDS(pType string, records models.Records) error {
+func checkProviderDS(provider *models.DNSProviderInstance, records models.Records) error {
+	pType := provider.ProviderType
 	switch {
-	case providers.ProviderHasCapability(pType, providers.CanUseDS):
+	case providers.InstanceHasCapability(provider, providers.CanUseDS):
 		// The provider can use DS records anywhere, including at the root
 		return nil
-	case !providers.ProviderHasCapability(pType, providers.CanUseDSForChildren):
+	case !providers.InstanceHasCapability(provider, providers.CanUseDSForChildren):
 		// Provider has no support for DS records
 		return fmt.Errorf("provider %s uses DS records but does not support them", pType)
 	default:
@@ -821,12 +867,12 @@ func checkProviderCapabilities(dc *models.DomainConfig) error {
 				continue
 			}
 			// fmt.Printf("  (checking if %q can %q for domain %q)\n", provider.ProviderType, ty.rType, dc.Name)
-			if !providerHasAtLeastOneCapability(provider.ProviderType, ty.caps...) {
-				return fmt.Errorf("domain %s uses %s records, but DNS provider type %s does not support them", dc.Name, ty.rType, provider.ProviderType)
+			if !providerHasAtLeastOneCapability(provider, ty.caps...) {
+				return fmt.Errorf("domain %s uses %s records, but DNS provider %s (type %s) does not support them", dc.Name, ty.rType, provider.Name, provider.ProviderType)
 			}
 
 			if ty.checkFunc != nil {
-				checkErr := ty.checkFunc(provider.ProviderType, dc.Records)
+				checkErr := ty.checkFunc(provider, dc.Records)
 				if checkErr != nil {
 					return fmt.Errorf("while checking %s records in domain %s: %w", ty.rType, dc.Name, checkErr)
 				}
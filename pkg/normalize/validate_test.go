@@ -198,6 +198,20 @@ func TestNSAtRoot(t *testing.T) {
 	}
 }
 
+func TestCheckTargetsSource(t *testing.T) {
+	rec := &models.RecordConfig{Type: "CNAME", Source: "dnsconfig.js:12"}
+	rec.SetLabel("@", "foo.com")
+	rec.SetTarget("bar.com.")
+	errs := checkTargets(rec, "foo.com")
+	if len(errs) != 1 {
+		t.Fatalf("Expect one error with CNAME on @, got %v", errs)
+	}
+	want := "dnsconfig.js:12: in CNAME @.foo.com: cannot create CNAME record for bare domain"
+	if errs[0].Error() != want {
+		t.Errorf("got %q, want %q", errs[0], want)
+	}
+}
+
 func TestURLFWDValid(t *testing.T) {
 	rec := &models.RecordConfig{Type: "URLFWD"}
 	rec.SetLabel("test1", "foo.com")
@@ -418,9 +432,14 @@ func init() {
 	})
 }
 
+// instance returns a provider instance of type pType.
+func instance(pType string) *models.DNSProviderInstance {
+	return &models.DNSProviderInstance{ProviderBase: models.ProviderBase{ProviderType: pType}}
+}
+
 func Test_DSChecks(t *testing.T) {
 	t.Run("no DS support", func(t *testing.T) {
-		err := checkProviderDS(ProviderNoDS, nil)
+		err := checkProviderDS(instance(ProviderNoDS), nil)
 		if err == nil {
 			t.Errorf("Provider %s implements no DS capabilities, so should have failed the check", ProviderNoDS)
 		}
@@ -437,7 +456,7 @@ func Test_DSChecks(t *testing.T) {
 
 		// check permutations of ProviderCanDS and having both DS caps
 		for _, pType := range []string{ProviderFullDS, ProviderBothDSCaps} {
-			err := checkProviderDS(pType, records)
+			err := checkProviderDS(instance(pType), records)
 			if err != nil {
 				t.Errorf("Provider %s implements full DS capabilities and should process the provided records", ProviderFullDS)
 			}
@@ -458,7 +477,7 @@ func Test_DSChecks(t *testing.T) {
 
 		t.Run("accepts when child DS records only", func(t *testing.T) {
 			records := models.Records{&childDS, &apexA}
-			err := checkProviderDS(ProviderChildDSOnly, records)
+			err := checkProviderDS(instance(ProviderChildDSOnly), records)
 			if err != nil {
 				t.Errorf("Provider %s implements child DS support so the provided records should be accepted",
 					ProviderChildDSOnly,
@@ -468,7 +487,7 @@ func Test_DSChecks(t *testing.T) {
 
 		t.Run("fails with apex and child DS records", func(t *testing.T) {
 			records := models.Records{&apexDS, &childDS, &apexA}
-			err := checkProviderDS(ProviderChildDSOnly, records)
+			err := checkProviderDS(instance(ProviderChildDSOnly), records)
 			if err == nil {
 				t.Errorf("Provider %s does not implement DS support at the zone apex, so should reject provided records",
 					ProviderChildDSOnly,
@@ -478,6 +497,35 @@ func Test_DSChecks(t *testing.T) {
 	})
 }
 
+// instanceCaps is a driver whose capabilities are set per instance.
+type instanceCaps struct {
+	providers.None
+	caps map[providers.Capability]bool
+}
+
+func (p instanceCaps) HasCapability(cap providers.Capability) bool { return p.caps[cap] }
+
+func Test_InstanceCapabilities(t *testing.T) {
+	childDS := models.RecordConfig{Type: "DS"}
+	childDS.SetLabel("child", "example.com")
+	dc := &models.DomainConfig{Name: "example.com", Records: models.Records{&childDS}}
+
+	// The capabilities of the driver take precedence over those of the type.
+	p := instance(ProviderFullDS)
+	p.Driver = instanceCaps{caps: map[providers.Capability]bool{}}
+	dc.DNSProviderInstances = []*models.DNSProviderInstance{p}
+	if err := checkProviderCapabilities(dc); err == nil {
+		t.Errorf("expected the instance to reject DS records")
+	}
+
+	p = instance(ProviderNoDS)
+	p.Driver = instanceCaps{caps: map[providers.Capability]bool{providers.CanUseDSForChildren: true}}
+	dc.DNSProviderInstances = []*models.DNSProviderInstance{p}
+	if err := checkProviderCapabilities(dc); err != nil {
+		t.Errorf("expected the instance to accept DS records, got %v", err)
+	}
+}
+
 func Test_errorRepeat(t *testing.T) {
 	type args struct {
 		label  string
@@ -0,0 +1,280 @@
+package notifications
+
+import (
+	"encoding/json"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+// sendOne sends a message about a failed correction with cfg, and
+// returns the request that r received.
+func sendOne(t *testing.T, r *recorder, cfg map[string]string) (method, path, body string) {
+	t.Helper()
+	n := initNotifier(t, cfg)
+	n.Notify("example.org", "bind", "CREATE A www 1.2.3.4", errString("denied"), false)
+	if err := n.Done(); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(r.requests))
+	}
+	return r.requests[0].Method, r.requests[0].URL.Path, r.bodies[0]
+}
+
+type errString string
+
+func (e errString) Error() string { return string(e) }
+
+const failedText = "**ERROR running correction on example.org[bind] -** (CREATE A www 1.2.3.4) Error: denied"
+
+func TestTeams(t *testing.T) {
+	r := newRecorder(t)
+	_, _, body := sendOne(t, r, map[string]string{"teams_url": r.URL})
+	want := `{"username":"DnsControl","text":"**DnsControl Error Making Changes example.org**\nCREATE A www 1.2.3.4\nError: denied"}`
+	if body != want {
+		t.Errorf("got %s, want %s", body, want)
+	}
+}
+
+func TestBonfire(t *testing.T) {
+	r := newRecorder(t)
+	n := initNotifier(t, map[string]string{"bonfire_url": r.URL})
+	n.Notify("example.com", "bind", "2 corrections\nCREATE A www 1.2.3.4", nil, true)
+	n.Done()
+	want := []string{"**Preview: example.com[bind] -** 2 corrections", "CREATE A www 1.2.3.4"}
+	if strings.Join(r.bodies, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", r.bodies, want)
+	}
+	if ct := r.requests[0].Header.Get("Content-Type"); ct != "text/markdown" {
+		t.Errorf("got Content-Type %q", ct)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	r := newRecorder(t)
+	_, _, body := sendOne(t, r, map[string]string{"webhook_url": r.URL, "webhook_secret": "s3cret"})
+	var got struct {
+		Batch, Preview bool
+		Errors         int
+		Events         []*Event
+		Summary, Text  string
+	}
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Errors != 1 || len(got.Events) != 1 || got.Events[0].Error != "denied" ||
+		got.Summary != "DNSControl push of example.org[bind] failed" || got.Text != failedText {
+		t.Errorf("got %s", body)
+	}
+	sig := r.requests[0].Header.Get(signatureHeader)
+	if want := sign("s3cret", []byte(body)); sig != want {
+		t.Errorf("got signature %q, want %q", sig, want)
+	}
+	// HMAC-SHA256 of "" with key "key".
+	if got, want := sign("key", nil), "sha256=5d5d139563c95b5967b9bd9a8c9b233a9dedb45072794cd232dc1b74832607d0"; got != want {
+		t.Errorf("sign: got %s, want %s", got, want)
+	}
+
+	// Without a secret, there is no signature.
+	r = newRecorder(t)
+	sendOne(t, r, map[string]string{"webhook_url": r.URL})
+	if sig := r.requests[0].Header.Get(signatureHeader); sig != "" {
+		t.Errorf("got signature %q without a secret", sig)
+	}
+}
+
+func TestPagerDuty(t *testing.T) {
+	r := newRecorder(t, 202)
+	_, path, body := sendOne(t, r, map[string]string{"pagerduty_routing_key": "R0UT1NG", "pagerduty_url": r.URL + "/v2/enqueue"})
+	if path != "/v2/enqueue" {
+		t.Errorf("got path %s", path)
+	}
+	var got struct {
+		RoutingKey  string `json:"routing_key"`
+		EventAction string `json:"event_action"`
+		DedupKey    string `json:"dedup_key"`
+		Payload     struct {
+			Summary, Source, Severity string
+			CustomDetails             struct{ Text string } `json:"custom_details"`
+		}
+	}
+	if err := json.Unmarshal([]byte(body), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.RoutingKey != "R0UT1NG" || got.EventAction != "trigger" || got.DedupKey != "dnscontrol:example.org[bind]" || got.Payload.Source != "dnscontrol" ||
+		got.Payload.Severity != "error" || got.Payload.Summary != "DNSControl push of example.org[bind] failed" ||
+		got.Payload.CustomDetails.Text != failedText {
+		t.Errorf("got %s", body)
+	}
+
+	// The corrections that succeed do not trigger an incident.
+	r = newRecorder(t, 202)
+	n := initNotifier(t, map[string]string{"pagerduty_routing_key": "R0UT1NG", "pagerduty_url": r.URL, "pagerduty_batch": "true"})
+	n.Notify("example.org", "bind", "CREATE A www 1.2.3.4", nil, false)
+	if err := n.Done(); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.requests) != 0 {
+		t.Errorf("got %d requests, want none", len(r.requests))
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	// Nothing listens on port 1 of localhost.
+	n := initNotifier(t, map[string]string{"telegram_token": "123:S3CRET", "telegram_chat_id": "-100", "telegram_url": "http://127.0.0.1:1", "retries": "0"})
+	n.Notify("example.org", "bind", "CREATE A www 1.2.3.4", nil, false)
+	err := n.Done()
+	if err == nil {
+		t.Fatal("got no error")
+	}
+	if strings.Contains(err.Error(), "S3CRET") || !strings.HasPrefix(err.Error(), "telegram: ") {
+		t.Errorf("got %q", err)
+	}
+}
+
+func TestMatrix(t *testing.T) {
+	r := newRecorder(t, 502)
+	n := initNotifier(t, map[string]string{"matrix_homeserver": r.URL + "/", "matrix_room": "!room:example.com", "matrix_token": "t0k3n"})
+	n.Notify("example.org", "bind", "CREATE A www 1.2.3.4", errString("denied"), false)
+	if err := n.Done(); err != nil {
+		t.Fatal(err)
+	}
+	if len(r.requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(r.requests))
+	}
+	req := r.requests[1]
+	if req.Method != "PUT" || !strings.HasPrefix(req.URL.Path, "/_matrix/client/v3/rooms/!room:example.com/send/m.room.message/") {
+		t.Errorf("got %s %s", req.Method, req.URL.Path)
+	}
+	if req.URL.Path != r.requests[0].URL.Path {
+		t.Errorf("the retry changed the transaction: %s, then %s", r.requests[0].URL.Path, req.URL.Path)
+	}
+	if auth := req.Header.Get("Authorization"); auth != "Bearer t0k3n" {
+		t.Errorf("got Authorization %q", auth)
+	}
+	var got struct{ MsgType, Body string }
+	json.Unmarshal([]byte(r.bodies[1]), &got)
+	if got.MsgType != "m.text" || got.Body != failedText {
+		t.Errorf("got %s", r.bodies[1])
+	}
+}
+
+func TestDiscord(t *testing.T) {
+	r := newRecorder(t, 204)
+	n := initNotifier(t, map[string]string{"discord_url": r.URL})
+	n.Notify("example.com", "bind", strings.Repeat("x", 3000), nil, false)
+	if err := n.Done(); err != nil {
+		t.Fatal(err)
+	}
+	var got struct{ Username, Content string }
+	json.Unmarshal([]byte(r.bodies[0]), &got)
+	if got.Username != "DNSControl" || len(got.Content) != discordMaxLength || !strings.HasSuffix(got.Content, "...") {
+		t.Errorf("got %s (%d characters)", got.Username, len(got.Content))
+	}
+}
+
+func TestTelegram(t *testing.T) {
+	r := newRecorder(t)
+	_, path, body := sendOne(t, r, map[string]string{"telegram_token": "123:ABC", "telegram_chat_id": "-100", "telegram_url": r.URL})
+	if path != "/bot123:ABC/sendMessage" {
+		t.Errorf("got path %s", path)
+	}
+	var got struct {
+		ChatID string `json:"chat_id"`
+		Text   string
+	}
+	json.Unmarshal([]byte(body), &got)
+	if got.ChatID != "-100" || got.Text != failedText {
+		t.Errorf("got %s", body)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"a bit too long", 10, "a bit t..."},
+		{"ééééé", 8, "éé..."}, // Not in the middle of a character.
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+// fakeSMTP is an SMTP server that accepts one message.
+func fakeSMTP(t *testing.T) (addr string, received chan []string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	received = make(chan []string, 1)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		tp := textproto.NewConn(c)
+		var lines []string
+		tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			lines = append(lines, line)
+			switch cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0]); cmd {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "DATA":
+				tp.PrintfLine("354 go ahead")
+				data, _ := tp.ReadDotLines()
+				lines = append(lines, data...)
+				tp.PrintfLine("250 ok")
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				received <- lines
+				return
+			default:
+				tp.PrintfLine("250 ok")
+			}
+		}
+	}()
+	return l.Addr().String(), received
+}
+
+func TestSMTP(t *testing.T) {
+	addr, received := fakeSMTP(t)
+	n := initNotifier(t, map[string]string{
+		"smtp_host": addr,
+		"smtp_from": "dnscontrol@example.com",
+		"smtp_to":   "ops@example.com, dns@example.com",
+		"batch":     "true",
+	})
+	notifyAll(n)
+	if err := n.Done(); err != nil {
+		t.Fatal(err)
+	}
+	session := strings.Join(<-received, "\n")
+	for _, want := range []string{
+		"MAIL FROM:<dnscontrol@example.com>",
+		"RCPT TO:<ops@example.com>",
+		"RCPT TO:<dns@example.com>",
+		"To: ops@example.com, dns@example.com",
+		"Subject: DNSControl push: 2 corrections, 1 error",
+		"Successfully ran correction for **example.com[bind]** - CREATE A www 1.2.3.4",
+	} {
+		if !strings.Contains(session, want) {
+			t.Errorf("%q not in the SMTP session:\n%s", want, session)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package notifications
+
+func init() {
+	initers = append(initers, backendType{
+		name:     "discord",
+		template: defaultTemplate,
+		new: func(cfg map[string]string) (backend, error) {
+			if url, ok := cfg["discord_url"]; ok {
+				return &discordNotifier{URL: url}, nil
+			}
+			return nil, nil
+		},
+	})
+}
+
+// discordMaxLength is the most characters that Discord accepts in a
+// message.
+const discordMaxLength = 2000
+
+// discordNotifier sends notifications to a Discord webhook.
+type discordNotifier struct {
+	URL string
+}
+
+func (d *discordNotifier) send(msg *message) error {
+	var payload struct {
+		Username string `json:"username"`
+		Content  string `json:"content"`
+	}
+	payload.Username = "DNSControl"
+	payload.Content = truncate(msg.Text, discordMaxLength)
+	return postJSON("POST", d.URL, payload, nil)
+}
@@ -0,0 +1,95 @@
+package notifications
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// httpError is an HTTP response whose status is not a success.
+type httpError struct {
+	status int
+	body   string
+}
+
+func (e *httpError) Error() string {
+	if e.body == "" {
+		return fmt.Sprintf("HTTP %d", e.status)
+	}
+	return fmt.Sprintf("HTTP %d: %s", e.status, e.body)
+}
+
+// retriable returns whether a message that could not be sent because
+// of err may be sent if retried: always, unless the server refused it
+// for a reason other than being unavailable or overloaded.
+func retriable(err error) bool {
+	var he *httpError
+	if errors.As(err, &he) {
+		return he.status >= 500 || he.status == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// postJSON sends v, as JSON, to target.
+func postJSON(method, target string, v interface{}, header http.Header) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return post(method, target, "application/json", body, header)
+}
+
+// post sends body to target, and fails if the response is not a
+// success. The errors do not include target, which may hold a secret
+// (such as the token of Telegram, or the URL of a webhook).
+func post(method, target, contentType string, body []byte, header http.Header) error {
+	req, err := http.NewRequest(method, target, bytes.NewReader(body))
+	if err != nil {
+		return redact(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return redact(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &httpError{status: resp.StatusCode, body: strings.TrimSpace(string(b))}
+	}
+	return nil
+}
+
+// redact removes the URL from err, if it is a *url.Error.
+func redact(err error) error {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		return fmt.Errorf("%s request failed: %w", uerr.Op, uerr.Err)
+	}
+	return err
+}
+
+// truncate shortens s to at most n bytes, for the services that
+// refuse longer messages.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	s = s[:n-3]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s + "..."
+}
@@ -0,0 +1,56 @@
+package notifications
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func init() {
+	initers = append(initers, backendType{
+		name:     "matrix",
+		template: defaultTemplate,
+		new: func(cfg map[string]string) (backend, error) {
+			hs, room, token := cfg["matrix_homeserver"], cfg["matrix_room"], cfg["matrix_token"]
+			if hs == "" && room == "" && token == "" {
+				return nil, nil
+			}
+			if hs == "" || room == "" || token == "" {
+				return nil, fmt.Errorf("matrix_homeserver, matrix_room and matrix_token are all required")
+			}
+			return &matrixNotifier{Homeserver: strings.TrimSuffix(hs, "/"), Room: room, Token: token}, nil
+		},
+	})
+}
+
+// matrixNotifier sends notifications to a Matrix room.
+type matrixNotifier struct {
+	Homeserver string // The URL of the homeserver.
+	Room       string // The room ID, such as !abc:example.com.
+	Token      string // The access token of the user that sends.
+
+	last  *message // The message of txnID.
+	txnID string
+}
+
+func (m *matrixNotifier) send(msg *message) error {
+	// The retries of a message keep its transaction ID, so that the
+	// homeserver does not post it twice.
+	if msg != m.last {
+		m.last = msg
+		m.txnID = fmt.Sprintf("dnscontrol-%d", time.Now().UnixNano())
+	}
+	u := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		m.Homeserver, url.PathEscape(m.Room), url.PathEscape(m.txnID))
+	var payload struct {
+		MsgType string `json:"msgtype"`
+		Body    string `json:"body"`
+	}
+	payload.MsgType = "m.text"
+	payload.Body = msg.Text
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+m.Token)
+	return postJSON("PUT", u, payload, header)
+}
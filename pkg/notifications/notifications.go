@@ -1,5 +1,13 @@
 package notifications
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
 // Notifier is a type that can send a notification
 type Notifier interface {
 	// Notify will be called after a correction is performed.
@@ -7,35 +15,255 @@ type Notifier interface {
 	// and a flag for whether this is a preview or if it actually ran.
 	// If preview is true, err will always be nil.
 	Notify(domain, provider string, message string, err error, preview bool)
-	// Done will be called exactly once after all notifications are done. This will allow "batched" notifiers to flush and send
-	Done()
+	// Done will be called exactly once after all notifications are done. This will allow "batched" notifiers to flush and send.
+	// It returns the errors of all the notifications that could not be sent.
+	Done() error
+}
+
+// Event is a correction that was previewed or performed.
+type Event struct {
+	Domain   string `json:"domain"`
+	Provider string `json:"provider"`
+	Message  string `json:"message"`
+	Error    string `json:"error,omitempty"` // Empty if it succeeded.
+	Preview  bool   `json:"preview"`
+}
+
+// Report is what a notification is about, and the data of its
+// template: one event, or all the events of the run if the
+// notifications are batched.
+type Report struct {
+	Batch   bool     `json:"batch"`
+	Preview bool     `json:"preview"`
+	Errors  int      `json:"errors"` // The number of events with an error.
+	Events  []*Event `json:"events"`
+}
+
+// Summary returns a line that sums r up, such as "DNSControl push: 3
+// corrections, 1 error", or "DNSControl push of example.com[bind]
+// failed" for a single event that is not batched.
+func (r *Report) Summary() string {
+	what := "push"
+	if r.Preview {
+		what = "preview"
+	}
+	if !r.Batch && len(r.Events) == 1 {
+		e := r.Events[0]
+		s := fmt.Sprintf("DNSControl %s of %s[%s]", what, e.Domain, e.Provider)
+		if e.Error != "" {
+			s += " failed"
+		}
+		return s
+	}
+	s := fmt.Sprintf("DNSControl %s: %s", what, plural(len(r.Events), "correction"))
+	if r.Errors != 0 {
+		s += ", " + plural(r.Errors, "error")
+	}
+	return s
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// message is what a backend sends.
+type message struct {
+	Subject string // The summary of Report.
+	Text    string // Report, formatted by the template.
+	Report  *Report
+}
+
+// backend sends messages to one destination.
+type backend interface {
+	send(msg *message) error
+}
+
+// backendType is a kind of backend, configured by the keys of the
+// notifications configuration that start with its name.
+type backendType struct {
+	name     string
+	template string // The default template.
+	// new returns the backend configured by cfg, or nil if cfg does
+	// not configure one.
+	new func(cfg map[string]string) (backend, error)
 }
 
 // new notification types should add themselves to this array
-var initers = []func(map[string]string) Notifier{}
+var initers = []backendType{}
+
+// defaultTemplate formats a report as the messages of DNSControl
+// always have been, with a summary first when they are batched.
+const defaultTemplate = `
+{{- if .Batch}}**{{.Summary}}**
+{{end}}
+{{- range .Events}}
+{{- if .Preview}}**Preview: {{.Domain}}[{{.Provider}}] -** {{.Message}}
+{{- else if .Error}}**ERROR running correction on {{.Domain}}[{{.Provider}}] -** ({{.Message}}) Error: {{.Error}}
+{{- else}}Successfully ran correction for **{{.Domain}}[{{.Provider}}]** - {{.Message}}
+{{- end}}
+{{end}}`
+
+var templateFuncs = template.FuncMap{
+	// indent indents the lines of s but the first.
+	"indent": func(s string) string { return strings.ReplaceAll(s, "\n", "\n    ") },
+}
+
+// The defaults of the options.
+const (
+	defaultRetries = 2
+)
+
+// retryDelay is the delay before the first retry. It doubles at each
+// retry.
+var retryDelay = time.Second
 
 // Init will take the given config map (from creds.json notifications key) and create a single Notifier with
 // all notifications it has full config for.
-func Init(config map[string]string) Notifier {
-	notifiers := multiNotifier{}
-	for _, i := range initers {
-		n := i(config)
-		if n != nil {
-			notifiers = append(notifiers, n)
+//
+// Besides the keys of each backend, the options are "template" (a Go
+// template that formats a Report), "batch" (whether to send a single
+// summary when Done is called, rather than a message per correction)
+// and "retries" (how many times to retry a message that could not be
+// sent). Each can be set for a single backend by prefixing it with the
+// name of the backend, as in "slack_template".
+func Init(config map[string]string) (Notifier, error) {
+	n := &notifier{}
+	for _, bt := range initers {
+		b, err := bt.new(config)
+		if err != nil {
+			return nil, fmt.Errorf("notifications: %s: %w", bt.name, err)
+		}
+		if b == nil {
+			continue
+		}
+		d, err := newDestination(bt, b, config)
+		if err != nil {
+			return nil, fmt.Errorf("notifications: %s: %w", bt.name, err)
+		}
+		n.destinations = append(n.destinations, d)
+	}
+	return n, nil
+}
+
+// option returns the option key of the backend called name.
+func option(cfg map[string]string, name, key string) string {
+	if v, ok := cfg[name+"_"+key]; ok {
+		return v
+	}
+	return cfg[key]
+}
+
+// destination is a backend, with its options.
+type destination struct {
+	name     string
+	backend  backend
+	template *template.Template
+	batch    bool
+	retries  int
+}
+
+func newDestination(bt backendType, b backend, cfg map[string]string) (*destination, error) {
+	d := &destination{name: bt.name, backend: b, retries: defaultRetries}
+	text := option(cfg, bt.name, "template")
+	if text == "" {
+		text = bt.template
+	}
+	var err error
+	if d.template, err = template.New(bt.name).Funcs(templateFuncs).Parse(text); err != nil {
+		return nil, err
+	}
+	if v := option(cfg, bt.name, "batch"); v != "" {
+		if d.batch, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("batch: %w", err)
+		}
+	}
+	if v := option(cfg, bt.name, "retries"); v != "" {
+		if d.retries, err = strconv.Atoi(v); err != nil || d.retries < 0 {
+			return nil, fmt.Errorf("retries: invalid number %q", v)
+		}
+	}
+	return d, nil
+}
+
+// send formats r and sends it, retrying if it fails but for a reason
+// that may go away.
+func (d *destination) send(r *Report) error {
+	var b strings.Builder
+	if err := d.template.Execute(&b, r); err != nil {
+		return fmt.Errorf("%s: %w", d.name, err)
+	}
+	msg := &message{Subject: r.Summary(), Text: strings.TrimSpace(b.String()), Report: r}
+	delay := retryDelay
+	var err error
+	for try := 0; try <= d.retries; try++ {
+		if try != 0 {
+			time.Sleep(delay)
+			delay *= 2
 		}
+		if err = d.backend.send(msg); err == nil || !retriable(err) {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", d.name, err)
 	}
-	return notifiers
+	return nil
 }
 
-type multiNotifier []Notifier
+// notifier sends to its destinations, at once or when Done is called.
+type notifier struct {
+	destinations []*destination
+	events       []*Event // For the batched destinations.
+	errs         []string
+}
 
-func (m multiNotifier) Notify(domain, provider string, message string, err error, preview bool) {
-	for _, n := range m {
-		n.Notify(domain, provider, message, err, preview)
+func (n *notifier) Notify(domain, provider string, message string, err error, preview bool) {
+	e := &Event{Domain: domain, Provider: provider, Message: message, Preview: preview}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	n.events = append(n.events, e)
+	for _, d := range n.destinations {
+		if !d.batch {
+			n.check(d.send(newReport(false, e)))
+		}
 	}
 }
-func (m multiNotifier) Done() {
-	for _, n := range m {
-		n.Done()
+
+func (n *notifier) Done() error {
+	if len(n.events) != 0 {
+		r := newReport(true, n.events...)
+		for _, d := range n.destinations {
+			if d.batch {
+				n.check(d.send(r))
+			}
+		}
+	}
+	n.events = nil
+	if len(n.errs) == 0 {
+		return nil
+	}
+	err := fmt.Errorf("%s", strings.Join(n.errs, "; "))
+	n.errs = nil
+	return err
+}
+
+func (n *notifier) check(err error) {
+	if err != nil {
+		n.errs = append(n.errs, err.Error())
+	}
+}
+
+func newReport(batch bool, events ...*Event) *Report {
+	r := &Report{Batch: batch, Events: events}
+	for _, e := range events {
+		r.Preview = r.Preview || e.Preview
+		if e.Error != "" {
+			r.Errors++
+		}
 	}
+	return r
 }
@@ -0,0 +1,216 @@
+package notifications
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func init() {
+	retryDelay = 0
+}
+
+// recorder is a server that records the bodies it is sent, and answers
+// with the next of its statuses (200 once they are used up).
+type recorder struct {
+	*httptest.Server
+	statuses []int
+	requests []*http.Request
+	bodies   []string
+}
+
+func newRecorder(t *testing.T, statuses ...int) *recorder {
+	r := &recorder{statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		b, _ := io.ReadAll(req.Body)
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, string(b))
+		status := http.StatusOK
+		if len(r.statuses) != 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// slackTexts returns the texts of the Slack messages that r received.
+func (r *recorder) slackTexts(t *testing.T) []string {
+	var texts []string
+	for _, b := range r.bodies {
+		var payload struct{ Text string }
+		if err := json.Unmarshal([]byte(b), &payload); err != nil {
+			t.Fatalf("%s: %s", b, err)
+		}
+		texts = append(texts, payload.Text)
+	}
+	return texts
+}
+
+func initNotifier(t *testing.T, cfg map[string]string) Notifier {
+	t.Helper()
+	n, err := Init(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func notifyAll(n Notifier) {
+	n.Notify("example.com", "bind", "CREATE A www 1.2.3.4", nil, false)
+	n.Notify("example.org", "bind", "DELETE A www 1.2.3.4\nCREATE A www 5.6.7.8", fmt.Errorf("denied"), false)
+}
+
+func TestDefaultTemplate(t *testing.T) {
+	s := newRecorder(t)
+	n := initNotifier(t, map[string]string{"slack_url": s.URL})
+	n.Notify("example.com", "bind", "CREATE A www 1.2.3.4", nil, true)
+	notifyAll(n)
+	if err := n.Done(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"**Preview: example.com[bind] -** CREATE A www 1.2.3.4",
+		"Successfully ran correction for **example.com[bind]** - CREATE A www 1.2.3.4",
+		"**ERROR running correction on example.org[bind] -** (DELETE A www 1.2.3.4\nCREATE A www 5.6.7.8) Error: denied",
+	}
+	if got := s.slackTexts(t); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	slack, teams := newRecorder(t), newRecorder(t)
+	n := initNotifier(t, map[string]string{
+		"slack_url":      slack.URL,
+		"teams_url":      teams.URL,
+		"template":       `{{.Summary}}{{range .Events}} {{.Domain}}{{end}}`,
+		"teams_template": `{{range .Events}}{{.Domain}}: {{indent .Message}}{{end}}`,
+	})
+	notifyAll(n)
+	n.Done()
+	want := []string{
+		"DNSControl push of example.com[bind] example.com",
+		"DNSControl push of example.org[bind] failed example.org",
+	}
+	if got := slack.slackTexts(t); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("slack: got %q, want %q", got, want)
+	}
+	want = []string{
+		"example.com: CREATE A www 1.2.3.4",
+		"example.org: DELETE A www 1.2.3.4\n    CREATE A www 5.6.7.8",
+	}
+	if got := teams.slackTexts(t); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("teams: got %q, want %q", got, want)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	slack, teams := newRecorder(t), newRecorder(t)
+	n := initNotifier(t, map[string]string{
+		"slack_url":   slack.URL,
+		"teams_url":   teams.URL,
+		"batch":       "true",
+		"teams_batch": "false",
+	})
+	notifyAll(n)
+	if len(slack.bodies) != 0 {
+		t.Errorf("batched messages sent before Done: %q", slack.bodies)
+	}
+	if err := n.Done(); err != nil {
+		t.Fatal(err)
+	}
+	want := "**DNSControl push: 2 corrections, 1 error**\n" +
+		"Successfully ran correction for **example.com[bind]** - CREATE A www 1.2.3.4\n" +
+		"**ERROR running correction on example.org[bind] -** (DELETE A www 1.2.3.4\nCREATE A www 5.6.7.8) Error: denied"
+	if got := slack.slackTexts(t); len(got) != 1 || got[0] != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if len(teams.bodies) != 2 {
+		t.Errorf("got %d teams messages, want 2", len(teams.bodies))
+	}
+
+	// Nothing happened: nothing is sent.
+	n = initNotifier(t, map[string]string{"slack_url": slack.URL, "batch": "true"})
+	n.Done()
+	if len(slack.bodies) != 1 {
+		t.Errorf("got %d messages, want 1", len(slack.bodies))
+	}
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		retries  string
+		statuses []int
+		requests int
+		wantErr  string
+	}{
+		{"ok", "", nil, 1, ""},
+		{"unavailable", "", []int{503, 429}, 3, ""},
+		{"exhausted", "2", []int{500, 500, 500}, 3, "slack: HTTP 500"},
+		{"no retries", "0", []int{500}, 1, "slack: HTTP 500"},
+		{"refused", "", []int{403}, 1, "slack: HTTP 403"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newRecorder(t, tt.statuses...)
+			n := initNotifier(t, map[string]string{"slack_url": s.URL, "retries": tt.retries})
+			n.Notify("example.com", "bind", "CREATE A www 1.2.3.4", nil, false)
+			err := n.Done()
+			if len(s.bodies) != tt.requests {
+				t.Errorf("got %d requests, want %d", len(s.bodies), tt.requests)
+			}
+			if (err == nil) != (tt.wantErr == "") || err != nil && err.Error() != tt.wantErr {
+				t.Errorf("got error %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDoneErrors(t *testing.T) {
+	slack, discord := newRecorder(t, 400, 400), newRecorder(t, 404)
+	n := initNotifier(t, map[string]string{"slack_url": slack.URL, "discord_url": discord.URL})
+	notifyAll(n)
+	err := n.Done()
+	want := "discord: HTTP 404; slack: HTTP 400; slack: HTTP 400"
+	if err == nil || err.Error() != want {
+		t.Errorf("got %v, want %q", err, want)
+	}
+	if err := n.Done(); err != nil {
+		t.Errorf("errors reported twice: %v", err)
+	}
+}
+
+func TestInitErrors(t *testing.T) {
+	tests := []struct {
+		cfg  map[string]string
+		want string
+	}{
+		{map[string]string{"slack_url": "x", "template": "{{.Nope"}, "notifications: slack: template: slack:1: unclosed action"},
+		{map[string]string{"slack_url": "x", "slack_batch": "yes please"}, "batch"},
+		{map[string]string{"slack_url": "x", "retries": "-1"}, `retries: invalid number "-1"`},
+		{map[string]string{"smtp_host": "localhost"}, "smtp_from and smtp_to are required"},
+		{map[string]string{"matrix_room": "!a:b"}, "matrix_homeserver, matrix_room and matrix_token are all required"},
+		{map[string]string{"telegram_token": "t"}, "telegram_token and telegram_chat_id are both required"},
+	}
+	for _, tt := range tests {
+		_, err := Init(tt.cfg)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: got error %v, want %q", tt.cfg, err, tt.want)
+		}
+	}
+	n, err := Init(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Notify("example.com", "bind", "CREATE A www 1.2.3.4", nil, false)
+	if err := n.Done(); err != nil {
+		t.Error(err)
+	}
+}
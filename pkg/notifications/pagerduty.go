@@ -0,0 +1,80 @@
+package notifications
+
+import "strings"
+
+func init() {
+	initers = append(initers, backendType{
+		name:     "pagerduty",
+		template: defaultTemplate,
+		new: func(cfg map[string]string) (backend, error) {
+			key, ok := cfg["pagerduty_routing_key"]
+			if !ok {
+				return nil, nil
+			}
+			url := cfg["pagerduty_url"]
+			if url == "" {
+				url = "https://events.pagerduty.com/v2/enqueue"
+			}
+			return &pagerdutyNotifier{URL: url, RoutingKey: key}, nil
+		},
+	})
+}
+
+// pagerdutySummaryLength is the most characters that PagerDuty accepts
+// in the summary of an event.
+const pagerdutySummaryLength = 1024
+
+// pagerdutyNotifier triggers PagerDuty events (Events API v2) when
+// corrections fail. Nothing is sent about the corrections that
+// succeed: each event opens an incident.
+type pagerdutyNotifier struct {
+	URL        string
+	RoutingKey string
+}
+
+func (p *pagerdutyNotifier) send(msg *message) error {
+	type payload struct {
+		Summary       string      `json:"summary"`
+		Source        string      `json:"source"`
+		Severity      string      `json:"severity"`
+		CustomDetails interface{} `json:"custom_details"`
+	}
+	var event struct {
+		RoutingKey  string  `json:"routing_key"`
+		EventAction string  `json:"event_action"`
+		DedupKey    string  `json:"dedup_key"`
+		Payload     payload `json:"payload"`
+	}
+	if msg.Report.Errors == 0 {
+		return nil
+	}
+	event.RoutingKey = p.RoutingKey
+	event.EventAction = "trigger"
+	event.DedupKey = pagerdutyDedupKey(msg.Report)
+	event.Payload = payload{
+		Summary:  truncate(msg.Subject, pagerdutySummaryLength),
+		Source:   "dnscontrol",
+		Severity: "error",
+		CustomDetails: map[string]interface{}{
+			"text":   msg.Text,
+			"events": msg.Report.Events,
+		},
+	}
+	return postJSON("POST", p.URL, event, nil)
+}
+
+// pagerdutyDedupKey returns the key of the incident of r, so that the
+// same failure, repeated at each run, is a single incident: the domain
+// and provider of a single event, or the failed ones of a batch.
+func pagerdutyDedupKey(r *Report) string {
+	var failed []string
+	seen := map[string]bool{}
+	for _, e := range r.Events {
+		k := e.Domain + "[" + e.Provider + "]"
+		if e.Error != "" && !seen[k] {
+			seen[k] = true
+			failed = append(failed, k)
+		}
+	}
+	return "dnscontrol:" + strings.Join(failed, ",")
+}
@@ -1,21 +1,15 @@
 package notifications
 
-import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"net/http"
-)
-
 func init() {
-	initers = append(initers, func(cfg map[string]string) Notifier {
-		if url, ok := cfg["slack_url"]; ok {
-			notifier := &slackNotifier{
-				URL: url,
+	initers = append(initers, backendType{
+		name:     "slack",
+		template: defaultTemplate,
+		new: func(cfg map[string]string) (backend, error) {
+			if url, ok := cfg["slack_url"]; ok {
+				return &slackNotifier{URL: url}, nil
 			}
-			return notifier
-		}
-		return nil
+			return nil, nil
+		},
 	})
 }
 
@@ -24,23 +18,12 @@ type slackNotifier struct {
 	URL string
 }
 
-func (s *slackNotifier) Notify(domain, provider, msg string, err error, preview bool) {
+func (s *slackNotifier) send(msg *message) error {
 	var payload struct {
 		Username string `json:"username"`
 		Text     string `json:"text"`
 	}
 	payload.Username = "DNSControl"
-
-	if preview {
-		payload.Text = fmt.Sprintf(`**Preview: %s[%s] -** %s`, domain, provider, msg)
-	} else if err != nil {
-		payload.Text = fmt.Sprintf(`**ERROR running correction on %s[%s] -** (%s) Error: %s`, domain, provider, msg, err)
-	} else {
-		payload.Text = fmt.Sprintf(`Successfully ran correction for **%s[%s]** - %s`, domain, provider, msg)
-	}
-
-	json, _ := json.Marshal(payload)
-	http.Post(s.URL, "text/json", bytes.NewReader(json))
+	payload.Text = msg.Text
+	return postJSON("POST", s.URL, payload, nil)
 }
-
-func (s *slackNotifier) Done() {}
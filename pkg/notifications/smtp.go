@@ -0,0 +1,68 @@
+package notifications
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+func init() {
+	initers = append(initers, backendType{
+		name:     "smtp",
+		template: defaultTemplate,
+		new: func(cfg map[string]string) (backend, error) {
+			host, ok := cfg["smtp_host"]
+			if !ok {
+				return nil, nil
+			}
+			n := &smtpNotifier{
+				Host:     host,
+				From:     cfg["smtp_from"],
+				Username: cfg["smtp_username"],
+				Password: cfg["smtp_password"],
+			}
+			for _, to := range strings.Split(cfg["smtp_to"], ",") {
+				if to = strings.TrimSpace(to); to != "" {
+					n.To = append(n.To, to)
+				}
+			}
+			if n.From == "" || len(n.To) == 0 {
+				return nil, fmt.Errorf("smtp_from and smtp_to are required")
+			}
+			if _, _, err := net.SplitHostPort(host); err != nil {
+				n.Host = net.JoinHostPort(host, "25")
+			}
+			return n, nil
+		},
+	})
+}
+
+// smtpNotifier sends notifications by email.
+type smtpNotifier struct {
+	Host     string // host:port
+	From     string
+	To       []string
+	Username string // If set, authenticate with PLAIN (which needs TLS).
+	Password string
+}
+
+func (s *smtpNotifier) send(msg *message) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, _ := net.SplitHostPort(s.Host)
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(s.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Text, "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return smtp.SendMail(s.Host, auth, s.From, s.To, []byte(b.String()))
+}
@@ -1,52 +1,45 @@
 package notifications
 
-import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"net/http"
-	"strings"
-)
-
 func init() {
-	initers = append(initers, func(cfg map[string]string) Notifier {
-		url, ok := cfg["teams_url"]
-		if !ok {
-			return nil
-		}
-
-		notifier := &teamsNotifier{
-			URL: url,
-		}
-		return notifier
+	initers = append(initers, backendType{
+		name:     "teams",
+		template: teamsTemplate,
+		new: func(cfg map[string]string) (backend, error) {
+			url, ok := cfg["teams_url"]
+			if !ok {
+				return nil, nil
+			}
+			return &teamsNotifier{URL: url}, nil
+		},
 	})
 }
 
+// teamsTemplate formats changes as 'preformated' text.
+const teamsTemplate = `
+{{- if .Batch}}**{{.Summary}}**
+{{end}}
+{{- range .Events}}
+{{- if .Preview}}**DnsControl Preview {{.Domain}}**
+{{- else if .Error}}**DnsControl Error Making Changes {{.Domain}}**
+{{- else}}**DnsControl Successfully Changed {{.Domain}}**
+{{- end}}
+{{indent .Message}}
+{{- if .Error}}
+Error: {{.Error}}
+{{- end}}
+{{end}}`
+
 // teamsNotifier sends notifications to teams or mattermost
 type teamsNotifier struct {
 	URL string
 }
 
-func (s *teamsNotifier) Notify(domain, provider, msg string, err error, preview bool) {
+func (s *teamsNotifier) send(msg *message) error {
 	var payload struct {
 		Username string `json:"username"`
 		Text     string `json:"text"`
 	}
 	payload.Username = "DnsControl"
-
-	// Format changes as 'preformated' text
-	msg = strings.ReplaceAll(msg, "\n", "\n    ")
-
-	if preview {
-		payload.Text = fmt.Sprintf("**DnsControl Preview %s**\n%s", domain, msg)
-	} else if err != nil {
-		payload.Text = fmt.Sprintf("**DnsControl Error Making Changes %s**\n%s\nError: %s", domain, msg, err)
-	} else {
-		payload.Text = fmt.Sprintf("**DnsControl Successfully Changed %s**\n%s", domain, msg)
-	}
-
-	json, _ := json.Marshal(payload)
-	http.Post(s.URL, "text/json", bytes.NewReader(json))
+	payload.Text = msg.Text
+	return postJSON("POST", s.URL, payload, nil)
 }
-
-func (s *teamsNotifier) Done() {}
@@ -0,0 +1,48 @@
+package notifications
+
+import (
+	"fmt"
+	"strings"
+)
+
+func init() {
+	initers = append(initers, backendType{
+		name:     "telegram",
+		template: defaultTemplate,
+		new: func(cfg map[string]string) (backend, error) {
+			token, chat := cfg["telegram_token"], cfg["telegram_chat_id"]
+			if token == "" && chat == "" {
+				return nil, nil
+			}
+			if token == "" || chat == "" {
+				return nil, fmt.Errorf("telegram_token and telegram_chat_id are both required")
+			}
+			url := cfg["telegram_url"]
+			if url == "" {
+				url = "https://api.telegram.org"
+			}
+			return &telegramNotifier{URL: strings.TrimSuffix(url, "/"), Token: token, ChatID: chat}, nil
+		},
+	})
+}
+
+// telegramMaxLength is the most characters that Telegram accepts in a
+// message.
+const telegramMaxLength = 4096
+
+// telegramNotifier sends notifications to a Telegram chat, as a bot.
+type telegramNotifier struct {
+	URL    string // Of the Bot API.
+	Token  string
+	ChatID string
+}
+
+func (t *telegramNotifier) send(msg *message) error {
+	var payload struct {
+		ChatID string `json:"chat_id"`
+		Text   string `json:"text"`
+	}
+	payload.ChatID = t.ChatID
+	payload.Text = truncate(msg.Text, telegramMaxLength)
+	return postJSON("POST", t.URL+"/bot"+t.Token+"/sendMessage", payload, nil)
+}
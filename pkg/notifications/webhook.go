@@ -0,0 +1,57 @@
+package notifications
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+)
+
+func init() {
+	initers = append(initers, backendType{
+		name:     "webhook",
+		template: defaultTemplate,
+		new: func(cfg map[string]string) (backend, error) {
+			url, ok := cfg["webhook_url"]
+			if !ok {
+				return nil, nil
+			}
+			return &webhookNotifier{URL: url, Secret: cfg["webhook_secret"]}, nil
+		},
+	})
+}
+
+// signatureHeader is the header that signs the body of a webhook
+// notification, if it has a secret.
+const signatureHeader = "X-DNSControl-Signature"
+
+// webhookNotifier posts the report, as JSON, to any URL.
+type webhookNotifier struct {
+	URL    string
+	Secret string // The key of the HMAC-SHA256 signature of the body.
+}
+
+func (w *webhookNotifier) send(msg *message) error {
+	body, err := json.Marshal(struct {
+		*Report
+		Summary string `json:"summary"`
+		Text    string `json:"text"`
+	}{msg.Report, msg.Subject, msg.Text})
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	if w.Secret != "" {
+		header.Set(signatureHeader, sign(w.Secret, body))
+	}
+	return post("POST", w.URL, "application/json", body, header)
+}
+
+// sign returns the signature of body with secret, as the value of
+// signatureHeader: "sha256=" and the hex of the HMAC-SHA256.
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
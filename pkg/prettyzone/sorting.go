@@ -66,6 +66,11 @@ func (z *ZoneGenData) Less(i, j int) bool {
 			return a.GetTargetField() < b.GetTargetField()
 		}
 		return a.MxPreference < b.MxPreference
+	case "HTTPS", "SVCB":
+		// sort by priority. If they are equal, sort by the rest.
+		if a.SvcPriority != b.SvcPriority {
+			return a.SvcPriority < b.SvcPriority
+		}
 	case "SRV":
 		//ta2, tb2 := a.(*dns.SRV), b.(*dns.SRV)
 		pa, pb := a.SrvPort, b.SrvPort
@@ -0,0 +1,253 @@
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+)
+
+// ChangeReporter is implemented by a CLI that wants the record-level
+// changes of each provider, not just the corrections.  ReportChanges
+// is called after EndProvider, before the corrections are printed.
+type ChangeReporter interface {
+	ReportChanges(changes []*ChangeReport)
+}
+
+// OwnerReporter is implemented by a CLI that groups the domains by
+// owner (see models.DomainConfig.Owner). StartOwner is called before
+// a domain whose owner is not that of the previous domain, with "" for
+// the domains that have none. A preview groups the domains by owner,
+// but a push does not, so an owner may start more than once. It is
+// only called if some domain has an owner.
+type OwnerReporter interface {
+	StartOwner(owner string)
+}
+
+// Report is a machine-readable account of a preview or push.
+type Report struct {
+	Push        bool            `json:"push"`
+	Domains     []*DomainReport `json:"domains"`
+	Warnings    []string        `json:"warnings,omitempty"`
+	Errors      []string        `json:"errors,omitempty"`
+	Corrections int             `json:"corrections"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// DomainReport is the part of a Report about one domain.
+type DomainReport struct {
+	Name      string            `json:"name"`
+	Owner     string            `json:"owner,omitempty"`
+	Providers []*ProviderReport `json:"providers"`
+	Warnings  []string          `json:"warnings,omitempty"`
+	Errors    []string          `json:"errors,omitempty"`
+}
+
+// ProviderReport is the part of a Report about one DNS provider or
+// registrar of a domain.
+type ProviderReport struct {
+	Name        string              `json:"name"`
+	Registrar   bool                `json:"registrar,omitempty"`
+	Skipped     bool                `json:"skipped,omitempty"`
+	Error       string              `json:"error,omitempty"`
+	Changes     []*ChangeReport     `json:"changes,omitempty"`
+	Corrections []*CorrectionReport `json:"corrections"`
+}
+
+// ChangeReport describes a change to the records at a RecordKey.
+type ChangeReport struct {
+	Verb string           `json:"verb"`
+	Key  models.RecordKey `json:"key"`
+	Old  models.Records   `json:"old,omitempty"`
+	New  models.Records   `json:"new,omitempty"`
+	Msgs []string         `json:"msgs,omitempty"`
+}
+
+// CorrectionReport describes a correction and, if it was run, the result.
+type CorrectionReport struct {
+	Msg      string `json:"msg"`
+	Executed bool   `json:"executed"`
+	Error    string `json:"error,omitempty"`
+}
+
+// JSONPrinter is a CLI that records everything in a Report, which can
+// then be written as JSON. If Inner is not nil, all calls are also
+// passed to it (so that the usual output is produced too).
+type JSONPrinter struct {
+	Inner  CLI
+	Report Report
+
+	owner      string
+	domain     *DomainReport
+	provider   *ProviderReport
+	correction *CorrectionReport
+}
+
+// Write writes the report as indented JSON.
+func (j *JSONPrinter) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(j.Report)
+}
+
+// StartOwner is called before the domains of an owner.
+func (j *JSONPrinter) StartOwner(owner string) {
+	j.owner = owner
+	if or, ok := j.Inner.(OwnerReporter); ok {
+		or.StartOwner(owner)
+	}
+}
+
+// SelectChange asks the Inner CLI, if it is a ChangeSelector, and
+// otherwise accepts the change.
+func (j *JSONPrinter) SelectChange(n, total int, change *ChangeReport) Selection {
+	if cs, ok := j.Inner.(ChangeSelector); ok {
+		return cs.SelectChange(n, total, change)
+	}
+	return Accept
+}
+
+// StartDomain is called at the start of each domain.
+func (j *JSONPrinter) StartDomain(domain string) {
+	j.domain = &DomainReport{Name: domain, Owner: j.owner, Providers: []*ProviderReport{}}
+	j.provider = nil
+	j.correction = nil
+	j.Report.Domains = append(j.Report.Domains, j.domain)
+	if j.Inner != nil {
+		j.Inner.StartDomain(domain)
+	}
+}
+
+func (j *JSONPrinter) startProvider(name string, skip bool, registrar bool) {
+	j.provider = &ProviderReport{
+		Name:        name,
+		Registrar:   registrar,
+		Skipped:     skip,
+		Corrections: []*CorrectionReport{},
+	}
+	j.correction = nil
+	if j.domain != nil {
+		j.domain.Providers = append(j.domain.Providers, j.provider)
+	}
+}
+
+// StartDNSProvider is called at the start of each new provider.
+func (j *JSONPrinter) StartDNSProvider(name string, skip bool) {
+	j.startProvider(name, skip, false)
+	if j.Inner != nil {
+		j.Inner.StartDNSProvider(name, skip)
+	}
+}
+
+// StartRegistrar is called at the start of each new registrar.
+func (j *JSONPrinter) StartRegistrar(name string, skip bool) {
+	j.startProvider(name, skip, true)
+	if j.Inner != nil {
+		j.Inner.StartRegistrar(name, skip)
+	}
+}
+
+// EndProvider is called at the end of each provider.
+func (j *JSONPrinter) EndProvider(name string, numCorrections int, err error) {
+	if err != nil && j.provider != nil {
+		j.provider.Error = err.Error()
+	}
+	if err == nil {
+		j.Report.Corrections += numCorrections
+	}
+	if j.Inner != nil {
+		j.Inner.EndProvider(name, numCorrections, err)
+	}
+}
+
+// ReportChanges records the record-level changes of the current provider.
+func (j *JSONPrinter) ReportChanges(changes []*ChangeReport) {
+	if j.provider == nil {
+		return
+	}
+	j.provider.Changes = append(j.provider.Changes, changes...)
+}
+
+// PrintCorrection is called to print/format each correction.
+func (j *JSONPrinter) PrintCorrection(n int, c *models.Correction) {
+	j.correction = &CorrectionReport{Msg: c.Msg}
+	if j.provider != nil {
+		j.provider.Corrections = append(j.provider.Corrections, j.correction)
+	}
+	if j.Inner != nil {
+		j.Inner.PrintCorrection(n, c)
+	}
+}
+
+// EndCorrection is called at the end of each correction.
+func (j *JSONPrinter) EndCorrection(err error) {
+	if j.correction != nil {
+		j.correction.Executed = true
+		if err != nil {
+			j.correction.Error = err.Error()
+		}
+	}
+	if j.Inner != nil {
+		j.Inner.EndCorrection(err)
+	}
+}
+
+// PromptToRun prompts the user to see if they want to execute a
+// correction. Without an Inner CLI to ask, the answer is always no.
+func (j *JSONPrinter) PromptToRun() bool {
+	if j.Inner == nil {
+		return false
+	}
+	return j.Inner.PromptToRun()
+}
+
+// Debugf is called to print/format debug information.
+func (j *JSONPrinter) Debugf(format string, args ...interface{}) {
+	if j.Inner != nil {
+		j.Inner.Debugf(format, args...)
+	}
+}
+
+// Printf is called to print/format information.
+func (j *JSONPrinter) Printf(format string, args ...interface{}) {
+	if j.Inner != nil {
+		j.Inner.Printf(format, args...)
+	}
+}
+
+// Println is called to print/format information.
+func (j *JSONPrinter) Println(lines ...string) {
+	if j.Inner != nil {
+		j.Inner.Println(lines...)
+	}
+}
+
+// Warnf is called to print/format a warning. Warnings are recorded
+// in the current domain, if any.
+func (j *JSONPrinter) Warnf(format string, args ...interface{}) {
+	msg := strings.TrimRight(fmt.Sprintf(format, args...), "\n")
+	if j.domain != nil {
+		j.domain.Warnings = append(j.domain.Warnings, msg)
+	} else {
+		j.Report.Warnings = append(j.Report.Warnings, msg)
+	}
+	if j.Inner != nil {
+		j.Inner.Warnf(format, args...)
+	}
+}
+
+// Errorf is called to print/format an error. Errors are recorded in
+// the current domain, if any.
+func (j *JSONPrinter) Errorf(format string, args ...interface{}) {
+	msg := strings.TrimRight(fmt.Sprintf(format, args...), "\n")
+	if j.domain != nil {
+		j.domain.Errors = append(j.domain.Errors, msg)
+	} else {
+		j.Report.Errors = append(j.Report.Errors, msg)
+	}
+	if j.Inner != nil {
+		j.Inner.Errorf(format, args...)
+	}
+}
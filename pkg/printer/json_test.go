@@ -0,0 +1,77 @@
+package printer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONPrinter(t *testing.T) {
+	inner := &bytes.Buffer{}
+	p := &JSONPrinter{Inner: ConsolePrinter{Writer: inner}}
+
+	p.Warnf("before any domain\n")
+	p.StartDomain("example.com")
+	p.StartDNSProvider("bind", false)
+	p.EndProvider("bind", 2, nil)
+	p.ReportChanges([]*ChangeReport{
+		{Verb: "CREATE", Key: models.RecordKey{NameFQDN: "www.example.com", Type: "A"}, Msgs: []string{"+ CREATE"}},
+	})
+	p.PrintCorrection(0, &models.Correction{Msg: "first"})
+	p.EndCorrection(nil)
+	p.PrintCorrection(1, &models.Correction{Msg: "second"})
+	p.EndCorrection(fmt.Errorf("boom"))
+	p.StartRegistrar("none", true)
+	p.StartDomain("example.org")
+	p.StartDNSProvider("r53", false)
+	p.EndProvider("r53", 0, fmt.Errorf("no access"))
+	p.Warnf("careful\n")
+
+	r := p.Report
+	assert.Equal(t, 2, r.Corrections)
+	assert.Equal(t, []string{"before any domain"}, r.Warnings)
+	assert.Len(t, r.Domains, 2)
+
+	d := r.Domains[0]
+	assert.Equal(t, "example.com", d.Name)
+	assert.Len(t, d.Providers, 2)
+	bind := d.Providers[0]
+	assert.Equal(t, "CREATE", bind.Changes[0].Verb)
+	assert.Equal(t, "www.example.com", bind.Changes[0].Key.NameFQDN)
+	assert.Equal(t, &CorrectionReport{Msg: "first", Executed: true}, bind.Corrections[0])
+	assert.Equal(t, &CorrectionReport{Msg: "second", Executed: true, Error: "boom"}, bind.Corrections[1])
+	assert.True(t, d.Providers[1].Registrar)
+	assert.True(t, d.Providers[1].Skipped)
+
+	d = r.Domains[1]
+	assert.Equal(t, "no access", d.Providers[0].Error)
+	assert.Equal(t, []string{"careful"}, d.Warnings)
+
+	// The inner printer produces the usual output.
+	assert.Contains(t, inner.String(), "#2: second\nFAILURE! boom\n")
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, p.Write(out))
+	var got Report
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	assert.Equal(t, 2, got.Corrections)
+}
+
+func TestJSONPrinterOwner(t *testing.T) {
+	inner := &bytes.Buffer{}
+	p := &JSONPrinter{Inner: ConsolePrinter{Writer: inner}}
+
+	p.StartOwner("web")
+	p.StartDomain("example.com")
+	p.StartOwner("")
+	p.StartDomain("example.org")
+
+	assert.Equal(t, "web", p.Report.Domains[0].Owner)
+	assert.Equal(t, "", p.Report.Domains[1].Owner)
+	assert.Equal(t, "==================== Owner: web\n******************** Domain: example.com\n"+
+		"==================== Owner: (none)\n******************** Domain: example.org\n", inner.String())
+}
@@ -84,6 +84,14 @@ func (c ConsolePrinter) StartDomain(domain string) {
 	fmt.Fprintf(c.Writer, "******************** Domain: %s\n", domain)
 }
 
+// StartOwner is called before the domains of each owner.
+func (c ConsolePrinter) StartOwner(owner string) {
+	if owner == "" {
+		owner = "(none)"
+	}
+	fmt.Fprintf(c.Writer, "==================== Owner: %s\n", owner)
+}
+
 // PrintCorrection is called to print/format each correction.
 func (c ConsolePrinter) PrintCorrection(i int, correction *models.Correction) {
 	fmt.Fprintf(c.Writer, "#%d: %s\n", i+1, correction.Msg)
@@ -0,0 +1,91 @@
+package printer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/fatih/color"
+)
+
+// Selection is the answer to SelectChange.
+type Selection int
+
+// The answers to SelectChange.
+const (
+	Reject    Selection = iota // Do not make this change.
+	Accept                     // Make this change.
+	AcceptAll                  // Make this change and the following ones.
+	RejectAll                  // Make neither this change nor the following ones.
+)
+
+// ChangeSelector is implemented by a CLI that can ask which of the
+// record-level changes of a provider to make (push --select).
+type ChangeSelector interface {
+	// SelectChange shows change, the n-th (from 0) of total, and
+	// returns whether to make it.
+	SelectChange(n, total int, change *ChangeReport) Selection
+}
+
+// SelectChange shows a change with its old and new records side by
+// side, and asks whether to make it. Anything but an explicit yes is
+// a no.
+func (c ConsolePrinter) SelectChange(n, total int, change *ChangeReport) Selection {
+	fmt.Fprintf(c.Writer, "[%d/%d] %s\n", n+1, total, strings.Join(change.Msgs, "\n"))
+	writeSideBySide(c.Writer, change.Old, change.New)
+	for {
+		fmt.Fprint(c.Writer, "Make this change? (y)es, (N)o, (a)ll remaining, (r)eject all remaining: ")
+		txt, err := c.Reader.ReadString('\n')
+		if err != nil {
+			fmt.Fprintln(c.Writer, "Rejecting the remaining changes")
+			return RejectAll
+		}
+		switch strings.ToLower(strings.TrimSpace(txt)) {
+		case "y", "yes":
+			return Accept
+		case "", "n", "no":
+			return Reject
+		case "a", "all":
+			return AcceptAll
+		case "r":
+			return RejectAll
+		}
+	}
+}
+
+// sideWidth is the width of a column of writeSideBySide. Longer
+// lines are wrapped.
+const sideWidth = 50
+
+// writeSideBySide writes the old records on the left, in red, and the
+// new ones on the right, in green.
+func writeSideBySide(w io.Writer, old, new models.Records) {
+	left, right := sideLines(old, "- "), sideLines(new, "+ ")
+	fmt.Fprintf(w, "    %-*s | %s\n", sideWidth, "existing", "desired")
+	for i := 0; i < len(left) || i < len(right); i++ {
+		var l, r string
+		if i < len(left) {
+			l = left[i]
+		}
+		if i < len(right) {
+			r = right[i]
+		}
+		// Pad before coloring: the escape codes have no width.
+		fmt.Fprintf(w, "    %s | %s\n", color.RedString("%-*s", sideWidth, l), color.GreenString("%s", r))
+	}
+}
+
+// sideLines returns recs as lines of at most sideWidth characters.
+func sideLines(recs models.Records, prefix string) []string {
+	var lines []string
+	for _, rec := range recs {
+		s := []rune(fmt.Sprintf("%s%s %d %s %s", prefix, rec.NameFQDN, rec.TTL, rec.Type, rec.GetTargetCombined()))
+		for len(s) > sideWidth {
+			lines = append(lines, string(s[:sideWidth]))
+			s = append([]rune("  "), s[sideWidth:]...)
+		}
+		lines = append(lines, string(s))
+	}
+	return lines
+}
@@ -0,0 +1,54 @@
+package printer
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectChange(t *testing.T) {
+	defer func(old bool) { color.NoColor = old }(color.NoColor)
+	color.NoColor = true
+
+	old := &models.RecordConfig{Type: "A", TTL: 300}
+	old.SetLabel("www", "example.com")
+	old.SetTarget("1.2.3.4")
+	new := &models.RecordConfig{Type: "A", TTL: 600}
+	new.SetLabel("www", "example.com")
+	new.SetTarget("1.2.3.4")
+	change := &ChangeReport{Verb: "CHANGE", Old: models.Records{old}, New: models.Records{new}, Msgs: []string{"± MODIFY-TTL www"}}
+
+	tests := []struct {
+		input string
+		want  Selection
+	}{
+		{"y\n", Accept},
+		{"\n", Reject},
+		{"what\nN\n", Reject},
+		{"a\n", AcceptAll},
+		{"r\n", RejectAll},
+		{"", RejectAll}, // EOF
+	}
+	for _, tt := range tests {
+		out := &bytes.Buffer{}
+		p := ConsolePrinter{Reader: bufio.NewReader(strings.NewReader(tt.input)), Writer: out}
+		assert.Equal(t, tt.want, p.SelectChange(1, 3, change), "input %q", tt.input)
+		assert.Contains(t, out.String(), "[2/3] ± MODIFY-TTL www\n")
+		assert.Contains(t, out.String(), "    - www.example.com 300 A 1.2.3.4"+strings.Repeat(" ", 19)+" | + www.example.com 600 A 1.2.3.4\n")
+	}
+}
+
+func TestSideLines(t *testing.T) {
+	rec := &models.RecordConfig{Type: "TXT", TTL: 300}
+	rec.SetLabel("@", "example.com")
+	rec.SetTargetTXT(strings.Repeat("x", 60))
+	lines := sideLines(models.Records{rec}, "+ ")
+	assert.Len(t, lines, 2)
+	assert.Len(t, lines[0], sideWidth)
+	assert.Equal(t, `+ example.com 300 TXT "`+strings.Repeat("x", 60)+`"`, lines[0]+strings.TrimPrefix(lines[1], "  "))
+}
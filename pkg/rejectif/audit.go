@@ -23,7 +23,8 @@ func (aud *Auditor) Add(rtype string, fn checker) {
 }
 
 // Audit performs the audit. For each record it calls each function in
-// the list of checks.
+// the list of checks. Errors name the place in dnsconfig.js where the
+// record is defined, if it is known.
 func (aud *Auditor) Audit(records models.Records) (errs []error) {
 	// No checks? Exit early.
 	if aud.checksFor == nil {
@@ -35,7 +36,7 @@ func (aud *Auditor) Audit(records models.Records) (errs []error) {
 		for _, f := range aud.checksFor[rc.Type] {
 			e := f(rc)
 			if e != nil {
-				errs = append(errs, e)
+				errs = append(errs, rc.WithSource(e))
 			}
 		}
 	}
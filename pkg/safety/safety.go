@@ -0,0 +1,222 @@
+// Package safety implements guardrails that stop a push that would
+// make suspiciously large or dangerous changes, such as deleting most
+// of a zone after a broken INCLUDE or require().
+//
+// The rules are evaluated on the record-level changes (a
+// diff2.ChangeList) of every zone, before any correction is run.
+package safety
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/diff2"
+)
+
+// Zone is the input of the rules: the changes to be made to a zone at
+// one provider.
+type Zone struct {
+	Domain   string // The name of the zone (punycode).
+	Provider string
+	Existing models.Records
+	Changes  diff2.ChangeList
+}
+
+// Violation reports that a rule would be broken.
+type Violation struct {
+	Rule     string
+	Domain   string // Empty if the rule applies to the entire run.
+	Provider string
+	Msg      string
+}
+
+func (v Violation) String() string {
+	if v.Domain == "" {
+		return fmt.Sprintf("%s: %s", v.Rule, v.Msg)
+	}
+	return fmt.Sprintf("%s: %s at %s: %s", v.Rule, v.Domain, v.Provider, v.Msg)
+}
+
+// Rule is a safety check. It is given every zone of the run, so that
+// it may check each zone or the run as a whole.
+type Rule interface {
+	Name() string
+	Check(zones []*Zone) []Violation
+}
+
+// Config selects the rules. A zero value disables a rule.
+type Config struct {
+	MaxDeletes       int      // Per zone.
+	MaxDeletePercent int      // Per zone, of the existing records.
+	ProtectApex      []string // Record types that may not be deleted or changed at the apex.
+	MaxChangedZones  int      // Per run.
+}
+
+// Rules returns the rules enabled by c.
+func (c Config) Rules() []Rule {
+	var rules []Rule
+	if c.MaxDeletes > 0 || c.MaxDeletePercent > 0 {
+		rules = append(rules, MaxDeletes{Count: c.MaxDeletes, Percent: c.MaxDeletePercent})
+	}
+	if len(c.ProtectApex) != 0 {
+		rules = append(rules, ProtectApex{Types: c.ProtectApex})
+	}
+	if c.MaxChangedZones > 0 {
+		rules = append(rules, MaxChangedZones{Count: c.MaxChangedZones})
+	}
+	return rules
+}
+
+// Check runs the rules and returns the violations, if any.
+func Check(rules []Rule, zones []*Zone) []Violation {
+	var vs []Violation
+	for _, r := range rules {
+		vs = append(vs, r.Check(zones)...)
+	}
+	return vs
+}
+
+// Keep the rules in alphabetical order.
+
+// MaxChangedZones limits the number of zones changed in one run. A
+// domain with several providers is counted once.
+type MaxChangedZones struct {
+	Count int
+}
+
+// Name returns the name of the rule.
+func (r MaxChangedZones) Name() string { return "max-changed-zones" }
+
+// Check implements Rule.
+func (r MaxChangedZones) Check(zones []*Zone) []Violation {
+	changed := map[string]bool{}
+	for _, z := range zones {
+		if countChanges(z.Changes) != 0 {
+			changed[z.Domain] = true
+		}
+	}
+	if len(changed) <= r.Count {
+		return nil
+	}
+	var names []string
+	for n := range changed {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return []Violation{{
+		Rule: r.Name(),
+		Msg:  fmt.Sprintf("%d zones would change, the limit is %d (%s)", len(changed), r.Count, strings.Join(names, ", ")),
+	}}
+}
+
+// MaxDeletes limits the number of records deleted from a zone, as a
+// count, a percentage of the existing records, or both.
+type MaxDeletes struct {
+	Count   int
+	Percent int
+}
+
+// Name returns the name of the rule.
+func (r MaxDeletes) Name() string { return "max-deletes" }
+
+// Check implements Rule.
+func (r MaxDeletes) Check(zones []*Zone) []Violation {
+	var vs []Violation
+	for _, z := range zones {
+		n := countDeletes(z.Changes)
+		if n == 0 {
+			continue
+		}
+		if r.Count > 0 && n > r.Count {
+			vs = append(vs, violation(r, z, "%d records would be deleted, the limit is %d", n, r.Count))
+			continue
+		}
+		if r.Percent > 0 && len(z.Existing) > 0 && n*100 > r.Percent*len(z.Existing) {
+			vs = append(vs, violation(r, z, "%d of %d records (%d%%) would be deleted, the limit is %d%%",
+				n, len(z.Existing), n*100/len(z.Existing), r.Percent))
+		}
+	}
+	return vs
+}
+
+// ProtectApex forbids deleting or changing any record of the given
+// types (for example NS, MX or SOA) at the apex of a zone.
+type ProtectApex struct {
+	Types []string
+}
+
+// Name returns the name of the rule.
+func (r ProtectApex) Name() string { return "protect-apex" }
+
+// Check implements Rule.
+func (r ProtectApex) Check(zones []*Zone) []Violation {
+	var vs []Violation
+	for _, z := range zones {
+		apex := strings.ToLower(z.Domain)
+		for _, rtype := range r.Types {
+			deleted, changed := 0, 0
+			for _, c := range z.Changes {
+				if c.Type != diff2.DELETE && c.Type != diff2.CHANGE {
+					continue
+				}
+				for _, rc := range c.Old {
+					if rc.Type != rtype || rc.NameFQDN != apex {
+						continue
+					}
+					if c.Type == diff2.DELETE {
+						deleted++
+					} else {
+						changed++
+					}
+				}
+			}
+			if deleted != 0 {
+				vs = append(vs, violation(r, z, "%s at the apex would be deleted", plural(deleted, rtype+" record")))
+			}
+			if changed != 0 {
+				vs = append(vs, violation(r, z, "%s at the apex would be changed", plural(changed, rtype+" record")))
+			}
+		}
+	}
+	return vs
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+func violation(r Rule, z *Zone, format string, args ...interface{}) Violation {
+	return Violation{
+		Rule:     r.Name(),
+		Domain:   z.Domain,
+		Provider: z.Provider,
+		Msg:      fmt.Sprintf(format, args...),
+	}
+}
+
+// countChanges returns the number of changes, ignoring REPORTs.
+func countChanges(changes diff2.ChangeList) int {
+	n := 0
+	for _, c := range changes {
+		if c.Type != diff2.REPORT {
+			n++
+		}
+	}
+	return n
+}
+
+// countDeletes returns the number of records deleted.
+func countDeletes(changes diff2.ChangeList) int {
+	n := 0
+	for _, c := range changes {
+		if c.Type == diff2.DELETE {
+			n += len(c.Old)
+		}
+	}
+	return n
+}
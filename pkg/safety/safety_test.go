@@ -0,0 +1,123 @@
+package safety
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/diff2"
+)
+
+func rec(name, rtype string) *models.RecordConfig {
+	rc := &models.RecordConfig{Type: rtype}
+	rc.SetLabel(name, "example.com")
+	return rc
+}
+
+func key(rc *models.RecordConfig) models.RecordKey {
+	return models.RecordKey{NameFQDN: rc.NameFQDN, Type: rc.Type}
+}
+
+// zone returns a zone of n A records, followed by the extra records.
+func zone(domain string, n int, extra ...*models.RecordConfig) *Zone {
+	z := &Zone{Domain: domain, Provider: "bind"}
+	for i := 0; i < n; i++ {
+		z.Existing = append(z.Existing, rec(fmt.Sprintf("host%d", i), "A"))
+	}
+	z.Existing = append(z.Existing, extra...)
+	return z
+}
+
+// deleteFirst deletes the first n records of z.
+func deleteFirst(z *Zone, n int) *Zone {
+	for _, rc := range z.Existing[:n] {
+		z.Changes = append(z.Changes, diff2.Change{Type: diff2.DELETE, Key: key(rc), Old: models.Records{rc}})
+	}
+	return z
+}
+
+func TestMaxDeletes(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    MaxDeletes
+		zone    *Zone
+		wantErr bool
+	}{
+		{"under count", MaxDeletes{Count: 5}, deleteFirst(zone("example.com", 10), 5), false},
+		{"over count", MaxDeletes{Count: 5}, deleteFirst(zone("example.com", 10), 6), true},
+		{"under percent", MaxDeletes{Percent: 50}, deleteFirst(zone("example.com", 10), 5), false},
+		{"over percent", MaxDeletes{Percent: 50}, deleteFirst(zone("example.com", 10), 6), true},
+		{"nothing deleted", MaxDeletes{Count: 1, Percent: 1}, zone("example.com", 10), false},
+	}
+	for _, tst := range tests {
+		t.Run(tst.name, func(t *testing.T) {
+			vs := tst.rule.Check([]*Zone{tst.zone})
+			if (len(vs) != 0) != tst.wantErr {
+				t.Errorf("got %v, wantErr=%v", vs, tst.wantErr)
+			}
+		})
+	}
+}
+
+func TestProtectApex(t *testing.T) {
+	mx1, mx2 := rec("@", "MX"), rec("@", "MX")
+	soa := rec("@", "SOA")
+	sub := rec("sub", "MX")
+	rule := ProtectApex{Types: []string{"MX", "NS", "SOA"}}
+
+	// Adding one is fine.
+	z := zone("example.com", 0, mx1)
+	z.Changes = diff2.ChangeList{{Type: diff2.CREATE, Key: key(mx2), New: models.Records{mx2}}}
+	if vs := rule.Check([]*Zone{z}); len(vs) != 0 {
+		t.Errorf("unexpected %v", vs)
+	}
+
+	// Deleting one of two is not.
+	z = zone("example.com", 0, mx1, mx2)
+	z.Changes = diff2.ChangeList{{Type: diff2.DELETE, Key: key(mx1), Old: models.Records{mx1}}}
+	if vs := rule.Check([]*Zone{z}); len(vs) != 1 || vs[0].Msg != "1 MX record at the apex would be deleted" {
+		t.Errorf("expected one violation, got %v", vs)
+	}
+
+	// Nor is replacing them, or changing the SOA.
+	z.Changes = append(z.Changes,
+		diff2.Change{Type: diff2.CREATE, Key: key(mx1), New: models.Records{rec("@", "MX")}},
+		diff2.Change{Type: diff2.CHANGE, Key: key(soa), Old: models.Records{soa}, New: models.Records{rec("@", "SOA")}},
+	)
+	if vs := rule.Check([]*Zone{z}); len(vs) != 2 || vs[1].Msg != "1 SOA record at the apex would be changed" {
+		t.Errorf("expected two violations, got %v", vs)
+	}
+
+	// Only the apex is protected.
+	z = zone("example.com", 0, sub)
+	z.Changes = diff2.ChangeList{{Type: diff2.DELETE, Key: key(sub), Old: models.Records{sub}}}
+	if vs := rule.Check([]*Zone{z}); len(vs) != 0 {
+		t.Errorf("unexpected %v", vs)
+	}
+}
+
+func TestMaxChangedZones(t *testing.T) {
+	a := deleteFirst(zone("a.com", 2), 1)
+	a2 := deleteFirst(zone("a.com", 2), 1)
+	a2.Provider = "other"
+	b := deleteFirst(zone("b.com", 2), 1)
+	unchanged := zone("c.com", 2)
+
+	rule := MaxChangedZones{Count: 1}
+	if vs := rule.Check([]*Zone{a, a2, unchanged}); len(vs) != 0 {
+		t.Errorf("unexpected %v", vs)
+	}
+	if vs := rule.Check([]*Zone{a, a2, b, unchanged}); len(vs) != 1 {
+		t.Errorf("expected one violation, got %v", vs)
+	}
+}
+
+func TestConfig_Rules(t *testing.T) {
+	if r := (Config{}).Rules(); len(r) != 0 {
+		t.Errorf("zero Config enables %v", r)
+	}
+	c := Config{MaxDeletes: 1, MaxDeletePercent: 10, ProtectApex: []string{"NS"}, MaxChangedZones: 3}
+	if r := c.Rules(); len(r) != 3 {
+		t.Errorf("expected 3 rules, got %v", r)
+	}
+}
@@ -0,0 +1,389 @@
+package spflib
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CachingResolver wraps a live resolver and adds caching to it.
+// GetSPF will return the cached value, if present and not expired.
+// An expired or missing value is looked up with the inner resolver,
+// and compared with the cached one. If they differ, GetSPF returns the
+// cached result, unless the policy is PolicyAutoUpdate.
+// GetA, GetAAAA and GetMX work the same way.
+// All records queries will be stored for the lifetime of the resolver,
+// and can be flushed to disk at the end.
+// All resolution errors from the inner resolver will be saved and can be retreived later.
+type CachingResolver interface {
+	Resolver
+	ChangedRecords() []string
+	RefreshedRecords() []string
+	ResolveErrors() []error
+	Save(filename string) error
+}
+
+// Policy is what is done when the cache is out of date: when the live
+// answer to a lookup differs from the cached one.
+type Policy string
+
+// The policies.
+const (
+	// PolicyWarn uses the cached answers, and warns.
+	PolicyWarn Policy = "warn"
+	// PolicyFail uses the cached answers, and fails.
+	PolicyFail Policy = "fail"
+	// PolicyAutoUpdate uses the live answers, and updates the cache.
+	PolicyAutoUpdate Policy = "auto-update"
+)
+
+// ParsePolicy returns the policy named s.
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(s); p {
+	case PolicyWarn, PolicyFail, PolicyAutoUpdate:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown SPF cache policy %q (want %s, %s or %s)", s, PolicyWarn, PolicyFail, PolicyAutoUpdate)
+}
+
+// DefaultCacheFile is the cache file if CacheConfig.File is not set.
+const DefaultCacheFile = "spfcache.json"
+
+// defaultTTL is how long an answer is cached when its TTL is not known.
+const defaultTTL = time.Hour
+
+// CacheConfig says where the cache is kept, and what to do when it is
+// out of date.
+type CacheConfig struct {
+	File    string // Default: DefaultCacheFile.
+	Policy  Policy // Default: PolicyWarn.
+	Refresh bool   // Look up every answer again, even if not expired.
+	Check   bool   // Do not update File with new timestamps (spf-refresh --check).
+}
+
+// Filename returns the cache file.
+func (cfg CacheConfig) Filename() string {
+	if cfg.File == "" {
+		return DefaultCacheFile
+	}
+	return cfg.File
+}
+
+// UpdatedFilename returns the file that an up-to-date copy of the cache
+// is written to when it is out of date: spfcache.updated.json for
+// spfcache.json.
+func (cfg CacheConfig) UpdatedFilename() string {
+	return strings.TrimSuffix(cfg.Filename(), ".json") + ".updated.json"
+}
+
+// Open reads the cache file, if it exists.
+func (cfg CacheConfig) Open() (CachingResolver, error) {
+	c := &cache{
+		records: map[string]*cacheEntry{},
+		inner:   LiveResolver{},
+		policy:  cfg.Policy,
+		refresh: cfg.Refresh,
+		now:     time.Now,
+	}
+	if c.policy == "" {
+		c.policy = PolicyWarn
+	}
+	f, err := os.Open(cfg.Filename())
+	if err != nil {
+		if os.IsNotExist(err) {
+			// doesn't exist, just make a new one
+			return c, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	if err := dec.Decode(&c.records); err != nil {
+		return nil, fmt.Errorf("%s: %w", cfg.Filename(), err)
+	}
+	return c, nil
+}
+
+// NewCache creates a new cache file named filename.
+func NewCache(filename string) (CachingResolver, error) {
+	return CacheConfig{File: filename}.Open()
+}
+
+type cacheEntry struct {
+	SPF  string
+	A    []string `json:",omitempty"`
+	AAAA []string `json:",omitempty"`
+	MX   []string `json:",omitempty"`
+
+	// When the values were looked up, and for how long (in seconds)
+	// they may be used without being looked up again. An entry
+	// without them has expired.
+	Fetched time.Time `json:",omitempty"`
+	TTL     uint32    `json:",omitempty"`
+
+	// value we have looked up this run
+	resolved map[string]*answer // By type: SPF, A, AAAA or MX.
+	used     bool
+}
+
+// answer is what the inner resolver answered to a lookup. The values
+// of A and AAAA lookups are sorted, so that they compare with those of
+// the cache.
+type answer struct {
+	values []string
+	ttl    time.Duration // 0 if not known.
+	err    error
+}
+
+var cachedTypes = []string{"SPF", "A", "AAAA", "MX"}
+
+// cached returns the field of e that caches the values of type rtype.
+func (e *cacheEntry) cached(rtype string) *[]string {
+	switch rtype {
+	case "A":
+		return &e.A
+	case "AAAA":
+		return &e.AAAA
+	default:
+		return &e.MX
+	}
+}
+
+// values returns the cached values of type rtype.
+func (e *cacheEntry) values(rtype string) []string {
+	if rtype == "SPF" {
+		if e.SPF == "" {
+			return nil
+		}
+		return []string{e.SPF}
+	}
+	return *e.cached(rtype)
+}
+
+func (e *cacheEntry) setValues(rtype string, vals []string) {
+	if rtype == "SPF" {
+		e.SPF = ""
+		if len(vals) != 0 {
+			e.SPF = vals[0]
+		}
+		return
+	}
+	*e.cached(rtype) = vals
+}
+
+func (e *cacheEntry) expired(now time.Time) bool {
+	return now.After(e.Fetched.Add(time.Duration(e.TTL) * time.Second))
+}
+
+type cache struct {
+	records map[string]*cacheEntry
+
+	inner   Resolver
+	policy  Policy
+	refresh bool
+	now     func() time.Time
+}
+
+func (c *cache) GetSPF(name string) (string, error) {
+	vals, err := c.lookup(name, "SPF")
+	if err != nil {
+		return "", err
+	}
+	return vals[0], nil
+}
+
+func (c *cache) GetA(name string) ([]net.IP, error) {
+	vals, err := c.lookup(name, "A")
+	return parseIPs(vals), err
+}
+
+func (c *cache) GetAAAA(name string) ([]net.IP, error) {
+	vals, err := c.lookup(name, "AAAA")
+	return parseIPs(vals), err
+}
+
+func (c *cache) GetMX(name string) ([]string, error) {
+	return c.lookup(name, "MX")
+}
+
+// lookup returns the values of type rtype of name: the cached ones if
+// they have not expired, and otherwise those of the inner resolver,
+// which it asks once. If the answers differ, the cached values win,
+// unless the policy is PolicyAutoUpdate.
+func (c *cache) lookup(name, rtype string) ([]string, error) {
+	entry, ok := c.records[name]
+	if !ok {
+		entry = &cacheEntry{}
+		c.records[name] = entry
+	}
+	entry.used = true
+	cached := entry.values(rtype)
+	if len(cached) != 0 && !c.refresh && !entry.expired(c.now()) {
+		return cached, nil
+	}
+
+	if entry.resolved == nil {
+		entry.resolved = map[string]*answer{}
+	}
+	a := entry.resolved[rtype]
+	if a == nil {
+		a = c.ask(name, rtype)
+		entry.resolved[rtype] = a
+	}
+	if a.err == nil && (len(cached) == 0 || c.policy == PolicyAutoUpdate) {
+		return a.values, nil
+	}
+	// return cached value
+	if len(cached) != 0 {
+		return cached, nil
+	}
+	return nil, a.err
+}
+
+// ttlResolver is a Resolver that also tells how long its answers may be
+// cached.
+type ttlResolver interface {
+	lookup(name, rtype string) ([]string, time.Duration, error)
+}
+
+func (c *cache) ask(name, rtype string) *answer {
+	a := &answer{}
+	if inner, ok := c.inner.(ttlResolver); ok {
+		a.values, a.ttl, a.err = inner.lookup(name, rtype)
+	} else {
+		switch rtype {
+		case "SPF":
+			var spf string
+			if spf, a.err = c.inner.GetSPF(name); a.err == nil {
+				a.values = []string{spf}
+			}
+		case "A":
+			a.values, a.err = ipStrings(c.inner.GetA(name))
+		case "AAAA":
+			a.values, a.err = ipStrings(c.inner.GetAAAA(name))
+		case "MX":
+			a.values, a.err = c.inner.GetMX(name)
+		}
+	}
+	if rtype == "A" || rtype == "AAAA" {
+		sort.Strings(a.values)
+	}
+	return a
+}
+
+func ipStrings(ips []net.IP, err error) ([]string, error) {
+	var s []string
+	for _, ip := range ips {
+		s = append(s, ip.String())
+	}
+	return s, err
+}
+
+func parseIPs(s []string) []net.IP {
+	var ips []net.IP
+	for _, v := range s {
+		if ip := net.ParseIP(v); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+	return ips
+}
+
+// ChangedRecords returns the names whose live answers differ from the
+// cached ones.
+func (c *cache) ChangedRecords() []string {
+	names := []string{}
+	for name, entry := range c.records {
+		for _, rtype := range cachedTypes {
+			a := entry.resolved[rtype]
+			if a != nil && a.err == nil && strings.Join(a.values, " ") != strings.Join(entry.values(rtype), " ") {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RefreshedRecords returns the names that were looked up again, and
+// whose live answers are the same as the cached ones: only their
+// timestamps are out of date.
+func (c *cache) RefreshedRecords() []string {
+	names := []string{}
+	for name, entry := range c.records {
+		refreshed := false
+		for _, rtype := range cachedTypes {
+			a := entry.resolved[rtype]
+			if a == nil || a.err != nil {
+				continue
+			}
+			if strings.Join(a.values, " ") != strings.Join(entry.values(rtype), " ") {
+				refreshed = false
+				break
+			}
+			refreshed = true
+		}
+		if refreshed {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *cache) ResolveErrors() (errs []error) {
+	for _, entry := range c.records {
+		for _, rtype := range cachedTypes {
+			if a := entry.resolved[rtype]; a != nil && a.err != nil {
+				errs = append(errs, a.err)
+			}
+		}
+	}
+	return
+}
+
+// Save writes the entries used by this run to filename, with the live
+// answers, or the cached ones where there are none.
+func (c *cache) Save(filename string) error {
+	now := c.now().UTC().Truncate(time.Second)
+	outRecs := make(map[string]*cacheEntry, len(c.records))
+	for k, entry := range c.records {
+		if !entry.used {
+			continue
+		}
+		out := &cacheEntry{Fetched: entry.Fetched, TTL: entry.TTL}
+		found, allLive := false, true
+		var ttl time.Duration
+		for _, rtype := range cachedTypes {
+			vals := entry.values(rtype)
+			if a := entry.resolved[rtype]; a != nil && a.err == nil {
+				vals = a.values
+				if a.ttl == 0 {
+					a.ttl = defaultTTL
+				}
+				if ttl == 0 || a.ttl < ttl {
+					ttl = a.ttl
+				}
+			} else if len(vals) != 0 {
+				allLive = false
+			}
+			out.setValues(rtype, vals)
+			found = found || len(vals) != 0
+		}
+		// Only live answers make the entry new again.
+		if allLive && ttl != 0 {
+			out.Fetched = now
+			out.TTL = uint32(ttl / time.Second)
+		}
+		if found {
+			outRecs[k] = out
+		}
+	}
+	dat, _ := json.MarshalIndent(outRecs, "", "  ")
+	return os.WriteFile(filename, dat, 0644)
+}
@@ -0,0 +1,142 @@
+package spflib
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openCache opens the cache in file, which asks inner, at the time now.
+func openCache(t *testing.T, cfg CacheConfig, inner Resolver, now time.Time) *cache {
+	t.Helper()
+	c, err := cfg.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc := c.(*cache)
+	cc.inner = inner
+	cc.now = func() time.Time { return now }
+	return cc
+}
+
+func TestCache(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "spfcache.json")
+	start := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	live := fakeDNS{
+		spf: map[string]string{"_spf.example.com": "v=spf1 mx -all"},
+		a:   map[string][]string{"mx.example.com": {"192.0.2.2", "192.0.2.1"}},
+		mx:  map[string][]string{"example.com": {"mx.example.com"}},
+	}
+	c := openCache(t, CacheConfig{File: file}, live, start)
+	if _, err := c.GetSPF("_spf.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetMX("example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetA("mx.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if changed := c.ChangedRecords(); len(changed) != 3 {
+		t.Errorf("got changed records %v, want all 3", changed)
+	}
+	if err := c.Save(file); err != nil {
+		t.Fatal(err)
+	}
+	dat, _ := os.ReadFile(file)
+	if !strings.Contains(string(dat), `"Fetched": "2023-03-01T12:00:00Z"`) || !strings.Contains(string(dat), `"TTL": 3600`) {
+		t.Errorf("no timestamp or TTL in %s", dat)
+	}
+
+	// Within the TTL, the cache is not checked.
+	changedDNS := fakeDNS{
+		spf: map[string]string{"_spf.example.com": "v=spf1 mx ip4:198.51.100.1 -all"},
+		a:   map[string][]string{"mx.example.com": {"192.0.2.3"}},
+		mx:  live.mx,
+	}
+	c = openCache(t, CacheConfig{File: file}, changedDNS, start.Add(time.Minute))
+	ips, err := c.GetA("mx.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []net.IP{net.ParseIP("192.0.2.1"), net.ParseIP("192.0.2.2")}; !reflect.DeepEqual(ips, want) {
+		t.Errorf("got %v, want %v", ips, want)
+	}
+	if changed := c.ChangedRecords(); len(changed) != 0 {
+		t.Errorf("got changed records %v, want none", changed)
+	}
+
+	// Refresh checks it anyway.
+	c = openCache(t, CacheConfig{File: file, Refresh: true}, changedDNS, start.Add(time.Minute))
+	if _, err := c.GetA("mx.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if changed := c.ChangedRecords(); !reflect.DeepEqual(changed, []string{"mx.example.com"}) {
+		t.Errorf("got changed records %v, want mx.example.com", changed)
+	}
+
+	// Once expired, the changes are found. The cached answers are
+	// returned, but with PolicyAutoUpdate.
+	later := start.Add(2 * time.Hour)
+	for _, policy := range []Policy{PolicyWarn, PolicyFail, PolicyAutoUpdate} {
+		c = openCache(t, CacheConfig{File: file, Policy: policy}, changedDNS, later)
+		spf, err := c.GetSPF("_spf.example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "v=spf1 mx -all"
+		if policy == PolicyAutoUpdate {
+			want = "v=spf1 mx ip4:198.51.100.1 -all"
+		}
+		if spf != want {
+			t.Errorf("%s: got %q, want %q", policy, spf, want)
+		}
+		if changed := c.ChangedRecords(); !reflect.DeepEqual(changed, []string{"_spf.example.com"}) {
+			t.Errorf("%s: got changed records %v, want _spf.example.com", policy, changed)
+		}
+	}
+
+	// The cached answers are used when the live lookup fails.
+	c = openCache(t, CacheConfig{File: file}, fakeDNS{fail: map[string]bool{"_spf.example.com": true}}, later)
+	if spf, err := c.GetSPF("_spf.example.com"); err != nil || spf != "v=spf1 mx -all" {
+		t.Errorf("got %q, %v", spf, err)
+	}
+	if errs := c.ResolveErrors(); len(errs) != 1 {
+		t.Errorf("got errors %v, want one", errs)
+	}
+
+	// An expired answer that is unchanged is refreshed, whatever the
+	// policy, and saved with a new timestamp.
+	c = openCache(t, CacheConfig{File: file, Policy: PolicyWarn}, live, later)
+	if _, err := c.GetSPF("_spf.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if changed := c.ChangedRecords(); len(changed) != 0 {
+		t.Errorf("got changed records %v, want none", changed)
+	}
+	if refreshed := c.RefreshedRecords(); !reflect.DeepEqual(refreshed, []string{"_spf.example.com"}) {
+		t.Errorf("got refreshed records %v, want _spf.example.com", refreshed)
+	}
+	if err := c.Save(file); err != nil {
+		t.Fatal(err)
+	}
+	dat, _ = os.ReadFile(file)
+	if !strings.Contains(string(dat), `"Fetched": "2023-03-01T14:00:00Z"`) {
+		t.Errorf("no new timestamp in %s", dat)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	for _, s := range []string{"warn", "fail", "auto-update"} {
+		if p, err := ParsePolicy(s); err != nil || string(p) != s {
+			t.Errorf("%s: got %q, %v", s, p, err)
+		}
+	}
+	if _, err := ParsePolicy("ignore"); err == nil {
+		t.Error("ignore: no error")
+	}
+}
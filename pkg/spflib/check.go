@@ -0,0 +1,447 @@
+package spflib
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Result is the result of an SPF check (RFC 7208, section 2.6).
+type Result string
+
+// The results of an SPF check.
+const (
+	None      Result = "none"      // There is no SPF record.
+	Neutral   Result = "neutral"   // The record says nothing about the address.
+	Pass      Result = "pass"      // The address may send mail for the domain.
+	Fail      Result = "fail"      // The address may not send mail for the domain.
+	SoftFail  Result = "softfail"  // The address probably may not send mail for the domain.
+	TempError Result = "temperror" // A DNS lookup failed.
+	PermError Result = "permerror" // The record, or one it refers to, is not valid.
+)
+
+// The limits of RFC 7208, section 4.6.4.
+const (
+	maxLookups     = 10 // DNS lookups by mechanisms and redirect=.
+	maxVoidLookups = 2  // Lookups that find nothing.
+	maxMXHosts     = 10 // Hosts of an mx mechanism.
+	maxPTRNames    = 10 // Names of a ptr mechanism or %{p} macro.
+)
+
+var qualifierResults = map[byte]Result{
+	'+': Pass,
+	'-': Fail,
+	'~': SoftFail,
+	'?': Neutral,
+}
+
+// Check returns whether ip may send mail from sender, an email address
+// or a domain, according to the SPF record of its domain. This is the
+// check_host() function of RFC 7208. The error explains a TempError or
+// a PermError.
+//
+// The HELO name, which the %{h} macro expands to, is taken to be the
+// domain of sender. exp= modifiers are not evaluated.
+func Check(ip net.IP, sender string, dnsres Resolver) (Result, error) {
+	domain := sender
+	if i := strings.LastIndexByte(sender, '@'); i >= 0 {
+		domain = sender[i+1:]
+	}
+	return CheckHost(ip, domain, sender, dnsres)
+}
+
+// CheckHost is Check for the SPF record of domain rather than that of
+// the domain of sender.
+func CheckHost(ip net.IP, domain, sender string, dnsres Resolver) (Result, error) {
+	local, senderDomain := "postmaster", sender
+	if i := strings.LastIndexByte(sender, '@'); i >= 0 {
+		senderDomain = sender[i+1:]
+		if i > 0 {
+			local = sender[:i]
+		}
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	c := &checker{
+		ip:     ip,
+		sender: local + "@" + senderDomain,
+		local:  local,
+		domain: senderDomain,
+		dnsres: dnsres,
+	}
+	r, err := c.checkHost(domain)
+	if err == nil && (r == TempError || r == PermError) {
+		err = fmt.Errorf("%s", r)
+	}
+	return r, err
+}
+
+// checker holds the state of a Check.
+type checker struct {
+	ip     net.IP // 4 bytes long if IPv4.
+	sender string
+	local  string // The local part of sender.
+	domain string // The domain of sender.
+	dnsres Resolver
+
+	lookups int
+	voids   int
+	stack   []string // The domains being checked, to detect loops.
+}
+
+// checkError is an error that ends a check with a TempError or a
+// PermError.
+type checkError struct {
+	result Result
+	err    error
+}
+
+func (e *checkError) Error() string { return e.err.Error() }
+func (e *checkError) Unwrap() error { return e.err }
+
+func permErrorf(format string, args ...interface{}) error {
+	return &checkError{PermError, fmt.Errorf(format, args...)}
+}
+
+func tempError(err error) error {
+	return &checkError{TempError, err}
+}
+
+// result returns the result of the check that ended with err.
+func result(err error) Result {
+	var ce *checkError
+	if errors.As(err, &ce) {
+		return ce.result
+	}
+	return TempError
+}
+
+func (c *checker) checkHost(domain string) (Result, error) {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	if !validDomain(domain) {
+		return None, nil
+	}
+	for _, d := range c.stack {
+		if d == domain {
+			return PermError, permErrorf("SPF loop: %s -> %s", strings.Join(c.stack, " -> "), domain)
+		}
+	}
+	c.stack = append(c.stack, domain)
+	defer func() { c.stack = c.stack[:len(c.stack)-1] }()
+
+	text, err := c.dnsres.GetSPF(domain)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNoSPF) || isNotFound(err):
+			return None, nil
+		case errors.As(err, new(*net.DNSError)):
+			return TempError, tempError(err)
+		}
+		return PermError, permErrorf("%s", err)
+	}
+	terms, err := parseTerms(text)
+	if err != nil {
+		return PermError, permErrorf("SPF record of %s: %s", domain, err)
+	}
+
+	var redirect *term
+	for _, t := range terms {
+		if t.modifier {
+			if t.name == "redirect" {
+				redirect = t
+			}
+			continue
+		}
+		match, err := c.match(t, domain)
+		if err != nil {
+			return result(err), err
+		}
+		if match {
+			return qualifierResults[t.qualifier], nil
+		}
+	}
+
+	if redirect == nil {
+		return Neutral, nil
+	}
+	// There is no all mechanism, since all matches.
+	if err := c.lookup(); err != nil {
+		return PermError, err
+	}
+	target, err := c.expand(redirect.domain, domain)
+	if err != nil {
+		return PermError, err
+	}
+	r, err := c.checkHost(target)
+	if r == None {
+		return PermError, permErrorf("redirect=%s: %s has no SPF record", redirect.domain, target)
+	}
+	return r, err
+}
+
+// match returns whether the mechanism t of the record of domain
+// matches.
+func (c *checker) match(t *term, domain string) (bool, error) {
+	switch t.name {
+	case "all":
+		return true, nil
+	case "ip4", "ip6":
+		return t.network.Contains(c.ip) && (len(c.ip) == net.IPv4len) == (t.name == "ip4"), nil
+	}
+
+	if err := c.lookup(); err != nil {
+		return false, err
+	}
+	target := domain
+	if t.domain != "" {
+		var err error
+		if target, err = c.expand(t.domain, domain); err != nil {
+			return false, err
+		}
+	}
+
+	switch t.name {
+	case "include":
+		r, err := c.checkHost(target)
+		switch r {
+		case Pass:
+			return true, nil
+		case None:
+			return false, permErrorf("include:%s: %s has no SPF record", t.domain, target)
+		case TempError, PermError:
+			return false, err
+		}
+		return false, nil
+
+	case "a":
+		ips, err := c.addrs(target)
+		if err != nil {
+			return false, err
+		}
+		if err := c.void(len(ips)); err != nil {
+			return false, err
+		}
+		return c.matchIPs(ips, t), nil
+
+	case "mx":
+		hosts, err := c.dnsres.GetMX(target)
+		if err != nil {
+			return false, tempError(err)
+		}
+		if err := c.void(len(hosts)); err != nil {
+			return false, err
+		}
+		if len(hosts) > maxMXHosts {
+			return false, permErrorf("%s has more than %d MX records", target, maxMXHosts)
+		}
+		for _, host := range hosts {
+			ips, err := c.addrs(host)
+			if err != nil {
+				return false, err
+			}
+			if c.matchIPs(ips, t) {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case "ptr":
+		names, err := c.validatedNames()
+		if err != nil {
+			return false, err
+		}
+		target = strings.ToLower(strings.TrimSuffix(target, "."))
+		for _, name := range names {
+			if name == target || strings.HasSuffix(name, "."+target) {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case "exists":
+		// exists looks up A records, whatever the address checked.
+		ips, err := c.dnsres.GetA(target)
+		if err != nil {
+			return false, tempError(err)
+		}
+		if err := c.void(len(ips)); err != nil {
+			return false, err
+		}
+		return len(ips) != 0, nil
+	}
+	return false, permErrorf("unsupported SPF mechanism %s", t.name)
+}
+
+// lookup counts a mechanism or modifier that looks up the DNS.
+func (c *checker) lookup() error {
+	c.lookups++
+	if c.lookups > maxLookups {
+		return permErrorf("more than %d DNS lookups", maxLookups)
+	}
+	return nil
+}
+
+// void counts a lookup that found n records if n is 0.
+func (c *checker) void(n int) error {
+	if n != 0 {
+		return nil
+	}
+	c.voids++
+	if c.voids > maxVoidLookups {
+		return permErrorf("more than %d DNS lookups found nothing", maxVoidLookups)
+	}
+	return nil
+}
+
+// addrs returns the addresses of name in the family of c.ip.
+func (c *checker) addrs(name string) ([]net.IP, error) {
+	var ips []net.IP
+	var err error
+	if len(c.ip) == net.IPv4len {
+		ips, err = c.dnsres.GetA(name)
+	} else {
+		ips, err = c.dnsres.GetAAAA(name)
+	}
+	if err != nil {
+		return nil, tempError(err)
+	}
+	return ips, nil
+}
+
+// matchIPs returns whether c.ip is in the network of one of ips, with
+// the prefix lengths of the a or mx mechanism t.
+func (c *checker) matchIPs(ips []net.IP, t *term) bool {
+	mask := net.CIDRMask(t.cidr4, 32)
+	if len(c.ip) != net.IPv4len {
+		mask = net.CIDRMask(t.cidr6, 128)
+	}
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil {
+			ip = ip4
+		}
+		if len(ip) == len(c.ip) && ip.Mask(mask).Equal(c.ip.Mask(mask)) {
+			return true
+		}
+	}
+	return false
+}
+
+// validatedNames returns the names of c.ip whose addresses include
+// c.ip (RFC 7208, section 5.5).
+func (c *checker) validatedNames() ([]string, error) {
+	ptr, ok := c.dnsres.(PTRResolver)
+	if !ok {
+		return nil, nil
+	}
+	names, err := ptr.GetPTR(c.ip)
+	if err != nil {
+		// A failed lookup does not match (RFC 7208, section 5.5).
+		return nil, nil
+	}
+	if err := c.void(len(names)); err != nil {
+		return nil, err
+	}
+	if len(names) > maxPTRNames {
+		names = names[:maxPTRNames]
+	}
+	var valid []string
+	for _, name := range names {
+		ips, err := c.addrs(name)
+		if err != nil {
+			continue
+		}
+		for _, ip := range ips {
+			if ip.Equal(c.ip) {
+				valid = append(valid, strings.ToLower(strings.TrimSuffix(name, ".")))
+				break
+			}
+		}
+	}
+	return valid, nil
+}
+
+// expand expands the macros of the domain-spec spec of the record of
+// domain.
+func (c *checker) expand(spec, domain string) (string, error) {
+	var err error
+	s, macroErr := expandMacros(spec, false, func(letter byte) string {
+		switch letter {
+		case 's':
+			return c.sender
+		case 'l':
+			return c.local
+		case 'o', 'h':
+			return c.domain
+		case 'd':
+			return domain
+		case 'i':
+			return macroIP(c.ip)
+		case 'v':
+			if len(c.ip) == net.IPv4len {
+				return "in-addr"
+			}
+			return "ip6"
+		case 'p':
+			var names []string
+			names, err = c.validatedNames()
+			for _, name := range names {
+				if name == domain || strings.HasSuffix(name, "."+domain) {
+					return name
+				}
+			}
+			if len(names) != 0 {
+				return names[0]
+			}
+			return "unknown"
+		}
+		return ""
+	})
+	if macroErr != nil {
+		return "", permErrorf("%s", macroErr)
+	}
+	if err != nil {
+		return "", err
+	}
+	// Too long a domain loses labels on the left (RFC 7208, section 7.3).
+	for len(s) > 253 {
+		i := strings.IndexByte(s, '.')
+		if i < 0 {
+			break
+		}
+		s = s[i+1:]
+	}
+	return s, nil
+}
+
+// macroIP returns ip as the %{i} macro does: dotted decimal for IPv4,
+// and dotted nibbles for IPv6.
+func macroIP(ip net.IP) string {
+	if len(ip) == net.IPv4len {
+		return ip.String()
+	}
+	nibbles := make([]string, 0, 32)
+	for _, b := range ip.To16() {
+		nibbles = append(nibbles, fmt.Sprintf("%x", b>>4), fmt.Sprintf("%x", b&0xf))
+	}
+	return strings.Join(nibbles, ".")
+}
+
+// validDomain returns whether domain is a valid name to check (RFC
+// 7208, section 4.3).
+func validDomain(domain string) bool {
+	if domain == "" || len(domain) > 253 {
+		return false
+	}
+	labels := strings.Split(domain, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, l := range labels {
+		if l == "" || len(l) > 63 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,261 @@
+package spflib
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeDNS answers from maps, by name. A name in fail makes every
+// lookup of it fail.
+type fakeDNS struct {
+	spf  map[string]string
+	a    map[string][]string
+	aaaa map[string][]string
+	mx   map[string][]string
+	ptr  map[string][]string // By address.
+	fail map[string]bool
+}
+
+func (f fakeDNS) GetSPF(name string) (string, error) {
+	if f.fail[name] {
+		return "", &net.DNSError{Err: "timeout", Name: name, IsTimeout: true}
+	}
+	if spf, ok := f.spf[name]; ok {
+		return spf, nil
+	}
+	return "", fmt.Errorf("%s has %w", name, ErrNoSPF)
+}
+
+func (f fakeDNS) GetA(name string) ([]net.IP, error)    { return f.ips(f.a, name) }
+func (f fakeDNS) GetAAAA(name string) ([]net.IP, error) { return f.ips(f.aaaa, name) }
+
+func (f fakeDNS) ips(m map[string][]string, name string) ([]net.IP, error) {
+	if f.fail[name] {
+		return nil, &net.DNSError{Err: "timeout", Name: name, IsTimeout: true}
+	}
+	var ips []net.IP
+	for _, s := range m[name] {
+		ips = append(ips, net.ParseIP(s))
+	}
+	return ips, nil
+}
+
+func (f fakeDNS) GetMX(name string) ([]string, error) {
+	if f.fail[name] {
+		return nil, &net.DNSError{Err: "timeout", Name: name, IsTimeout: true}
+	}
+	return f.mx[name], nil
+}
+
+func (f fakeDNS) GetPTR(ip net.IP) ([]string, error) {
+	return f.ptr[ip.String()], nil
+}
+
+var checkDNS = fakeDNS{
+	spf: map[string]string{
+		"example.com":         "v=spf1 ip4:192.0.2.0/28 a:web.example.com mx include:_spf.example.com redirect=other.example.com",
+		"_spf.example.com":    "v=spf1 ip6:2001:db8:1::/48 -ip4:198.51.100.1 ip4:198.51.100.0/24 ?all",
+		"other.example.com":   "v=spf1 ~ip4:203.0.113.0/24 -all",
+		"macro.example.com":   "v=spf1 exists:%{ir}.%{lr-}._spf.%{d} -all",
+		"ptr.example.com":     "v=spf1 ptr -all",
+		"cidr.example.com":    "v=spf1 a:web.example.com/24 mx:example.com//64 -all",
+		"neutral.example.com": "v=spf1 ip4:192.0.2.1",
+		"loop.example.com":    "v=spf1 include:loop2.example.com -all",
+		"loop2.example.com":   "v=spf1 redirect=loop.example.com",
+		"void.example.com":    "v=spf1 a:nx1.example.com a:nx2.example.com a:nx3.example.com -all",
+		"many.example.com":    "v=spf1 a:a1.example.com a:a2.example.com a:a3.example.com a:a4.example.com a:a5.example.com a:a6.example.com a:a7.example.com a:a8.example.com a:a9.example.com a:a10.example.com a:a11.example.com -all",
+		"bad.example.com":     "v=spf1 ip4:192.0.2.300 -all",
+		"noinc.example.com":   "v=spf1 include:nospf.example.com -all",
+		"temp.example.com":    "v=spf1 a:down.example.com -all",
+		"redir.example.com":   "v=spf1 redirect=nospf.example.com",
+		"ignored.example.com": "v=spf1 redirect=other.example.com -ip4:203.0.113.1 ?all",
+	},
+	a: map[string][]string{
+		"web.example.com":                             {"192.0.2.100"},
+		"mail.example.com":                            {"192.0.2.200"},
+		"mail.ptr.example.com":                        {"192.0.2.50"},
+		"1.2.0.192.bad.strong._spf.macro.example.com": {"127.0.0.2"},
+	},
+	aaaa: map[string][]string{
+		"mail.example.com": {"2001:db8:2::1"},
+	},
+	mx: map[string][]string{
+		"example.com": {"mail.example.com"},
+	},
+	ptr: map[string][]string{
+		"192.0.2.50": {"mail.ptr.example.com", "spoofed.ptr.example.com"},
+	},
+	fail: map[string]bool{"down.example.com": true},
+}
+
+func init() {
+	for i := 1; i <= 11; i++ {
+		checkDNS.a[fmt.Sprintf("a%d.example.com", i)] = []string{"198.18.0.1"}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		ip     string
+		sender string
+		want   Result
+		err    string
+	}{
+		{"192.0.2.5", "user@example.com", Pass, ""},
+		{"192.0.2.100", "user@example.com", Pass, ""},
+		{"192.0.2.200", "user@example.com", Pass, ""},
+		{"2001:db8:2::1", "user@example.com", Pass, ""},
+		{"2001:db8:1::25", "user@example.com", Pass, ""},
+		{"::ffff:192.0.2.5", "user@example.com", Pass, ""},
+		// include: only matches when the included record passes.
+		{"198.51.100.1", "user@example.com", Fail, ""},
+		{"198.51.100.2", "user@example.com", Pass, ""},
+		// redirect=
+		{"203.0.113.7", "user@example.com", SoftFail, ""},
+		{"10.0.0.1", "user@example.com", Fail, ""},
+		{"10.0.0.1", "example.com", Fail, ""},
+		{"203.0.113.1", "user@ignored.example.com", Fail, ""},
+		{"203.0.113.7", "user@ignored.example.com", Neutral, ""},
+		{"10.0.0.1", "user@neutral.example.com", Neutral, ""},
+		{"10.0.0.1", "user@nospf.example.com", None, ""},
+		{"10.0.0.1", "user@localhost", None, ""},
+		// Macros.
+		{"192.0.2.1", "strong-bad@macro.example.com", Pass, ""},
+		{"192.0.2.1", "strong-good@macro.example.com", Fail, ""},
+		// ptr validates the name it finds.
+		{"192.0.2.50", "user@ptr.example.com", Pass, ""},
+		{"192.0.2.51", "user@ptr.example.com", Fail, ""},
+		// Prefix lengths of a and mx.
+		{"192.0.2.99", "user@cidr.example.com", Pass, ""},
+		{"2001:db8:2::ffff", "user@cidr.example.com", Pass, ""},
+		{"2001:db8:3::1", "user@cidr.example.com", Fail, ""},
+		// Errors.
+		{"10.0.0.1", "user@loop.example.com", PermError, "SPF loop: loop.example.com -> loop2.example.com -> loop.example.com"},
+		{"10.0.0.1", "user@void.example.com", PermError, "more than 2 DNS lookups found nothing"},
+		{"10.0.0.1", "user@many.example.com", PermError, "more than 10 DNS lookups"},
+		{"198.18.0.1", "user@many.example.com", Pass, ""},
+		{"10.0.0.1", "user@bad.example.com", PermError, "ip4:192.0.2.300 is not a valid ip4 network"},
+		{"10.0.0.1", "user@noinc.example.com", PermError, "include:nospf.example.com: nospf.example.com has no SPF record"},
+		{"10.0.0.1", "user@redir.example.com", PermError, "redirect=nospf.example.com: nospf.example.com has no SPF record"},
+		{"10.0.0.1", "user@temp.example.com", TempError, "timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip+" "+tt.sender, func(t *testing.T) {
+			got, err := Check(net.ParseIP(tt.ip), tt.sender, checkDNS)
+			if got != tt.want {
+				t.Errorf("got %s (%v), want %s", got, err, tt.want)
+			}
+			if tt.err == "" && err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("got error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	// The examples of RFC 7208, section 7.4.
+	c := &checker{
+		ip:     net.ParseIP("192.0.2.3").To4(),
+		sender: "strong-bad@email.example.com",
+		local:  "strong-bad",
+		domain: "email.example.com",
+		dnsres: fakeDNS{},
+	}
+	tests := map[string]string{
+		"%{s}":                              "strong-bad@email.example.com",
+		"%{o}":                              "email.example.com",
+		"%{d}":                              "email.example.com",
+		"%{d4}":                             "email.example.com",
+		"%{d3}":                             "email.example.com",
+		"%{d2}":                             "example.com",
+		"%{d1}":                             "com",
+		"%{dr}":                             "com.example.email",
+		"%{d2r}":                            "example.email",
+		"%{l}":                              "strong-bad",
+		"%{l-}":                             "strong.bad",
+		"%{lr}":                             "strong-bad",
+		"%{lr-}":                            "bad.strong",
+		"%{l1r-}":                           "strong",
+		"%{ir}.%{v}._spf.%{d2}":             "3.2.0.192.in-addr._spf.example.com",
+		"%{lr-}.lp._spf.%{d2}":              "bad.strong.lp._spf.example.com",
+		"%{lr-}.lp.%{ir}.%{v}._spf.%{d2}":   "bad.strong.lp.3.2.0.192.in-addr._spf.example.com",
+		"%{ir}.%{v}.%{l1r-}.lp._spf.%{d2}":  "3.2.0.192.in-addr.strong.lp._spf.example.com",
+		"%{d2}.trusted-domains.example.net": "example.com.trusted-domains.example.net",
+		"%{S}":                              "strong-bad%40email.example.com",
+		"%%%_%-":                            "% %20",
+		"%{p}":                              "unknown",
+	}
+	for spec, want := range tests {
+		got, err := c.expand(spec, "email.example.com")
+		if err != nil || got != want {
+			t.Errorf("%s: got %q (%v), want %q", spec, got, err, want)
+		}
+	}
+
+	c.ip = net.ParseIP("2001:db8::cb01")
+	want := "1.0.b.c.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6._spf.example.com"
+	if got, err := c.expand("%{ir}.%{v}._spf.%{d2}", "email.example.com"); err != nil || got != want {
+		t.Errorf("IPv6: got %q (%v), want %q", got, err, want)
+	}
+
+	for _, spec := range []string{"%{x}", "%{d0}", "%{c}", "%{d", "%a", "example.com%"} {
+		if _, err := c.expand(spec, "email.example.com"); err == nil {
+			t.Errorf("%s: no error", spec)
+		}
+	}
+}
+
+func TestParseTerm(t *testing.T) {
+	tests := []struct {
+		text string
+		want string // The term, formatted; "" if it is not valid.
+	}{
+		{"-all", "- all"},
+		{"include:_spf.example.com", "+ include _spf.example.com"},
+		{"~a", "~ a  32 128"},
+		{"a:example.com/24//64", "+ a example.com 24 64"},
+		{"mx//48", "+ mx  32 48"},
+		{"MX:%{d}/28", "+ mx %{d} 28 128"},
+		{"?ptr", "? ptr"},
+		{"ptr:example.com", "+ ptr example.com"},
+		{"exists:%{i}.example.com", "+ exists %{i}.example.com"},
+		{"ip4:192.0.2.1", "+ ip4 192.0.2.1/32"},
+		{"ip6:2001:db8::/32", "+ ip6 2001:db8::/32"},
+		{"redirect=example.com", "redirect= example.com"},
+		{"exp=explain.example.com", "exp= explain.example.com"},
+		{"foo=bar", "foo= bar"},
+		{"all:example.com", ""},
+		{"include", ""},
+		{"exists:", ""},
+		{"a/33", ""},
+		{"ip4:2001:db8::1", ""},
+		{"ip6:192.0.2.1", ""},
+		{"redirect:example.com", ""},
+		{"redirect=%{z}", ""},
+		{"foo", ""},
+	}
+	for _, tt := range tests {
+		tm, err := parseTerm(tt.text)
+		got := ""
+		if err == nil {
+			switch {
+			case tm.modifier:
+				got = tm.name + "= " + tm.domain
+			case tm.network != nil:
+				got = fmt.Sprintf("%c %s %s", tm.qualifier, tm.name, tm.network)
+			case tm.name == "a" || tm.name == "mx":
+				got = fmt.Sprintf("%c %s %s %d %d", tm.qualifier, tm.name, tm.domain, tm.cidr4, tm.cidr6)
+			default:
+				got = strings.TrimSpace(fmt.Sprintf("%c %s %s", tm.qualifier, tm.name, tm.domain))
+			}
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q (%v), want %q", tt.text, got, err, tt.want)
+		}
+	}
+}
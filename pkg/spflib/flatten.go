@@ -104,26 +104,62 @@ func (s *SPFRecord) split(thisfqdn string, pattern string, nextIdx int, m map[st
 	newRec.split(nextFQDN, pattern, nextIdx+1, m, 0, txtMaxSize)
 }
 
-// Flatten optimizes s.
+// Flatten optimizes s: the includes, redirects, and a and mx parts
+// whose domain matches spec are replaced by what they resolve to. a
+// and mx parts that resolved to nothing are kept.
 func (s *SPFRecord) Flatten(spec string) *SPFRecord {
 	newRec := &SPFRecord{}
+	var tail []*SPFPart // What redirect= adds, after the mechanisms.
 	for _, p := range s.Parts {
-		if p.IncludeRecord == nil {
-			// non-includes copy straight over
-			newRec.Parts = append(newRec.Parts, p)
-		} else if !matchesFlatSpec(spec, p.IncludeDomain) {
-			// includes that don't match get copied straight across
-			newRec.Parts = append(newRec.Parts, p)
-		} else {
+		switch {
+		case p.IncludeRecord != nil && matchesFlatSpec(spec, p.IncludeDomain):
 			// flatten child recursively
 			flattenedChild := p.IncludeRecord.Flatten(spec)
-			// include their parts (skipping final all term)
-			newRec.Parts = append(newRec.Parts, flattenedChild.Parts[:len(flattenedChild.Parts)-1]...)
+			if p.IsRedirect {
+				// the child's policy, all included, applies to what the
+				// mechanisms don't match.
+				tail = flattenedChild.Parts
+			} else {
+				newRec.Parts = append(newRec.Parts, flattenedChild.mechanisms()...)
+			}
+		case len(p.Nets) != 0 && matchesFlatSpec(spec, p.LookupDomain):
+			for _, n := range p.Nets {
+				newRec.Parts = append(newRec.Parts, &SPFPart{Text: n})
+			}
+		case p.IsRedirect:
+			tail = []*SPFPart{p}
+		default:
+			// non-includes copy straight over
+			newRec.Parts = append(newRec.Parts, p)
 		}
 	}
+	newRec.Parts = append(newRec.Parts, tail...)
 	return newRec
 }
 
+// mechanisms returns the parts of s that are inlined where s is
+// included: its mechanisms but all. A redirect= that is not flattened
+// becomes an include:, which matches when the redirect would pass.
+func (s *SPFRecord) mechanisms() []*SPFPart {
+	var parts []*SPFPart
+	for _, p := range s.Parts {
+		switch {
+		case p.IsRedirect && p.IsLookup:
+			parts = append(parts, &SPFPart{
+				Text:          "include:" + p.IncludeDomain,
+				IsLookup:      true,
+				IncludeRecord: p.IncludeRecord,
+				IncludeDomain: p.IncludeDomain,
+			})
+		case p.term != nil && (p.term.modifier || p.term.name == "all"):
+			// skip all and the modifiers
+		default:
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
 func matchesFlatSpec(spec, fqdn string) bool {
 	if spec == "*" {
 		return true
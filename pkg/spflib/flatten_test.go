@@ -31,6 +31,40 @@ func TestFlatten(t *testing.T) {
 	t.Log(rec.Print())
 }
 
+func TestFlattenResolved(t *testing.T) {
+	dnsres := fakeDNS{
+		spf: map[string]string{
+			"_spf.example.net":  "v=spf1 ip4:198.51.100.0/24 redirect=_spf2.example.net",
+			"_spf2.example.net": "v=spf1 ip6:2001:db8:2::/48 -all",
+			"_spf.example.org":  "v=spf1 mx:example.org redirect=_spf.example.net",
+		},
+		a:    map[string][]string{"example.com": {"192.0.2.1"}, "mx.example.org": {"203.0.113.5"}},
+		aaaa: map[string][]string{"example.com": {"2001:db8::1"}},
+		mx:   map[string][]string{"example.org": {"mx.example.org"}},
+	}
+	tests := []struct {
+		text, spec, want string
+	}{
+		{"v=spf1 a ~mx include:_spf.example.net -all", "*",
+			"v=spf1 ip4:192.0.2.1 ip6:2001:db8::1 ~mx ip4:198.51.100.0/24 ip6:2001:db8:2::/48 -all"},
+		{"v=spf1 a include:_spf.example.net -all", "_spf.example.net",
+			"v=spf1 a ip4:198.51.100.0/24 include:_spf2.example.net -all"},
+		{"v=spf1 -a/24 redirect=_spf.example.org", "*",
+			"v=spf1 -ip4:192.0.2.0/24 -ip6:2001:db8::1 ip4:203.0.113.5 ip4:198.51.100.0/24 ip6:2001:db8:2::/48 -all"},
+		{"v=spf1 redirect=_spf.example.org a", "example.com",
+			"v=spf1 ip4:192.0.2.1 ip6:2001:db8::1 redirect=_spf.example.org"},
+	}
+	for _, tt := range tests {
+		rec, err := ParseDomain(tt.text, "example.com", dnsres)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rec.Flatten(tt.spec).TXT(); got != tt.want {
+			t.Errorf("%s flattening %s:\ngot  %s\nwant %s", tt.text, tt.spec, got, tt.want)
+		}
+	}
+}
+
 // each test is array of strings.
 // first item is unsplit input
 // next is @ spf record
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"net"
+	"strconv"
 	"strings"
 )
 
@@ -32,76 +34,163 @@ type SPFPart struct {
 	IsLookup      bool
 	IncludeRecord *SPFRecord
 	IncludeDomain string
-}
+	IsRedirect    bool // IncludeDomain is that of redirect=, not include:.
+
+	// For a and mx: the domain they look up, and the networks they
+	// match, as ip4: and ip6: parts with the qualifier of Text. Nets is
+	// nil unless Parse was given a Resolver and the domain is known
+	// (it has no macros).
+	LookupDomain string
+	Nets         []string
 
-var qualifiers = map[byte]bool{
-	'?': true,
-	'~': true,
-	'-': true,
-	'+': true,
+	term *term
 }
 
-// Parse parses a raw SPF record.
+// Parse parses a raw SPF record. If dnsres is not nil, the records
+// that its include: and redirect= parts name are parsed, and the
+// networks of its a and mx parts are looked up. Parts whose domain has
+// macros are not resolved.
 func Parse(text string, dnsres Resolver) (*SPFRecord, error) {
+	return ParseDomain(text, "", dnsres)
+}
+
+// ParseDomain is Parse for the SPF record of domain, which the a and
+// mx parts without a domain look up.
+func ParseDomain(text, domain string, dnsres Resolver) (*SPFRecord, error) {
+	return parse(text, strings.ToLower(strings.TrimSuffix(domain, ".")), dnsres, nil)
+}
+
+// parse parses text, the SPF record of domain. stack is the domains
+// whose records include it, to detect loops.
+func parse(text, domain string, dnsres Resolver, stack []string) (*SPFRecord, error) {
 	if !strings.HasPrefix(text, "v=spf1 ") {
 		return nil, fmt.Errorf("not an SPF record")
 	}
-	parts := strings.Split(text, " ")
+	if domain != "" {
+		for _, d := range stack {
+			if d == domain {
+				return nil, fmt.Errorf("SPF loop: %s -> %s", strings.Join(stack, " -> "), domain)
+			}
+		}
+		stack = append(stack, domain)
+	}
+
 	rec := &SPFRecord{}
-	for pi, part := range parts[1:] {
+	var redirect *SPFPart
+	hasAll := false
+	for _, part := range strings.Split(text, " ")[1:] {
 		if part == "" {
 			continue
 		}
-		p := &SPFPart{Text: part}
-		if qualifiers[part[0]] {
-			part = part[1:]
+		t, err := parseTerm(part)
+		if err != nil {
+			return nil, err
 		}
+		p := &SPFPart{Text: part, term: t}
 		rec.Parts = append(rec.Parts, p)
-		if part == "all" {
+		switch {
+		case t.modifier:
+			if t.name == "redirect" {
+				p.IncludeDomain = t.domain
+				p.IsRedirect = true
+				redirect = p
+			}
+		case t.name == "all":
 			// all. nothing else matters.
-			break
-		} else if strings.HasPrefix(part, "a") || strings.HasPrefix(part, "mx") {
+			hasAll = true
+		case t.name == "a" || t.name == "mx":
 			p.IsLookup = true
-		} else if strings.HasPrefix(part, "ip4:") || strings.HasPrefix(part, "ip6:") {
-			// ip address, 0 lookups
-			continue
-		} else if strings.HasPrefix(part, "include:") || strings.HasPrefix(part, "redirect=") {
-			// redirect is only partially implemented. redirect is a
-			// complex and IMHO ambiguously defined feature.  We only
-			// implement the most simple edge case: when it is the last item
-			// in the string.  In that situation, it is the equivalent of
-			// include:.
-			if strings.HasPrefix(part, "redirect=") {
-				// pi + 2: because pi starts at 0 when it iterates starting on parts[1],
-				// and because len(parts) is one bigger than the highest index.
-				if (pi + 2) != len(parts) {
-					return nil, fmt.Errorf("%s must be last item", part)
-				}
-				p.IncludeDomain = strings.TrimPrefix(part, "redirect=")
-			} else {
-				p.IncludeDomain = strings.TrimPrefix(part, "include:")
+			p.LookupDomain = t.domain
+			if p.LookupDomain == "" {
+				p.LookupDomain = domain
 			}
+		case t.name == "include":
 			p.IsLookup = true
-			if dnsres != nil {
-				subRecord, err := dnsres.GetSPF(p.IncludeDomain)
-				if err != nil {
-					return nil, err
-				}
-				p.IncludeRecord, err = Parse(subRecord, dnsres)
-				if err != nil {
-					return nil, fmt.Errorf("in included SPF: %s", err)
-				}
-			}
-		} else if strings.HasPrefix(part, "exists:") || strings.HasPrefix(part, "ptr:") {
+			p.IncludeDomain = t.domain
+		case t.name == "exists" || t.name == "ptr":
 			p.IsLookup = true
-		} else {
-			return nil, fmt.Errorf("unsupported SPF part %s", part)
 		}
+		if hasAll {
+			break
+		}
+	}
+	// redirect= is ignored when the record has all (RFC 7208, section
+	// 6.1).
+	if redirect != nil && !hasAll {
+		redirect.IsLookup = true
+	}
 
+	if dnsres == nil {
+		return rec, nil
+	}
+	for _, p := range rec.Parts {
+		switch {
+		case p.IncludeDomain != "" && p.IsLookup && !strings.Contains(p.IncludeDomain, "%"):
+			subRecord, err := dnsres.GetSPF(p.IncludeDomain)
+			if err != nil {
+				return nil, err
+			}
+			p.IncludeRecord, err = parse(subRecord, strings.ToLower(strings.TrimSuffix(p.IncludeDomain, ".")), dnsres, stack)
+			if err != nil {
+				return nil, fmt.Errorf("in included SPF: %s", err)
+			}
+		case p.LookupDomain != "" && !strings.Contains(p.LookupDomain, "%"):
+			nets, err := lookupNets(p.term, p.LookupDomain, dnsres)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", p.Text, err)
+			}
+			p.Nets = nets
+		}
 	}
 	return rec, nil
 }
 
+// lookupNets returns the networks that the a or mx mechanism t
+// matches, as ip4: and ip6: parts with the qualifier of t.
+func lookupNets(t *term, domain string, dnsres Resolver) ([]string, error) {
+	hosts := []string{domain}
+	if t.name == "mx" {
+		var err error
+		if hosts, err = dnsres.GetMX(domain); err != nil {
+			return nil, err
+		}
+		if len(hosts) > maxMXHosts {
+			return nil, fmt.Errorf("%s has more than %d MX records", domain, maxMXHosts)
+		}
+	}
+	qualifier := ""
+	if t.qualifier != '+' {
+		qualifier = string(t.qualifier)
+	}
+	nets := []string{}
+	seen := map[string]bool{}
+	add := func(ips []net.IP, mechanism string, prefix, bits int) {
+		for _, ip := range ips {
+			n := ip.Mask(net.CIDRMask(prefix, bits)).String()
+			if prefix != bits {
+				n += "/" + strconv.Itoa(prefix)
+			}
+			n = qualifier + mechanism + ":" + n
+			if !seen[n] {
+				seen[n] = true
+				nets = append(nets, n)
+			}
+		}
+	}
+	for _, host := range hosts {
+		ips, err := dnsres.GetA(host)
+		if err != nil {
+			return nil, err
+		}
+		add(ips, "ip4", t.cidr4, 32)
+		if ips, err = dnsres.GetAAAA(host); err != nil {
+			return nil, err
+		}
+		add(ips, "ip6", t.cidr6, 128)
+	}
+	return nets, nil
+}
+
 func dump(rec *SPFRecord, indent string, w io.Writer) {
 
 	fmt.Fprintf(w, "%sTotal Lookups: %d\n", indent, rec.Lookups())
@@ -1,6 +1,7 @@
 package spflib
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -42,16 +43,19 @@ func TestParseWithDoubleSpaces(t *testing.T) {
 }
 
 func TestParseRedirectNotLast(t *testing.T) {
-	// Make sure redirect=foo fails if it isn't the last item.
+	// redirect=foo is ignored when there is an all (RFC 7208, section 6.1).
 	dnsres, err := NewCache("testdata-dns1.json")
 	if err != nil {
 		t.Fatal(err)
 	}
-	_, err = Parse(strings.Join([]string{"v=spf1",
+	rec, err := Parse(strings.Join([]string{"v=spf1",
 		"redirect=servers.mcsv.net",
 		"~all"}, " "), dnsres)
-	if err == nil {
-		t.Fatal("should fail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := rec.Lookups(); n != 0 {
+		t.Errorf("got %d lookups, want 0", n)
 	}
 }
 
@@ -95,3 +99,41 @@ func TestParseRedirectLast(t *testing.T) {
 	}
 	t.Log(rec.Print())
 }
+
+func TestParseLoop(t *testing.T) {
+	dnsres := fakeDNS{spf: map[string]string{
+		"a.example": "v=spf1 include:b.example -all",
+		"b.example": "v=spf1 redirect=a.example",
+	}}
+	_, err := ParseDomain(dnsres.spf["a.example"], "a.example", dnsres)
+	if err == nil || !strings.Contains(err.Error(), "SPF loop: a.example -> b.example -> a.example") {
+		t.Fatalf("got error %v, want a loop", err)
+	}
+}
+
+func TestParseNets(t *testing.T) {
+	dnsres := fakeDNS{
+		a:    map[string][]string{"example.com": {"192.0.2.1"}, "mx1.example.com": {"192.0.2.10", "192.0.2.11"}},
+		aaaa: map[string][]string{"example.com": {"2001:db8::1"}},
+		mx:   map[string][]string{"example.com": {"mx1.example.com"}},
+	}
+	rec, err := ParseDomain("v=spf1 a -mx/24 a:%{d}.example exp=why.example -all", "example.com", dnsres)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"ip4:192.0.2.1", "ip6:2001:db8::1"},
+		{"-ip4:192.0.2.0/24"},
+		nil, // Has a macro.
+		nil,
+		nil,
+	}
+	for i, p := range rec.Parts {
+		if !reflect.DeepEqual(p.Nets, want[i]) {
+			t.Errorf("%s: got %v, want %v", p.Text, p.Nets, want[i])
+		}
+	}
+	if n := rec.Lookups(); n != 3 {
+		t.Errorf("got %d lookups, want 3", n)
+	}
+}
@@ -1,140 +1,250 @@
 package spflib
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"net"
-	"os"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/miekg/dns"
 )
 
-// Resolver looks up spf txt records associated with a FQDN.
+// Resolver looks up spf txt records associated with a FQDN, and the
+// addresses and mail exchangers that the a and mx mechanisms need.
+// A name that does not exist, or has no records of the type asked
+// for, is not an error: GetA, GetAAAA and GetMX return nothing.
 type Resolver interface {
 	GetSPF(string) (string, error)
+	GetA(string) ([]net.IP, error)
+	GetAAAA(string) ([]net.IP, error)
+	GetMX(string) ([]string, error)
+}
+
+// PTRResolver is implemented by a Resolver that can look up the names
+// of an address, for the ptr mechanism and the %{p} macro. Without it,
+// ptr never matches.
+type PTRResolver interface {
+	GetPTR(net.IP) ([]string, error)
 }
 
+// ErrNoSPF is returned, wrapped, by a Resolver when a name has no SPF
+// record.
+var ErrNoSPF = errors.New("no SPF record")
+
 // LiveResolver simply queries DNS to resolve SPF records.
 type LiveResolver struct{}
 
 // GetSPF looks up the SPF record named "name".
 func (l LiveResolver) GetSPF(name string) (string, error) {
-	vals, err := net.LookupTXT(name)
+	vals, _, err := l.lookup(name, "SPF")
 	if err != nil {
 		return "", err
 	}
-	spf := ""
-	for _, v := range vals {
-		if strings.HasPrefix(v, "v=spf1") {
-			if spf != "" {
-				return "", fmt.Errorf("%s has multiple SPF records", name)
-			}
-			spf = v
-		}
-	}
-	if spf == "" {
-		return "", fmt.Errorf("%s has no SPF record", name)
-	}
-	return spf, nil
+	return vals[0], nil
 }
 
-// CachingResolver wraps a live resolver and adds caching to it.
-// GetSPF will always return the cached value, if present.
-// It will also query the inner resolver and compare results.
-// If a given lookup has inconsistencies between cache and live,
-// GetSPF will return the cached result.
-// All records queries will be stored for the lifetime of the resolver,
-// and can be flushed to disk at the end.
-// All resolution errors from the inner resolver will be saved and can be retreived later.
-type CachingResolver interface {
-	Resolver
-	ChangedRecords() []string
-	ResolveErrors() []error
-	Save(filename string) error
+// GetA looks up the IPv4 addresses of name.
+func (l LiveResolver) GetA(name string) ([]net.IP, error) {
+	vals, _, err := l.lookup(name, "A")
+	return parseIPs(vals), err
 }
 
-type cacheEntry struct {
-	SPF string
-
-	// value we have looked up this run
-	resolvedSPF  string
-	resolveError error
+// GetAAAA looks up the IPv6 addresses of name.
+func (l LiveResolver) GetAAAA(name string) ([]net.IP, error) {
+	vals, _, err := l.lookup(name, "AAAA")
+	return parseIPs(vals), err
 }
 
-type cache struct {
-	records map[string]*cacheEntry
-
-	inner Resolver
+// GetMX looks up the mail exchangers of name, in order of preference.
+func (l LiveResolver) GetMX(name string) ([]string, error) {
+	vals, _, err := l.lookup(name, "MX")
+	return vals, err
 }
 
-// NewCache creates a new cache file named filename.
-func NewCache(filename string) (CachingResolver, error) {
-	f, err := os.Open(filename)
+// lookup looks up the records of type rtype (SPF, A, AAAA or MX) of
+// name, and returns their values and how long they may be cached: the
+// smallest TTL of the answers, or 0 if it is not known. The value of
+// an SPF lookup is the SPF record; that of an MX lookup, the hosts in
+// order of preference.
+//
+// The nameserver of /etc/resolv.conf is asked, so that the TTLs are
+// known. Where there is none (as on Windows), the system resolver is
+// used instead.
+func (l LiveResolver) lookup(name, rtype string) ([]string, time.Duration, error) {
+	server, err := systemNameserver()
+	if err != nil {
+		vals, err := lookupSystem(name, rtype)
+		return vals, 0, err
+	}
+	qtype := dns.StringToType[rtype]
+	if rtype == "SPF" {
+		qtype = dns.TypeTXT
+	}
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), qtype)
+	m.SetEdns0(4096, false)
+	in, _, err := client.Exchange(m, server)
+	if err == nil && in.Truncated {
+		in, _, err = tcpClient.Exchange(m, server)
+	}
 	if err != nil {
-		if os.IsNotExist(err) {
-			// doesn't exist, just make a new one
-			return &cache{
-				records: map[string]*cacheEntry{},
-				inner:   LiveResolver{},
-			}, nil
+		return nil, 0, &net.DNSError{Err: err.Error(), Name: name, Server: server, IsTemporary: true}
+	}
+	switch in.Rcode {
+	case dns.RcodeSuccess, dns.RcodeNameError:
+	default:
+		return nil, 0, &net.DNSError{Err: dns.RcodeToString[in.Rcode], Name: name, Server: server, IsTemporary: true}
+	}
+
+	var vals []string
+	var ttl uint32
+	type mx struct {
+		pref uint16
+		host string
+	}
+	var mxs []mx
+	for _, rr := range in.Answer {
+		if rr.Header().Rrtype != qtype {
+			// A CNAME the resolver followed.
+			continue
+		}
+		if ttl == 0 || rr.Header().Ttl < ttl {
+			ttl = rr.Header().Ttl
+		}
+		switch rr := rr.(type) {
+		case *dns.TXT:
+			vals = append(vals, strings.Join(rr.Txt, ""))
+		case *dns.A:
+			vals = append(vals, rr.A.String())
+		case *dns.AAAA:
+			vals = append(vals, rr.AAAA.String())
+		case *dns.MX:
+			mxs = append(mxs, mx{rr.Preference, rr.Mx})
 		}
-		return nil, err
 	}
-	dec := json.NewDecoder(f)
-	recs := map[string]*cacheEntry{}
-	if err := dec.Decode(&recs); err != nil {
-		return nil, err
+	if rtype == "MX" {
+		sort.SliceStable(mxs, func(i, j int) bool { return mxs[i].pref < mxs[j].pref })
+		for _, mx := range mxs {
+			vals = append(vals, mx.host)
+		}
+		vals = mxHosts(vals)
 	}
-	return &cache{
-		records: recs,
-		inner:   LiveResolver{},
-	}, nil
+	if rtype == "SPF" {
+		spf, err := findSPF(name, vals)
+		if err != nil {
+			return nil, 0, err
+		}
+		vals = []string{spf}
+	}
+	return vals, time.Duration(ttl) * time.Second, nil
 }
 
-func (c *cache) GetSPF(name string) (string, error) {
-	entry, ok := c.records[name]
-	if !ok {
-		entry = &cacheEntry{}
-		c.records[name] = entry
+var (
+	client    = &dns.Client{Timeout: 5 * time.Second}
+	tcpClient = &dns.Client{Net: "tcp", Timeout: 5 * time.Second}
+)
+
+// systemNameserver returns the first nameserver of /etc/resolv.conf,
+// as "host:port".
+func systemNameserver() (string, error) {
+	conf, err := dns.ClientConfigFromFile("/etc/resolv.conf")
+	if err != nil {
+		return "", err
 	}
-	if entry.resolvedSPF == "" && entry.resolveError == nil {
-		entry.resolvedSPF, entry.resolveError = c.inner.GetSPF(name)
+	if len(conf.Servers) == 0 {
+		return "", fmt.Errorf("no nameserver in /etc/resolv.conf")
 	}
-	// return cached value
-	if entry.SPF != "" {
-		return entry.SPF, nil
+	return net.JoinHostPort(conf.Servers[0], conf.Port), nil
+}
+
+// lookupSystem is lookup with the resolver of the system, which does
+// not tell the TTLs.
+func lookupSystem(name, rtype string) ([]string, error) {
+	var vals []string
+	var err error
+	switch rtype {
+	case "SPF":
+		if vals, err = net.LookupTXT(name); err == nil {
+			var spf string
+			if spf, err = findSPF(name, vals); err == nil {
+				return []string{spf}, nil
+			}
+		} else if isNotFound(err) {
+			err = fmt.Errorf("%s has %w", name, ErrNoSPF)
+		}
+		return nil, err
+	case "A", "AAAA":
+		network := "ip4"
+		if rtype == "AAAA" {
+			network = "ip6"
+		}
+		var ips []net.IP
+		ips, err = net.DefaultResolver.LookupIP(context.Background(), network, name)
+		vals, err = ipStrings(ips, err)
+	case "MX":
+		var mxs []*net.MX
+		mxs, err = net.LookupMX(name)
+		for _, mx := range mxs {
+			vals = append(vals, mx.Host)
+		}
+		vals = mxHosts(vals)
+	}
+	if isNotFound(err) {
+		return nil, nil
 	}
-	// if not cached, return results of inner resolver
-	return entry.resolvedSPF, entry.resolveError
+	return vals, err
 }
 
-func (c *cache) ChangedRecords() []string {
-	names := []string{}
-	for name, entry := range c.records {
-		if entry.resolvedSPF != entry.SPF {
-			names = append(names, name)
+// findSPF returns the SPF record among the TXT records vals of name.
+func findSPF(name string, vals []string) (string, error) {
+	spf := ""
+	for _, v := range vals {
+		if strings.HasPrefix(v, "v=spf1") {
+			if spf != "" {
+				return "", fmt.Errorf("%s has multiple SPF records", name)
+			}
+			spf = v
 		}
 	}
-	return names
+	if spf == "" {
+		return "", fmt.Errorf("%s has %w", name, ErrNoSPF)
+	}
+	return spf, nil
 }
 
-func (c *cache) ResolveErrors() (errs []error) {
-	for _, entry := range c.records {
-		if entry.resolveError != nil {
-			errs = append(errs, entry.resolveError)
+// mxHosts returns the hosts of MX records, without the final dot.
+func mxHosts(hosts []string) []string {
+	var out []string
+	for _, h := range hosts {
+		// A "null MX" (RFC 7505) has no hosts.
+		if h != "." {
+			out = append(out, strings.TrimSuffix(h, "."))
 		}
 	}
-	return
+	return out
 }
-func (c *cache) Save(filename string) error {
-	outRecs := make(map[string]*cacheEntry, len(c.records))
-	for k, entry := range c.records {
-		// move resolved data into cached field
-		// only take those we actually resolved
-		if entry.resolvedSPF != "" {
-			entry.SPF = entry.resolvedSPF
-			outRecs[k] = entry
+
+// GetPTR looks up the names of ip.
+func (l LiveResolver) GetPTR(ip net.IP) ([]string, error) {
+	names, err := net.LookupAddr(ip.String())
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
 		}
+		return nil, err
 	}
-	dat, _ := json.MarshalIndent(outRecs, "", "  ")
-	return os.WriteFile(filename, dat, 0644)
+	for i := range names {
+		names[i] = strings.TrimSuffix(names[i], ".")
+	}
+	return names, nil
+}
+
+// isNotFound returns whether err means that a name or its records do
+// not exist.
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
 }
@@ -0,0 +1,285 @@
+package spflib
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A term is a mechanism or a modifier of an SPF record (RFC 7208,
+// section 4.6.1).
+type term struct {
+	qualifier byte   // Of a mechanism: '+', '-', '~' or '?'.
+	name      string // In lower case, such as "include" or "redirect".
+	modifier  bool
+	domain    string     // The domain-spec, "" if none was given.
+	network   *net.IPNet // Of ip4 and ip6.
+	cidr4     int        // Of a and mx: the prefix lengths.
+	cidr6     int
+}
+
+var qualifiers = map[byte]bool{
+	'?': true,
+	'~': true,
+	'-': true,
+	'+': true,
+}
+
+var (
+	modifierName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9._-]*$`)
+	dualCIDR     = regexp.MustCompile(`^(.*?)(?:/([0-9]+))?(?://([0-9]+))?$`)
+)
+
+// parseTerm parses a term of an SPF record. Macros are checked but not
+// expanded.
+func parseTerm(text string) (*term, error) {
+	if i := strings.IndexByte(text, '='); i > 0 && modifierName.MatchString(text[:i]) {
+		t := &term{name: strings.ToLower(text[:i]), modifier: true, domain: text[i+1:]}
+		switch t.name {
+		case "redirect", "exp":
+			if err := checkDomainSpec(t.domain, false); err != nil {
+				return nil, fmt.Errorf("%s: %s", text, err)
+			}
+		}
+		// Unknown modifiers are ignored.
+		return t, nil
+	}
+
+	t := &term{qualifier: '+', cidr4: 32, cidr6: 128}
+	rest := text
+	if qualifiers[rest[0]] {
+		t.qualifier = rest[0]
+		rest = rest[1:]
+	}
+	t.name = rest
+	if i := strings.IndexAny(rest, ":/"); i >= 0 {
+		t.name, rest = rest[:i], rest[i:]
+	} else {
+		rest = ""
+	}
+	t.name = strings.ToLower(t.name)
+
+	switch t.name {
+	case "all":
+		if rest != "" {
+			return nil, fmt.Errorf("unsupported SPF part %s", text)
+		}
+	case "include", "exists":
+		if !strings.HasPrefix(rest, ":") {
+			return nil, fmt.Errorf("%s needs a domain", text)
+		}
+		t.domain = rest[1:]
+	case "ptr":
+		if rest != "" && !strings.HasPrefix(rest, ":") {
+			return nil, fmt.Errorf("unsupported SPF part %s", text)
+		}
+		t.domain = strings.TrimPrefix(rest, ":")
+	case "a", "mx":
+		m := dualCIDR.FindStringSubmatch(rest)
+		if m[1] != "" && !strings.HasPrefix(m[1], ":") {
+			return nil, fmt.Errorf("unsupported SPF part %s", text)
+		}
+		t.domain = strings.TrimPrefix(m[1], ":")
+		var err error
+		if t.cidr4, err = prefixLength(m[2], 32); err != nil {
+			return nil, fmt.Errorf("%s: %s", text, err)
+		}
+		if t.cidr6, err = prefixLength(m[3], 128); err != nil {
+			return nil, fmt.Errorf("%s: %s", text, err)
+		}
+	case "ip4", "ip6":
+		addr := strings.TrimPrefix(rest, ":")
+		if addr == rest {
+			return nil, fmt.Errorf("%s needs an address", text)
+		}
+		bits := 32
+		if t.name == "ip6" {
+			bits = 128
+		}
+		if !strings.Contains(addr, "/") {
+			addr += "/" + strconv.Itoa(bits)
+		}
+		ip, network, err := net.ParseCIDR(addr)
+		if err != nil || (ip.To4() != nil) != (bits == 32) {
+			return nil, fmt.Errorf("%s is not a valid %s network", text, t.name)
+		}
+		t.network = network
+		return t, nil
+	default:
+		return nil, fmt.Errorf("unsupported SPF part %s", text)
+	}
+	if t.domain != "" || t.name == "include" || t.name == "exists" {
+		if err := checkDomainSpec(t.domain, false); err != nil {
+			return nil, fmt.Errorf("%s: %s", text, err)
+		}
+	}
+	return t, nil
+}
+
+// prefixLength parses the prefix length s, which may be empty, of an a
+// or mx mechanism.
+func prefixLength(s string, bits int) (int, error) {
+	if s == "" {
+		return bits, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n > bits {
+		return 0, fmt.Errorf("invalid prefix length /%s", s)
+	}
+	return n, nil
+}
+
+// parseTerms parses the terms of the SPF record text, which must all
+// be valid.
+func parseTerms(text string) ([]*term, error) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.EqualFold(fields[0], "v=spf1") {
+		return nil, fmt.Errorf("not an SPF record")
+	}
+	var terms []*term
+	seen := map[string]bool{}
+	for _, f := range fields[1:] {
+		t, err := parseTerm(f)
+		if err != nil {
+			return nil, err
+		}
+		if t.modifier && (t.name == "redirect" || t.name == "exp") {
+			if seen[t.name] {
+				return nil, fmt.Errorf("more than one %s modifier", t.name)
+			}
+			seen[t.name] = true
+		}
+		terms = append(terms, t)
+	}
+	return terms, nil
+}
+
+// checkDomainSpec returns an error if spec is not a valid domain-spec,
+// or its macros are not valid. exp is whether spec is the explanation
+// string of an exp= modifier, where more macros are permitted.
+func checkDomainSpec(spec string, exp bool) error {
+	if spec == "" {
+		return fmt.Errorf("empty domain")
+	}
+	_, err := expandMacros(spec, exp, func(byte) string { return "x" })
+	return err
+}
+
+// expandMacros expands the macros of spec (RFC 7208, section 7).
+// value returns the value of a macro letter, in lower case.
+func expandMacros(spec string, exp bool, value func(letter byte) string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(spec); i++ {
+		if spec[i] != '%' {
+			b.WriteByte(spec[i])
+			continue
+		}
+		i++
+		if i == len(spec) {
+			return "", fmt.Errorf("%q ends with %%", spec)
+		}
+		switch spec[i] {
+		case '%':
+			b.WriteByte('%')
+			continue
+		case '_':
+			b.WriteByte(' ')
+			continue
+		case '-':
+			b.WriteString("%20")
+			continue
+		case '{':
+		default:
+			return "", fmt.Errorf("invalid macro %%%c in %q", spec[i], spec)
+		}
+		end := strings.IndexByte(spec[i:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated macro in %q", spec)
+		}
+		m := spec[i+1 : i+end]
+		i += end
+		s, err := expandMacro(m, exp, value)
+		if err != nil {
+			return "", fmt.Errorf("%%{%s} in %q: %s", m, spec, err)
+		}
+		b.WriteString(s)
+	}
+	return b.String(), nil
+}
+
+// expandMacro expands the macro %{m}.
+func expandMacro(m string, exp bool, value func(letter byte) string) (string, error) {
+	if m == "" {
+		return "", fmt.Errorf("no macro letter")
+	}
+	letter := m[0]
+	lower := letter | 0x20
+	switch lower {
+	case 's', 'l', 'o', 'd', 'i', 'p', 'h', 'v':
+	case 'c', 'r', 't':
+		if !exp {
+			return "", fmt.Errorf("only permitted in exp=")
+		}
+	default:
+		return "", fmt.Errorf("unknown macro letter %c", letter)
+	}
+	m = m[1:]
+
+	// The transformers: a number of parts, then r to reverse them.
+	digits := strings.IndexFunc(m, func(r rune) bool { return r < '0' || r > '9' })
+	if digits < 0 {
+		digits = len(m)
+	}
+	keep := 0
+	if digits > 0 {
+		var err error
+		if keep, err = strconv.Atoi(m[:digits]); err != nil || keep == 0 {
+			return "", fmt.Errorf("invalid number of parts %s", m[:digits])
+		}
+	}
+	m = m[digits:]
+	reverse := false
+	if m != "" && (m[0] == 'r' || m[0] == 'R') {
+		reverse = true
+		m = m[1:]
+	}
+	delimiters := m
+	if strings.Trim(delimiters, ".-+,/_=") != "" {
+		return "", fmt.Errorf("invalid delimiters %q", delimiters)
+	}
+	if delimiters == "" {
+		delimiters = "."
+	}
+
+	parts := strings.FieldsFunc(value(lower), func(r rune) bool { return strings.ContainsRune(delimiters, r) })
+	if reverse {
+		for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+			parts[i], parts[j] = parts[j], parts[i]
+		}
+	}
+	if keep > 0 && keep < len(parts) {
+		parts = parts[len(parts)-keep:]
+	}
+	s := strings.Join(parts, ".")
+	if letter != lower {
+		s = urlEscape(s)
+	}
+	return s, nil
+}
+
+// urlEscape escapes the characters of s that are not "unreserved"
+// (RFC 3986, section 2.3), as upper case macro letters require.
+func urlEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-._~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
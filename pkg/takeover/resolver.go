@@ -0,0 +1,131 @@
+package takeover
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Rcode is the response code of a DNS query, as a string: "NOERROR",
+// "NXDOMAIN", "REFUSED", "SERVFAIL"...
+type Rcode string
+
+// The response codes that matter to the checks.
+const (
+	NoError  Rcode = "NOERROR"
+	NXDomain Rcode = "NXDOMAIN"
+	// NotAuth is also returned when a server answers, but not
+	// authoritatively.
+	NotAuth Rcode = "NOTAUTH"
+)
+
+// Resolver is how the checks query the DNS. LiveResolver queries the
+// network; FileResolver replays canned answers, for tests and to
+// audit offline.
+type Resolver interface {
+	// Resolve returns the response code of a recursive query for the
+	// address of name.
+	Resolve(name string) (Rcode, error)
+	// AskServer returns the response code of a query for the SOA of
+	// zone sent to the nameserver server, or NotAuth if the server
+	// answers without authority.
+	AskServer(zone, server string) (Rcode, error)
+}
+
+// LiveResolver queries the DNS.
+type LiveResolver struct {
+	// Server is the recursive resolver ("host:port"). If empty, the
+	// first nameserver of /etc/resolv.conf is used.
+	Server string
+}
+
+var client = &dns.Client{Timeout: 5 * time.Second}
+
+// Resolve implements Resolver.
+func (r *LiveResolver) Resolve(name string) (Rcode, error) {
+	server := r.Server
+	if server == "" {
+		conf, err := dns.ClientConfigFromFile("/etc/resolv.conf")
+		if err != nil {
+			return "", fmt.Errorf("no resolver configured: %w", err)
+		}
+		if len(conf.Servers) == 0 {
+			return "", fmt.Errorf("no resolver configured in /etc/resolv.conf")
+		}
+		server = net.JoinHostPort(conf.Servers[0], conf.Port)
+	}
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(name), dns.TypeA)
+	in, _, err := client.Exchange(m, server)
+	if err != nil {
+		return "", err
+	}
+	return Rcode(dns.RcodeToString[in.Rcode]), nil
+}
+
+// AskServer implements Resolver.
+func (r *LiveResolver) AskServer(zone, server string) (Rcode, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(zone), dns.TypeSOA)
+	m.RecursionDesired = false
+	in, _, err := client.Exchange(m, net.JoinHostPort(strings.TrimSuffix(server, "."), "53"))
+	if err != nil {
+		return "", err
+	}
+	if in.Rcode == dns.RcodeSuccess && !in.Authoritative {
+		return NotAuth, nil
+	}
+	return Rcode(dns.RcodeToString[in.Rcode]), nil
+}
+
+// FileResolver answers from a JSON file such as:
+//
+//	{
+//	  "resolve": {"old-app.herokuapp.com": "NXDOMAIN"},
+//	  "servers": {"ns1.digitalocean.com": {"sub.example.com": "REFUSED"}}
+//	}
+//
+// Names are lower case, without the final dot. A query that is not in
+// the file is an error.
+type FileResolver struct {
+	Names   map[string]Rcode            `json:"resolve"`
+	Servers map[string]map[string]Rcode `json:"servers"`
+}
+
+// NewFileResolver reads a FileResolver from filename.
+func NewFileResolver(filename string) (*FileResolver, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	r := &FileResolver{}
+	if err := json.Unmarshal(b, r); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return r, nil
+}
+
+// Resolve implements Resolver.
+func (r *FileResolver) Resolve(name string) (Rcode, error) {
+	if rcode, ok := r.Names[canonical(name)]; ok {
+		return rcode, nil
+	}
+	return "", fmt.Errorf("%s is not in the resolver file", canonical(name))
+}
+
+// AskServer implements Resolver.
+func (r *FileResolver) AskServer(zone, server string) (Rcode, error) {
+	if rcode, ok := r.Servers[canonical(server)][canonical(zone)]; ok {
+		return rcode, nil
+	}
+	return "", fmt.Errorf("%s at %s is not in the resolver file", canonical(zone), canonical(server))
+}
+
+func canonical(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
@@ -0,0 +1,68 @@
+package takeover
+
+import "regexp"
+
+// Service is a hosting service whose names can be taken over.
+type Service struct {
+	Name    string
+	Pattern *regexp.Regexp // Matches the names of the service (lower case, without the final dot).
+
+	// Claimable is true if anyone can claim a name of the service that
+	// does not exist (NXDOMAIN), or a zone its nameservers do not serve.
+	Claimable bool
+
+	// Hint is how to check a name that the DNS cannot tell is free:
+	// names that resolve whether or not the resource behind them
+	// exists. Empty if a query is enough.
+	Hint string
+}
+
+func suffix(s string) *regexp.Regexp {
+	return regexp.MustCompile(`(^|\.)` + regexp.QuoteMeta(s) + `$`)
+}
+
+// Services are the hosting services whose names a CNAME, ALIAS or
+// R53_ALIAS may point at. Keep these in alphabetical order.
+var Services = []*Service{
+	{Name: "AWS CloudFront", Pattern: suffix("cloudfront.net"), Hint: "check that a distribution has the name as an alternate domain name"},
+	{Name: "AWS Elastic Beanstalk", Pattern: suffix("elasticbeanstalk.com"), Claimable: true},
+	{Name: "AWS S3", Pattern: regexp.MustCompile(`(^|\.)s3([.-][a-z0-9-]+)*\.amazonaws\.com$`), Hint: "check that the bucket exists"},
+	{Name: "Azure", Pattern: regexp.MustCompile(`(^|\.)(azurewebsites\.net|cloudapp\.net|cloudapp\.azure\.com|trafficmanager\.net|blob\.core\.windows\.net|azureedge\.net|azure-api\.net|azurecontainer\.io)$`), Claimable: true},
+	{Name: "Bitbucket", Pattern: suffix("bitbucket.io"), Hint: "check that the repository exists"},
+	{Name: "Fastly", Pattern: suffix("fastly.net"), Hint: "check that a service has the name as a domain"},
+	{Name: "Fly.io", Pattern: suffix("fly.dev"), Claimable: true},
+	{Name: "GitHub Pages", Pattern: suffix("github.io"), Hint: "check that the repository exists and has the name as its custom domain"},
+	{Name: "Google Cloud Storage", Pattern: suffix("c.storage.googleapis.com"), Hint: "check that the bucket exists"},
+	{Name: "Heroku", Pattern: suffix("herokuapp.com"), Claimable: true},
+	{Name: "Netlify", Pattern: regexp.MustCompile(`(^|\.)netlify\.(app|com)$`), Hint: "check that a site has the name as a custom domain"},
+	{Name: "Pantheon", Pattern: suffix("pantheonsite.io"), Hint: "check that the site exists"},
+	{Name: "Read the Docs", Pattern: suffix("readthedocs.io"), Hint: "check that the project exists"},
+	{Name: "Shopify", Pattern: suffix("myshopify.com"), Hint: "check that a shop has the name as a domain"},
+	{Name: "Surge", Pattern: suffix("surge.sh"), Hint: "check that the project exists"},
+	{Name: "Zendesk", Pattern: suffix("zendesk.com"), Hint: "check that the help center exists"},
+}
+
+// DNSServices are the DNS hosting services a subdomain may be delegated
+// to. Keep these in alphabetical order.
+var DNSServices = []*Service{
+	{Name: "AWS Route 53", Pattern: regexp.MustCompile(`(^|\.)awsdns-\d+\.(com|net|org|co\.uk)$`), Claimable: true},
+	{Name: "Azure DNS", Pattern: regexp.MustCompile(`(^|\.)azure-dns\.(com|net|org|info)$`), Claimable: true},
+	{Name: "Cloudflare", Pattern: suffix("ns.cloudflare.com")},
+	{Name: "DigitalOcean", Pattern: suffix("digitalocean.com"), Claimable: true},
+	{Name: "DNSimple", Pattern: suffix("dnsimple.com"), Claimable: true},
+	{Name: "Google Cloud DNS", Pattern: suffix("googledomains.com"), Claimable: true},
+	{Name: "Hurricane Electric", Pattern: suffix("he.net"), Claimable: true},
+	{Name: "Linode", Pattern: suffix("linode.com"), Claimable: true},
+	{Name: "NS1", Pattern: suffix("nsone.net"), Claimable: true},
+}
+
+// match returns the service of name, or nil.
+func match(services []*Service, name string) *Service {
+	name = canonical(name)
+	for _, s := range services {
+		if s.Pattern.MatchString(name) {
+			return s
+		}
+	}
+	return nil
+}
@@ -0,0 +1,228 @@
+// Package takeover finds records that let someone else take over a
+// name: a CNAME to a deleted cloud resource that anyone can create
+// again, or a delegation to nameservers that no longer serve the zone.
+package takeover
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+)
+
+// Status is the result of checking a record.
+type Status string
+
+// The statuses, from the most to the least serious.
+const (
+	Vulnerable Status = "vulnerable" // Anyone can claim the target.
+	Dangling   Status = "dangling"   // The target does not exist; its domain may be registrable.
+	Lame       Status = "lame"       // The nameservers do not serve the zone.
+	Unverified Status = "unverified" // The target resolves whether or not it is claimed. See the hint.
+	Unchecked  Status = "unchecked"  // The query failed.
+	OK         Status = "ok"
+)
+
+// Finding is the result of checking a record.
+type Finding struct {
+	Status  Status `json:"status"`
+	Domain  string `json:"domain"`
+	Name    string `json:"name"` // FQDN.
+	Type    string `json:"type"`
+	Target  string `json:"target"` // The target, or the nameservers of a delegation.
+	Service string `json:"service,omitempty"`
+	Source  string `json:"source,omitempty"` // Where the record is defined ("file:line"), if known.
+	Reason  string `json:"reason"`
+}
+
+// Found returns whether any of findings is a takeover risk.
+func Found(findings []*Finding) bool {
+	for _, f := range findings {
+		switch f.Status {
+		case Vulnerable, Dangling, Lame:
+			return true
+		}
+	}
+	return false
+}
+
+// Checker checks the records of a configuration.
+type Checker struct {
+	r        Resolver
+	managed  map[string]bool
+	resolved map[string]result
+}
+
+type result struct {
+	rcode Rcode
+	err   error
+}
+
+// NewChecker returns a Checker that queries r. Targets in the domains
+// of cfg are not checked: dnscontrol lint reports those.
+func NewChecker(cfg *models.DNSConfig, r Resolver) *Checker {
+	c := &Checker{r: r, managed: map[string]bool{}, resolved: map[string]result{}}
+	for _, dc := range cfg.Domains {
+		c.managed[dc.Name] = true
+	}
+	return c
+}
+
+// Domain checks the CNAME, ALIAS and R53_ALIAS records of dc, and the
+// NS records that delegate a subdomain. Only the records that were
+// checked are returned. dc must be normalized.
+func (c *Checker) Domain(dc *models.DomainConfig) []*Finding {
+	var findings []*Finding
+	delegations := map[string][]*models.RecordConfig{}
+	var order []string
+	for _, rc := range dc.Records {
+		switch rc.Type {
+		case "CNAME", "ALIAS", "R53_ALIAS":
+			if f := c.alias(rc); f != nil {
+				findings = append(findings, f)
+			}
+		case "NS":
+			if rc.GetLabel() == "@" {
+				continue
+			}
+			if delegations[rc.NameFQDN] == nil {
+				order = append(order, rc.NameFQDN)
+			}
+			delegations[rc.NameFQDN] = append(delegations[rc.NameFQDN], rc)
+		}
+	}
+	for _, name := range order {
+		findings = append(findings, c.delegation(delegations[name]))
+	}
+	for _, f := range findings {
+		f.Domain = dc.UniqueName
+		if f.Domain == "" {
+			f.Domain = dc.Name
+		}
+	}
+	return findings
+}
+
+func (c *Checker) isManaged(name string) bool {
+	for name = canonical(name); name != ""; {
+		if c.managed[name] {
+			return true
+		}
+		i := strings.IndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[i+1:]
+	}
+	return false
+}
+
+func (c *Checker) resolve(name string) (Rcode, error) {
+	name = canonical(name)
+	res, ok := c.resolved[name]
+	if !ok {
+		res.rcode, res.err = c.r.Resolve(name)
+		c.resolved[name] = res
+	}
+	return res.rcode, res.err
+}
+
+func newFinding(rc *models.RecordConfig, target string) *Finding {
+	return &Finding{Name: rc.NameFQDN, Type: rc.Type, Target: target, Source: rc.Source}
+}
+
+// alias checks a record that points at another name.
+func (c *Checker) alias(rc *models.RecordConfig) *Finding {
+	target := canonical(rc.GetTargetField())
+	if target == "" || c.isManaged(target) {
+		return nil
+	}
+	f := newFinding(rc, target)
+	svc := match(Services, target)
+	if svc != nil {
+		f.Service = svc.Name
+	}
+
+	rcode, err := c.resolve(target)
+	switch {
+	case err != nil:
+		f.Status, f.Reason = Unchecked, fmt.Sprintf("cannot resolve %s: %s", target, err)
+	case rcode == NXDomain && svc != nil && svc.Claimable:
+		f.Status, f.Reason = Vulnerable, fmt.Sprintf("%s does not exist, and anyone can create it on %s", target, svc.Name)
+	case rcode == NXDomain:
+		f.Status, f.Reason = Dangling, fmt.Sprintf("%s does not exist", target)
+	case rcode != NoError:
+		f.Status, f.Reason = Unchecked, fmt.Sprintf("resolving %s returned %s", target, rcode)
+	case svc != nil && svc.Hint != "":
+		f.Status, f.Reason = Unverified, fmt.Sprintf("%s resolves even if the resource is deleted: %s", svc.Name, svc.Hint)
+	default:
+		f.Status, f.Reason = OK, fmt.Sprintf("%s resolves", target)
+	}
+	return f
+}
+
+// delegation checks the NS records of a subdomain. Every nameserver
+// is checked: resolvers query them all, so a single one that anyone
+// can register is enough to take the zone over.
+func (c *Checker) delegation(nss []*models.RecordConfig) *Finding {
+	var servers []string
+	for _, rc := range nss {
+		servers = append(servers, canonical(rc.GetTargetField()))
+	}
+	f := newFinding(nss[0], strings.Join(servers, ","))
+	zone := nss[0].NameFQDN
+
+	var svc *Service
+	var serving, missing, failures []string
+	unchecked := false
+	for _, server := range servers {
+		if s := match(DNSServices, server); s != nil && svc == nil {
+			svc = s
+			f.Service = s.Name
+		}
+		if c.isManaged(server) {
+			// In-bailiwick nameservers are checked by the checks of
+			// their own records.
+			serving = append(serving, fmt.Sprintf("%s is managed here", server))
+			continue
+		}
+		rcode, err := c.resolve(server)
+		if err == nil && rcode == NXDomain {
+			missing = append(missing, server)
+			continue
+		}
+		if err == nil {
+			rcode, err = c.r.AskServer(zone, server)
+		}
+		switch {
+		case err != nil:
+			unchecked = true
+			failures = append(failures, fmt.Sprintf("%s: %s", server, err))
+		case rcode == NoError:
+			serving = append(serving, fmt.Sprintf("%s serves %s", server, zone))
+		default:
+			failures = append(failures, fmt.Sprintf("%s: %s", server, rcode))
+		}
+	}
+
+	switch {
+	case len(missing) == 1:
+		f.Status = Vulnerable
+		f.Reason = fmt.Sprintf("nameserver %s does not exist; whoever registers its domain controls %s", missing[0], zone)
+	case len(missing) > 1:
+		f.Status = Vulnerable
+		f.Reason = fmt.Sprintf("nameservers %s do not exist; whoever registers their domains controls %s", strings.Join(missing, ", "), zone)
+	case len(serving) != 0:
+		f.Status, f.Reason = OK, strings.Join(serving, ", ")
+	case unchecked:
+		f.Status = Unchecked
+		f.Reason = fmt.Sprintf("cannot check that the nameservers serve %s (%s)", zone, strings.Join(failures, ", "))
+	case svc != nil && svc.Claimable:
+		f.Status = Vulnerable
+		f.Reason = fmt.Sprintf("no nameserver serves %s (%s), and anyone can create the zone on %s", zone, strings.Join(failures, ", "), svc.Name)
+	default:
+		f.Status = Lame
+		f.Reason = fmt.Sprintf("no nameserver serves %s (%s)", zone, strings.Join(failures, ", "))
+	}
+	return f
+}
@@ -0,0 +1,127 @@
+package takeover
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+)
+
+func record(rtype, name, target string) *models.RecordConfig {
+	rc := &models.RecordConfig{Type: rtype, Metadata: map[string]string{}}
+	rc.SetLabel(name, "example.com")
+	rc.SetTarget(target)
+	return rc
+}
+
+const fixture = `{
+  "resolve": {
+    "old-app.herokuapp.com": "NXDOMAIN",
+    "app.herokuapp.com": "NOERROR",
+    "bucket.s3.amazonaws.com": "NOERROR",
+    "www.gone.example.net": "NXDOMAIN",
+    "www.example.org": "NOERROR",
+    "broken.example.org": "SERVFAIL",
+    "ns1.digitalocean.com": "NOERROR",
+    "ns2.digitalocean.com": "NOERROR",
+    "ns.example.net": "NOERROR",
+    "ns.expired.example": "NXDOMAIN"
+  },
+  "servers": {
+    "ns1.digitalocean.com": {"sub.example.com": "REFUSED", "ok.example.com": "REFUSED"},
+    "ns2.digitalocean.com": {"sub.example.com": "REFUSED", "ok.example.com": "NOERROR"},
+    "ns.example.net": {"lame.example.com": "NOTAUTH", "partial.example.com": "NOERROR"}
+  }
+}`
+
+func TestChecker(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "resolver.json")
+	if err := os.WriteFile(file, []byte(fixture), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewFileResolver(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dc := &models.DomainConfig{Name: "example.com", Records: models.Records{
+		record("CNAME", "old", "old-app.herokuapp.com."),
+		record("CNAME", "app", "app.herokuapp.com."),
+		record("CNAME", "assets", "bucket.s3.amazonaws.com."),
+		record("CNAME", "gone", "www.gone.example.net."),
+		record("CNAME", "fine", "www.example.org."),
+		record("CNAME", "broken", "broken.example.org."),
+		record("CNAME", "unknown", "not-in-file.example.org."),
+		record("CNAME", "internal", "www.example.com."),
+		record("NS", "@", "ns1.example.net."),
+		record("NS", "sub", "ns1.digitalocean.com."),
+		record("NS", "sub", "ns2.digitalocean.com."),
+		record("NS", "ok", "ns1.digitalocean.com."),
+		record("NS", "ok", "ns2.digitalocean.com."),
+		record("NS", "lame", "ns.example.net."),
+		record("NS", "expired", "ns.expired.example."),
+		record("NS", "partial", "ns.example.net."),
+		record("NS", "partial", "ns.expired.example."),
+		record("NS", "inside", "ns1.example.com."),
+		record("NS", "inside", "ns.expired.example."),
+	}}
+	cfg := &models.DNSConfig{Domains: []*models.DomainConfig{dc}}
+	got := NewChecker(cfg, r).Domain(dc)
+
+	want := []struct {
+		name    string
+		status  Status
+		service string
+	}{
+		{"old.example.com", Vulnerable, "Heroku"},
+		{"app.example.com", OK, "Heroku"},
+		{"assets.example.com", Unverified, "AWS S3"},
+		{"gone.example.com", Dangling, ""},
+		{"fine.example.com", OK, ""},
+		{"broken.example.com", Unchecked, ""},
+		{"unknown.example.com", Unchecked, ""},
+		{"sub.example.com", Vulnerable, "DigitalOcean"},
+		{"ok.example.com", OK, "DigitalOcean"},
+		{"lame.example.com", Lame, ""},
+		{"expired.example.com", Vulnerable, ""},
+		{"partial.example.com", Vulnerable, ""},
+		{"inside.example.com", Vulnerable, ""},
+	}
+	if len(got) != len(want) {
+		for _, f := range got {
+			t.Logf("%+v", f)
+		}
+		t.Fatalf("got %d findings, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		f := got[i]
+		if f.Name != w.name || f.Status != w.status || f.Service != w.service || f.Domain != "example.com" {
+			t.Errorf("finding %d: got %s %s %q (%s), want %s %s %q", i, f.Name, f.Status, f.Service, f.Reason, w.name, w.status, w.service)
+		}
+	}
+	if !Found(got) {
+		t.Error("Found() = false, want true")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := map[string]string{
+		"bucket.s3.amazonaws.com":                   "AWS S3",
+		"bucket.s3-website-us-east-1.amazonaws.com": "AWS S3",
+		"bucket.s3.eu-west-1.amazonaws.com":         "AWS S3",
+		"example.azurewebsites.net.":                "Azure",
+		"user.github.io":                            "GitHub Pages",
+		"ec2-1-2-3-4.compute-1.amazonaws.com":       "",
+		"notgithub.io":                              "",
+	}
+	for name, want := range tests {
+		got := ""
+		if s := match(Services, name); s != nil {
+			got = s.Name
+		}
+		if got != want {
+			t.Errorf("match(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package yamlconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Record is a record. Its fields are those of the IR (as printed by
+// print-ir), with a few additions:
+//
+//	ttl     may be a duration, as in TTL(): "1h", "2d"...
+//	absent  true for ENSURE_ABSENT_REC()
+//	fqdn    ignored, as written by get-zones --format=yaml
+type Record struct {
+	Type   string
+	TTL    TTL
+	Absent bool
+
+	fields map[string]interface{} // The fields of the IR.
+	line   int
+}
+
+// recordFields are the fields a record may have: the fields of the IR,
+// and the additions of Record.
+var recordFields = func() map[string]bool {
+	fields := map[string]bool{"target": true, "absent": true, "fqdn": true}
+	t := reflect.TypeOf(models.RecordConfig{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}()
+
+// typedFields are the prefixes of the fields that only some types of
+// records have.
+var typedFields = []struct {
+	prefix string
+	types  []string
+}{
+	{"azure_alias", []string{"AZURE_ALIAS"}},
+	{"caa", []string{"CAA"}},
+	{"ds", []string{"DS"}},
+	{"mx", []string{"MX"}},
+	{"naptr", []string{"NAPTR"}},
+	{"r53_alias", []string{"R53_ALIAS"}},
+	{"soa", []string{"SOA"}},
+	{"srv", []string{"SRV"}},
+	{"sshfp", []string{"SSHFP"}},
+	{"svc", []string{"HTTPS", "SVCB"}},
+	{"tlsa", []string{"TLSA"}},
+	{"txt", []string{"TXT"}},
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (r *Record) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: a record must be a mapping", n.Line)
+	}
+	r.line = n.Line
+	if err := n.Decode(&r.fields); err != nil {
+		return err
+	}
+
+	if t, ok := r.fields["type"].(string); ok {
+		r.Type = t
+	}
+	if r.Type == "" {
+		return fmt.Errorf("line %d: the record has no type", n.Line)
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i].Value, n.Content[i+1]
+		if !recordFields[key] {
+			return fmt.Errorf("line %d: field %s is not a field of records", n.Content[i].Line, key)
+		}
+		for _, tf := range typedFields {
+			if strings.HasPrefix(key, tf.prefix) && !contains(tf.types, r.Type) {
+				return fmt.Errorf("line %d: field %s is not a field of %s records", n.Content[i].Line, key, r.Type)
+			}
+		}
+		switch key {
+		case "ttl":
+			if err := value.Decode(&r.TTL); err != nil {
+				return err
+			}
+		case "absent":
+			if err := value.Decode(&r.Absent); err != nil {
+				return err
+			}
+		}
+	}
+	delete(r.fields, "ttl")
+	delete(r.fields, "absent")
+	delete(r.fields, "fqdn")
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// compile returns r as the IR. file is where r was read from.
+func (r *Record) compile(file string) (*models.RecordConfig, error) {
+	fields := map[string]interface{}{}
+	for k, v := range r.fields {
+		fields[k] = v
+	}
+	// Metadata values are strings, but YAML reads true and 1 as a
+	// boolean and a number.
+	if meta, ok := fields["meta"].(map[string]interface{}); ok {
+		strs := map[string]string{}
+		for k, v := range meta {
+			strs[k] = fmt.Sprint(v)
+		}
+		fields["meta"] = strs
+	}
+	if _, ok := fields["name"]; !ok {
+		fields["name"] = "@"
+	}
+	// TXT records are stored as in TXT(): the strings, and their
+	// concatenation as the target.
+	if r.Type == "TXT" {
+		if _, ok := fields["txtstrings"]; !ok {
+			fields["txtstrings"] = []interface{}{fields["target"]}
+		} else if _, ok := fields["target"]; !ok {
+			var target []string
+			if list, ok := fields["txtstrings"].([]interface{}); ok {
+				for _, s := range list {
+					target = append(target, fmt.Sprint(s))
+				}
+			}
+			fields["target"] = strings.Join(target, "")
+		}
+	}
+
+	j, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("%s:%d: %w", file, r.line, err)
+	}
+	rc := &models.RecordConfig{}
+	if err := json.Unmarshal(j, rc); err != nil {
+		return nil, fmt.Errorf("%s:%d: %s record: %w", file, r.line, r.Type, err)
+	}
+	if rc.Metadata == nil {
+		rc.Metadata = map[string]string{}
+	}
+	rc.TTL = uint32(r.TTL)
+	rc.Source = fmt.Sprintf("%s:%d", file, r.line)
+	return rc, nil
+}
+
+// TTL is a TTL: a number of seconds, or a duration as in TTL() and
+// DefaultTTL(), such as "5m" or "1d".
+type TTL uint32
+
+var durationRE = regexp.MustCompile(`^(\d+)([smhdwny]?)$`)
+
+var durationUnits = map[string]uint64{
+	"": 1, "s": 1, "m": 60, "h": 3600,
+	"d": 86400, "w": 7 * 86400, "n": 30 * 86400, "y": 365 * 86400,
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (t *TTL) UnmarshalYAML(n *yaml.Node) error {
+	m := durationRE.FindStringSubmatch(n.Value)
+	if n.Kind != yaml.ScalarNode || m == nil {
+		return fmt.Errorf("line %d: %q is not a valid TTL", n.Line, n.Value)
+	}
+	v, err := strconv.ParseUint(m[1], 10, 32)
+	if err == nil {
+		v *= durationUnits[m[2]]
+	}
+	if err != nil || v > 1<<32-1 {
+		return fmt.Errorf("line %d: TTL %q is too large", n.Line, n.Value)
+	}
+	*t = TTL(v)
+	return nil
+}
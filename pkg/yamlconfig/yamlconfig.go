@@ -0,0 +1,389 @@
+// Package yamlconfig reads a configuration written in YAML, or in JSON
+// (which is YAML too), as an alternative to dnsconfig.js. It is
+// documented in documentation/yaml-config.md.
+//
+// The configuration is compiled to the same models.DNSConfig that
+// dnsconfig.js produces, before normalization: the records are
+// validated by pkg/normalize as usual.
+package yamlconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is a configuration file.
+type Config struct {
+	Include      []string             `yaml:"include"` // More configuration files.
+	Registrars   map[string]*Provider `yaml:"registrars"`
+	DNSProviders map[string]*Provider `yaml:"dns_providers"`
+	Domains      []*Domain            `yaml:"domains"`
+}
+
+// Provider is a registrar or a DNS provider, as NewRegistrar() and
+// NewDnsProvider() declare them.
+type Provider struct {
+	Type string                 `yaml:"type"` // Empty to use the TYPE in creds.json.
+	Meta map[string]interface{} `yaml:"meta"`
+}
+
+// Domain is a domain, as D() declares it.
+type Domain struct {
+	Name         string       `yaml:"name"`
+	Registrar    string       `yaml:"registrar"`
+	DNSProviders ProviderList `yaml:"dns_providers"`
+	DefaultTTL   TTL          `yaml:"default_ttl"`
+	Owner        string       `yaml:"owner"`   // OWNER()
+	Contact      string       `yaml:"contact"` // The second argument of OWNER().
+	Fragment     `yaml:",inline"`
+}
+
+// Fragment is the part of a domain that a file can hold, to be
+// included in domains of a configuration, or in D() in dnsconfig.js.
+type Fragment struct {
+	Include     []string          `yaml:"include"` // More fragments.
+	Meta        map[string]string `yaml:"meta"`
+	NoPurge     bool              `yaml:"no_purge"`    // NO_PURGE
+	AutoDNSSEC  string            `yaml:"auto_dnssec"` // "on" or "off"
+	Nameservers []string          `yaml:"nameservers"` // NAMESERVER()
+	Ignore      []*Ignore         `yaml:"ignore"`
+	Unmanaged   []*Unmanaged      `yaml:"unmanaged"`
+	Records     []*Record         `yaml:"records"`
+}
+
+// Ignore is IGNORE_NAME() if Label is set, or IGNORE_TARGET() if
+// Target is.
+type Ignore struct {
+	Label  string `yaml:"label"`
+	Target string `yaml:"target"`
+	Types  string `yaml:"types"`
+}
+
+// Unmanaged is UNMANAGED().
+type Unmanaged struct {
+	Label  string `yaml:"label"`
+	Types  string `yaml:"types"`
+	Target string `yaml:"target"`
+}
+
+// ProviderList is the DNS providers of a domain: a list of names, or
+// a map of names to the number of nameservers to use, as the second
+// argument of DnsProvider().
+type ProviderList map[string]int
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (p *ProviderList) UnmarshalYAML(n *yaml.Node) error {
+	*p = ProviderList{}
+	if n.Kind == yaml.SequenceNode {
+		var names []string
+		if err := n.Decode(&names); err != nil {
+			return err
+		}
+		for _, name := range names {
+			(*p)[name] = -1
+		}
+		return nil
+	}
+	return n.Decode((*map[string]int)(p))
+}
+
+// IsConfigFile returns whether file is a YAML or JSON configuration,
+// rather than JavaScript.
+func IsConfigFile(file string) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// loader reads files and their includes.
+type loader struct {
+	loading []string // The files being read, outermost first.
+}
+
+// decode reads file into v, which must be a pointer to a Config or a
+// Fragment. Fields that are not in v are errors. It returns the
+// absolute path of file, to detect include cycles.
+func (l *loader) decode(file string, v interface{}) (string, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	for i, f := range l.loading {
+		if f == abs {
+			return "", fmt.Errorf("include cycle: %s -> %s", strings.Join(l.loading[i:], " -> "), abs)
+		}
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("%s: %w", file, err)
+	}
+	return abs, nil
+}
+
+// relative returns include, which is relative to the file that
+// includes it, as a path relative to the current directory.
+func relative(file, include string) string {
+	if filepath.IsAbs(include) {
+		return include
+	}
+	return filepath.Join(filepath.Dir(file), include)
+}
+
+// Load reads the configuration in file.
+func Load(file string) (*models.DNSConfig, error) {
+	cfg := &models.DNSConfig{
+		Registrars:   []*models.RegistrarConfig{},
+		DNSProviders: []*models.DNSProviderConfig{},
+		Domains:      []*models.DomainConfig{},
+	}
+	if err := Merge(cfg, file); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Merge adds the configurations in files to cfg, which may already
+// hold registrars, providers and domains (from dnsconfig.js, for
+// example). A name may not be declared twice.
+func Merge(cfg *models.DNSConfig, files ...string) error {
+	l := &loader{}
+	for _, file := range files {
+		if err := l.loadConfig(cfg, file); err != nil {
+			return err
+		}
+	}
+
+	// Check the references once all the files are read, since they
+	// may be in any of them.
+	registrars := map[string]bool{}
+	for _, r := range cfg.Registrars {
+		registrars[r.Name] = true
+	}
+	providers := map[string]bool{}
+	for _, p := range cfg.DNSProviders {
+		providers[p.Name] = true
+	}
+	for _, dc := range cfg.Domains {
+		if !registrars[dc.RegistrarName] {
+			return fmt.Errorf("domain %q: registrar %q is not declared in registrars", dc.Name, dc.RegistrarName)
+		}
+		for name := range dc.DNSProviderNames {
+			if !providers[name] {
+				return fmt.Errorf("domain %q: DNS provider %q is not declared in dns_providers", dc.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+func (l *loader) loadConfig(cfg *models.DNSConfig, file string) error {
+	var c Config
+	abs, err := l.decode(file, &c)
+	if err != nil {
+		return err
+	}
+	l.loading = append(l.loading, abs)
+	defer func() { l.loading = l.loading[:len(l.loading)-1] }()
+
+	for _, name := range sortedKeys(c.Registrars) {
+		for _, r := range cfg.Registrars {
+			if r.Name == name {
+				return fmt.Errorf("%s: registrar %q is declared more than once", file, name)
+			}
+		}
+		p := c.Registrars[name]
+		meta, err := p.meta()
+		if err != nil {
+			return fmt.Errorf("%s: registrar %q: %w", file, name, err)
+		}
+		cfg.Registrars = append(cfg.Registrars, &models.RegistrarConfig{Name: name, Type: p.typ(), Metadata: meta})
+	}
+	for _, name := range sortedKeys(c.DNSProviders) {
+		for _, d := range cfg.DNSProviders {
+			if d.Name == name {
+				return fmt.Errorf("%s: DNS provider %q is declared more than once", file, name)
+			}
+		}
+		p := c.DNSProviders[name]
+		meta, err := p.meta()
+		if err != nil {
+			return fmt.Errorf("%s: DNS provider %q: %w", file, name, err)
+		}
+		cfg.DNSProviders = append(cfg.DNSProviders, &models.DNSProviderConfig{Name: name, Type: p.typ(), Metadata: meta})
+	}
+
+	for _, d := range c.Domains {
+		if d.Name == "" {
+			return fmt.Errorf("%s: a domain has no name", file)
+		}
+		if cfg.FindDomain(d.Name) != nil {
+			return fmt.Errorf("%s: %s is declared more than once", file, d.Name)
+		}
+		if d.Registrar == "" {
+			return fmt.Errorf("%s: domain %q has no registrar", file, d.Name)
+		}
+		dc := &models.DomainConfig{
+			Name:             d.Name,
+			RegistrarName:    d.Registrar,
+			DNSProviderNames: d.DNSProviders,
+			Metadata:         map[string]string{},
+			Records:          models.Records{},
+			Nameservers:      []*models.Nameserver{},
+		}
+		if dc.DNSProviderNames == nil {
+			dc.DNSProviderNames = map[string]int{}
+		}
+		if err := l.apply(dc, file, &d.Fragment); err != nil {
+			return fmt.Errorf("domain %q: %w", d.Name, err)
+		}
+		if d.Owner != "" {
+			dc.Metadata[models.DomainOwner] = d.Owner
+		}
+		if d.Contact != "" {
+			dc.Metadata[models.DomainContact] = d.Contact
+		}
+		for _, rc := range append(dc.Records, dc.EnsureAbsent...) {
+			if rc.TTL == 0 {
+				rc.TTL = uint32(d.DefaultTTL)
+			}
+		}
+		cfg.Domains = append(cfg.Domains, dc)
+	}
+
+	for _, inc := range c.Include {
+		if err := l.loadConfig(cfg, relative(file, inc)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadFragment reads the fragment in file, for require() in
+// dnsconfig.js. The records that have no TTL have a TTL of 0.
+func LoadFragment(file string) (*models.DomainConfig, error) {
+	var frag Fragment
+	l := &loader{}
+	abs, err := l.decode(file, &frag)
+	if err != nil {
+		return nil, err
+	}
+	l.loading = append(l.loading, abs)
+	dc := &models.DomainConfig{Metadata: map[string]string{}, Records: models.Records{}}
+	if err := l.apply(dc, file, &frag); err != nil {
+		return nil, err
+	}
+	return dc, nil
+}
+
+func (l *loader) loadFragment(dc *models.DomainConfig, file string) error {
+	var frag Fragment
+	abs, err := l.decode(file, &frag)
+	if err != nil {
+		return err
+	}
+	l.loading = append(l.loading, abs)
+	defer func() { l.loading = l.loading[:len(l.loading)-1] }()
+	return l.apply(dc, file, &frag)
+}
+
+// apply adds frag, which was read from file, to dc. The fragments it
+// includes are added first.
+func (l *loader) apply(dc *models.DomainConfig, file string, frag *Fragment) error {
+	for _, inc := range frag.Include {
+		if err := l.loadFragment(dc, relative(file, inc)); err != nil {
+			return err
+		}
+	}
+
+	for k, v := range frag.Meta {
+		dc.Metadata[k] = v
+	}
+	if frag.NoPurge {
+		dc.KeepUnknown = true
+	}
+	switch frag.AutoDNSSEC {
+	case "":
+	case "on", "off":
+		dc.AutoDNSSEC = frag.AutoDNSSEC
+	default:
+		return fmt.Errorf("%s: auto_dnssec must be on or off, not %q", file, frag.AutoDNSSEC)
+	}
+	for _, ns := range frag.Nameservers {
+		dc.Nameservers = append(dc.Nameservers, &models.Nameserver{Name: ns})
+	}
+
+	for _, ig := range frag.Ignore {
+		switch {
+		case ig.Label != "" && ig.Target != "":
+			return fmt.Errorf("%s: ignore: set label or target, not both", file)
+		case ig.Label != "":
+			types := ig.Types
+			if types == "" {
+				types = "*"
+			}
+			dc.IgnoredNames = append(dc.IgnoredNames, &models.IgnoreName{Pattern: ig.Label, Types: types})
+			dc.Unmanaged = append(dc.Unmanaged, &models.UnmanagedConfig{LabelPattern: ig.Label, RTypePattern: types})
+		case ig.Target != "":
+			dc.IgnoredTargets = append(dc.IgnoredTargets, &models.IgnoreTarget{Pattern: ig.Target, Type: ig.Types})
+			dc.Unmanaged = append(dc.Unmanaged, &models.UnmanagedConfig{RTypePattern: ig.Types, TargetPattern: ig.Target})
+		default:
+			return fmt.Errorf("%s: ignore: set label or target", file)
+		}
+	}
+	for _, u := range frag.Unmanaged {
+		dc.Unmanaged = append(dc.Unmanaged, &models.UnmanagedConfig{LabelPattern: u.Label, RTypePattern: u.Types, TargetPattern: u.Target})
+	}
+
+	for _, r := range frag.Records {
+		rc, err := r.compile(file)
+		if err != nil {
+			return err
+		}
+		if r.Absent {
+			dc.EnsureAbsent = append(dc.EnsureAbsent, rc)
+		} else {
+			dc.Records = append(dc.Records, rc)
+		}
+	}
+	return nil
+}
+
+func (p *Provider) typ() string {
+	if p == nil || p.Type == "" {
+		return "-"
+	}
+	return p.Type
+}
+
+func (p *Provider) meta() (json.RawMessage, error) {
+	if p == nil || len(p.Meta) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(p.Meta)
+}
+
+func sortedKeys(m map[string]*Provider) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
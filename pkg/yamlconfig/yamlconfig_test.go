@@ -0,0 +1,169 @@
+package yamlconfig
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles writes files, by name, in a new directory, and returns it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"dnsconfig.yaml": `
+include: [more.yaml]
+registrars:
+  none:
+dns_providers:
+  bind: {type: BIND}
+domains:
+  - name: example.com
+    registrar: none
+    dns_providers: [bind]
+    default_ttl: 5m
+    owner: web
+    include: [mail.yaml]
+    records:
+      - {type: A, name: www, target: 1.2.3.4, ttl: 1d}
+      - {type: TXT, name: old, target: gone, absent: true}
+      - {type: TXT, name: split, txtstrings: [a, b]}
+`,
+		"mail.yaml": `
+records:
+  - {type: MX, mxpreference: 10, target: mx.example.net.}
+`,
+		"more.yaml": `
+domains:
+  - name: example.org
+    registrar: none
+    dns_providers: {bind: 2}
+`,
+	})
+
+	cfg, err := Load(filepath.Join(dir, "dnsconfig.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Registrars) != 1 || cfg.Registrars[0].Type != "-" {
+		t.Errorf("registrars: got %+v", cfg.Registrars)
+	}
+	if len(cfg.Domains) != 2 {
+		t.Fatalf("got %d domains, want 2", len(cfg.Domains))
+	}
+
+	dc := cfg.Domains[0]
+	if got := dc.DNSProviderNames["bind"]; got != -1 {
+		t.Errorf("nameserver count: got %d, want -1", got)
+	}
+	var got []string
+	for _, rc := range dc.Records {
+		got = append(got, fmt.Sprintf("%s %s %s %d", rc.Type, rc.Name, rc.GetTargetField(), rc.TTL))
+	}
+	want := []string{"MX @ mx.example.net. 300", "A www 1.2.3.4 86400", "TXT split ab 300"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("records:\ngot  %v\nwant %v", got, want)
+	}
+	if len(dc.EnsureAbsent) != 1 || dc.EnsureAbsent[0].Name != "old" {
+		t.Errorf("absent: got %+v", dc.EnsureAbsent)
+	}
+	if dc.Owner() != "web" {
+		t.Errorf("owner: got %q, want web", dc.Owner())
+	}
+	if src := dc.Records[1].Source; !strings.HasSuffix(src, "dnsconfig.yaml:15") {
+		t.Errorf("source: got %q", src)
+	}
+
+	if got := cfg.Domains[1].DNSProviderNames["bind"]; got != 2 {
+		t.Errorf("nameserver count: got %d, want 2", got)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	const head = "registrars: {none: }\ndns_providers: {bind: }\n"
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{
+			name:  "unknown field",
+			files: map[string]string{"c.yaml": head + "domain: []\n"},
+			want:  "field domain not found",
+		},
+		{
+			name: "unknown record field",
+			files: map[string]string{"c.yaml": head + `domains:
+  - {name: a.com, registrar: none, records: [{type: A, adress: 1.2.3.4}]}
+`},
+			want: "field adress is not a field of records",
+		},
+		{
+			name: "field of another type",
+			files: map[string]string{"c.yaml": head + `domains:
+  - {name: a.com, registrar: none, records: [{type: A, mxpreference: 10, target: 1.2.3.4}]}
+`},
+			want: "field mxpreference is not a field of A records",
+		},
+		{
+			name: "bad TTL",
+			files: map[string]string{"c.yaml": head + `domains:
+  - {name: a.com, registrar: none, records: [{type: A, target: 1.2.3.4, ttl: 1x}]}
+`},
+			want: `"1x" is not a valid TTL`,
+		},
+		{
+			name:  "undeclared registrar",
+			files: map[string]string{"c.yaml": head + "domains: [{name: a.com, registrar: other}]\n"},
+			want:  `registrar "other" is not declared`,
+		},
+		{
+			name:  "undeclared provider",
+			files: map[string]string{"c.yaml": head + "domains: [{name: a.com, registrar: none, dns_providers: [dns]}]\n"},
+			want:  `DNS provider "dns" is not declared`,
+		},
+		{
+			name:  "duplicate domain",
+			files: map[string]string{"c.yaml": head + "domains: [{name: a.com, registrar: none}, {name: a.com, registrar: none}]\n"},
+			want:  "a.com is declared more than once",
+		},
+		{
+			name: "include cycle",
+			files: map[string]string{
+				"c.yaml": head + "domains: [{name: a.com, registrar: none, include: [f.yaml]}]\n",
+				"f.yaml": "include: [g.yaml]\n",
+				"g.yaml": "include: [f.yaml]\n",
+			},
+			want: "include cycle",
+		},
+		{
+			name: "auto_dnssec",
+			files: map[string]string{"c.yaml": head + `domains:
+  - {name: a.com, registrar: none, auto_dnssec: yes}
+`},
+			want: "auto_dnssec must be on or off",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeFiles(t, tt.files)
+			_, err := Load(filepath.Join(dir, "c.yaml"))
+			if err == nil {
+				t.Fatalf("no error, want %q", tt.want)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got error %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -36,13 +36,22 @@ var features = providers.DocumentationNotes{
 	providers.CanAutoDNSSEC:          providers.Can("Just writes out a comment indicating DNSSEC was requested"),
 	providers.CanGetZones:            providers.Can(),
 	providers.CanUseCAA:              providers.Can(),
+	providers.CanUseCERT:             providers.Can(),
+	providers.CanUseDNAME:            providers.Can(),
 	providers.CanUseDS:               providers.Can(),
+	providers.CanUseHINFO:            providers.Can(),
+	providers.CanUseLOC:              providers.Can(),
 	providers.CanUseNAPTR:            providers.Can(),
+	providers.CanUseOPENPGPKEY:       providers.Can(),
 	providers.CanUsePTR:              providers.Can(),
+	providers.CanUseRP:               providers.Can(),
+	providers.CanUseSMIMEA:           providers.Can(),
 	providers.CanUseSOA:              providers.Can(),
 	providers.CanUseSRV:              providers.Can(),
 	providers.CanUseSSHFP:            providers.Can(),
+	providers.CanUseSVCB:             providers.Can(),
 	providers.CanUseTLSA:             providers.Can(),
+	providers.CanUseURI:              providers.Can(),
 	providers.CantUseNOPURGE:         providers.Cannot(),
 	providers.DocCreateDomains:       providers.Can("Driver just maintains list of zone files. It should automatically add missing ones."),
 	providers.DocDualHost:            providers.Can(),
@@ -117,8 +126,13 @@ type bindProvider struct {
 	nameservers    []*models.Nameserver
 	directory      string
 	filenameformat string
-	zonefile       string // Where the zone data is expected
-	zoneFileFound  bool   // Did the zonefile exist?
+}
+
+// ConcurrencySafe returns true: c is not changed after it is
+// initialized, and each zonefile is only read and written by the
+// calls for its domain. It implements providers.ConcurrencySafe.
+func (c *bindProvider) ConcurrencySafe() bool {
+	return true
 }
 
 // GetNameservers returns the nameservers for a domain.
@@ -153,38 +167,66 @@ func (c *bindProvider) ListZones() ([]string, error) {
 
 // GetZoneRecords gets the records of a zone and returns them in RecordConfig format.
 func (c *bindProvider) GetZoneRecords(domain string) (models.Records, error) {
+	return c.readRecords(domain, domain, "")
+}
+
+// GetDomainRecords gets the records of the zonefile of dc, which
+// depends on its tag. It implements providers.DomainRecordsGetter.
+func (c *bindProvider) GetDomainRecords(dc *models.DomainConfig) (models.Records, error) {
+	return c.readRecords(dc.UniqueName, dc.Name, dc.Tag)
+}
 
+// zonefile returns the name of the zonefile of a domain (see
+// makeFileName).
+func (c *bindProvider) zonefile(uniquename, domain, tag string) string {
+	return filepath.Join(c.directory, makeFileName(c.filenameformat, uniquename, domain, tag))
+}
+
+func (c *bindProvider) readRecords(uniquename, domain, tag string) (models.Records, error) {
 	if _, err := os.Stat(c.directory); os.IsNotExist(err) {
 		printer.Printf("\nWARNING: BIND directory %q does not exist!\n", c.directory)
 	}
+	foundRecords, _, err := readZoneFile(c.zonefile(uniquename, domain, tag), domain)
+	return foundRecords, err
+}
 
-	if c.zonefile == "" {
-		// This layering violation is needed for tests only.
-		// Otherwise, this is set already.
-		c.zonefile = filepath.Join(c.directory,
-			makeFileName(c.filenameformat, domain, domain, ""))
-	}
-	content, err := os.ReadFile(c.zonefile)
+// readZoneFile reads and parses the zonefile. found is false if the
+// file does not exist, which is not an error.
+func readZoneFile(zonefile string, domain string) (foundRecords models.Records, found bool, err error) {
+	content, err := os.ReadFile(zonefile)
 	if os.IsNotExist(err) {
 		// If the file doesn't exist, that's not an error. Just informational.
-		c.zoneFileFound = false
-		fmt.Fprintf(os.Stderr, "File does not yet exist: %q (will create)\n", c.zonefile)
-		return nil, nil
+		fmt.Fprintf(os.Stderr, "File does not yet exist: %q (will create)\n", zonefile)
+		return nil, false, nil
 	}
 	if err != nil {
-		return nil, fmt.Errorf("can't open %s: %w", c.zonefile, err)
+		return nil, false, fmt.Errorf("can't open %s: %w", zonefile, err)
 	}
-	c.zoneFileFound = true
-
-	zonefileName := c.zonefile
 
-	return ParseZoneContents(string(content), domain, zonefileName)
+	foundRecords, err = ParseZoneContents(string(content), domain, zonefile)
+	return foundRecords, true, err
 }
 
 // ParseZoneContents parses a string as a BIND zone and returns the records.
 func ParseZoneContents(content string, zoneName string, zonefileName string) (models.Records, error) {
-	zp := dns.NewZoneParser(strings.NewReader(content), zoneName, zonefileName)
+	zp := dns.NewZoneParser(strings.NewReader(generateTTL(content)), zoneName, zonefileName)
+	return parseZone(zp, zoneName, zonefileName)
+}
 
+// ParseZoneFile parses a BIND zonefile and returns the records. Unlike
+// ParseZoneContents, $INCLUDE is permitted: a relative path is relative
+// to the directory of zonefileName.
+func ParseZoneFile(zoneName string, zonefileName string) (models.Records, error) {
+	content, err := os.ReadFile(zonefileName)
+	if err != nil {
+		return nil, err
+	}
+	zp := dns.NewZoneParser(strings.NewReader(generateTTL(string(content))), zoneName, zonefileName)
+	zp.SetIncludeAllowed(true)
+	return parseZone(zp, zoneName, zonefileName)
+}
+
+func parseZone(zp *dns.ZoneParser, zoneName string, zonefileName string) (models.Records, error) {
 	foundRecords := models.Records{}
 	for rr, ok := zp.Next(); ok; rr, ok = zp.Next() {
 		rec, err := models.RRtoRC(rr, zoneName)
@@ -216,10 +258,14 @@ func (c *bindProvider) GetDomainCorrections(dc *models.DomainConfig) ([]*models.
 		comments = append(comments, "Automatic DNSSEC signing requested")
 	}
 
-	c.zonefile = filepath.Join(c.directory,
-		makeFileName(c.filenameformat, dc.UniqueName, dc.Name, dc.Tag))
+	// The zonefile is kept in a local variable (not in c) because
+	// corrections for other domains may be gathered before F is called.
+	zonefile := c.zonefile(dc.UniqueName, dc.Name, dc.Tag)
 
-	foundRecords, err := c.GetZoneRecords(dc.Name)
+	if _, err := os.Stat(c.directory); os.IsNotExist(err) {
+		printer.Printf("\nWARNING: BIND directory %q does not exist!\n", c.directory)
+	}
+	foundRecords, zoneFileFound, err := readZoneFile(zonefile, dc.Name)
 	if err != nil {
 		return nil, err
 	}
@@ -267,24 +313,24 @@ func (c *bindProvider) GetDomainCorrections(dc *models.DomainConfig) ([]*models.
 
 		for _, i := range create {
 			changes = true
-			if c.zoneFileFound {
+			if zoneFileFound {
 				fmt.Fprintln(buf, i)
 			}
 		}
 		for _, i := range del {
 			changes = true
-			if c.zoneFileFound {
+			if zoneFileFound {
 				fmt.Fprintln(buf, i)
 			}
 		}
 		for _, i := range mod {
 			changes = true
-			if c.zoneFileFound {
+			if zoneFileFound {
 				fmt.Fprintln(buf, i)
 			}
 		}
 
-		if c.zoneFileFound {
+		if zoneFileFound {
 			msg = fmt.Sprintf("GENERATE_ZONEFILE: '%s'. Changes:\n%s", dc.Name, buf)
 		} else {
 			msg = fmt.Sprintf("GENERATE_ZONEFILE: '%s' (new file with %d records)\n", dc.Name, len(create))
@@ -313,8 +359,8 @@ func (c *bindProvider) GetDomainCorrections(dc *models.DomainConfig) ([]*models.
 			&models.Correction{
 				Msg: msg,
 				F: func() error {
-					printer.Printf("WRITING ZONEFILE: %v\n", c.zonefile)
-					zf, err := os.Create(c.zonefile)
+					printer.Printf("WRITING ZONEFILE: %v\n", zonefile)
+					zf, err := os.Create(zonefile)
 					if err != nil {
 						return fmt.Errorf("could not create zonefile: %w", err)
 					}
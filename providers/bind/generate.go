@@ -0,0 +1,42 @@
+package bind
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	ttlRE   = regexp.MustCompile(`(?i)^[0-9]+([smhdw][0-9]*)*$`)
+	classRE = regexp.MustCompile(`(?i)^(IN|CH|CS|HS|CLASS[0-9]+)$`)
+)
+
+// generateTTL adds the $TTL in effect to the $GENERATE directives of
+// the zonefile content that do not set a TTL. The parser gives the
+// records of a $GENERATE a TTL of 3600, rather than the default of the
+// zone as BIND does. The $GENERATE directives of $INCLUDEd files are
+// not changed.
+func generateTTL(content string) string {
+	lines := strings.SplitAfter(content, "\n")
+	ttl := ""
+	for i, line := range lines {
+		fields := strings.Fields(strings.SplitN(line, ";", 2)[0])
+		if len(fields) == 0 {
+			continue
+		}
+		switch {
+		case strings.EqualFold(fields[0], "$TTL") && len(fields) > 1:
+			ttl = fields[1]
+		case strings.EqualFold(fields[0], "$GENERATE") && len(fields) > 4 && ttl != "":
+			// $GENERATE range lhs [ttl] [class] type rhs
+			if ttlRE.MatchString(fields[3]) || (classRE.MatchString(fields[3]) && ttlRE.MatchString(fields[4])) {
+				continue
+			}
+			end := 0 // The end of lhs in line.
+			for _, f := range fields[:3] {
+				end += strings.Index(line[end:], f) + len(f)
+			}
+			lines[i] = line[:end] + " " + ttl + line[end:]
+		}
+	}
+	return strings.Join(lines, "")
+}
@@ -0,0 +1,19 @@
+package bind
+
+import "testing"
+
+func Test_generateTTL(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"$GENERATE 1-3 host$ A 192.0.2.$\n", "$GENERATE 1-3 host$ A 192.0.2.$\n"},
+		{"$TTL 300\n$GENERATE 1-3 host$ A 192.0.2.$\n", "$TTL 300\n$GENERATE 1-3 host$ 300 A 192.0.2.$\n"},
+		{"$TTL 1h\n$generate 1-3\t1$  IN A 192.0.2.$ ; one\n", "$TTL 1h\n$generate 1-3\t1$ 1h  IN A 192.0.2.$ ; one\n"},
+		{"$TTL 300\n$GENERATE 1-3 host$ 60 A 192.0.2.$\n", "$TTL 300\n$GENERATE 1-3 host$ 60 A 192.0.2.$\n"},
+		{"$TTL 300\n$GENERATE 1-3 host$ IN 1d A 192.0.2.$", "$TTL 300\n$GENERATE 1-3 host$ IN 1d A 192.0.2.$"},
+		{"$TTL 300\n$TTL 600 ; later\n$GENERATE 1-3 host$ CNAME x$", "$TTL 300\n$TTL 600 ; later\n$GENERATE 1-3 host$ 600 CNAME x$"},
+	}
+	for _, tt := range tests {
+		if got := generateTTL(tt.in); got != tt.want {
+			t.Errorf("generateTTL(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -1,10 +1,11 @@
 package bind
 
 import (
-	"fmt"
+	"strings"
+
 	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/printer"
 	"github.com/StackExchange/dnscontrol/v3/pkg/soautil"
-	"strings"
 )
 
 func makeSoa(origin string, defSoa *SoaDefaults, existing, desired *models.RecordConfig) (*models.RecordConfig, uint32) {
@@ -28,8 +29,8 @@ func makeSoa(origin string, defSoa *SoaDefaults, existing, desired *models.Recor
 	if strings.Contains(soaMail, "@") {
 		soaMail = soautil.RFC5322MailToBind(soaMail)
 	} else {
-		fmt.Println("WARNING: SOA hostmaster address must be in the format hostmaster@example.com")
-		fmt.Println("WARNING: hostmaster.example.com is deprecated and will be dropped in a future version")
+		printer.Warnf("SOA hostmaster address must be in the format hostmaster@example.com\n")
+		printer.Warnf("hostmaster.example.com is deprecated and will be dropped in a future version\n")
 	}
 
 	soaRec.TTL = firstNonZero(desired.TTL, defSoa.TTL, existing.TTL, models.DefaultTTL)
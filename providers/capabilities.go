@@ -2,7 +2,12 @@
 
 package providers
 
-import "log"
+import (
+	"log"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+)
 
 // Capability is a bitmasked set of "features" that a provider supports. Only use constants from this package.
 type Capability uint32
@@ -32,6 +37,12 @@ const (
 	// CanUseCAA indicates the provider can handle CAA records
 	CanUseCAA
 
+	// CanUseCERT indicates the provider can handle CERT records
+	CanUseCERT
+
+	// CanUseDNAME indicates the provider can handle DNAME records
+	CanUseDNAME
+
 	// CanUseDS indicates that the provider can handle DS record types. This
 	// implies CanUseDSForChildren without specifying the latter explicitly.
 	CanUseDS
@@ -40,15 +51,30 @@ const (
 	// only for children records, not at the root of the zone.
 	CanUseDSForChildren
 
+	// CanUseHINFO indicates the provider can handle HINFO records
+	CanUseHINFO
+
+	// CanUseLOC indicates the provider can handle LOC records
+	CanUseLOC
+
 	// CanUseNAPTR indicates the provider can handle NAPTR records
 	CanUseNAPTR
 
+	// CanUseOPENPGPKEY indicates the provider can handle OPENPGPKEY records
+	CanUseOPENPGPKEY
+
 	// CanUsePTR indicates the provider can handle PTR records
 	CanUsePTR
 
+	// CanUseRP indicates the provider can handle RP records
+	CanUseRP
+
 	// CanUseRoute53Alias indicates the provider support the specific R53_ALIAS records that only the Route53 provider supports
 	CanUseRoute53Alias
 
+	// CanUseSMIMEA indicates the provider can handle SMIMEA records
+	CanUseSMIMEA
+
 	// CanUseSOA indicates the provider supports full management of a zone's SOA record
 	CanUseSOA
 
@@ -58,9 +84,15 @@ const (
 	// CanUseSSHFP indicates the provider can handle SSHFP records
 	CanUseSSHFP
 
+	// CanUseSVCB indicates the provider can handle SVCB and HTTPS records
+	CanUseSVCB
+
 	// CanUseTLSA indicates the provider can handle TLSA records
 	CanUseTLSA
 
+	// CanUseURI indicates the provider can handle URI records
+	CanUseURI
+
 	// CantUseNOPURGE indicates NO_PURGE is broken for this provider. To make it
 	// work would require complex emulation of an incremental update mechanism,
 	// so it is easier to simply mark this feature as not working for this
@@ -87,6 +119,37 @@ func ProviderHasCapability(pType string, cap Capability) bool {
 	return providerCapabilities[pType][cap]
 }
 
+// InstanceCapabilities is implemented by a DNSServiceProvider whose
+// capabilities are configured per instance (in creds.json) rather than
+// fixed for its type, such as MOCK.
+type InstanceCapabilities interface {
+	HasCapability(Capability) bool
+}
+
+// InstanceHasCapability returns true if the provider instance has
+// capability. The capabilities of the driver, if it has its own, take
+// precedence over those of the provider type.
+func InstanceHasCapability(p *models.DNSProviderInstance, cap Capability) bool {
+	if ic, ok := p.Driver.(InstanceCapabilities); ok {
+		return ic.HasCapability(cap)
+	}
+	return ProviderHasCapability(p.ProviderType, cap)
+}
+
+// ParseCapability returns the capability with the given name (for
+// example "CanUseSRV").
+func ParseCapability(name string) (Capability, bool) {
+	for c := Capability(0); ; c++ {
+		s := c.String()
+		if strings.HasPrefix(s, "Capability(") {
+			return 0, false
+		}
+		if s == name {
+			return c, true
+		}
+	}
+}
+
 // DocumentationNote is a way for providers to give more detail about what features they support.
 type DocumentationNote struct {
 	HasFeature    bool
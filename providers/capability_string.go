@@ -14,24 +14,33 @@ func _() {
 	_ = x[CanUseAlias-3]
 	_ = x[CanUseAzureAlias-4]
 	_ = x[CanUseCAA-5]
-	_ = x[CanUseDS-6]
-	_ = x[CanUseDSForChildren-7]
-	_ = x[CanUseNAPTR-8]
-	_ = x[CanUsePTR-9]
-	_ = x[CanUseRoute53Alias-10]
-	_ = x[CanUseSOA-11]
-	_ = x[CanUseSRV-12]
-	_ = x[CanUseSSHFP-13]
-	_ = x[CanUseTLSA-14]
-	_ = x[CantUseNOPURGE-15]
-	_ = x[DocCreateDomains-16]
-	_ = x[DocDualHost-17]
-	_ = x[DocOfficiallySupported-18]
+	_ = x[CanUseCERT-6]
+	_ = x[CanUseDNAME-7]
+	_ = x[CanUseDS-8]
+	_ = x[CanUseDSForChildren-9]
+	_ = x[CanUseHINFO-10]
+	_ = x[CanUseLOC-11]
+	_ = x[CanUseNAPTR-12]
+	_ = x[CanUseOPENPGPKEY-13]
+	_ = x[CanUsePTR-14]
+	_ = x[CanUseRP-15]
+	_ = x[CanUseRoute53Alias-16]
+	_ = x[CanUseSMIMEA-17]
+	_ = x[CanUseSOA-18]
+	_ = x[CanUseSRV-19]
+	_ = x[CanUseSSHFP-20]
+	_ = x[CanUseSVCB-21]
+	_ = x[CanUseTLSA-22]
+	_ = x[CanUseURI-23]
+	_ = x[CantUseNOPURGE-24]
+	_ = x[DocCreateDomains-25]
+	_ = x[DocDualHost-26]
+	_ = x[DocOfficiallySupported-27]
 }
 
-const _Capability_name = "CanAutoDNSSECCanGetZonesCanUseAKAMAICDNCanUseAliasCanUseAzureAliasCanUseCAACanUseDSCanUseDSForChildrenCanUseNAPTRCanUsePTRCanUseRoute53AliasCanUseSOACanUseSRVCanUseSSHFPCanUseTLSACantUseNOPURGEDocCreateDomainsDocDualHostDocOfficiallySupported"
+const _Capability_name = "CanAutoDNSSECCanGetZonesCanUseAKAMAICDNCanUseAliasCanUseAzureAliasCanUseCAACanUseCERTCanUseDNAMECanUseDSCanUseDSForChildrenCanUseHINFOCanUseLOCCanUseNAPTRCanUseOPENPGPKEYCanUsePTRCanUseRPCanUseRoute53AliasCanUseSMIMEACanUseSOACanUseSRVCanUseSSHFPCanUseSVCBCanUseTLSACanUseURICantUseNOPURGEDocCreateDomainsDocDualHostDocOfficiallySupported"
 
-var _Capability_index = [...]uint8{0, 13, 24, 39, 50, 66, 75, 83, 102, 113, 122, 140, 149, 158, 169, 179, 193, 209, 220, 242}
+var _Capability_index = [...]uint16{0, 13, 24, 39, 50, 66, 75, 85, 96, 104, 123, 134, 143, 154, 170, 179, 187, 205, 217, 226, 235, 246, 256, 266, 275, 289, 305, 316, 338}
 
 func (i Capability) String() string {
 	if i >= Capability(len(_Capability_index)-1) {
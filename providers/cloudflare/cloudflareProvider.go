@@ -6,6 +6,7 @@ import (
 	"log"
 	"net"
 	"strings"
+	"sync"
 
 	"github.com/StackExchange/dnscontrol/v3/models"
 	"github.com/StackExchange/dnscontrol/v3/pkg/diff"
@@ -64,7 +65,8 @@ func init() {
 
 // cloudflareProvider is the handle for API calls.
 type cloudflareProvider struct {
-	domainIndex     map[string]string // Call c.fetchDomainList() to populate before use.
+	mu              sync.Mutex        // Held to read or replace domainIndex and nameservers.
+	domainIndex     map[string]string // Call c.domains() to read.
 	nameservers     map[string][]string
 	ipConversions   []transform.IPConversion
 	ignoredLabels   []string
@@ -83,14 +85,20 @@ func labelMatches(label string, matches []string) bool {
 	return false
 }
 
+// ConcurrencySafe returns true: the zones of the account are only read
+// or replaced while holding c.mu, and nothing else is changed after c is
+// initialized. It implements providers.ConcurrencySafe.
+func (c *cloudflareProvider) ConcurrencySafe() bool {
+	return true
+}
+
 // GetNameservers returns the nameservers for a domain.
 func (c *cloudflareProvider) GetNameservers(domain string) ([]*models.Nameserver, error) {
-	if c.domainIndex == nil {
-		if err := c.fetchDomainList(); err != nil {
-			return nil, err
-		}
+	_, nameservers, err := c.domains()
+	if err != nil {
+		return nil, err
 	}
-	ns, ok := c.nameservers[domain]
+	ns, ok := nameservers[domain]
 	if !ok {
 		return nil, fmt.Errorf("nameservers for %s not found in cloudflare account", domain)
 	}
@@ -102,8 +110,12 @@ func (c *cloudflareProvider) ListZones() ([]string, error) {
 	if err := c.fetchDomainList(); err != nil {
 		return nil, err
 	}
-	zones := make([]string, 0, len(c.domainIndex))
-	for d := range c.domainIndex {
+	domainIndex, _, err := c.domains()
+	if err != nil {
+		return nil, err
+	}
+	zones := make([]string, 0, len(domainIndex))
+	for d := range domainIndex {
 		zones = append(zones, d)
 	}
 	return zones, nil
@@ -136,12 +148,11 @@ func (c *cloudflareProvider) GetZoneRecords(domain string) (models.Records, erro
 }
 
 func (c *cloudflareProvider) getDomainID(name string) (string, error) {
-	if c.domainIndex == nil {
-		if err := c.fetchDomainList(); err != nil {
-			return "", err
-		}
+	domainIndex, _, err := c.domains()
+	if err != nil {
+		return "", err
 	}
-	id, ok := c.domainIndex[name]
+	id, ok := domainIndex[name]
 	if !ok {
 		return "", fmt.Errorf("'%s' not a zone in cloudflare account", name)
 	}
@@ -885,16 +896,15 @@ func getProxyMetadata(r *models.RecordConfig) map[string]string {
 
 // EnsureZoneExists creates a zone if it does not exist
 func (c *cloudflareProvider) EnsureZoneExists(domain string) error {
-	if c.domainIndex == nil {
-		if err := c.fetchDomainList(); err != nil {
-			return err
-		}
+	domainIndex, _, err := c.domains()
+	if err != nil {
+		return err
 	}
-	if _, ok := c.domainIndex[domain]; ok {
+	if _, ok := domainIndex[domain]; ok {
 		return nil
 	}
 	var id string
-	id, err := c.createZone(domain)
+	id, err = c.createZone(domain)
 	printer.Printf("Added zone for %s to Cloudflare account: %s\n", domain, id)
 	return err
 }
@@ -12,21 +12,41 @@ import (
 
 // get list of domains for account. Cache so the ids can be looked up from domain name
 func (c *cloudflareProvider) fetchDomainList() error {
-	c.domainIndex = map[string]string{}
-	c.nameservers = map[string][]string{}
+	domainIndex := map[string]string{}
+	nameservers := map[string][]string{}
 	zones, err := c.cfClient.ListZones(context.Background())
 	if err != nil {
 		return fmt.Errorf("failed fetching domain list from cloudflare(%q): %s", c.cfClient.APIEmail, err)
 	}
 
 	for _, zone := range zones {
-		c.domainIndex[zone.Name] = zone.ID
-		c.nameservers[zone.Name] = append(c.nameservers[zone.Name], zone.NameServers...)
+		domainIndex[zone.Name] = zone.ID
+		nameservers[zone.Name] = append(nameservers[zone.Name], zone.NameServers...)
 	}
 
+	// The maps are replaced, never changed, so that they may be read
+	// concurrently (see domains).
+	c.mu.Lock()
+	c.domainIndex, c.nameservers = domainIndex, nameservers
+	c.mu.Unlock()
 	return nil
 }
 
+// domains returns the IDs and the nameservers of the zones of the
+// account, by name. The zones are listed on first use.
+func (c *cloudflareProvider) domains() (map[string]string, map[string][]string, error) {
+	c.mu.Lock()
+	domainIndex, nameservers := c.domainIndex, c.nameservers
+	c.mu.Unlock()
+	if domainIndex != nil {
+		return domainIndex, nameservers, nil
+	}
+	if err := c.fetchDomainList(); err != nil {
+		return nil, nil, err
+	}
+	return c.domains()
+}
+
 // get all records for a domain
 func (c *cloudflareProvider) getRecordsForDomain(id string, domain string) ([]*models.RecordConfig, error) {
 	records := []*models.RecordConfig{}
@@ -47,10 +47,14 @@ var features = providers.DocumentationNotes{
 	providers.CanGetZones:            providers.Can(),
 	providers.CanUseAlias:            providers.Can(),
 	providers.CanUseCAA:              providers.Can(),
+	providers.CanUseDNAME:            providers.Cannot(),
 	providers.CanUseDS:               providers.Cannot(),
 	providers.CanUseDSForChildren:    providers.Cannot(),
+	providers.CanUseHINFO:            providers.Cannot("Supported by dns.he.net but not yet implemented"),
+	providers.CanUseLOC:              providers.Cannot("Supported by dns.he.net but not yet implemented"),
 	providers.CanUseNAPTR:            providers.Can(),
 	providers.CanUsePTR:              providers.Can(),
+	providers.CanUseRP:               providers.Cannot("Supported by dns.he.net but not yet implemented"),
 	providers.CanUseSOA:              providers.Cannot(),
 	providers.CanUseSRV:              providers.Can(),
 	providers.CanUseSSHFP:            providers.Can(),
@@ -359,8 +363,10 @@ func (c *hednsProvider) GetZoneRecords(domain string) (models.Records, error) {
 			return false
 		}
 
-		// Ignore record types that dnscontrol does not support
-		if rc.Type == "HINFO" || rc.Type == "AFSDB" || rc.Type == "RP" || rc.Type == "LOC" {
+		// Ignore record types that dnscontrol does not support (AFSDB) or
+		// this provider does not yet implement (see features). They are
+		// left untouched.
+		if rc.Type == "AFSDB" || rc.Type == "HINFO" || rc.Type == "LOC" || rc.Type == "RP" {
 			return true
 		}
 
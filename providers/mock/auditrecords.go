@@ -0,0 +1,13 @@
+package mock
+
+import "github.com/StackExchange/dnscontrol/v3/models"
+
+// AuditRecords returns a list of errors corresponding to the records
+// that aren't supported by this provider.  If all records are
+// supported, an empty list is returned.
+//
+// MOCK supports every record. An instance that mimics another provider
+// uses that provider's AuditRecords instead.
+func AuditRecords(records []*models.RecordConfig) []error {
+	return nil
+}
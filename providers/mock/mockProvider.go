@@ -0,0 +1,245 @@
+package mock
+
+/*
+
+mock -
+  An in-memory DNS provider and registrar, for testing.
+
+	Zones are kept in memory, or in the JSON file named by "state" in
+	creds.json so that they survive between runs. The capabilities can
+	be set per instance, so that a MOCK can stand in for any real
+	provider in tests of dnsconfig.js.
+
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/pkg/diff2"
+	"github.com/StackExchange/dnscontrol/v3/providers"
+)
+
+var features = providers.DocumentationNotes{
+	providers.CanAutoDNSSEC:          providers.Can("Accepted but ignored"),
+	providers.CanGetZones:            providers.Can(),
+	providers.CanUseAlias:            providers.Can(),
+	providers.CanUseCAA:              providers.Can(),
+	providers.CanUseCERT:             providers.Can(),
+	providers.CanUseDNAME:            providers.Can(),
+	providers.CanUseDS:               providers.Can(),
+	providers.CanUseHINFO:            providers.Can(),
+	providers.CanUseLOC:              providers.Can(),
+	providers.CanUseNAPTR:            providers.Can(),
+	providers.CanUseOPENPGPKEY:       providers.Can(),
+	providers.CanUsePTR:              providers.Can(),
+	providers.CanUseRP:               providers.Can(),
+	providers.CanUseSMIMEA:           providers.Can(),
+	providers.CanUseSOA:              providers.Can(),
+	providers.CanUseSRV:              providers.Can(),
+	providers.CanUseSSHFP:            providers.Can(),
+	providers.CanUseSVCB:             providers.Can(),
+	providers.CanUseTLSA:             providers.Can(),
+	providers.CanUseURI:              providers.Can(),
+	providers.DocCreateDomains:       providers.Can(),
+	providers.DocDualHost:            providers.Can(),
+	providers.DocOfficiallySupported: providers.Cannot("For testing only"),
+}
+
+func init() {
+	fns := providers.DspFuncs{
+		Initializer:   newDsp,
+		RecordAuditor: AuditRecords,
+	}
+	providers.RegisterDomainServiceProviderType("MOCK", fns, features)
+	providers.RegisterRegistrarType("MOCK", newReg)
+}
+
+// mockProvider is the provider handle for the MOCK driver.
+type mockProvider struct {
+	store       *store
+	nameservers []*models.Nameserver
+	// mimic is the provider type whose capabilities and RecordAuditor
+	// are used, unless overridden by capabilities.
+	mimic        string
+	capabilities map[providers.Capability]bool
+}
+
+func newDsp(config map[string]string, providermeta json.RawMessage) (providers.DNSServiceProvider, error) {
+	return newProvider(config)
+}
+
+func newReg(config map[string]string) (providers.Registrar, error) {
+	return newProvider(config)
+}
+
+func newProvider(config map[string]string) (*mockProvider, error) {
+	s, err := openStore(config["state"])
+	if err != nil {
+		return nil, err
+	}
+	api := &mockProvider{
+		store:        s,
+		mimic:        "MOCK",
+		capabilities: map[providers.Capability]bool{},
+	}
+
+	api.nameservers, err = models.ToNameservers(splitList(config["nameservers"]))
+	if err != nil {
+		return nil, err
+	}
+
+	if m := config["mimic"]; m != "" {
+		if _, ok := providers.DNSProviderTypes[m]; !ok {
+			return nil, fmt.Errorf("MOCK can not mimic unknown DNS provider type %q", m)
+		}
+		api.mimic = m
+	}
+
+	// "CanUseLOC,-CanUseSRV" adds LOC and removes SRV.
+	for _, name := range splitList(config["capabilities"]) {
+		has := !strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+		c, ok := providers.ParseCapability(name)
+		if !ok {
+			return nil, fmt.Errorf("MOCK: unknown capability %q", name)
+		}
+		api.capabilities[c] = has
+	}
+
+	return api, nil
+}
+
+// splitList splits a comma separated list, ignoring empty items.
+func splitList(s string) []string {
+	var l []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			l = append(l, item)
+		}
+	}
+	return l
+}
+
+// HasCapability returns true if this instance has the capability. It
+// implements providers.InstanceCapabilities.
+func (c *mockProvider) HasCapability(cap providers.Capability) bool {
+	if has, ok := c.capabilities[cap]; ok {
+		return has
+	}
+	return providers.ProviderHasCapability(c.mimic, cap)
+}
+
+// ConcurrencySafe returns true: the store is locked. It implements
+// providers.ConcurrencySafe.
+func (c *mockProvider) ConcurrencySafe() bool {
+	return true
+}
+
+// AuditRecords runs the RecordAuditor of the provider type this
+// instance mimics. It implements providers.InstanceAuditor.
+func (c *mockProvider) AuditRecords(records []*models.RecordConfig) []error {
+	if c.mimic == "MOCK" {
+		return AuditRecords(records)
+	}
+	return providers.AuditRecords(c.mimic, records)
+}
+
+// GetNameservers returns the nameservers for a domain.
+func (c *mockProvider) GetNameservers(string) ([]*models.Nameserver, error) {
+	return c.nameservers, nil
+}
+
+// ListZones returns all the zones.
+func (c *mockProvider) ListZones() ([]string, error) {
+	return c.store.zones(), nil
+}
+
+// EnsureZoneExists creates a zone if it does not exist.
+func (c *mockProvider) EnsureZoneExists(domain string) error {
+	return c.store.createZone(domain)
+}
+
+// GetZoneRecords gets the records of a zone and returns them in RecordConfig format.
+func (c *mockProvider) GetZoneRecords(domain string) (models.Records, error) {
+	recs, ok, err := c.store.records(domain)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("zone %q does not exist", domain)
+	}
+	return recs, nil
+}
+
+// GetDomainCorrections returns corrections to update a domain. Like
+// BIND, a zone that does not exist is created by the first correction.
+func (c *mockProvider) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correction, error) {
+	dc.Punycode()
+
+	existing, _, err := c.store.records(dc.Name)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetZoneRecordsCorrections(dc, existing)
+}
+
+// GetZoneRecordsCorrections returns corrections to update a domain
+// whose records are existing. It implements
+// providers.ZoneRecordsCorrector.
+func (c *mockProvider) GetZoneRecordsCorrections(dc *models.DomainConfig, existing models.Records) ([]*models.Correction, error) {
+	dc.Punycode()
+
+	changes, err := diff2.ByRecordSet(existing, dc, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var corrections []*models.Correction
+	for _, change := range changes {
+		if change.Type == diff2.REPORT {
+			corrections = append(corrections, &models.Correction{Msg: change.MsgsJoined})
+			continue
+		}
+		// Copy all params to avoid overwrites
+		zone := dc.Name
+		key := change.Key
+		recs := change.New // Empty for a DELETE.
+		corrections = append(corrections, &models.Correction{
+			Msg: change.MsgsJoined,
+			F: func() error {
+				return c.store.replaceRecordSet(zone, key, recs)
+			},
+		})
+	}
+	return corrections, nil
+}
+
+// GetRegistrarCorrections returns corrections to update the
+// nameservers of a domain at the registrar.
+func (c *mockProvider) GetRegistrarCorrections(dc *models.DomainConfig) ([]*models.Correction, error) {
+	found := c.store.delegation(dc.Name)
+	sort.Strings(found)
+
+	var desired []string
+	for _, ns := range dc.Nameservers {
+		desired = append(desired, ns.Name)
+	}
+	sort.Strings(desired)
+
+	if strings.Join(found, ",") == strings.Join(desired, ",") {
+		return nil, nil
+	}
+	domain := dc.Name
+	return []*models.Correction{
+		{
+			Msg: fmt.Sprintf("Change Nameservers from '%s' to '%s'", strings.Join(found, ","), strings.Join(desired, ",")),
+			F: func() error {
+				return c.store.setDelegation(domain, desired)
+			},
+		},
+	}, nil
+}
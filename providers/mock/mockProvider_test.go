@@ -0,0 +1,132 @@
+package mock
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+	"github.com/StackExchange/dnscontrol/v3/providers"
+)
+
+func domainConfig(recs ...*models.RecordConfig) *models.DomainConfig {
+	return &models.DomainConfig{Name: "example.com", Records: recs}
+}
+
+func a(name, ip string) *models.RecordConfig {
+	rc := &models.RecordConfig{Type: "A", TTL: 300}
+	rc.SetLabel(name, "example.com")
+	if err := rc.SetTarget(ip); err != nil {
+		panic(err)
+	}
+	return rc
+}
+
+// push runs the corrections for dc and returns how many there were.
+func push(t *testing.T, p *mockProvider, dc *models.DomainConfig) int {
+	t.Helper()
+	corrections, err := p.GetDomainCorrections(dc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range corrections {
+		if c.F == nil {
+			continue
+		}
+		if err := c.F(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return len(corrections)
+}
+
+func TestPushAndState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	p, err := newProvider(map[string]string{"state": path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.GetZoneRecords("example.com"); err == nil {
+		t.Errorf("expected an error for a zone that does not exist")
+	}
+	if n := push(t, p, domainConfig(a("@", "1.2.3.4"), a("www", "1.2.3.4"), a("www", "5.6.7.8"))); n != 2 {
+		t.Errorf("expected 2 corrections, got %d", n)
+	}
+	if n := push(t, p, domainConfig(a("@", "1.2.3.4"), a("www", "1.2.3.4"), a("www", "5.6.7.8"))); n != 0 {
+		t.Errorf("expected no corrections, got %d", n)
+	}
+	if n := push(t, p, domainConfig(a("www", "5.6.7.8"))); n != 2 {
+		t.Errorf("expected 2 corrections, got %d", n)
+	}
+
+	// The state is read back from the file.
+	delete(stores, path)
+	p, err = newProvider(map[string]string{"state": path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	zones, _ := p.ListZones()
+	if len(zones) != 1 || zones[0] != "example.com" {
+		t.Errorf("unexpected zones %v", zones)
+	}
+	recs, err := p.GetZoneRecords("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 1 || recs[0].GetLabelFQDN() != "www.example.com" || recs[0].GetTargetField() != "5.6.7.8" {
+		t.Errorf("unexpected records %v", recs)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	p, err := newProvider(map[string]string{"capabilities": "-CanUseSRV, CanUseAzureAlias"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.HasCapability(providers.CanUseSRV) || !p.HasCapability(providers.CanUseAzureAlias) || !p.HasCapability(providers.CanUseCAA) {
+		t.Errorf("capabilities are not overridden")
+	}
+
+	if _, err := newProvider(map[string]string{"capabilities": "CanFly"}); err == nil {
+		t.Errorf("expected an error for an unknown capability")
+	}
+	if _, err := newProvider(map[string]string{"mimic": "NOSUCHPROVIDER"}); err == nil {
+		t.Errorf("expected an error for an unknown provider type")
+	}
+}
+
+func TestMimic(t *testing.T) {
+	providers.RegisterDomainServiceProviderType("MOCK_TEST_NOSRV", providers.DspFuncs{
+		RecordAuditor: func(rcs []*models.RecordConfig) []error { return []error{nil} },
+	}, providers.DocumentationNotes{providers.CanUseCAA: providers.Can()})
+
+	p, err := newProvider(map[string]string{"mimic": "MOCK_TEST_NOSRV"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.HasCapability(providers.CanUseSRV) || !p.HasCapability(providers.CanUseCAA) {
+		t.Errorf("capabilities are not mimicked")
+	}
+	if es := p.AuditRecords(nil); len(es) != 1 {
+		t.Errorf("RecordAuditor is not mimicked")
+	}
+}
+
+func TestRegistrar(t *testing.T) {
+	p, err := newProvider(map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dc := domainConfig()
+	dc.Nameservers, _ = models.ToNameservers([]string{"ns2.example.net", "ns1.example.net"})
+	corrections, err := p.GetRegistrarCorrections(dc)
+	if err != nil || len(corrections) != 1 {
+		t.Fatalf("expected one correction, got %v %v", corrections, err)
+	}
+	if err := corrections[0].F(); err != nil {
+		t.Fatal(err)
+	}
+	if corrections, _ := p.GetRegistrarCorrections(dc); len(corrections) != 0 {
+		t.Errorf("expected no corrections, got %v", corrections)
+	}
+}
@@ -0,0 +1,176 @@
+package mock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/StackExchange/dnscontrol/v3/models"
+)
+
+// store holds the zones and delegations of a MOCK instance. If path is
+// set, the state is read from and written to that JSON file.
+type store struct {
+	sync.Mutex
+	path  string
+	state state
+}
+
+// state is the content of the state file.
+type state struct {
+	// Zones maps the name of a zone to its records.
+	Zones map[string]models.Records `json:"zones"`
+	// Delegations maps the name of a domain to its nameservers at
+	// the (fake) registrar.
+	Delegations map[string][]string `json:"delegations,omitempty"`
+}
+
+var (
+	storesMu sync.Mutex
+	stores   = map[string]*store{}
+)
+
+// openStore returns the store of the state file at path. Instances
+// (DNS provider and registrar) that use the same file share the store.
+// If path is empty, a new in-memory store is returned.
+func openStore(path string) (*store, error) {
+	if path == "" {
+		return &store{state: state{Zones: map[string]models.Records{}}}, nil
+	}
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	if s, ok := stores[path]; ok {
+		return s, nil
+	}
+	s := &store{path: path, state: state{Zones: map[string]models.Records{}}}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	stores[path] = s
+	return s, nil
+}
+
+// load reads the state file. A missing file is an empty state.
+func (s *store) load() error {
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading MOCK state: %w", err)
+	}
+	if err := json.Unmarshal(b, &s.state); err != nil {
+		return fmt.Errorf("parsing MOCK state %s: %w", s.path, err)
+	}
+	if s.state.Zones == nil {
+		s.state.Zones = map[string]models.Records{}
+	}
+	// The FQDNs are not stored.
+	for zone, recs := range s.state.Zones {
+		for _, rc := range recs {
+			rc.SetLabel(rc.Name, zone)
+		}
+	}
+	return nil
+}
+
+// save writes the state file, if there is one. It must be called with
+// the lock held.
+func (s *store) save() error {
+	if s.path == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, append(b, '\n'), 0o644)
+}
+
+// zones returns the names of the zones, sorted.
+func (s *store) zones() []string {
+	s.Lock()
+	defer s.Unlock()
+	var names []string
+	for name := range s.state.Zones {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// records returns a copy of the records of zone, and whether the zone
+// exists.
+func (s *store) records(zone string) (models.Records, bool, error) {
+	s.Lock()
+	defer s.Unlock()
+	recs, ok := s.state.Zones[zone]
+	if !ok {
+		return nil, false, nil
+	}
+	cp, err := copyRecords(recs)
+	return cp, true, err
+}
+
+// createZone creates zone, if it does not exist.
+func (s *store) createZone(zone string) error {
+	s.Lock()
+	defer s.Unlock()
+	if _, ok := s.state.Zones[zone]; ok {
+		return nil
+	}
+	s.state.Zones[zone] = models.Records{}
+	return s.save()
+}
+
+// replaceRecordSet replaces the records of zone with the key by recs.
+// The zone is created if it does not exist.
+func (s *store) replaceRecordSet(zone string, key models.RecordKey, recs models.Records) error {
+	cp, err := copyRecords(recs)
+	if err != nil {
+		return err
+	}
+	s.Lock()
+	defer s.Unlock()
+	var kept models.Records
+	for _, rc := range s.state.Zones[zone] {
+		if rc.Key() != key {
+			kept = append(kept, rc)
+		}
+	}
+	s.state.Zones[zone] = append(kept, cp...)
+	return s.save()
+}
+
+// delegation returns the nameservers of domain at the registrar.
+func (s *store) delegation(domain string) []string {
+	s.Lock()
+	defer s.Unlock()
+	return append([]string(nil), s.state.Delegations[domain]...)
+}
+
+// setDelegation sets the nameservers of domain at the registrar.
+func (s *store) setDelegation(domain string, nss []string) error {
+	s.Lock()
+	defer s.Unlock()
+	if s.state.Delegations == nil {
+		s.state.Delegations = map[string][]string{}
+	}
+	s.state.Delegations[domain] = nss
+	return s.save()
+}
+
+func copyRecords(recs models.Records) (models.Records, error) {
+	cp := make(models.Records, 0, len(recs))
+	for _, rc := range recs {
+		n, err := rc.Copy()
+		if err != nil {
+			return nil, err
+		}
+		cp = append(cp, n)
+	}
+	return cp, nil
+}
@@ -12,6 +12,13 @@ import (
 	"github.com/mittwald/go-powerdns/pdnshttp"
 )
 
+// ConcurrencySafe returns true: dsp is not changed after it is
+// initialized, and its API client keeps no state between requests. It
+// implements providers.ConcurrencySafe.
+func (dsp *powerdnsProvider) ConcurrencySafe() bool {
+	return true
+}
+
 // GetNameservers returns the nameservers for a domain.
 func (dsp *powerdnsProvider) GetNameservers(string) ([]*models.Nameserver, error) {
 	var r []string
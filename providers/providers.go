@@ -31,6 +31,31 @@ type ZoneLister interface {
 	ListZones() ([]string, error)
 }
 
+// ConcurrencySafe should be implemented by providers whose methods may
+// be called concurrently (for different zones). Other providers are
+// called one at a time by preview/push, whatever --concurrency and
+// --provider-concurrency say.
+type ConcurrencySafe interface {
+	ConcurrencySafe() bool
+}
+
+// DomainRecordsGetter should be implemented by providers whose zone
+// depends on more than the name of the domain, such as the zonefile of
+// a tagged (split horizon) domain in BIND. preview/push then use it
+// instead of GetZoneRecords.
+type DomainRecordsGetter interface {
+	GetDomainRecords(dc *models.DomainConfig) (models.Records, error)
+}
+
+// ZoneRecordsCorrector should be implemented by providers that can
+// compute the corrections of a domain from records already fetched by
+// GetZoneRecords. preview/push then fetch the records of a zone only
+// once when they need both the records and the corrections (such as
+// --report or the safety limits).
+type ZoneRecordsCorrector interface {
+	GetZoneRecordsCorrections(dc *models.DomainConfig, existing models.Records) ([]*models.Correction, error)
+}
+
 // RegistrarInitializer is a function to create a registrar. Function will be passed the unprocessed json payload from the configuration file for the given provider.
 type RegistrarInitializer func(map[string]string) (Registrar, error)
 
@@ -143,6 +168,22 @@ func AuditRecords(dType string, rcs models.Records) []error {
 	return p.RecordAuditor(rcs)
 }
 
+// InstanceAuditor is implemented by a DNSServiceProvider whose
+// RecordAuditor depends on its configuration rather than its type.
+type InstanceAuditor interface {
+	AuditRecords([]*models.RecordConfig) []error
+}
+
+// AuditInstanceRecords calls the RecordAudit function for a provider
+// instance. The auditor of the driver, if it has its own, takes
+// precedence over that of the provider type.
+func AuditInstanceRecords(p *models.DNSProviderInstance, rcs models.Records) []error {
+	if ia, ok := p.Driver.(InstanceAuditor); ok {
+		return ia.AuditRecords(rcs)
+	}
+	return AuditRecords(p.ProviderType, rcs)
+}
+
 // None is a basic provider type that does absolutely nothing. Can be useful as a placeholder for third parties or unimplemented providers.
 type None struct{}
 
@@ -8,6 +8,7 @@ import (
 	"log"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 	"unicode/utf8"
 
@@ -27,12 +28,13 @@ import (
 )
 
 type route53Provider struct {
-	client          *r53.Client
-	registrar       *r53d.Client
-	delegationSet   *string
-	zonesByID       map[string]r53Types.HostedZone
-	zonesByDomain   map[string]r53Types.HostedZone
-	originalRecords []r53Types.ResourceRecordSet
+	client        *r53.Client
+	registrar     *r53d.Client
+	delegationSet *string
+
+	mu            sync.Mutex // Held to list the zones (see getZones).
+	zonesByID     map[string]r53Types.HostedZone
+	zonesByDomain map[string]r53Types.HostedZone
 }
 
 func newRoute53Reg(conf map[string]string) (providers.Registrar, error) {
@@ -68,8 +70,7 @@ func newRoute53(m map[string]string, metadata json.RawMessage) (*route53Provider
 		dls = aws.String(val)
 	}
 	api := &route53Provider{client: r53.NewFromConfig(config), registrar: r53d.NewFromConfig(config), delegationSet: dls}
-	err = api.getZones()
-	if err != nil {
+	if _, _, err := api.getZones(); err != nil {
 		return nil, err
 	}
 	return api, nil
@@ -120,26 +121,39 @@ func withRetry(f func() error) {
 	}
 }
 
+// ConcurrencySafe returns true: the zones are listed while holding
+// r.mu, and nothing else is kept between calls. It implements
+// providers.ConcurrencySafe.
+func (r *route53Provider) ConcurrencySafe() bool {
+	return true
+}
+
 // ListZones lists the zones on this account.
 func (r *route53Provider) ListZones() ([]string, error) {
-	if err := r.getZones(); err != nil {
+	zonesByDomain, _, err := r.getZones()
+	if err != nil {
 		return nil, err
 	}
 	var zones []string
-	for i := range r.zonesByDomain {
+	for i := range zonesByDomain {
 		zones = append(zones, i)
 	}
 	return zones, nil
 }
 
-func (r *route53Provider) getZones() error {
+// getZones returns the zones of the account, by domain and by ID. They
+// are listed once, and again after a zone is created. The maps are
+// never changed once returned, therefore they may be read concurrently.
+func (r *route53Provider) getZones() (map[string]r53Types.HostedZone, map[string]r53Types.HostedZone, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if r.zonesByDomain != nil {
-		return nil
+		return r.zonesByDomain, r.zonesByID, nil
 	}
 
 	var nextMarker *string
-	r.zonesByDomain = make(map[string]r53Types.HostedZone)
-	r.zonesByID = make(map[string]r53Types.HostedZone)
+	zonesByDomain := make(map[string]r53Types.HostedZone)
+	zonesByID := make(map[string]r53Types.HostedZone)
 	for {
 		var out *r53.ListHostedZonesOutput
 		var err error
@@ -149,14 +163,14 @@ func (r *route53Provider) getZones() error {
 			return err
 		})
 		if err != nil && strings.Contains(err.Error(), "is not authorized") {
-			return errors.New("check your credentials, you're not authorized to perform actions on Route 53 AWS Service")
+			return nil, nil, errors.New("check your credentials, you're not authorized to perform actions on Route 53 AWS Service")
 		} else if err != nil {
-			return err
+			return nil, nil, err
 		}
 		for _, z := range out.HostedZones {
 			domain := strings.TrimSuffix(aws.ToString(z.Name), ".")
-			r.zonesByDomain[domain] = z
-			r.zonesByID[parseZoneID(aws.ToString(z.Id))] = z
+			zonesByDomain[domain] = z
+			zonesByID[parseZoneID(aws.ToString(z.Id))] = z
 		}
 		if out.NextMarker != nil {
 			nextMarker = out.NextMarker
@@ -164,7 +178,8 @@ func (r *route53Provider) getZones() error {
 			break
 		}
 	}
-	return nil
+	r.zonesByDomain, r.zonesByID = zonesByDomain, zonesByID
+	return zonesByDomain, zonesByID, nil
 }
 
 type errDomainNoExist struct {
@@ -184,16 +199,16 @@ func (e errZoneNoExist) Error() string {
 }
 
 func (r *route53Provider) GetNameservers(domain string) ([]*models.Nameserver, error) {
-	if err := r.getZones(); err != nil {
+	zonesByDomain, _, err := r.getZones()
+	if err != nil {
 		return nil, err
 	}
 
-	zone, ok := r.zonesByDomain[domain]
+	zone, ok := zonesByDomain[domain]
 	if !ok {
 		return nil, errDomainNoExist{domain}
 	}
 	var z *r53.GetHostedZoneOutput
-	var err error
 	withRetry(func() error {
 		z, err = r.client.GetHostedZone(context.Background(), &r53.GetHostedZoneInput{Id: zone.Id})
 		return err
@@ -210,53 +225,57 @@ func (r *route53Provider) GetNameservers(domain string) ([]*models.Nameserver, e
 }
 
 func (r *route53Provider) GetZoneRecords(domain string) (models.Records, error) {
-	if err := r.getZones(); err != nil {
+	zonesByDomain, _, err := r.getZones()
+	if err != nil {
 		return nil, err
 	}
 
-	if zone, ok := r.zonesByDomain[domain]; ok {
-		return r.getZoneRecords(zone)
+	if zone, ok := zonesByDomain[domain]; ok {
+		records, _, err := r.getZoneRecords(zone)
+		return records, err
 	}
 
 	return nil, errDomainNoExist{domain}
 }
 
 func (r *route53Provider) getZone(dc *models.DomainConfig) (r53Types.HostedZone, error) {
-	if err := r.getZones(); err != nil {
+	zonesByDomain, zonesByID, err := r.getZones()
+	if err != nil {
 		return r53Types.HostedZone{}, err
 	}
 
 	if zoneID, ok := dc.Metadata["zone_id"]; ok {
-		zone, ok := r.zonesByID[zoneID]
+		zone, ok := zonesByID[zoneID]
 		if !ok {
 			return r53Types.HostedZone{}, errZoneNoExist{zoneID}
 		}
 		return zone, nil
 	}
 
-	if zone, ok := r.zonesByDomain[dc.Name]; ok {
+	if zone, ok := zonesByDomain[dc.Name]; ok {
 		return zone, nil
 	}
 
 	return r53Types.HostedZone{}, errDomainNoExist{dc.Name}
 }
 
-func (r *route53Provider) getZoneRecords(zone r53Types.HostedZone) (models.Records, error) {
+// getZoneRecords returns the records of zone, and the record sets
+// they were made from.
+func (r *route53Provider) getZoneRecords(zone r53Types.HostedZone) (models.Records, []r53Types.ResourceRecordSet, error) {
 	records, err := r.fetchRecordSets(zone.Id)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	r.originalRecords = records
 
 	var existingRecords = []*models.RecordConfig{}
 	for _, set := range records {
 		rts, err := nativeToRecords(set, unescape(zone.Name))
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		existingRecords = append(existingRecords, rts...)
 	}
-	return existingRecords, nil
+	return existingRecords, records, nil
 }
 
 func (r *route53Provider) GetDomainCorrections(dc *models.DomainConfig) ([]*models.Correction, error) {
@@ -267,7 +286,7 @@ func (r *route53Provider) GetDomainCorrections(dc *models.DomainConfig) ([]*mode
 		return nil, err
 	}
 
-	existingRecords, err := r.getZoneRecords(zone)
+	existingRecords, originalRecords, err := r.getZoneRecords(zone)
 	if err != nil {
 		return nil, err
 	}
@@ -357,7 +376,7 @@ func (r *route53Provider) GetDomainCorrections(dc *models.DomainConfig) ([]*mode
 					found bool
 				)
 				// Find the original resource set:
-				for _, orec := range r.originalRecords {
+				for _, orec := range originalRecords {
 					if unescape(orec.Name) == currentKey.NameFQDN && (string(orec.Type) == currentKey.Type || currentKey.Type == "R53_ALIAS_"+string(orec.Type)) {
 						rrset = orec
 						found = true
@@ -821,11 +840,12 @@ func unescape(s *string) string {
 }
 
 func (r *route53Provider) EnsureZoneExists(domain string) error {
-	if err := r.getZones(); err != nil {
+	zonesByDomain, _, err := r.getZones()
+	if err != nil {
 		return err
 	}
 
-	if _, ok := r.zonesByDomain[domain]; ok {
+	if _, ok := zonesByDomain[domain]; ok {
 		return nil
 	}
 	if r.delegationSet != nil {
@@ -840,10 +860,11 @@ func (r *route53Provider) EnsureZoneExists(domain string) error {
 	}
 
 	// reset zone cache
+	r.mu.Lock()
 	r.zonesByDomain = nil
 	r.zonesByID = nil
+	r.mu.Unlock()
 
-	var err error
 	withRetry(func() error {
 		_, err := r.client.CreateHostedZone(context.Background(), in)
 		return err